@@ -0,0 +1,421 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// Appointment is a booking of one or more services with an employee
+type Appointment struct {
+	ID         string    `json:"id"`
+	LocationID string    `json:"location_id"`
+	EmployeeID string    `json:"employee_id"`
+	ClientID   string    `json:"client_id"`
+	ServiceIDs []string  `json:"service_ids"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AppointmentService ...
+type AppointmentService struct {
+	appointmentStore AppointmentStore
+	employeeStore    EmployeeStore
+	serviceStore     ServiceStore
+	clientStore      ClientStore
+	locationStore    LocationStore
+}
+
+// NewAppointmentService constructor for AppointmentService
+func NewAppointmentService(appointmentStore AppointmentStore, employeeStore EmployeeStore, serviceStore ServiceStore, clientStore ClientStore, locationStore LocationStore) AppointmentService {
+	return AppointmentService{appointmentStore: appointmentStore, employeeStore: employeeStore, serviceStore: serviceStore, clientStore: clientStore, locationStore: locationStore}
+}
+
+// GetAppointmentsByLocationID gets appointments of the location overlapping the given time range
+func (s *AppointmentService) GetAppointmentsByLocationID(ctx context.Context, locationID string, startTime time.Time, endTime time.Time, actor Actor) ([]*Appointment, error) {
+	const op = "app/appointmentService.GetAppointmentsByLocationID"
+
+	err := actor.can(ctx, opReadAppointment)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	if !startTime.Before(endTime) {
+		return nil, errors.Invalid(op, "start time must be before end time")
+	}
+
+	appointments, err := s.appointmentStore.GetAppointmentsByLocationIDAndTimeRange(ctx, locationID, startTime, endTime)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get appointments by location id")
+	}
+
+	return appointments, nil
+}
+
+// getAppointment gets the appointment, which must belong to the location. Appointments of other locations are not found
+func (s *AppointmentService) getAppointment(ctx context.Context, locationID string, id string) (*Appointment, error) {
+	const op = "app/appointmentService.getAppointment"
+
+	appointment, err := s.appointmentStore.GetAppointmentByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get appointment by id")
+	}
+
+	if appointment == nil || appointment.LocationID != locationID {
+		return nil, errors.NotFound(op)
+	}
+
+	return appointment, nil
+}
+
+// GetAppointmentByID ...
+func (s *AppointmentService) GetAppointmentByID(ctx context.Context, locationID string, id string, actor Actor) (*Appointment, error) {
+	const op = "app/appointmentService.GetAppointmentByID"
+
+	err := actor.can(ctx, opReadAppointment)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	appointment, err := s.getAppointment(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get appointment")
+	}
+
+	return appointment, nil
+}
+
+// getAppointmentDuration sums up durations of the services, which must all be offered at the location
+func (s *AppointmentService) getAppointmentDuration(ctx context.Context, locationID string, serviceIDs []string) (time.Duration, error) {
+	const op = "app/appointmentService.getAppointmentDuration"
+
+	if len(serviceIDs) == 0 {
+		return 0, errors.InvalidField(op, "service_ids", errors.CodeRequired, "at least one service required")
+	}
+
+	var duration time.Duration
+
+	for i, serviceID := range serviceIDs {
+		service, err := s.serviceStore.GetServiceByID(ctx, serviceID)
+
+		if err != nil {
+			return 0, errors.Wrap(op, err, "failed to get service by id")
+		}
+
+		if service == nil || service.LocationID != locationID {
+			return 0, errors.InvalidField(op, fmt.Sprintf("service_ids[%d]", i), errors.CodeInvalidValue, "service not found in location")
+		}
+
+		duration += time.Duration(service.Duration) * time.Minute
+	}
+
+	return duration, nil
+}
+
+// validateEmployee checks that the employee works at the location
+func (s *AppointmentService) validateEmployee(ctx context.Context, locationID string, employeeID string) error {
+	const op = "app/appointmentService.validateEmployee"
+
+	employee, err := s.employeeStore.GetEmployeeByID(ctx, employeeID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to get employee by id")
+	}
+
+	if employee == nil || employee.LocationID != locationID {
+		return errors.InvalidField(op, "employee_id", errors.CodeInvalidValue, "employee not found in location")
+	}
+
+	return nil
+}
+
+// validateClient checks that the client is a client of the business of the location
+func (s *AppointmentService) validateClient(ctx context.Context, locationID string, clientID string) error {
+	const op = "app/appointmentService.validateClient"
+
+	location, err := s.locationStore.GetLocationByID(ctx, locationID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to get location by id")
+	}
+
+	if location == nil {
+		return errors.NotFound(op)
+	}
+
+	client, err := s.clientStore.GetClientByID(ctx, clientID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to get client by id")
+	}
+
+	if client == nil || client.BusinessID != location.BusinessID {
+		return errors.InvalidField(op, "client_id", errors.CodeInvalidValue, "client not found in business")
+	}
+
+	return nil
+}
+
+// checkDoubleBooking fails when the employee has another appointment overlapping the given time range.
+// The "EX_appointment_1" exclusion constraint guards the same invariant against concurrent bookings.
+func (s *AppointmentService) checkDoubleBooking(ctx context.Context, appointment *Appointment) error {
+	const op = "app/appointmentService.checkDoubleBooking"
+
+	appointments, err := s.appointmentStore.GetAppointmentsByEmployeeIDAndTimeRange(ctx, appointment.EmployeeID, appointment.StartTime, appointment.EndTime)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to get appointments by employee id")
+	}
+
+	for _, existingAppointment := range appointments {
+		if existingAppointment.ID != appointment.ID {
+			return errors.Conflict(op, "employee already has an appointment at this time").WithCode(errors.CodeAppointmentOverlap)
+		}
+	}
+
+	return nil
+}
+
+// CreateAppointmentInput ...
+type CreateAppointmentInput struct {
+	LocationID string    `json:"location_id"`
+	EmployeeID string    `json:"employee_id"`
+	ClientID   string    `json:"client_id"`
+	ServiceIDs []string  `json:"service_ids"`
+	StartTime  time.Time `json:"start_time"`
+	// EndTime defaults to StartTime plus the total duration of the services
+	EndTime time.Time `json:"end_time"`
+	Note    string    `json:"note"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateAppointmentInput) Validate() error {
+	const op = "app/CreateAppointmentInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("employee_id", input.EmployeeID)
+	v.Check(len(input.ServiceIDs) > 0, "service_ids", errors.CodeRequired, "at least one service required")
+	v.Check(!input.StartTime.IsZero(), "start_time", errors.CodeRequired, "start_time is required")
+	v.Check(input.EndTime.IsZero() || input.StartTime.Before(input.EndTime), "end_time", errors.CodeOutOfRange, "start time must be before end time")
+
+	return v.Err(op)
+}
+
+// CreateAppointment books an appointment unless the employee is already booked at that time
+func (s *AppointmentService) CreateAppointment(ctx context.Context, input *CreateAppointmentInput, actor Actor) (*Appointment, error) {
+	const op = "app/appointmentService.CreateAppointment"
+
+	err := actor.can(ctx, opCreateAppointment)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	err = s.validateEmployee(ctx, input.LocationID, input.EmployeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid employee")
+	}
+
+	if input.ClientID != "" {
+		err = s.validateClient(ctx, input.LocationID, input.ClientID)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "invalid client")
+		}
+	}
+
+	duration, err := s.getAppointmentDuration(ctx, input.LocationID, input.ServiceIDs)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid services")
+	}
+
+	endTime := input.StartTime.Add(duration)
+
+	if !input.EndTime.IsZero() {
+		endTime = input.EndTime
+	}
+
+	if !input.StartTime.Before(endTime) {
+		return nil, errors.InvalidField(op, "end_time", errors.CodeOutOfRange, "start time must be before end time")
+	}
+
+	now := time.Now()
+
+	appointment := &Appointment{
+		ID:         uuid.Must(uuid.New(), nil).String(),
+		LocationID: input.LocationID,
+		EmployeeID: input.EmployeeID,
+		ClientID:   input.ClientID,
+		ServiceIDs: input.ServiceIDs,
+		StartTime:  input.StartTime,
+		EndTime:    endTime,
+		Note:       input.Note,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	err = s.checkDoubleBooking(ctx, appointment)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to book appointment")
+	}
+
+	err = s.appointmentStore.StoreAppointment(ctx, appointment)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to store appointment")
+	}
+
+	return appointment, nil
+}
+
+// UpdateAppointmentInput ...
+type UpdateAppointmentInput struct {
+	EmployeeID string    `json:"employee_id"`
+	ClientID   string    `json:"client_id"`
+	ServiceIDs []string  `json:"service_ids"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Note       string    `json:"note"`
+}
+
+// Validate checks the fields of the input
+func (input *UpdateAppointmentInput) Validate() error {
+	const op = "app/UpdateAppointmentInput.Validate"
+
+	v := &errors.Validation{}
+	v.Check(input.ServiceIDs == nil || len(input.ServiceIDs) > 0, "service_ids", errors.CodeRequired, "at least one service required")
+	v.Check(input.StartTime.IsZero() || input.EndTime.IsZero() || input.StartTime.Before(input.EndTime), "end_time", errors.CodeOutOfRange, "start time must be before end time")
+
+	return v.Err(op)
+}
+
+// UpdateAppointment reschedules or modifies an appointment
+func (s *AppointmentService) UpdateAppointment(ctx context.Context, locationID string, id string, input *UpdateAppointmentInput, actor Actor) (*Appointment, error) {
+	const op = "app/appointmentService.UpdateAppointment"
+
+	err := actor.can(ctx, opUpdateAppointment)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	appointment, err := s.getAppointment(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get appointment")
+	}
+
+	if input.EmployeeID != "" {
+		err = s.validateEmployee(ctx, appointment.LocationID, input.EmployeeID)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "invalid employee")
+		}
+
+		appointment.EmployeeID = input.EmployeeID
+	}
+	if input.ClientID != "" {
+		err = s.validateClient(ctx, appointment.LocationID, input.ClientID)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "invalid client")
+		}
+
+		appointment.ClientID = input.ClientID
+	}
+	if input.Note != "" {
+		appointment.Note = input.Note
+	}
+
+	// Rescheduling or changing services shifts the end time, unless given explicitly
+	if input.ServiceIDs != nil || !input.StartTime.IsZero() {
+		if input.ServiceIDs != nil {
+			appointment.ServiceIDs = input.ServiceIDs
+		}
+		if !input.StartTime.IsZero() {
+			appointment.StartTime = input.StartTime
+		}
+
+		duration, err := s.getAppointmentDuration(ctx, appointment.LocationID, appointment.ServiceIDs)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "invalid services")
+		}
+
+		appointment.EndTime = appointment.StartTime.Add(duration)
+	}
+	if !input.EndTime.IsZero() {
+		appointment.EndTime = input.EndTime
+	}
+
+	if !appointment.StartTime.Before(appointment.EndTime) {
+		return nil, errors.InvalidField(op, "end_time", errors.CodeOutOfRange, "start time must be before end time")
+	}
+
+	appointment.UpdatedAt = time.Now()
+
+	err = s.checkDoubleBooking(ctx, appointment)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to book appointment")
+	}
+
+	err = s.appointmentStore.UpdateAppointment(ctx, appointment)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to update appointment")
+	}
+
+	return appointment, nil
+}
+
+// DeleteAppointment cancels appointment
+func (s *AppointmentService) DeleteAppointment(ctx context.Context, locationID string, id string, actor Actor) (*Appointment, error) {
+	const op = "app/appointmentService.DeleteAppointment"
+
+	err := actor.can(ctx, opDeleteAppointment)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	appointment, err := s.getAppointment(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get appointment")
+	}
+
+	err = s.appointmentStore.DeleteAppointment(ctx, appointment)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to delete appointment")
+	}
+
+	return appointment, nil
+}
@@ -0,0 +1,255 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+)
+
+func setupAppointmentFixtures(t *testing.T, employeeStore app.EmployeeStore, serviceStore app.ServiceStore) (*app.Employee, *app.Service) {
+	employee := &app.Employee{
+		ID:         "1",
+		LocationID: "1",
+		Name:       "employee1",
+	}
+
+	err := employeeStore.StoreEmployee(context.Background(), employee)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &app.Service{
+		ID:         "1",
+		LocationID: employee.LocationID,
+		Name:       "haircut",
+		Duration:   30,
+	}
+
+	err = serviceStore.StoreService(context.Background(), service)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return employee, service
+}
+
+func TestCreateAppointmentHappyPath(t *testing.T) {
+	appointmentStore := memstore.NewAppointmentStore()
+	employeeStore := memstore.NewEmployeeStore()
+	serviceStore := memstore.NewServiceStore()
+	appointmentService := app.NewAppointmentService(appointmentStore, employeeStore, serviceStore, memstore.NewClientStore(), memstore.NewLocationStore())
+	actor := app.MockActor
+
+	employee, service := setupAppointmentFixtures(t, employeeStore, serviceStore)
+	startTime := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("should create appointment", func(t *testing.T) {
+		input := &app.CreateAppointmentInput{
+			LocationID: employee.LocationID,
+			EmployeeID: employee.ID,
+			ServiceIDs: []string{service.ID},
+			StartTime:  startTime,
+		}
+		appointment, err := appointmentService.CreateAppointment(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if appointment.EndTime.Equal(startTime.Add(30*time.Minute)) == false {
+			t.Error("end time should be derived from service duration")
+		}
+	})
+
+	t.Run("should not double book employee", func(t *testing.T) {
+		input := &app.CreateAppointmentInput{
+			LocationID: employee.LocationID,
+			EmployeeID: employee.ID,
+			ServiceIDs: []string{service.ID},
+			StartTime:  startTime.Add(15 * time.Minute),
+		}
+		_, err := appointmentService.CreateAppointment(context.Background(), input, actor)
+
+		if errors.Is(errors.KindConflict, err) == false {
+			t.Error("error should be conflict kind")
+		}
+	})
+
+	t.Run("should book back to back appointment", func(t *testing.T) {
+		input := &app.CreateAppointmentInput{
+			LocationID: employee.LocationID,
+			EmployeeID: employee.ID,
+			ServiceIDs: []string{service.ID},
+			StartTime:  startTime.Add(30 * time.Minute),
+		}
+		_, err := appointmentService.CreateAppointment(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+}
+
+func TestUpdateAppointmentHappyPath(t *testing.T) {
+	appointmentStore := memstore.NewAppointmentStore()
+	employeeStore := memstore.NewEmployeeStore()
+	serviceStore := memstore.NewServiceStore()
+	appointmentService := app.NewAppointmentService(appointmentStore, employeeStore, serviceStore, memstore.NewClientStore(), memstore.NewLocationStore())
+	actor := app.MockActor
+
+	employee, service := setupAppointmentFixtures(t, employeeStore, serviceStore)
+	startTime := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	appointment := &app.Appointment{
+		ID:         "1",
+		LocationID: employee.LocationID,
+		EmployeeID: employee.ID,
+		ServiceIDs: []string{service.ID},
+		StartTime:  startTime,
+		EndTime:    startTime.Add(30 * time.Minute),
+	}
+
+	err := appointmentStore.StoreAppointment(context.Background(), appointment)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("should reschedule appointment", func(t *testing.T) {
+		input := &app.UpdateAppointmentInput{
+			StartTime: startTime.Add(15 * time.Minute),
+		}
+		updatedAppointment, err := appointmentService.UpdateAppointment(context.Background(), appointment.LocationID, appointment.ID, input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if updatedAppointment.EndTime.Equal(startTime.Add(45*time.Minute)) == false {
+			t.Error("end time should move along with start time")
+		}
+	})
+}
+
+func TestDeleteAppointmentHappyPath(t *testing.T) {
+	appointmentStore := memstore.NewAppointmentStore()
+	employeeStore := memstore.NewEmployeeStore()
+	serviceStore := memstore.NewServiceStore()
+	appointmentService := app.NewAppointmentService(appointmentStore, employeeStore, serviceStore, memstore.NewClientStore(), memstore.NewLocationStore())
+	actor := app.MockActor
+
+	appointment := &app.Appointment{
+		ID:         "2",
+		LocationID: "1",
+		EmployeeID: "1",
+	}
+
+	err := appointmentStore.StoreAppointment(context.Background(), appointment)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("should delete appointment", func(t *testing.T) {
+		_, err := appointmentService.DeleteAppointment(context.Background(), appointment.LocationID, appointment.ID, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+}
+
+func TestAppointmentOfOtherLocation(t *testing.T) {
+	appointmentStore := memstore.NewAppointmentStore()
+	appointmentService := app.NewAppointmentService(appointmentStore, memstore.NewEmployeeStore(), memstore.NewServiceStore(), memstore.NewClientStore(), memstore.NewLocationStore())
+	actor := app.MockActor
+	appointment := &app.Appointment{ID: "3", LocationID: "1", EmployeeID: "1", Note: "note"}
+
+	appointmentStore.StoreAppointment(context.Background(), appointment)
+
+	_, err := appointmentService.GetAppointmentByID(context.Background(), "2", appointment.ID, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected appointment of other location not to be found, got %v", err)
+	}
+
+	_, err = appointmentService.UpdateAppointment(context.Background(), "2", appointment.ID, &app.UpdateAppointmentInput{Note: "moved"}, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected update through other location to be not found, got %v", err)
+	}
+
+	_, err = appointmentService.DeleteAppointment(context.Background(), "2", appointment.ID, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected delete through other location to be not found, got %v", err)
+	}
+
+	storedAppointment, err := appointmentStore.GetAppointmentByID(context.Background(), appointment.ID)
+
+	if err != nil || storedAppointment == nil || storedAppointment.Note != "note" {
+		t.Errorf("expected appointment to be left unchanged, got %+v", storedAppointment)
+	}
+}
+
+func TestAppointmentClientOfOtherBusiness(t *testing.T) {
+	appointmentStore := memstore.NewAppointmentStore()
+	employeeStore := memstore.NewEmployeeStore()
+	serviceStore := memstore.NewServiceStore()
+	clientStore := memstore.NewClientStore()
+	locationStore := memstore.NewLocationStore()
+	appointmentService := app.NewAppointmentService(appointmentStore, employeeStore, serviceStore, clientStore, locationStore)
+	actor := app.MockActor
+
+	employee, service := setupAppointmentFixtures(t, employeeStore, serviceStore)
+	locationStore.StoreLocation(context.Background(), &app.Location{ID: employee.LocationID, BusinessID: "1"})
+	clientStore.StoreClient(context.Background(), &app.Client{ID: "1", BusinessID: "1", Name: "client1"})
+	clientStore.StoreClient(context.Background(), &app.Client{ID: "2", BusinessID: "2", Name: "client2"})
+
+	input := &app.CreateAppointmentInput{
+		LocationID: employee.LocationID,
+		EmployeeID: employee.ID,
+		ClientID:   "2",
+		ServiceIDs: []string{service.ID},
+		StartTime:  time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	_, err := appointmentService.CreateAppointment(context.Background(), input, actor)
+
+	if errors.Is(errors.KindInvalid, err) == false {
+		t.Errorf("expected client of other business to be invalid, got %v", err)
+	}
+
+	input.ClientID = "1"
+
+	appointment, err := appointmentService.CreateAppointment(context.Background(), input, actor)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = appointmentService.UpdateAppointment(context.Background(), appointment.LocationID, appointment.ID, &app.UpdateAppointmentInput{ClientID: "2"}, actor)
+
+	if errors.Is(errors.KindInvalid, err) == false {
+		t.Errorf("expected client of other business to be invalid, got %v", err)
+	}
+
+	storedAppointment, err := appointmentStore.GetAppointmentByID(context.Background(), appointment.ID)
+
+	if err != nil || storedAppointment.ClientID != "1" {
+		t.Errorf("expected appointment to keep client 1, got %+v", storedAppointment)
+	}
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// pqExclusionViolation is raised when "EX_appointment_1" rejects overlapping appointments
+const pqExclusionViolation = "23P01"
+
+// AppointmentStore ...
+type AppointmentStore interface {
+	GetAppointmentsByLocationIDAndTimeRange(ctx context.Context, locationID string, startTime time.Time, endTime time.Time) ([]*Appointment, error)
+	GetAppointmentsByEmployeeIDAndTimeRange(ctx context.Context, employeeID string, startTime time.Time, endTime time.Time) ([]*Appointment, error)
+	GetAppointmentByID(ctx context.Context, id string) (*Appointment, error)
+	StoreAppointment(ctx context.Context, appointment *Appointment) error
+	UpdateAppointment(ctx context.Context, appointment *Appointment) error
+	DeleteAppointment(ctx context.Context, appointment *Appointment) error
+	PurgeAppointmentsByEmployeeIDs(ctx context.Context, employeeIDs []string) error
+}
+
+type appointmentStore struct {
+	db *sql.DB
+}
+
+// NewAppointmentStore ...
+func NewAppointmentStore(db *sql.DB) AppointmentStore {
+	return &appointmentStore{db: db}
+}
+
+func (s *appointmentStore) queryAppointments(ctx context.Context, query string, args ...interface{}) ([]*Appointment, error) {
+	const op = "app/appointmentStore.queryAppointments"
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, args...)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	appointments := make([]*Appointment, 0)
+
+	for rows.Next() {
+		appointment := &Appointment{}
+		clientID := sql.NullString{}
+
+		err := rows.Scan(&appointment.ID, &appointment.LocationID, &appointment.EmployeeID, &clientID, pq.Array(&appointment.ServiceIDs), &appointment.StartTime, &appointment.EndTime, &appointment.Note, &appointment.CreatedAt, &appointment.UpdatedAt)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		appointment.ClientID = clientID.String
+		appointments = append(appointments, appointment)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return appointments, nil
+}
+
+// GetAppointmentsByLocationIDAndTimeRange gets Appointments of a Location overlapping the time range
+func (s *appointmentStore) GetAppointmentsByLocationIDAndTimeRange(ctx context.Context, locationID string, startTime time.Time, endTime time.Time) ([]*Appointment, error) {
+	const op = "app/appointmentStore.GetAppointmentsByLocationIDAndTimeRange"
+
+	query := `
+		SELECT id, location_id, employee_id, client_id, service_ids, start_time, end_time, note, created_at, updated_at
+		FROM appointment
+		WHERE location_id=$1
+			AND start_time < $3
+			AND end_time > $2
+		ORDER BY start_time;
+	`
+
+	appointments, err := s.queryAppointments(ctx, query, locationID, startTime, endTime)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to query appointments")
+	}
+
+	return appointments, nil
+}
+
+// GetAppointmentsByEmployeeIDAndTimeRange gets Appointments of an Employee overlapping the time range
+func (s *appointmentStore) GetAppointmentsByEmployeeIDAndTimeRange(ctx context.Context, employeeID string, startTime time.Time, endTime time.Time) ([]*Appointment, error) {
+	const op = "app/appointmentStore.GetAppointmentsByEmployeeIDAndTimeRange"
+
+	query := `
+		SELECT id, location_id, employee_id, client_id, service_ids, start_time, end_time, note, created_at, updated_at
+		FROM appointment
+		WHERE employee_id=$1
+			AND start_time < $3
+			AND end_time > $2
+		ORDER BY start_time;
+	`
+
+	appointments, err := s.queryAppointments(ctx, query, employeeID, startTime, endTime)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to query appointments")
+	}
+
+	return appointments, nil
+}
+
+// GetAppointmentByID gets Appointment by ID
+func (s *appointmentStore) GetAppointmentByID(ctx context.Context, id string) (*Appointment, error) {
+	const op = "app/appointmentStore.GetAppointmentByID"
+
+	query := `
+		SELECT id, location_id, employee_id, client_id, service_ids, start_time, end_time, note, created_at, updated_at
+		FROM appointment
+		WHERE id=$1;
+	`
+
+	appointment := &Appointment{}
+	clientID := sql.NullString{}
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	err := row.Scan(&appointment.ID, &appointment.LocationID, &appointment.EmployeeID, &clientID, pq.Array(&appointment.ServiceIDs), &appointment.StartTime, &appointment.EndTime, &appointment.Note, &appointment.CreatedAt, &appointment.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	appointment.ClientID = clientID.String
+
+	return appointment, nil
+}
+
+// StoreAppointment persists Appointment
+func (s *appointmentStore) StoreAppointment(ctx context.Context, appointment *Appointment) error {
+	const op = "app/appointmentStore.StoreAppointment"
+
+	query := `
+		INSERT INTO appointment (id, location_id, employee_id, client_id, service_ids, start_time, end_time, note, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, appointment.ID, appointment.LocationID, appointment.EmployeeID, toNullString(appointment.ClientID), pq.Array(appointment.ServiceIDs), appointment.StartTime, appointment.EndTime, appointment.Note, appointment.CreatedAt, appointment.UpdatedAt)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pqExclusionViolation {
+		return errors.Conflict(op, "employee already has an appointment at this time").WithCode(errors.CodeAppointmentOverlap)
+	}
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// UpdateAppointment updates Appointment including all fields
+func (s *appointmentStore) UpdateAppointment(ctx context.Context, appointment *Appointment) error {
+	const op = "app/appointmentStore.UpdateAppointment"
+
+	query := `
+		UPDATE appointment
+		SET employee_id=$2, client_id=$3, service_ids=$4, start_time=$5, end_time=$6, note=$7, updated_at=$8
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, appointment.ID, appointment.EmployeeID, toNullString(appointment.ClientID), pq.Array(appointment.ServiceIDs), appointment.StartTime, appointment.EndTime, appointment.Note, appointment.UpdatedAt)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pqExclusionViolation {
+		return errors.Conflict(op, "employee already has an appointment at this time").WithCode(errors.CodeAppointmentOverlap)
+	}
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// DeleteAppointment deletes Appointment
+func (s *appointmentStore) DeleteAppointment(ctx context.Context, appointment *Appointment) error {
+	const op = "app/appointmentStore.DeleteAppointment"
+
+	query := `
+		DELETE FROM appointment
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, appointment.ID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// PurgeAppointmentsByEmployeeIDs permanently deletes the Appointments of the Employees
+func (s *appointmentStore) PurgeAppointmentsByEmployeeIDs(ctx context.Context, employeeIDs []string) error {
+	const op = "app/appointmentStore.PurgeAppointmentsByEmployeeIDs"
+
+	if len(employeeIDs) == 0 {
+		return nil
+	}
+
+	placeholder, args := makeIDsArgs(employeeIDs)
+
+	query := fmt.Sprintf(`
+		DELETE FROM appointment
+		WHERE employee_id IN (%s);
+	`, placeholder)
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, args...)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// availabilitySlotInterval is the granularity at which bookable slots start
+const availabilitySlotInterval = 15 * time.Minute
+
+// availabilityMaxDays limits the date range of a single availability lookup
+const availabilityMaxDays = 31
+
+// TimeSlot is a free, bookable period of an employee
+type TimeSlot struct {
+	EmployeeID string    `json:"employee_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+}
+
+// AvailabilityService calculates when employees can be booked
+type AvailabilityService struct {
+	locationStore         LocationStore
+	employeeStore         EmployeeStore
+	serviceStore          ServiceStore
+	employeeScheduleStore EmployeeScheduleStore
+	appointmentStore      AppointmentStore
+}
+
+// NewAvailabilityService constructor for AvailabilityService
+func NewAvailabilityService(locationStore LocationStore, employeeStore EmployeeStore, serviceStore ServiceStore, employeeScheduleStore EmployeeScheduleStore, appointmentStore AppointmentStore) AvailabilityService {
+	return AvailabilityService{locationStore: locationStore, employeeStore: employeeStore, serviceStore: serviceStore, employeeScheduleStore: employeeScheduleStore, appointmentStore: appointmentStore}
+}
+
+// GetAvailableSlotsInput ...
+type GetAvailableSlotsInput struct {
+	LocationID string
+	ServiceID  string
+	// EmployeeID restricts the lookup to a single employee. All employees of the location are considered otherwise
+	EmployeeID string
+	// StartDate and EndDate are inclusive dates, of which only the year, month and day are used. They are days in the
+	// time zone of the location, like working hours. StartDate defaults to today at the location and EndDate to StartDate
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// Validate checks the fields of the input
+func (input *GetAvailableSlotsInput) Validate() error {
+	const op = "app/GetAvailableSlotsInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("service_id", input.ServiceID)
+	v.Check(!input.StartDate.IsZero() || input.EndDate.IsZero(), "date", errors.CodeRequired, "date is required with end_date")
+
+	if !input.StartDate.IsZero() && !input.EndDate.IsZero() {
+		v.Check(!input.EndDate.Before(input.StartDate), "end_date", errors.CodeOutOfRange, "start date must not be after end date")
+		v.Check(input.EndDate.Sub(input.StartDate) <= availabilityMaxDays*24*time.Hour, "end_date", errors.CodeOutOfRange, "date range too long")
+	}
+
+	return v.Err(op)
+}
+
+// GetAvailableSlots returns free slots long enough for the service, sorted by start time
+func (s *AvailabilityService) GetAvailableSlots(ctx context.Context, input *GetAvailableSlotsInput, actor Actor) ([]*TimeSlot, error) {
+	const op = "app/availabilityService.GetAvailableSlots"
+
+	err := actor.can(ctx, opReadAvailability)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	service, err := s.serviceStore.GetServiceByID(ctx, input.ServiceID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get service by id")
+	}
+
+	if service == nil || service.LocationID != input.LocationID {
+		return nil, errors.NotFound(op)
+	}
+
+	location, err := s.locationStore.GetLocationByID(ctx, input.LocationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get location by id")
+	}
+
+	if location == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	timeZone, err := location.loadTimeZone()
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to load time zone of location")
+	}
+
+	employees, err := s.employeeStore.GetEmployeesByLocationID(ctx, input.LocationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employees by location id")
+	}
+
+	now := time.Now()
+	startDate := input.StartDate
+
+	if startDate.IsZero() {
+		startDate = now.In(timeZone)
+	}
+
+	endDate := input.EndDate
+
+	if endDate.IsZero() {
+		endDate = startDate
+	}
+
+	duration := time.Duration(service.Duration) * time.Minute
+	rangeStart := dateIn(startDate, timeZone)
+	rangeEnd := dateIn(endDate, timeZone).AddDate(0, 0, 1)
+	slots := []*TimeSlot{}
+
+	for _, employee := range employees {
+		if input.EmployeeID != "" && employee.ID != input.EmployeeID {
+			continue
+		}
+
+		workingHours, err := s.employeeScheduleStore.GetWorkingHoursByEmployeeID(ctx, employee.ID)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to get working hours")
+		}
+
+		overrides, err := s.employeeScheduleStore.GetWorkingHoursOverridesByEmployeeIDAndDateRange(ctx, employee.ID, rangeStart, rangeEnd)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to get working hours overrides")
+		}
+
+		timeOffs, err := s.employeeScheduleStore.GetTimeOffsByEmployeeIDAndTimeRange(ctx, employee.ID, rangeStart, rangeEnd)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to get time offs")
+		}
+
+		appointments, err := s.appointmentStore.GetAppointmentsByEmployeeIDAndTimeRange(ctx, employee.ID, rangeStart, rangeEnd)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to get appointments")
+		}
+
+		for date := rangeStart; date.Before(rangeEnd); date = date.AddDate(0, 0, 1) {
+			daySlots := calculateAvailableSlots(employee.ID, date, workingHours, overrides, timeOffs, appointments, duration, now)
+			slots = append(slots, daySlots...)
+		}
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].StartTime.Before(slots[j].StartTime)
+	})
+
+	return slots, nil
+}
+
+type timeRange struct {
+	start time.Time
+	end   time.Time
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// dateIn returns the start of the day of the date of t in the time zone
+func dateIn(t time.Time, timeZone *time.Location) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, timeZone)
+}
+
+// getWorkingTimeRanges resolves working hours on the date. Overrides for the date take precedence over the weekly template
+func getWorkingTimeRanges(date time.Time, workingHours []*WorkingHours, overrides []*WorkingHoursOverride) []timeRange {
+	year, month, day := date.Date()
+	dateKey := date.Format(dateLayout)
+	hasOverride := false
+	ranges := []timeRange{}
+
+	toTime := func(minute int) time.Time {
+		return time.Date(year, month, day, 0, minute, 0, 0, date.Location())
+	}
+
+	for _, override := range overrides {
+		if override.Date.Format(dateLayout) != dateKey {
+			continue
+		}
+
+		if override.IsDayOff {
+			return []timeRange{}
+		}
+
+		hasOverride = true
+		ranges = append(ranges, timeRange{start: toTime(override.StartMinute), end: toTime(override.EndMinute)})
+	}
+
+	if hasOverride == false {
+		for _, wh := range workingHours {
+			if wh.Weekday == date.Weekday() {
+				ranges = append(ranges, timeRange{start: toTime(wh.StartMinute), end: toTime(wh.EndMinute)})
+			}
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Before(ranges[j].start)
+	})
+
+	return ranges
+}
+
+// subtractTimeRange removes the busy range from each of the free ranges
+func subtractTimeRange(free []timeRange, busy timeRange) []timeRange {
+	result := []timeRange{}
+
+	for _, f := range free {
+		if !busy.start.Before(f.end) || !busy.end.After(f.start) {
+			result = append(result, f)
+			continue
+		}
+
+		if f.start.Before(busy.start) {
+			result = append(result, timeRange{start: f.start, end: busy.start})
+		}
+
+		if busy.end.Before(f.end) {
+			result = append(result, timeRange{start: busy.end, end: f.end})
+		}
+	}
+
+	return result
+}
+
+// calculateAvailableSlots returns slots of the given duration on the date which are within working hours
+// and do not collide with time offs or appointments. Slots start at availabilitySlotInterval boundaries and not before now
+func calculateAvailableSlots(employeeID string, date time.Time, workingHours []*WorkingHours, overrides []*WorkingHoursOverride, timeOffs []*TimeOff, appointments []*Appointment, duration time.Duration, now time.Time) []*TimeSlot {
+	free := getWorkingTimeRanges(date, workingHours, overrides)
+
+	for _, timeOff := range timeOffs {
+		free = subtractTimeRange(free, timeRange{start: timeOff.StartTime, end: timeOff.EndTime})
+	}
+
+	for _, appointment := range appointments {
+		free = subtractTimeRange(free, timeRange{start: appointment.StartTime, end: appointment.EndTime})
+	}
+
+	slots := []*TimeSlot{}
+
+	if duration <= 0 {
+		return slots
+	}
+
+	for _, f := range free {
+		start := f.start
+
+		if start.Before(now) {
+			start = now
+		}
+
+		if aligned := start.Truncate(availabilitySlotInterval); aligned.Before(start) {
+			start = aligned.Add(availabilitySlotInterval)
+		}
+
+		for ; !start.Add(duration).After(f.end); start = start.Add(availabilitySlotInterval) {
+			slots = append(slots, &TimeSlot{EmployeeID: employeeID, StartTime: start, EndTime: start.Add(duration)})
+		}
+	}
+
+	return slots
+}
@@ -0,0 +1,189 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/memstore"
+)
+
+func TestCalculateAvailableSlots(t *testing.T) {
+	// 2020-01-06 is a Monday
+	date := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+	now := date.AddDate(0, 0, -1)
+	workingHours := []*app.WorkingHours{
+		{EmployeeID: "1", Weekday: time.Monday, StartMinute: 9 * 60, EndMinute: 11 * 60},
+	}
+
+	t.Run("should return slots within working hours", func(t *testing.T) {
+		slots := app.CalculateAvailableSlots("1", date, workingHours, nil, nil, nil, time.Hour, now)
+
+		// 9:00, 9:15, 9:30, 9:45, 10:00
+		if len(slots) != 5 {
+			t.Errorf("expected 5 slots, got %d", len(slots))
+			return
+		}
+
+		if slots[0].StartTime.Equal(date.Add(9*time.Hour)) == false {
+			t.Error("first slot should start at 9:00")
+		}
+
+		if slots[4].EndTime.Equal(date.Add(11*time.Hour)) == false {
+			t.Error("last slot should end at 11:00")
+		}
+	})
+
+	t.Run("should return no slots on other weekdays", func(t *testing.T) {
+		slots := app.CalculateAvailableSlots("1", date.AddDate(0, 0, 1), workingHours, nil, nil, nil, time.Hour, now)
+
+		if len(slots) != 0 {
+			t.Errorf("expected no slots, got %d", len(slots))
+		}
+	})
+
+	t.Run("should use override instead of working hours", func(t *testing.T) {
+		overrides := []*app.WorkingHoursOverride{
+			{EmployeeID: "1", Date: date, StartMinute: 13 * 60, EndMinute: 14 * 60},
+		}
+		slots := app.CalculateAvailableSlots("1", date, workingHours, overrides, nil, nil, time.Hour, now)
+
+		if len(slots) != 1 || slots[0].StartTime.Equal(date.Add(13*time.Hour)) == false {
+			t.Error("expected single slot at 13:00")
+		}
+	})
+
+	t.Run("should return no slots on day off", func(t *testing.T) {
+		overrides := []*app.WorkingHoursOverride{
+			{EmployeeID: "1", Date: date, IsDayOff: true},
+		}
+		slots := app.CalculateAvailableSlots("1", date, workingHours, overrides, nil, nil, time.Hour, now)
+
+		if len(slots) != 0 {
+			t.Errorf("expected no slots, got %d", len(slots))
+		}
+	})
+
+	t.Run("should exclude appointments and time offs", func(t *testing.T) {
+		appointments := []*app.Appointment{
+			{EmployeeID: "1", StartTime: date.Add(9*time.Hour + 30*time.Minute), EndTime: date.Add(10 * time.Hour)},
+		}
+		timeOffs := []*app.TimeOff{
+			{EmployeeID: "1", StartTime: date.Add(10*time.Hour + 45*time.Minute), EndTime: date.Add(12 * time.Hour)},
+		}
+		slots := app.CalculateAvailableSlots("1", date, workingHours, nil, timeOffs, appointments, 30*time.Minute, now)
+
+		// 9:00 and 10:00, 10:15
+		if len(slots) != 3 {
+			t.Errorf("expected 3 slots, got %d", len(slots))
+			return
+		}
+
+		if slots[1].StartTime.Equal(date.Add(10*time.Hour)) == false {
+			t.Error("second slot should start when appointment ends")
+		}
+	})
+
+	t.Run("should not return slots in the past", func(t *testing.T) {
+		slots := app.CalculateAvailableSlots("1", date, workingHours, nil, nil, nil, time.Hour, date.Add(9*time.Hour+50*time.Minute))
+
+		if len(slots) != 1 || slots[0].StartTime.Equal(date.Add(10*time.Hour)) == false {
+			t.Error("expected single slot at 10:00")
+		}
+	})
+}
+
+func TestGetAvailableSlotsHappyPath(t *testing.T) {
+	locationStore := memstore.NewLocationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	serviceStore := memstore.NewServiceStore()
+	employeeScheduleStore := memstore.NewEmployeeScheduleStore()
+	appointmentStore := memstore.NewAppointmentStore()
+	availabilityService := app.NewAvailabilityService(locationStore, employeeStore, serviceStore, employeeScheduleStore, appointmentStore)
+	actor := app.MockActor
+
+	employee, service := setupAppointmentFixtures(t, employeeStore, serviceStore)
+	location := &app.Location{ID: employee.LocationID, Name: "location1", TimeZone: "UTC"}
+	date := app.StartOfDay(time.Now().UTC()).AddDate(0, 0, 1)
+
+	err := locationStore.StoreLocation(context.Background(), location)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = employeeScheduleStore.ReplaceWorkingHours(context.Background(), employee.ID, []*app.WorkingHours{
+		{EmployeeID: employee.ID, Weekday: date.Weekday(), StartMinute: 9 * 60, EndMinute: 10 * 60},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = appointmentStore.StoreAppointment(context.Background(), &app.Appointment{
+		ID:         "1",
+		LocationID: employee.LocationID,
+		EmployeeID: employee.ID,
+		StartTime:  date.Add(9 * time.Hour),
+		EndTime:    date.Add(9*time.Hour + 30*time.Minute),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should get available slots", func(t *testing.T) {
+		input := &app.GetAvailableSlotsInput{
+			LocationID: employee.LocationID,
+			ServiceID:  service.ID,
+			StartDate:  date,
+			EndDate:    date,
+		}
+		slots, err := availabilityService.GetAvailableSlots(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(slots) != 1 || slots[0].StartTime.Equal(date.Add(9*time.Hour+30*time.Minute)) == false {
+			t.Error("expected single slot at 9:30")
+		}
+	})
+
+	t.Run("should get available slots in the time zone of the location", func(t *testing.T) {
+		timeZone, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		location.TimeZone = timeZone.String()
+
+		err = locationStore.UpdateLocation(context.Background(), location)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := &app.GetAvailableSlotsInput{
+			LocationID: employee.LocationID,
+			ServiceID:  service.ID,
+			StartDate:  date,
+		}
+		slots, err := availabilityService.GetAvailableSlots(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		year, month, day := date.Date()
+		start := time.Date(year, month, day, 9, 0, 0, 0, timeZone)
+
+		if len(slots) != 3 || slots[0].StartTime.Equal(start) == false {
+			t.Errorf("expected slots from 9:00 in Asia/Ho_Chi_Minh, got %d slots", len(slots))
+		}
+	})
+}
@@ -9,6 +9,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/minheq/kedul_server_main/auth"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/patch"
+	"github.com/minheq/kedul_server_main/query"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 // Business ...
@@ -19,18 +22,28 @@ type Business struct {
 	ProfileImageID string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	DeletedAt      time.Time
+	Version        int
+}
+
+// BusinessFields are the fields lists of Businesses can be filtered and sorted by
+var BusinessFields = query.Fields{
+	{Name: "name", Column: "name", Type: query.String, Value: func(record interface{}) interface{} { return record.(*Business).Name }},
+	{Name: "created_at", Column: "created_at", Type: query.Time, Value: func(record interface{}) interface{} { return record.(*Business).CreatedAt }},
 }
 
 // BusinessService ...
 type BusinessService struct {
-	businessStore BusinessStore
-	locationStore LocationStore
-	employeeStore EmployeeStore
+	businessStore     BusinessStore
+	locationStore     LocationStore
+	employeeStore     EmployeeStore
+	employeeRoleStore EmployeeRoleStore
+	transactor        transaction.Transactor
 }
 
 // NewBusinessService constructor for AuthService
-func NewBusinessService(businessStore BusinessStore, locationStore LocationStore, employeeStore EmployeeStore) BusinessService {
-	return BusinessService{businessStore: businessStore, locationStore: locationStore, employeeStore: employeeStore}
+func NewBusinessService(businessStore BusinessStore, locationStore LocationStore, employeeStore EmployeeStore, employeeRoleStore EmployeeRoleStore, transactor transaction.Transactor) BusinessService {
+	return BusinessService{businessStore: businessStore, locationStore: locationStore, employeeStore: employeeStore, employeeRoleStore: employeeRoleStore, transactor: transactor}
 }
 
 // GetBusinessByID ...
@@ -43,11 +56,15 @@ func (s *BusinessService) GetBusinessByID(ctx context.Context, id string) (*Busi
 		return nil, errors.Wrap(op, err, "failed to get business by id")
 	}
 
+	if business == nil {
+		return nil, errors.NotFound(op)
+	}
+
 	return business, nil
 }
 
-func (s *BusinessService) getBusinessesAsEmployeeByUserID(ctx context.Context, userID string) ([]*Business, error) {
-	const op = "app/businessService.getBusinessesAsEmployeeByUserID"
+func (s *BusinessService) getBusinessIDsAsEmployeeByUserID(ctx context.Context, userID string) ([]string, error) {
+	const op = "app/businessService.getBusinessIDsAsEmployeeByUserID"
 
 	userAsEmployeeList, err := s.employeeStore.GetEmployeesByUserID(ctx, userID)
 
@@ -73,55 +90,36 @@ func (s *BusinessService) getBusinessesAsEmployeeByUserID(ctx context.Context, u
 		businessIDs = append(businessIDs, location.BusinessID)
 	}
 
-	businesses, err := s.businessStore.GetBusinessesByIDs(ctx, businessIDs)
-
-	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get businesses by businessIDs")
-	}
-
-	return businesses, nil
+	return businessIDs, nil
 }
 
-// GetBusinessesByUserID ...
-func (s *BusinessService) GetBusinessesByUserID(ctx context.Context, userID string, currentUser *auth.User) ([]*Business, error) {
+// GetBusinessesByUserID gets a page of the businesses the user owns or is an employee of
+func (s *BusinessService) GetBusinessesByUserID(ctx context.Context, userID string, args PageArgs, currentUser *auth.User) ([]*Business, *Page, error) {
 	const op = "app/businessService.GetBusinessesByUserID"
 
 	if userID != currentUser.ID {
-		return nil, errors.Unauthorized(op, fmt.Errorf("current user not owner"))
+		return nil, nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
 	}
 
-	businessesAsEmployee, err := s.getBusinessesAsEmployeeByUserID(ctx, userID)
+	err := args.Validate()
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get businesses by based on user as employee")
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
 	}
 
-	businessesAsOwner, err := s.businessStore.GetBusinessesByUserID(ctx, userID)
+	businessIDsAsEmployee, err := s.getBusinessIDsAsEmployeeByUserID(ctx, userID)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get businesses by based on user as owner")
+		return nil, nil, errors.Wrap(op, err, "failed to get business ids based on user as employee")
 	}
 
-	businesses := []*Business{}
-
-	for _, businessAsEmployee := range businessesAsEmployee {
-		businesses = append(businesses, businessAsEmployee)
-	}
-
-	for _, business := range businessesAsOwner {
-		alreadyExists := false
-		for _, appendedBusiness := range businesses {
-			if appendedBusiness.ID == business.ID {
-				alreadyExists = true
-			}
-		}
+	businesses, page, err := s.businessStore.GetBusinessPageByUserIDOrIDs(ctx, userID, businessIDsAsEmployee, args)
 
-		if alreadyExists == false {
-			businesses = append(businesses, business)
-		}
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "failed to get business page")
 	}
 
-	return businesses, nil
+	return businesses, page, nil
 }
 
 // CreateBusinessInput ...
@@ -130,10 +128,26 @@ type CreateBusinessInput struct {
 	ProfileImageID string `json:"profile_image_id"`
 }
 
+// Validate checks the fields of the input
+func (input *CreateBusinessInput) Validate() error {
+	const op = "app/CreateBusinessInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("name", input.Name)
+
+	return v.Err(op)
+}
+
 // CreateBusiness creates business
 func (s *BusinessService) CreateBusiness(ctx context.Context, userID string, input *CreateBusinessInput) (*Business, error) {
 	const op = "app/businessService.CreateBusiness"
 
+	err := input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
 	existingBusiness, err := s.businessStore.GetBusinessByName(ctx, strings.TrimSpace(input.Name))
 
 	if err != nil {
@@ -141,7 +155,7 @@ func (s *BusinessService) CreateBusiness(ctx context.Context, userID string, inp
 	}
 
 	if existingBusiness != nil {
-		return nil, errors.Invalid(op, fmt.Sprintf("business with name %s already exists", strings.TrimSpace(input.Name)))
+		return nil, errors.Conflict(op, fmt.Sprintf("business with name %s already exists", strings.TrimSpace(input.Name))).WithCode(errors.CodeBusinessNameTaken)
 	}
 
 	now := time.Now()
@@ -170,18 +184,72 @@ type UpdateBusinessInput struct {
 	ProfileImageID string `json:"profile_image_id"`
 }
 
-// UpdateBusiness updates business
-func (s *BusinessService) UpdateBusiness(ctx context.Context, id string, input *UpdateBusinessInput, currentUser *auth.User) (*Business, error) {
+// Validate checks the fields of the input
+func (input *UpdateBusinessInput) Validate() error {
+	const op = "app/UpdateBusinessInput.Validate"
+
+	v := &errors.Validation{}
+	v.NotBlank("name", input.Name)
+
+	return v.Err(op)
+}
+
+// UpdateBusiness updates business. Empty fields of the input are left unchanged
+func (s *BusinessService) UpdateBusiness(ctx context.Context, id string, version int, input *UpdateBusinessInput, currentUser *auth.User) (*Business, error) {
 	const op = "app/businessService.UpdateBusiness"
 
-	existingBusiness, err := s.businessStore.GetBusinessByName(ctx, strings.TrimSpace(input.Name))
+	err := input.Validate()
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to get business by name")
+		return nil, errors.Wrap(op, err, "invalid input")
 	}
 
-	if existingBusiness != nil {
-		return nil, errors.Invalid(op, fmt.Sprintf("business with name %s already exists", strings.TrimSpace(input.Name)))
+	business, err := s.PatchBusiness(ctx, id, version, &PatchBusinessInput{Name: patch.Changed(input.Name), ProfileImageID: patch.Changed(input.ProfileImageID)}, currentUser)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to patch business")
+	}
+
+	return business, nil
+}
+
+// PatchBusinessInput is a merge patch of a business. The name cannot be removed
+type PatchBusinessInput struct {
+	Name           patch.String `json:"name"`
+	ProfileImageID patch.String `json:"profile_image_id"`
+}
+
+// Validate checks the fields of the input
+func (input *PatchBusinessInput) Validate() error {
+	const op = "app/PatchBusinessInput.Validate"
+
+	v := &errors.Validation{}
+	input.Name.Required(v, "name")
+	input.ProfileImageID.Optional(v, "profile_image_id")
+
+	return v.Err(op)
+}
+
+// PatchBusiness changes the fields of business in the input, and removes those that are null, unless version is set and outdated
+func (s *BusinessService) PatchBusiness(ctx context.Context, id string, version int, input *PatchBusinessInput, currentUser *auth.User) (*Business, error) {
+	const op = "app/businessService.PatchBusiness"
+
+	err := input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	if input.Name.Set {
+		existingBusiness, err := s.businessStore.GetBusinessByName(ctx, strings.TrimSpace(input.Name.Value))
+
+		if err != nil {
+			return nil, errors.Unexpected(op, err, "failed to get business by name")
+		}
+
+		if existingBusiness != nil && existingBusiness.ID != id {
+			return nil, errors.Conflict(op, fmt.Sprintf("business with name %s already exists", strings.TrimSpace(input.Name.Value))).WithCode(errors.CodeBusinessNameTaken)
+		}
 	}
 
 	business, err := s.businessStore.GetBusinessByID(ctx, id)
@@ -195,29 +263,33 @@ func (s *BusinessService) UpdateBusiness(ctx context.Context, id string, input *
 	}
 
 	if business.UserID != currentUser.ID {
-		return nil, errors.Unauthorized(op, fmt.Errorf("current user not owner"))
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
+	}
+
+	if version != 0 && business.Version != version {
+		return nil, errors.PreconditionFailed(op, "business was changed meanwhile")
 	}
 
 	business.UpdatedAt = time.Now()
 
-	if input.Name != "" {
-		business.Name = strings.TrimSpace(input.Name)
+	if input.Name.Set {
+		business.Name = strings.TrimSpace(input.Name.Value)
 	}
-	if input.ProfileImageID != "" {
-		business.ProfileImageID = input.ProfileImageID
+	if input.ProfileImageID.Set {
+		business.ProfileImageID = input.ProfileImageID.Value
 	}
 
 	err = s.businessStore.UpdateBusiness(ctx, business)
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update business")
+		return nil, errors.Wrap(op, err, "failed to update business")
 	}
 
 	return business, nil
 }
 
-// DeleteBusiness updates business
-func (s *BusinessService) DeleteBusiness(ctx context.Context, id string, currentUser *auth.User) (*Business, error) {
+// DeleteBusiness marks business as deleted along with its locations, their employees and employee roles
+func (s *BusinessService) DeleteBusiness(ctx context.Context, id string, version int, currentUser *auth.User) (*Business, error) {
 	const op = "app/businessService.DeleteBusiness"
 
 	business, err := s.businessStore.GetBusinessByID(ctx, id)
@@ -231,13 +303,123 @@ func (s *BusinessService) DeleteBusiness(ctx context.Context, id string, current
 	}
 
 	if business.UserID != currentUser.ID {
-		return nil, errors.Unauthorized(op, fmt.Errorf("current user not owner"))
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
 	}
 
-	err = s.businessStore.DeleteBusiness(ctx, business)
+	if version != 0 && business.Version != version {
+		return nil, errors.PreconditionFailed(op, "business was changed meanwhile")
+	}
+
+	business.DeletedAt = time.Now()
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		locations, err := s.locationStore.GetLocationsByBusinessID(ctx, business.ID)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to get locations by business id")
+		}
+
+		for _, location := range locations {
+			err = deleteLocationChildren(ctx, s.employeeStore, s.employeeRoleStore, location.ID, business.DeletedAt)
+
+			if err != nil {
+				return errors.Wrap(op, err, "failed to delete location children")
+			}
+		}
+
+		err = s.locationStore.DeleteLocationsByBusinessID(ctx, business.ID, business.DeletedAt)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to delete locations by business id")
+		}
+
+		err = s.businessStore.DeleteBusiness(ctx, business)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to delete business")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to delete business")
+	}
+
+	return business, nil
+}
+
+// RestoreBusiness restores deleted business along with the locations, employees and employee roles deleted with it
+func (s *BusinessService) RestoreBusiness(ctx context.Context, id string, currentUser *auth.User) (*Business, error) {
+	const op = "app/businessService.RestoreBusiness"
+
+	business, err := s.businessStore.GetDeletedBusinessByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get deleted business by id")
+	}
+
+	if business == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	if business.UserID != currentUser.ID {
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
+	}
+
+	now := time.Now()
+
+	if !isRestorable(business.DeletedAt, now) {
+		return nil, errors.NotFound(op)
+	}
+
+	existingBusiness, err := s.businessStore.GetBusinessByName(ctx, business.Name)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to get business by name")
+	}
+
+	if existingBusiness != nil {
+		return nil, errors.Conflict(op, fmt.Sprintf("business with name %s already exists", business.Name)).WithCode(errors.CodeBusinessNameTaken)
+	}
+
+	deletedAt := business.DeletedAt
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.locationStore.RestoreLocationsByBusinessID(ctx, business.ID, deletedAt)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to restore locations by business id")
+		}
+
+		locations, err := s.locationStore.GetLocationsByBusinessID(ctx, business.ID)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to get locations by business id")
+		}
+
+		for _, location := range locations {
+			err = restoreLocationChildren(ctx, s.employeeStore, s.employeeRoleStore, location.ID, deletedAt)
+
+			if err != nil {
+				return errors.Wrap(op, err, "failed to restore location children")
+			}
+		}
+
+		business.UpdatedAt = now
+		business.DeletedAt = time.Time{}
+
+		err = s.businessStore.RestoreBusiness(ctx, business)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to restore business")
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update business")
+		return nil, errors.Wrap(op, err, "failed to restore business")
 	}
 
 	return business, nil
@@ -1,98 +1,26 @@
-package app
+package app_test
 
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/minheq/kedul_server_main/app"
 	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+	"github.com/minheq/kedul_server_main/patch"
 )
 
-type mockBusinessStore struct {
-	businesses []*Business
-}
-
-func (s *mockBusinessStore) GetBusinessesByIDs(ctx context.Context, ids []string) ([]*Business, error) {
-	businesses := []*Business{}
-
-	for _, l := range s.businesses {
-		for _, id := range ids {
-			if l.ID == id {
-				businesses = append(businesses, l)
-			}
-		}
-	}
-
-	return businesses, nil
-}
-
-func (s *mockBusinessStore) GetBusinessesByUserID(ctx context.Context, userID string) ([]*Business, error) {
-	businesses := make([]*Business, 0)
-
-	for _, e := range s.businesses {
-		if e.UserID == userID {
-			businesses = append(businesses, e)
-		}
-	}
-
-	return businesses, nil
-}
-
-func (s *mockBusinessStore) GetBusinessByID(ctx context.Context, id string) (*Business, error) {
-	for _, b := range s.businesses {
-		if b.ID == id {
-			return b, nil
-		}
-	}
-
-	return nil, nil
-}
-
-func (s *mockBusinessStore) GetBusinessByName(ctx context.Context, name string) (*Business, error) {
-	for _, b := range s.businesses {
-		if b.Name == name {
-			return b, nil
-		}
-	}
-
-	return nil, nil
-}
-
-func (s *mockBusinessStore) StoreBusiness(ctx context.Context, business *Business) error {
-	s.businesses = append(s.businesses, business)
-
-	return nil
-}
-
-func (s *mockBusinessStore) UpdateBusiness(ctx context.Context, business *Business) error {
-	for i, b := range s.businesses {
-		if b.ID == business.ID {
-			s.businesses[i] = business
-			break
-		}
-	}
-
-	return nil
-}
-
-func (s *mockBusinessStore) DeleteBusiness(ctx context.Context, business *Business) error {
-	for i, b := range s.businesses {
-		if b.ID == business.ID {
-			s.businesses = append(s.businesses[:i], s.businesses[i+1:]...)
-			break
-		}
-	}
-
-	return nil
-}
-
 func TestCreateBusinessHappyPath(t *testing.T) {
-	businessStore := &mockBusinessStore{}
-	locationStore := &mockLocationStore{}
-	employeeStore := &mockEmployeeStore{}
-	businessService := NewBusinessService(businessStore, locationStore, employeeStore)
+	businessStore := memstore.NewBusinessStore()
+	locationStore := memstore.NewLocationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	businessService := app.NewBusinessService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
 
 	t.Run("should create business", func(t *testing.T) {
-		input := &CreateBusinessInput{
+		input := &app.CreateBusinessInput{
 			Name: "business1",
 		}
 
@@ -106,15 +34,16 @@ func TestCreateBusinessHappyPath(t *testing.T) {
 }
 
 func TestUpdateBusinessHappyPath(t *testing.T) {
-	businessStore := &mockBusinessStore{}
-	locationStore := &mockLocationStore{}
-	employeeStore := &mockEmployeeStore{}
-	businessService := NewBusinessService(businessStore, locationStore, employeeStore)
+	businessStore := memstore.NewBusinessStore()
+	locationStore := memstore.NewLocationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	businessService := app.NewBusinessService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
 
 	currentUser := &auth.User{
 		ID: "1",
 	}
-	business := &Business{
+	business := &app.Business{
 		ID:     "1",
 		UserID: currentUser.ID,
 		Name:   "business2",
@@ -128,11 +57,11 @@ func TestUpdateBusinessHappyPath(t *testing.T) {
 	}
 
 	t.Run("should update business", func(t *testing.T) {
-		input := &UpdateBusinessInput{
+		input := &app.UpdateBusinessInput{
 			Name: "new business2",
 		}
 
-		_, err := businessService.UpdateBusiness(context.Background(), business.ID, input, currentUser)
+		_, err := businessService.UpdateBusiness(context.Background(), business.ID, 0, input, currentUser)
 
 		if err != nil {
 			t.Error(err)
@@ -141,15 +70,60 @@ func TestUpdateBusinessHappyPath(t *testing.T) {
 	})
 }
 
+func TestPatchBusiness(t *testing.T) {
+	businessStore := memstore.NewBusinessStore()
+	businessService := app.NewBusinessService(businessStore, memstore.NewLocationStore(), memstore.NewEmployeeStore(), memstore.NewEmployeeRoleStore(), memstore.NewTransactor())
+	currentUser := &auth.User{ID: "4"}
+	business := &app.Business{ID: "4", UserID: currentUser.ID, Name: "business6", ProfileImageID: "image"}
+
+	businessStore.StoreBusiness(context.Background(), business)
+
+	t.Run("should remove null fields and leave absent fields unchanged", func(t *testing.T) {
+		input := &app.PatchBusinessInput{ProfileImageID: patch.Null()}
+
+		got, err := businessService.PatchBusiness(context.Background(), business.ID, 0, input, currentUser)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if got.Name != "business6" || got.ProfileImageID != "" {
+			t.Errorf("expected name business6 without profile image, got %+v", got)
+		}
+	})
+
+	t.Run("should keep own name", func(t *testing.T) {
+		input := &app.PatchBusinessInput{Name: patch.Value("business6")}
+
+		_, err := businessService.PatchBusiness(context.Background(), business.ID, 0, input, currentUser)
+
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should not remove name", func(t *testing.T) {
+		input := &app.PatchBusinessInput{Name: patch.Null()}
+
+		_, err := businessService.PatchBusiness(context.Background(), business.ID, 0, input, currentUser)
+
+		if errors.ErrorCode(err) != errors.CodeValidationFailed || errors.ErrorViolations(err)[0].Code != errors.CodeRequired {
+			t.Errorf("expected name to be required, got %v", err)
+		}
+	})
+}
+
 func TestDeleteBusinessHappyPath(t *testing.T) {
-	businessStore := &mockBusinessStore{}
-	locationStore := &mockLocationStore{}
-	employeeStore := &mockEmployeeStore{}
-	businessService := NewBusinessService(businessStore, locationStore, employeeStore)
+	businessStore := memstore.NewBusinessStore()
+	locationStore := memstore.NewLocationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	businessService := app.NewBusinessService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
 	currentUser := &auth.User{
 		ID: "2",
 	}
-	business := &Business{
+	business := &app.Business{
 		ID:     "2",
 		UserID: currentUser.ID,
 		Name:   "business4",
@@ -163,11 +137,120 @@ func TestDeleteBusinessHappyPath(t *testing.T) {
 	}
 
 	t.Run("should update business", func(t *testing.T) {
-		_, err := businessService.DeleteBusiness(context.Background(), business.ID, currentUser)
+		_, err := businessService.DeleteBusiness(context.Background(), business.ID, 0, currentUser)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+}
+
+func TestDeleteBusinessCascade(t *testing.T) {
+	businessStore := memstore.NewBusinessStore()
+	locationStore := memstore.NewLocationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	businessService := app.NewBusinessService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
+	currentUser := &auth.User{ID: "3"}
+
+	business := &app.Business{ID: "3", UserID: currentUser.ID, Name: "business5"}
+	location := &app.Location{ID: "3", BusinessID: business.ID, Name: "location5"}
+	employeeRole := &app.EmployeeRole{ID: "3", LocationID: location.ID, Name: "owner", IsOwner: true}
+	employee := &app.Employee{ID: "3", LocationID: location.ID, UserID: currentUser.ID, EmployeeRoleID: employeeRole.ID}
+	removedEmployee := &app.Employee{ID: "4", LocationID: location.ID, EmployeeRoleID: employeeRole.ID, DeletedAt: time.Now().Add(-time.Hour)}
+
+	businessStore.StoreBusiness(context.Background(), business)
+	locationStore.StoreLocation(context.Background(), location)
+	employeeRoleStore.StoreEmployeeRole(context.Background(), employeeRole)
+	employeeStore.StoreEmployee(context.Background(), employee)
+	employeeStore.StoreEmployee(context.Background(), removedEmployee)
+
+	t.Run("should delete locations, employees and employee roles with business", func(t *testing.T) {
+		_, err := businessService.DeleteBusiness(context.Background(), business.ID, 0, currentUser)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		storedLocation, _ := locationStore.GetLocationByID(context.Background(), location.ID)
+		storedEmployee, _ := employeeStore.GetEmployeeByID(context.Background(), employee.ID)
+		storedEmployeeRole, _ := employeeRoleStore.GetEmployeeRoleByID(context.Background(), employeeRole.ID)
+
+		if storedLocation != nil || storedEmployee != nil || storedEmployeeRole != nil {
+			t.Error("children of business should be deleted")
+		}
+
+		_, err = businessService.GetBusinessByID(context.Background(), business.ID)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Errorf("deleted business should not be found, got %v", err)
+		}
+	})
+
+	t.Run("should restore only what was deleted with business", func(t *testing.T) {
+		_, err := businessService.RestoreBusiness(context.Background(), business.ID, currentUser)
 
 		if err != nil {
 			t.Error(err)
 			return
 		}
+
+		storedBusiness, _ := businessStore.GetBusinessByID(context.Background(), business.ID)
+		storedLocation, _ := locationStore.GetLocationByID(context.Background(), location.ID)
+		storedEmployee, _ := employeeStore.GetEmployeeByID(context.Background(), employee.ID)
+		storedEmployeeRole, _ := employeeRoleStore.GetEmployeeRoleByID(context.Background(), employeeRole.ID)
+
+		if storedBusiness == nil || storedLocation == nil || storedEmployee == nil || storedEmployeeRole == nil {
+			t.Error("business and its children should be restored")
+		}
+
+		storedRemovedEmployee, _ := employeeStore.GetEmployeeByID(context.Background(), removedEmployee.ID)
+
+		if storedRemovedEmployee != nil {
+			t.Error("employee deleted before business should stay deleted")
+		}
+	})
+}
+
+func TestRestoreBusiness(t *testing.T) {
+	businessStore := memstore.NewBusinessStore()
+	locationStore := memstore.NewLocationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	businessService := app.NewBusinessService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
+	currentUser := &auth.User{ID: "4"}
+
+	expired := &app.Business{ID: "4", UserID: currentUser.ID, Name: "business6", DeletedAt: time.Now().Add(-app.DeletedRetention - time.Hour)}
+	renamed := &app.Business{ID: "5", UserID: currentUser.ID, Name: "business7", DeletedAt: time.Now()}
+	taken := &app.Business{ID: "6", UserID: "5", Name: "business7"}
+
+	businessStore.StoreBusiness(context.Background(), expired)
+	businessStore.StoreBusiness(context.Background(), renamed)
+	businessStore.StoreBusiness(context.Background(), taken)
+
+	t.Run("should not restore business after retention", func(t *testing.T) {
+		_, err := businessService.RestoreBusiness(context.Background(), expired.ID, currentUser)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Errorf("expected not found, got %v", err)
+		}
+	})
+
+	t.Run("should not restore business whose name was taken", func(t *testing.T) {
+		_, err := businessService.RestoreBusiness(context.Background(), renamed.ID, currentUser)
+
+		if errors.ErrorCode(err) != errors.CodeBusinessNameTaken {
+			t.Errorf("expected %s, got %v", errors.CodeBusinessNameTaken, err)
+		}
+	})
+
+	t.Run("should not restore business of another user", func(t *testing.T) {
+		_, err := businessService.RestoreBusiness(context.Background(), renamed.ID, &auth.User{ID: "5"})
+
+		if errors.Is(errors.KindForbidden, err) == false {
+			t.Errorf("expected forbidden, got %v", err)
+		}
 	})
 }
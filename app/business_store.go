@@ -4,19 +4,25 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 // BusinessStore ...
 type BusinessStore interface {
 	GetBusinessesByIDs(ctx context.Context, ids []string) ([]*Business, error)
 	GetBusinessesByUserID(ctx context.Context, userID string) ([]*Business, error)
+	GetBusinessPageByUserIDOrIDs(ctx context.Context, userID string, ids []string, args PageArgs) ([]*Business, *Page, error)
 	GetBusinessByID(ctx context.Context, id string) (*Business, error)
 	GetBusinessByName(ctx context.Context, name string) (*Business, error)
 	StoreBusiness(ctx context.Context, b *Business) error
 	UpdateBusiness(ctx context.Context, b *Business) error
 	DeleteBusiness(ctx context.Context, b *Business) error
+	GetDeletedBusinessByID(ctx context.Context, id string) (*Business, error)
+	RestoreBusiness(ctx context.Context, b *Business) error
+	PurgeBusinesses(ctx context.Context, deletedBefore time.Time) ([]string, error)
 }
 
 // businessStore ...
@@ -40,23 +46,27 @@ func (s *businessStore) GetBusinessesByIDs(ctx context.Context, ids []string) ([
 	placeholder, args := makeIDsArgs(ids)
 
 	query := fmt.Sprintf(`
-		SELECT id, user_id, name, profile_image_id, created_at, updated_at
+		SELECT id, user_id, name, profile_image_id, created_at, updated_at, version
 		FROM business
 		WHERE id IN (%s)
+			AND deleted_at IS NULL
+		ORDER BY created_at;
 	`, placeholder)
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := transaction.DB(ctx, s.db).Query(query, args...)
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
 
+	defer rows.Close()
+
 	businesses := make([]*Business, 0)
 
 	for rows.Next() {
 		business := &Business{}
 
-		err := rows.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt)
+		err := rows.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt, &business.Version)
 
 		if err != nil {
 			return nil, errors.Wrap(op, err, "row scan error")
@@ -79,22 +89,26 @@ func (s *businessStore) GetBusinessesByUserID(ctx context.Context, userID string
 	const op = "app/businessStore.GetBusinessesByUserID"
 
 	query := `
-		SELECT id, user_id, name, profile_image_id, created_at, updated_at
+		SELECT id, user_id, name, profile_image_id, created_at, updated_at, version
 		FROM business
-		WHERE user_id=$1;
+		WHERE user_id=$1
+			AND deleted_at IS NULL
+		ORDER BY created_at;
 	`
 	businesses := make([]*Business, 0)
 
-	rows, err := s.db.Query(query, userID)
+	rows, err := transaction.DB(ctx, s.db).Query(query, userID)
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
 		business := &Business{}
 
-		err := rows.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt)
+		err := rows.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt, &business.Version)
 
 		if err != nil {
 			return nil, errors.Wrap(op, err, "row scan error")
@@ -112,26 +126,70 @@ func (s *businessStore) GetBusinessesByUserID(ctx context.Context, userID string
 	return businesses, nil
 }
 
+// GetBusinessPageByUserIDOrIDs gets a page of the Businesses of UserID or with one of the IDs, filtered and sorted by args
+func (s *businessStore) GetBusinessPageByUserIDOrIDs(ctx context.Context, userID string, ids []string, args PageArgs) ([]*Business, *Page, error) {
+	const op = "app/businessStore.GetBusinessPageByUserIDOrIDs"
+
+	condition := "user_id=$1"
+	params := []interface{}{userID}
+
+	if len(ids) > 0 {
+		placeholder, idParams := makeIDsArgs(ids)
+		params = append(idParams, userID)
+		condition = fmt.Sprintf("(id IN (%s) OR user_id=$%d)", placeholder, len(params))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, name, profile_image_id, created_at, updated_at, version
+		FROM business
+		WHERE %s
+			AND deleted_at IS NULL
+	`, condition)
+
+	businesses := make([]*Business, 0)
+
+	start, end, page, err := queryPage(ctx, s.db, query, params, args, func(rows *transaction.Rows) (Cursor, error) {
+		business := &Business{}
+
+		err := rows.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt, &business.Version)
+
+		if err != nil {
+			return Cursor{}, err
+		}
+
+		businesses = append(businesses, business)
+
+		return args.CursorOf(business, business.CreatedAt, business.ID), nil
+	})
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "database error")
+	}
+
+	return businesses[start:end], page, nil
+}
+
 // GetBusinessByID gets Business by ID
 func (s *businessStore) GetBusinessByID(ctx context.Context, id string) (*Business, error) {
 	const op = "app/businessStore.GetBusinessByID"
 
 	query := `
-		SELECT id, user_id, name, profile_image_id, created_at, updated_at
+		SELECT id, user_id, name, profile_image_id, created_at, updated_at, version
 		FROM business
-		WHERE id=$1;
+		WHERE id=$1
+			AND deleted_at IS NULL;
 	`
 
 	var business Business
 
-	row := s.db.QueryRow(query, id)
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	err := row.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt, &business.Version)
 
-	if row == nil {
+	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 
-	err := row.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt)
-
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
@@ -144,16 +202,17 @@ func (s *businessStore) GetBusinessByName(ctx context.Context, name string) (*Bu
 	const op = "app/businessStore.GetBusinessByName"
 
 	query := `
-		SELECT id, user_id, name, profile_image_id, created_at, updated_at
+		SELECT id, user_id, name, profile_image_id, created_at, updated_at, version
 		FROM business
-		WHERE name=$1;
+		WHERE name=$1
+			AND deleted_at IS NULL;
 	`
 
 	var b Business
 
-	row := s.db.QueryRow(query, name)
+	row := transaction.DB(ctx, s.db).QueryRow(query, name)
 
-	err := row.Scan(&b.ID, &b.UserID, &b.Name, &b.ProfileImageID, &b.CreatedAt, &b.UpdatedAt)
+	err := row.Scan(&b.ID, &b.UserID, &b.Name, &b.ProfileImageID, &b.CreatedAt, &b.UpdatedAt, &b.Version)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -175,44 +234,120 @@ func (s *businessStore) StoreBusiness(ctx context.Context, b *Business) error {
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := s.db.Exec(query, b.ID, b.UserID, b.Name, b.ProfileImageID, b.CreatedAt, b.UpdatedAt)
+	_, err := transaction.DB(ctx, s.db).Exec(query, b.ID, b.UserID, b.Name, b.ProfileImageID, b.CreatedAt, b.UpdatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	b.Version = 1
+
 	return nil
 }
 
-// UpdateBusiness updates Business including all fields
+// UpdateBusiness updates Business including all fields. It fails with KindPreconditionFailed unless the stored Version is still the same, and increments Version
 func (s *businessStore) UpdateBusiness(ctx context.Context, b *Business) error {
 	const op = "app/businessStore.UpdateBusiness"
 
 	query := `
 		UPDATE business
-		SET name=$2, profile_image_id=$3, updated_at=$4
-		WHERE id=$1;
+		SET name=$2, profile_image_id=$3, updated_at=$4, version=version+1
+		WHERE id=$1
+			AND version=$5;
 	`
 
-	_, err := s.db.Exec(query, b.ID, b.Name, b.ProfileImageID, b.UpdatedAt)
+	result, err := transaction.DB(ctx, s.db).Exec(query, b.ID, b.Name, b.ProfileImageID, b.UpdatedAt, b.Version)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	if count == 0 {
+		return errors.PreconditionFailed(op, "business was changed meanwhile")
+	}
+
+	b.Version++
+
 	return nil
 }
 
-// DeleteBusiness deletes Business
+// DeleteBusiness marks Business as deleted at DeletedAt. Like updates, it requires and increments Version. It can be restored until it is purged
 func (s *businessStore) DeleteBusiness(ctx context.Context, b *Business) error {
 	const op = "app/businessStore.DeleteBusiness"
 
 	query := `
-		DELETE FROM business
+		UPDATE business
+		SET deleted_at=$2, version=version+1
+		WHERE id=$1
+			AND version=$3;
+	`
+
+	result, err := transaction.DB(ctx, s.db).Exec(query, b.ID, b.DeletedAt, b.Version)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	if count == 0 {
+		return errors.PreconditionFailed(op, "business was changed meanwhile")
+	}
+
+	b.Version++
+
+	return nil
+}
+
+// GetDeletedBusinessByID gets Business by ID when it is deleted
+func (s *businessStore) GetDeletedBusinessByID(ctx context.Context, id string) (*Business, error) {
+	const op = "app/businessStore.GetDeletedBusinessByID"
+
+	query := `
+		SELECT id, user_id, name, profile_image_id, created_at, updated_at, version, deleted_at
+		FROM business
+		WHERE id=$1
+			AND deleted_at IS NOT NULL;
+	`
+
+	var business Business
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	err := row.Scan(&business.ID, &business.UserID, &business.Name, &business.ProfileImageID, &business.CreatedAt, &business.UpdatedAt, &business.Version, &business.DeletedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return &business, nil
+}
+
+// RestoreBusiness unmarks deleted Business
+func (s *businessStore) RestoreBusiness(ctx context.Context, b *Business) error {
+	const op = "app/businessStore.RestoreBusiness"
+
+	query := `
+		UPDATE business
+		SET deleted_at=NULL, updated_at=$2
 		WHERE id=$1;
 	`
 
-	_, err := s.db.Exec(query, b.ID)
+	_, err := transaction.DB(ctx, s.db).Exec(query, b.ID, b.UpdatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
@@ -220,3 +355,22 @@ func (s *businessStore) DeleteBusiness(ctx context.Context, b *Business) error {
 
 	return nil
 }
+
+// PurgeBusinesses permanently deletes Businesses deleted before the time, and returns their ids
+func (s *businessStore) PurgeBusinesses(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	const op = "app/businessStore.PurgeBusinesses"
+
+	query := `
+		DELETE FROM business
+		WHERE deleted_at < $1
+		RETURNING id;
+	`
+
+	ids, err := queryIDs(ctx, s.db, query, deletedBefore)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return ids, nil
+}
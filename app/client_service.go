@@ -0,0 +1,444 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/phone"
+)
+
+// Client is a customer of a business. UserID is set once the client logs in with the same phone number
+type Client struct {
+	ID          string    `json:"id"`
+	BusinessID  string    `json:"business_id"`
+	UserID      string    `json:"user_id"`
+	Name        string    `json:"name"`
+	PhoneNumber string    `json:"phone_number"`
+	CountryCode string    `json:"country_code"`
+	Email       string    `json:"email"`
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
+	Birthday    time.Time `json:"birthday"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ClientService ...
+type ClientService struct {
+	clientStore   ClientStore
+	locationStore LocationStore
+}
+
+// NewClientService constructor for ClientService
+func NewClientService(clientStore ClientStore, locationStore LocationStore) ClientService {
+	return ClientService{clientStore: clientStore, locationStore: locationStore}
+}
+
+// getBusinessID resolves the business of the location. Clients are shared between locations of a business
+func (s *ClientService) getBusinessID(ctx context.Context, locationID string) (string, error) {
+	const op = "app/clientService.getBusinessID"
+
+	location, err := s.locationStore.GetLocationByID(ctx, locationID)
+
+	if err != nil {
+		return "", errors.Wrap(op, err, "failed to get location by id")
+	}
+
+	if location == nil {
+		return "", errors.NotFound(op)
+	}
+
+	return location.BusinessID, nil
+}
+
+func (s *ClientService) getClient(ctx context.Context, locationID string, id string) (*Client, error) {
+	const op = "app/clientService.getClient"
+
+	businessID, err := s.getBusinessID(ctx, locationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business id")
+	}
+
+	client, err := s.clientStore.GetClientByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get client by id")
+	}
+
+	if client == nil || client.BusinessID != businessID {
+		return nil, errors.NotFound(op)
+	}
+
+	return client, nil
+}
+
+// formatClientPhoneNumber normalizes the phone number. Clients without phone number are allowed
+func formatClientPhoneNumber(op string, phoneNumber string, countryCode string) (string, error) {
+	if strings.TrimSpace(phoneNumber) == "" {
+		return "", nil
+	}
+
+	formattedPhoneNumber, err := phone.FormatPhoneNumber(phoneNumber, countryCode)
+
+	if err != nil {
+		return "", errors.InvalidField(op, "phone_number", errors.CodeInvalidFormat, "invalid phone number")
+	}
+
+	return formattedPhoneNumber, nil
+}
+
+func validateBirthday(v *errors.Validation, birthday string) {
+	if birthday == "" {
+		return
+	}
+
+	_, err := time.Parse(dateLayout, birthday)
+
+	v.Check(err == nil, "birthday", errors.CodeInvalidFormat, "birthday must be in YYYY-MM-DD format")
+}
+
+func parseBirthday(op string, birthday string) (time.Time, error) {
+	if birthday == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(dateLayout, birthday)
+
+	if err != nil {
+		return time.Time{}, errors.InvalidField(op, "birthday", errors.CodeInvalidFormat, "birthday must be in YYYY-MM-DD format")
+	}
+
+	return date, nil
+}
+
+// checkPhoneNumberAvailable fails when another client of the business has the phone number
+func (s *ClientService) checkPhoneNumberAvailable(ctx context.Context, client *Client) error {
+	const op = "app/clientService.checkPhoneNumberAvailable"
+
+	if client.PhoneNumber == "" {
+		return nil
+	}
+
+	existingClient, err := s.clientStore.GetClientByPhoneNumber(ctx, client.BusinessID, client.PhoneNumber, client.CountryCode)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to get client by phone number")
+	}
+
+	if existingClient != nil && existingClient.ID != client.ID {
+		return errors.Conflict(op, fmt.Sprintf("client with phone number %s already exists", client.PhoneNumber)).WithCode(errors.CodeClientPhoneNumberTaken)
+	}
+
+	return nil
+}
+
+// GetClients gets clients of the business the location belongs to, optionally filtered by name or phone number
+func (s *ClientService) GetClients(ctx context.Context, locationID string, search string, actor Actor) ([]*Client, error) {
+	const op = "app/clientService.GetClients"
+
+	err := actor.can(ctx, opReadClient)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	businessID, err := s.getBusinessID(ctx, locationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business id")
+	}
+
+	clients, err := s.clientStore.GetClientsByBusinessID(ctx, businessID, strings.TrimSpace(search))
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get clients by business id")
+	}
+
+	return clients, nil
+}
+
+// GetClientByID ...
+func (s *ClientService) GetClientByID(ctx context.Context, locationID string, id string, actor Actor) (*Client, error) {
+	const op = "app/clientService.GetClientByID"
+
+	err := actor.can(ctx, opReadClient)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	client, err := s.getClient(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get client")
+	}
+
+	return client, nil
+}
+
+// CreateClientInput ...
+type CreateClientInput struct {
+	LocationID  string   `json:"location_id"`
+	Name        string   `json:"name"`
+	PhoneNumber string   `json:"phone_number"`
+	CountryCode string   `json:"country_code"`
+	Email       string   `json:"email"`
+	Notes       string   `json:"notes"`
+	Tags        []string `json:"tags"`
+	// Birthday in YYYY-MM-DD format
+	Birthday string `json:"birthday"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateClientInput) Validate() error {
+	const op = "app/CreateClientInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("name", input.Name)
+
+	if input.PhoneNumber != "" {
+		phone.ValidatePhoneNumber(v, "phone_number", input.PhoneNumber, input.CountryCode)
+	}
+
+	validateBirthday(v, input.Birthday)
+
+	return v.Err(op)
+}
+
+// CreateClient creates client for the business the location belongs to
+func (s *ClientService) CreateClient(ctx context.Context, input *CreateClientInput, actor Actor) (*Client, error) {
+	const op = "app/clientService.CreateClient"
+
+	err := actor.can(ctx, opCreateClient)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	businessID, err := s.getBusinessID(ctx, input.LocationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business id")
+	}
+
+	phoneNumber, err := formatClientPhoneNumber(op, input.PhoneNumber, input.CountryCode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	birthday, err := parseBirthday(op, input.Birthday)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := input.Tags
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	now := time.Now()
+
+	client := &Client{
+		ID:          uuid.Must(uuid.New(), nil).String(),
+		BusinessID:  businessID,
+		Name:        strings.TrimSpace(input.Name),
+		PhoneNumber: phoneNumber,
+		CountryCode: phone.CountryCodeOf(phoneNumber, input.CountryCode),
+		Email:       strings.TrimSpace(input.Email),
+		Notes:       input.Notes,
+		Tags:        tags,
+		Birthday:    birthday,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err = s.checkPhoneNumberAvailable(ctx, client)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid phone number")
+	}
+
+	err = s.clientStore.StoreClient(ctx, client)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to store client")
+	}
+
+	return client, nil
+}
+
+// UpdateClientInput ...
+type UpdateClientInput struct {
+	Name        string   `json:"name"`
+	PhoneNumber string   `json:"phone_number"`
+	CountryCode string   `json:"country_code"`
+	Email       string   `json:"email"`
+	Notes       string   `json:"notes"`
+	Tags        []string `json:"tags"`
+	Birthday    string   `json:"birthday"`
+}
+
+// Validate checks the fields of the input
+func (input *UpdateClientInput) Validate() error {
+	const op = "app/UpdateClientInput.Validate"
+
+	v := &errors.Validation{}
+	v.NotBlank("name", input.Name)
+	validateBirthday(v, input.Birthday)
+
+	return v.Err(op)
+}
+
+// UpdateClient updates client
+func (s *ClientService) UpdateClient(ctx context.Context, locationID string, id string, input *UpdateClientInput, actor Actor) (*Client, error) {
+	const op = "app/clientService.UpdateClient"
+
+	err := actor.can(ctx, opUpdateClient)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	client, err := s.getClient(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get client")
+	}
+
+	if strings.TrimSpace(input.Name) != "" {
+		client.Name = strings.TrimSpace(input.Name)
+	}
+	if input.PhoneNumber != "" {
+		if input.CountryCode != "" {
+			client.CountryCode = input.CountryCode
+		}
+
+		phoneNumber, err := formatClientPhoneNumber(op, input.PhoneNumber, client.CountryCode)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// The linked user no longer owns the phone number
+		if phoneNumber != client.PhoneNumber {
+			client.UserID = ""
+		}
+
+		client.PhoneNumber = phoneNumber
+		client.CountryCode = phone.CountryCodeOf(phoneNumber, client.CountryCode)
+	}
+	if input.Email != "" {
+		client.Email = strings.TrimSpace(input.Email)
+	}
+	if input.Notes != "" {
+		client.Notes = input.Notes
+	}
+	if input.Tags != nil {
+		client.Tags = input.Tags
+	}
+	if input.Birthday != "" {
+		client.Birthday, err = parseBirthday(op, input.Birthday)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client.UpdatedAt = time.Now()
+
+	err = s.checkPhoneNumberAvailable(ctx, client)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid phone number")
+	}
+
+	err = s.clientStore.UpdateClient(ctx, client)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to update client")
+	}
+
+	return client, nil
+}
+
+// DeleteClient ...
+func (s *ClientService) DeleteClient(ctx context.Context, locationID string, id string, actor Actor) (*Client, error) {
+	const op = "app/clientService.DeleteClient"
+
+	err := actor.can(ctx, opDeleteClient)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	client, err := s.getClient(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get client")
+	}
+
+	err = s.clientStore.DeleteClient(ctx, client)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to delete client")
+	}
+
+	return client, nil
+}
+
+// LinkClientsToUser links clients in all businesses having the phone number of the user, once the user has verified it
+func (s *ClientService) LinkClientsToUser(ctx context.Context, user *auth.User) ([]*Client, error) {
+	const op = "app/clientService.LinkClientsToUser"
+
+	if user.IsPhoneNumberVerified == false {
+		return nil, errors.Invalid(op, "phone number not verified").WithCode(errors.CodePhoneNumberNotVerified)
+	}
+
+	clients, err := s.clientStore.GetClientsByPhoneNumber(ctx, user.PhoneNumber, user.CountryCode)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get clients by phone number")
+	}
+
+	linkedClients := []*Client{}
+
+	for _, client := range clients {
+		if client.UserID == user.ID {
+			continue
+		}
+
+		client.UserID = user.ID
+		client.UpdatedAt = time.Now()
+
+		err = s.clientStore.UpdateClient(ctx, client)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to update client")
+		}
+
+		linkedClients = append(linkedClients, client)
+	}
+
+	return linkedClients, nil
+}
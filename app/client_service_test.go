@@ -0,0 +1,131 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+)
+
+func TestCreateClientHappyPath(t *testing.T) {
+	clientStore := memstore.NewClientStore()
+	locationStore := memstore.NewLocationStore()
+	clientService := app.NewClientService(clientStore, locationStore)
+	actor := app.MockActor
+
+	location := &app.Location{ID: "1", BusinessID: "1", Name: "location1"}
+
+	err := locationStore.StoreLocation(context.Background(), location)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should create client with normalized phone number", func(t *testing.T) {
+		input := &app.CreateClientInput{
+			LocationID:  location.ID,
+			Name:        "Nguyen Van A",
+			PhoneNumber: "+84 90 123 4567",
+			CountryCode: "VN",
+			Tags:        []string{"vip"},
+			Birthday:    "1990-01-31",
+		}
+		client, err := clientService.CreateClient(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if client.BusinessID != location.BusinessID {
+			t.Error("client should belong to business of the location")
+		}
+
+		if client.PhoneNumber != "+84901234567" {
+			t.Errorf("phone number should be normalized, got %s", client.PhoneNumber)
+		}
+	})
+
+	t.Run("should not create client with same phone number", func(t *testing.T) {
+		input := &app.CreateClientInput{
+			LocationID:  location.ID,
+			Name:        "Nguyen Van B",
+			PhoneNumber: "0901234567",
+			CountryCode: "VN",
+		}
+		_, err := clientService.CreateClient(context.Background(), input, actor)
+
+		if errors.Is(errors.KindConflict, err) == false {
+			t.Error("error should be conflict kind")
+		}
+	})
+
+	t.Run("should search clients by name and phone number", func(t *testing.T) {
+		clients, err := clientService.GetClients(context.Background(), location.ID, "van a", actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(clients) != 1 {
+			t.Errorf("expected 1 client by name, got %d", len(clients))
+		}
+
+		clients, err = clientService.GetClients(context.Background(), location.ID, "0901234", actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(clients) != 1 {
+			t.Errorf("expected 1 client by phone number, got %d", len(clients))
+		}
+	})
+}
+
+func TestLinkClientsToUser(t *testing.T) {
+	clientStore := memstore.NewClientStore()
+	locationStore := memstore.NewLocationStore()
+	clientService := app.NewClientService(clientStore, locationStore)
+
+	clients := []*app.Client{
+		{ID: "1", BusinessID: "1", Name: "client1", PhoneNumber: "+84901234567", CountryCode: "VN"},
+		{ID: "2", BusinessID: "2", Name: "client1", PhoneNumber: "+84901234567", CountryCode: "VN"},
+		{ID: "3", BusinessID: "1", Name: "client3", PhoneNumber: "+84907654321", CountryCode: "VN"},
+	}
+
+	for _, client := range clients {
+		err := clientStore.StoreClient(context.Background(), client)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user := auth.NewUser("+84901234567", "VN")
+	user.IsPhoneNumberVerified = true
+
+	t.Run("should link clients with same phone number", func(t *testing.T) {
+		linkedClients, err := clientService.LinkClientsToUser(context.Background(), user)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(linkedClients) != 2 {
+			t.Errorf("expected 2 linked clients, got %d", len(linkedClients))
+		}
+
+		otherClient, err := clientStore.GetClientByID(context.Background(), clients[2].ID)
+
+		if err != nil || otherClient.UserID != "" {
+			t.Error("client with other phone number should not be linked")
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// ClientStore ...
+type ClientStore interface {
+	GetClientsByBusinessID(ctx context.Context, businessID string, search string) ([]*Client, error)
+	GetClientsByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) ([]*Client, error)
+	GetClientByID(ctx context.Context, id string) (*Client, error)
+	GetClientByPhoneNumber(ctx context.Context, businessID string, phoneNumber string, countryCode string) (*Client, error)
+	StoreClient(ctx context.Context, client *Client) error
+	UpdateClient(ctx context.Context, client *Client) error
+	DeleteClient(ctx context.Context, client *Client) error
+	PurgeClientsByBusinessIDs(ctx context.Context, businessIDs []string) error
+}
+
+type clientStore struct {
+	db *sql.DB
+}
+
+// NewClientStore ...
+func NewClientStore(db *sql.DB) ClientStore {
+	return &clientStore{db: db}
+}
+
+const clientColumns = "id, business_id, user_id, name, phone_number, country_code, email, notes, tags, birthday, created_at, updated_at"
+
+func scanClient(row rowScanner) (*Client, error) {
+	client := &Client{}
+	userID := sql.NullString{}
+	birthday := pq.NullTime{}
+
+	err := row.Scan(&client.ID, &client.BusinessID, &userID, &client.Name, &client.PhoneNumber, &client.CountryCode, &client.Email, &client.Notes, pq.Array(&client.Tags), &birthday, &client.CreatedAt, &client.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.UserID = userID.String
+	client.Birthday = birthday.Time
+
+	return client, nil
+}
+
+func (s *clientStore) queryClients(ctx context.Context, op string, query string, args ...interface{}) ([]*Client, error) {
+	clients := make([]*Client, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, args...)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		client, err := scanClient(rows)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		clients = append(clients, client)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return clients, nil
+}
+
+// GetClientsByBusinessID gets Clients of a Business. Search, when not empty, matches part of the name or digits of the phone number
+func (s *clientStore) GetClientsByBusinessID(ctx context.Context, businessID string, search string) ([]*Client, error) {
+	const op = "app/clientStore.GetClientsByBusinessID"
+
+	query := `
+		SELECT ` + clientColumns + `
+		FROM client
+		WHERE business_id=$1
+			AND (
+				$2 = ''
+				OR name ILIKE '%' || $2 || '%'
+				OR ($3 <> '' AND regexp_replace(phone_number, '\D', '', 'g') LIKE '%' || $3 || '%')
+			)
+		ORDER BY name;
+	`
+
+	return s.queryClients(ctx, op, query, businessID, escapeLike(search), phoneSearchDigits(search))
+}
+
+// GetClientsByPhoneNumber gets Clients with the phone number across all Businesses
+func (s *clientStore) GetClientsByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) ([]*Client, error) {
+	const op = "app/clientStore.GetClientsByPhoneNumber"
+
+	query := `
+		SELECT ` + clientColumns + `
+		FROM client
+		WHERE phone_number=$1 AND country_code=$2;
+	`
+
+	return s.queryClients(ctx, op, query, phoneNumber, countryCode)
+}
+
+// GetClientByID gets Client by ID
+func (s *clientStore) GetClientByID(ctx context.Context, id string) (*Client, error) {
+	const op = "app/clientStore.GetClientByID"
+
+	query := `
+		SELECT ` + clientColumns + `
+		FROM client
+		WHERE id=$1;
+	`
+
+	client, err := scanClient(transaction.DB(ctx, s.db).QueryRow(query, id))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return client, nil
+}
+
+// GetClientByPhoneNumber gets Client of a Business by phone number
+func (s *clientStore) GetClientByPhoneNumber(ctx context.Context, businessID string, phoneNumber string, countryCode string) (*Client, error) {
+	const op = "app/clientStore.GetClientByPhoneNumber"
+
+	query := `
+		SELECT ` + clientColumns + `
+		FROM client
+		WHERE business_id=$1 AND phone_number=$2 AND country_code=$3;
+	`
+
+	client, err := scanClient(transaction.DB(ctx, s.db).QueryRow(query, businessID, phoneNumber, countryCode))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return client, nil
+}
+
+// StoreClient persists Client
+func (s *clientStore) StoreClient(ctx context.Context, client *Client) error {
+	const op = "app/clientStore.StoreClient"
+
+	query := `
+		INSERT INTO client (` + clientColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, client.ID, client.BusinessID, toNullString(client.UserID), client.Name, client.PhoneNumber, client.CountryCode, client.Email, client.Notes, pq.Array(client.Tags), toNullTime(client.Birthday), client.CreatedAt, client.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// UpdateClient updates Client
+func (s *clientStore) UpdateClient(ctx context.Context, client *Client) error {
+	const op = "app/clientStore.UpdateClient"
+
+	query := `
+		UPDATE client
+		SET user_id=$2, name=$3, phone_number=$4, country_code=$5, email=$6, notes=$7, tags=$8, birthday=$9, updated_at=$10
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, client.ID, toNullString(client.UserID), client.Name, client.PhoneNumber, client.CountryCode, client.Email, client.Notes, pq.Array(client.Tags), toNullTime(client.Birthday), client.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// DeleteClient deletes Client
+func (s *clientStore) DeleteClient(ctx context.Context, client *Client) error {
+	const op = "app/clientStore.DeleteClient"
+
+	query := `
+		DELETE FROM client
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, client.ID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// digitsOnly strips everything but digits, e.g. to compare phone numbers regardless of formatting
+// phoneSearchDigits drops the national trunk prefix of the search, as phone numbers are stored in E.164 format
+// without it, e.g. 0901 matches +84901234567
+func phoneSearchDigits(search string) string {
+	return strings.TrimLeft(digitsOnly(search), "0")
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// PurgeClientsByBusinessIDs permanently deletes the Clients of the Businesses
+func (s *clientStore) PurgeClientsByBusinessIDs(ctx context.Context, businessIDs []string) error {
+	const op = "app/clientStore.PurgeClientsByBusinessIDs"
+
+	if len(businessIDs) == 0 {
+		return nil
+	}
+
+	placeholder, args := makeIDsArgs(businessIDs)
+
+	query := fmt.Sprintf(`
+		DELETE FROM client
+		WHERE business_id IN (%s);
+	`, placeholder)
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, args...)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/query"
 )
 
 // EmployeeRole ...
@@ -16,12 +17,24 @@ type EmployeeRole struct {
 	LocationID    string    `json:"location_id"`
 	Name          string    `json:"name"`
 	PermissionIDs []string  `json:"permission_ids"`
+	IsOwner       bool      `json:"is_owner"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	DeletedAt     time.Time `json:"deleted_at"`
+	Version       int       `json:"version"`
 	// These permissions are retrieved in the application code based on PermissionIDs
 	Permissions []Permission
 }
 
+// ownerRoleName is the name of the role of the owner employee, which no other role may have
+const ownerRoleName = "owner"
+
+// EmployeeRoleFields are the fields lists of EmployeeRoles can be filtered and sorted by
+var EmployeeRoleFields = query.Fields{
+	{Name: "name", Column: "name", Type: query.String, Value: func(record interface{}) interface{} { return record.(*EmployeeRole).Name }},
+	{Name: "created_at", Column: "created_at", Type: query.Time, Value: func(record interface{}) interface{} { return record.(*EmployeeRole).CreatedAt }},
+}
+
 // EmployeeRoleService ...
 type EmployeeRoleService struct {
 	employeeRoleStore EmployeeRoleStore
@@ -33,24 +46,90 @@ func NewEmployeeRoleService(employeeStore EmployeeStore, employeeRoleStore Emplo
 	return EmployeeRoleService{employeeStore: employeeStore, employeeRoleStore: employeeRoleStore}
 }
 
+// GetEmployeeRolesByLocationID gets a page of the employee roles of the location
+func (s *EmployeeRoleService) GetEmployeeRolesByLocationID(ctx context.Context, locationID string, args PageArgs, actor Actor) ([]*EmployeeRole, *Page, error) {
+	const op = "app/employeeRoleService.GetEmployeeRolesByLocationID"
+
+	err := actor.can(ctx, opReadEmployeeRole)
+
+	if err != nil {
+		return nil, nil, errors.Forbidden(op, err)
+	}
+
+	err = args.Validate()
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
+	}
+
+	employeeRoles, page, err := s.employeeRoleStore.GetEmployeeRolePageByLocationID(ctx, locationID, args)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "failed to get employee role page")
+	}
+
+	return employeeRoles, page, nil
+}
+
+// getEmployeeRole gets the employee role, which must belong to the location. Roles of other locations are not found
+func (s *EmployeeRoleService) getEmployeeRole(ctx context.Context, locationID string, id string) (*EmployeeRole, error) {
+	const op = "app/employeeRoleService.getEmployeeRole"
+
+	employeeRole, err := s.employeeRoleStore.GetEmployeeRoleByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee role by id")
+	}
+
+	if employeeRole == nil || employeeRole.LocationID != locationID {
+		return nil, errors.NotFound(op)
+	}
+
+	return employeeRole, nil
+}
+
 // GetEmployeeRoleByID ...
-func (s *EmployeeRoleService) GetEmployeeRoleByID(ctx context.Context, id string) (*EmployeeRole, error) {
+func (s *EmployeeRoleService) GetEmployeeRoleByID(ctx context.Context, locationID string, id string, actor Actor) (*EmployeeRole, error) {
 	const op = "app/employeeRoleService.GetEmployeeRoleByID"
 
-	employeeRole, err := s.employeeRoleStore.GetEmployeeRoleByID(ctx, id)
+	err := actor.can(ctx, opReadEmployeeRole)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	employeeRole, err := s.getEmployeeRole(ctx, locationID, id)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get employeeRole by id")
+		return nil, errors.Wrap(op, err, "failed to get employee role")
 	}
 
 	return employeeRole, nil
 }
 
+// validateEmployeeRoleName checks that the name is not reserved for the owner role
+func validateEmployeeRoleName(v *errors.Validation, name string) {
+	v.Check(!strings.EqualFold(strings.TrimSpace(name), ownerRoleName), "name", errors.CodeInvalidValue, fmt.Sprintf("%s is reserved for the owner role", ownerRoleName))
+}
+
 // CreateEmployeeRoleInput ...
 type CreateEmployeeRoleInput struct {
-	LocationID    string   `db:"location_id"`
-	Name          string   `db:"name"`
-	PermissionIDs []string `db:"permission_ids"`
+	LocationID    string   `json:"location_id"`
+	Name          string   `json:"name"`
+	PermissionIDs []string `json:"permission_ids"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateEmployeeRoleInput) Validate() error {
+	const op = "app/CreateEmployeeRoleInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("name", input.Name)
+	v.Check(input.PermissionIDs != nil, "permission_ids", errors.CodeRequired, "permission_ids is required")
+	validateEmployeeRoleName(v, input.Name)
+	validatePermissionIDs(v, input.PermissionIDs)
+
+	return v.Err(op)
 }
 
 // CreateEmployeeRole creates employeeRole
@@ -60,23 +139,21 @@ func (s *EmployeeRoleService) CreateEmployeeRole(ctx context.Context, input *Cre
 	err := actor.can(ctx, opCreateEmployeeRole)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
 	}
 
-	now := time.Now()
-
-	permissions, err := getPermissionsByPermissionIDs(input.PermissionIDs)
+	err = input.Validate()
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to get permissions")
+		return nil, errors.Wrap(op, err, "invalid input")
 	}
 
-	if input.Name == "" {
-		return nil, errors.Invalid(op, "name field required")
-	}
+	now := time.Now()
+
+	permissions, err := GetPermissionsByPermissionIDs(input.PermissionIDs)
 
-	if input.PermissionIDs == nil {
-		return nil, errors.Invalid(op, "permissions field required")
+	if err != nil {
+		return nil, errors.Invalid(op, "invalid permissions")
 	}
 
 	employeeRole := &EmployeeRole{
@@ -100,32 +177,50 @@ func (s *EmployeeRoleService) CreateEmployeeRole(ctx context.Context, input *Cre
 
 // UpdateEmployeeRoleInput ...
 type UpdateEmployeeRoleInput struct {
-	Name          string   `db:"name"`
-	PermissionIDs []string `db:"permission_ids"`
+	Name          string   `json:"name"`
+	PermissionIDs []string `json:"permission_ids"`
+}
+
+// Validate checks the fields of the input
+func (input *UpdateEmployeeRoleInput) Validate() error {
+	const op = "app/UpdateEmployeeRoleInput.Validate"
+
+	v := &errors.Validation{}
+	v.NotBlank("name", input.Name)
+	validateEmployeeRoleName(v, input.Name)
+	validatePermissionIDs(v, input.PermissionIDs)
+
+	return v.Err(op)
 }
 
-// UpdateEmployeeRole updates employeeRole
-func (s *EmployeeRoleService) UpdateEmployeeRole(ctx context.Context, id string, input *UpdateEmployeeRoleInput, actor Actor) (*EmployeeRole, error) {
+// UpdateEmployeeRole updates employeeRole, unless version is set and outdated
+func (s *EmployeeRoleService) UpdateEmployeeRole(ctx context.Context, locationID string, id string, version int, input *UpdateEmployeeRoleInput, actor Actor) (*EmployeeRole, error) {
 	const op = "app/employeeRoleService.UpdateEmployeeRole"
 
 	err := actor.can(ctx, opUpdateEmployeeRole)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
 	}
 
-	employeeRole, err := s.employeeRoleStore.GetEmployeeRoleByID(ctx, id)
+	err = input.Validate()
 
-	if employeeRole.Name == "owner" {
-		return nil, errors.Invalid(op, "cannot update owner role")
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
 	}
 
+	employeeRole, err := s.getEmployeeRole(ctx, locationID, id)
+
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get employeeRole by id")
+		return nil, errors.Wrap(op, err, "failed to get employee role")
 	}
 
-	if employeeRole == nil {
-		return nil, errors.NotFound(op)
+	if version != 0 && employeeRole.Version != version {
+		return nil, errors.PreconditionFailed(op, "employee role was changed meanwhile")
+	}
+
+	if employeeRole.IsOwner {
+		return nil, errors.Invalid(op, "cannot update owner role").WithCode(errors.CodeOwnerRoleImmutable)
 	}
 
 	employeeRole.UpdatedAt = time.Now()
@@ -135,10 +230,10 @@ func (s *EmployeeRoleService) UpdateEmployeeRole(ctx context.Context, id string,
 	}
 	if input.PermissionIDs != nil {
 		employeeRole.PermissionIDs = input.PermissionIDs
-		permissions, err := getPermissionsByPermissionIDs(input.PermissionIDs)
+		permissions, err := GetPermissionsByPermissionIDs(input.PermissionIDs)
 
 		if err != nil {
-			return nil, errors.Unexpected(op, err, "failed to get permissions")
+			return nil, errors.Invalid(op, "invalid permissions")
 		}
 
 		employeeRole.Permissions = permissions
@@ -147,34 +242,34 @@ func (s *EmployeeRoleService) UpdateEmployeeRole(ctx context.Context, id string,
 	err = s.employeeRoleStore.UpdateEmployeeRole(ctx, employeeRole)
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update employeeRole")
+		return nil, errors.Wrap(op, err, "failed to update employeeRole")
 	}
 
 	return employeeRole, nil
 }
 
 // DeleteEmployeeRole updates employeeRole
-func (s *EmployeeRoleService) DeleteEmployeeRole(ctx context.Context, id string, actor Actor) (*EmployeeRole, error) {
+func (s *EmployeeRoleService) DeleteEmployeeRole(ctx context.Context, locationID string, id string, version int, actor Actor) (*EmployeeRole, error) {
 	const op = "app/employeeRoleService.DeleteEmployeeRole"
 
 	err := actor.can(ctx, opDeleteEmployeeRole)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
 	}
 
-	employeeRole, err := s.employeeRoleStore.GetEmployeeRoleByID(ctx, id)
+	employeeRole, err := s.getEmployeeRole(ctx, locationID, id)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get employee role by id")
+		return nil, errors.Wrap(op, err, "failed to get employee role")
 	}
 
-	if employeeRole == nil {
-		return nil, errors.NotFound(op)
+	if version != 0 && employeeRole.Version != version {
+		return nil, errors.PreconditionFailed(op, "employee role was changed meanwhile")
 	}
 
-	if employeeRole.Name == "owner" {
-		return nil, errors.Invalid(op, "cannot delete owner role")
+	if employeeRole.IsOwner {
+		return nil, errors.Invalid(op, "cannot delete owner role").WithCode(errors.CodeOwnerRoleImmutable)
 	}
 
 	employeesWithTheRole, err := s.employeeStore.GetEmployeesByEmployeeRoleID(ctx, employeeRole.ID)
@@ -188,13 +283,15 @@ func (s *EmployeeRoleService) DeleteEmployeeRole(ctx context.Context, id string,
 	}
 
 	if len(employeesWithTheRole) > 0 {
-		return nil, errors.Invalid(op, fmt.Sprintf("employees with role=%s still exist. remove them and restart operation", employeeRole.Name))
+		return nil, errors.Conflict(op, fmt.Sprintf("employees with role=%s still exist. remove them and restart operation", employeeRole.Name)).WithCode(errors.CodeEmployeeRoleInUse)
 	}
 
+	employeeRole.DeletedAt = time.Now()
+
 	err = s.employeeRoleStore.DeleteEmployeeRole(ctx, employeeRole)
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update employeeRole")
+		return nil, errors.Wrap(op, err, "failed to update employeeRole")
 	}
 
 	return employeeRole, nil
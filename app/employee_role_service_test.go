@@ -1,77 +1,22 @@
-package app
+package app_test
 
 import (
 	"context"
-	"fmt"
 	"testing"
 
+	"github.com/minheq/kedul_server_main/app"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
 )
 
-type mockEmployeeRoleStore struct {
-	employeeRoles []*EmployeeRole
-}
-
-func (s *mockEmployeeRoleStore) GetEmployeeRoleByID(ctx context.Context, id string) (*EmployeeRole, error) {
-	for _, employeeRole := range s.employeeRoles {
-		if employeeRole.ID == id {
-			permissions, err := getPermissionsByPermissionIDs(employeeRole.PermissionIDs)
-
-			if err != nil {
-				return nil, fmt.Errorf("failed to get permissions")
-			}
-
-			employeeRole.Permissions = permissions
-
-			return employeeRole, nil
-		}
-	}
-
-	return nil, nil
-}
-
-func (s *mockEmployeeRoleStore) StoreEmployeeRole(ctx context.Context, employee *EmployeeRole) error {
-	s.employeeRoles = append(s.employeeRoles, employee)
-
-	return nil
-}
-
-func (s *mockEmployeeRoleStore) UpdateEmployeeRole(ctx context.Context, employee *EmployeeRole) error {
-	for i, b := range s.employeeRoles {
-		if b.ID == employee.ID {
-			s.employeeRoles[i] = employee
-			break
-		}
-	}
-
-	return nil
-}
-
-func (s *mockEmployeeRoleStore) DeleteEmployeeRole(ctx context.Context, employee *EmployeeRole) error {
-	for i, b := range s.employeeRoles {
-		if b.ID == employee.ID {
-			s.employeeRoles = append(s.employeeRoles[:i], s.employeeRoles[i+1:]...)
-			break
-		}
-	}
-
-	return nil
-}
-
-type mockActor struct{}
-
-func (m *mockActor) can(ctx context.Context, operation Operation) error {
-	return nil
-}
-
 func TestCreateEmployeeRoleHappyPath(t *testing.T) {
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	employeeRoleService := NewEmployeeRoleService(employeeStore, employeeRoleStore)
-	actor := &mockActor{}
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeRoleService := app.NewEmployeeRoleService(employeeStore, employeeRoleStore)
+	actor := app.MockActor
 
 	t.Run("should create employee", func(t *testing.T) {
-		input := &CreateEmployeeRoleInput{
+		input := &app.CreateEmployeeRoleInput{
 			Name:          "role_name1",
 			PermissionIDs: []string{},
 		}
@@ -85,15 +30,15 @@ func TestCreateEmployeeRoleHappyPath(t *testing.T) {
 }
 
 func TestUpdateEmployeeRoleHappyPath(t *testing.T) {
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	employeeRoleService := NewEmployeeRoleService(employeeStore, employeeRoleStore)
-	actor := &mockActor{}
-	location := &Location{
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeRoleService := app.NewEmployeeRoleService(employeeStore, employeeRoleStore)
+	actor := app.MockActor
+	location := &app.Location{
 		ID:   "1",
 		Name: "location1",
 	}
-	employeeRole := &EmployeeRole{
+	employeeRole := &app.EmployeeRole{
 		LocationID:    location.ID,
 		Name:          "role_name2",
 		PermissionIDs: []string{},
@@ -107,10 +52,10 @@ func TestUpdateEmployeeRoleHappyPath(t *testing.T) {
 	}
 
 	t.Run("should update employeeRole", func(t *testing.T) {
-		input := &UpdateEmployeeRoleInput{
+		input := &app.UpdateEmployeeRoleInput{
 			Name: "role_name3",
 		}
-		_, err := employeeRoleService.UpdateEmployeeRole(context.Background(), employeeRole.ID, input, actor)
+		_, err := employeeRoleService.UpdateEmployeeRole(context.Background(), employeeRole.LocationID, employeeRole.ID, 0, input, actor)
 
 		if err != nil {
 			t.Error(err)
@@ -120,16 +65,16 @@ func TestUpdateEmployeeRoleHappyPath(t *testing.T) {
 }
 
 func TestDeleteEmployeeRoleHappyPath(t *testing.T) {
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	employeeRoleService := NewEmployeeRoleService(employeeStore, employeeRoleStore)
-	actor := &mockActor{}
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeRoleService := app.NewEmployeeRoleService(employeeStore, employeeRoleStore)
+	actor := app.MockActor
 
-	location := &Location{
+	location := &app.Location{
 		ID:   "2",
 		Name: "location2",
 	}
-	employeeRole := &EmployeeRole{
+	employeeRole := &app.EmployeeRole{
 		LocationID:    location.ID,
 		Name:          "role_name4",
 		PermissionIDs: []string{},
@@ -143,7 +88,7 @@ func TestDeleteEmployeeRoleHappyPath(t *testing.T) {
 	}
 
 	t.Run("should delete employeeRole", func(t *testing.T) {
-		_, err := employeeRoleService.DeleteEmployeeRole(context.Background(), employeeRole.ID, actor)
+		_, err := employeeRoleService.DeleteEmployeeRole(context.Background(), employeeRole.LocationID, employeeRole.ID, 0, actor)
 
 		if err != nil {
 			t.Error(err)
@@ -152,23 +97,60 @@ func TestDeleteEmployeeRoleHappyPath(t *testing.T) {
 	})
 }
 
+func TestReservedEmployeeRoleName(t *testing.T) {
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeRoleService := app.NewEmployeeRoleService(employeeStore, employeeRoleStore)
+	actor := app.MockActor
+	employeeRole := &app.EmployeeRole{ID: "5", LocationID: "5", Name: "role_name5", PermissionIDs: []string{}}
+
+	employeeRoleStore.StoreEmployeeRole(context.Background(), employeeRole)
+
+	t.Run("should not create employee role named owner", func(t *testing.T) {
+		input := &app.CreateEmployeeRoleInput{LocationID: "5", Name: " Owner", PermissionIDs: []string{}}
+
+		_, err := employeeRoleService.CreateEmployeeRole(context.Background(), input, actor)
+
+		if errors.ErrorCode(err) != errors.CodeValidationFailed || errors.ErrorViolations(err)[0].Field != "name" {
+			t.Errorf("expected name to be refused, got %v", err)
+		}
+	})
+
+	t.Run("should not rename employee role to owner", func(t *testing.T) {
+		input := &app.UpdateEmployeeRoleInput{Name: "owner"}
+
+		_, err := employeeRoleService.UpdateEmployeeRole(context.Background(), employeeRole.LocationID, employeeRole.ID, 0, input, actor)
+
+		if errors.ErrorCode(err) != errors.CodeValidationFailed || errors.ErrorViolations(err)[0].Field != "name" {
+			t.Errorf("expected name to be refused, got %v", err)
+		}
+
+		storedEmployeeRole, _ := employeeRoleStore.GetEmployeeRoleByID(context.Background(), employeeRole.ID)
+
+		if storedEmployeeRole.Name != employeeRole.Name {
+			t.Errorf("expected employee role to be unchanged, got %+v", storedEmployeeRole)
+		}
+	})
+}
+
 func TestEmployeeRolePermissions(t *testing.T) {
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	businessStore := &mockBusinessStore{}
-	employeeStore := &mockEmployeeStore{}
-	locationStore := &mockLocationStore{}
-	permissionService := NewPermissionService(employeeRoleStore, employeeStore)
-	locationService := NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore)
-	employeeRoleService := NewEmployeeRoleService(employeeStore, employeeRoleStore)
-
-	location := &Location{
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	businessStore := memstore.NewBusinessStore()
+	employeeStore := memstore.NewEmployeeStore()
+	locationStore := memstore.NewLocationStore()
+	permissionService := app.NewPermissionService(employeeRoleStore, employeeStore)
+	locationService := app.NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
+	employeeRoleService := app.NewEmployeeRoleService(employeeStore, employeeRoleStore)
+
+	location := &app.Location{
 		ID:   "3",
 		Name: "location3",
 	}
-	employeeRole := &EmployeeRole{
+	employeeRole := &app.EmployeeRole{
+		ID:            "5",
 		LocationID:    location.ID,
 		Name:          "role_name5",
-		PermissionIDs: []string{permManageLocation.ID},
+		PermissionIDs: []string{app.PermManageLocation.ID},
 	}
 
 	err := employeeRoleStore.StoreEmployeeRole(context.Background(), employeeRole)
@@ -178,8 +160,10 @@ func TestEmployeeRolePermissions(t *testing.T) {
 		return
 	}
 
-	employee := &Employee{
+	employee := &app.Employee{
+		ID:             "1",
 		LocationID:     location.ID,
+		UserID:         "1",
 		Name:           "employee1",
 		EmployeeRoleID: employeeRole.ID,
 	}
@@ -206,19 +190,19 @@ func TestEmployeeRolePermissions(t *testing.T) {
 	}
 
 	t.Run("should not be able to updateEmployeeRole", func(t *testing.T) {
-		_, err = employeeRoleService.DeleteEmployeeRole(context.Background(), employeeRole.ID, actor)
+		_, err = employeeRoleService.DeleteEmployeeRole(context.Background(), employeeRole.LocationID, employeeRole.ID, 0, actor)
 
-		if errors.Is(errors.KindUnauthorized, err) == false {
+		if errors.Is(errors.KindForbidden, err) == false {
 			t.Errorf("deleting employee role should fail due insufficient permissions")
 			return
 		}
 	})
 
 	t.Run("should be able to updateLocation", func(t *testing.T) {
-		input := &UpdateLocationInput{
+		input := &app.UpdateLocationInput{
 			Name: "new name",
 		}
-		_, err := locationService.UpdateLocation(context.Background(), location.ID, input, actor)
+		_, err := locationService.UpdateLocation(context.Background(), location.ID, 0, input, actor)
 
 		if err != nil {
 			t.Errorf("updating location should pass")
@@ -226,3 +210,30 @@ func TestEmployeeRolePermissions(t *testing.T) {
 		}
 	})
 }
+
+func TestEmployeeRoleOfOtherLocation(t *testing.T) {
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeRoleService := app.NewEmployeeRoleService(memstore.NewEmployeeStore(), employeeRoleStore)
+	employeeRole := &app.EmployeeRole{ID: "7", LocationID: "1", Name: "role7", PermissionIDs: []string{}}
+	actor := app.MockActor
+
+	employeeRoleStore.StoreEmployeeRole(context.Background(), employeeRole)
+
+	_, err := employeeRoleService.GetEmployeeRoleByID(context.Background(), "2", employeeRole.ID, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected employee role of other location not to be found, got %v", err)
+	}
+
+	_, err = employeeRoleService.UpdateEmployeeRole(context.Background(), "2", employeeRole.ID, 0, &app.UpdateEmployeeRoleInput{Name: "renamed"}, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected update through other location to be not found, got %v", err)
+	}
+
+	_, err = employeeRoleService.DeleteEmployeeRole(context.Background(), "2", employeeRole.ID, 0, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected delete through other location to be not found, got %v", err)
+	}
+}
@@ -3,17 +3,24 @@ package app
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 // EmployeeRoleStore ...
 type EmployeeRoleStore interface {
+	GetEmployeeRolesByLocationID(ctx context.Context, locationID string) ([]*EmployeeRole, error)
+	GetEmployeeRolePageByLocationID(ctx context.Context, locationID string, args PageArgs) ([]*EmployeeRole, *Page, error)
 	GetEmployeeRoleByID(ctx context.Context, id string) (*EmployeeRole, error)
 	StoreEmployeeRole(ctx context.Context, employeeRole *EmployeeRole) error
 	UpdateEmployeeRole(ctx context.Context, employeeRole *EmployeeRole) error
 	DeleteEmployeeRole(ctx context.Context, employeeRole *EmployeeRole) error
+	DeleteEmployeeRolesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error
+	RestoreEmployeeRolesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error
+	PurgeEmployeeRoles(ctx context.Context, deletedBefore time.Time) error
 }
 
 type employeeRoleStore struct {
@@ -30,26 +37,27 @@ func (s *employeeRoleStore) GetEmployeeRoleByID(ctx context.Context, id string)
 	const op = "app/employeeRoleStore.GetEmployeeRoleByID"
 
 	query := `
-		SELECT id, location_id, name, permission_ids, created_at, updated_at
+		SELECT id, location_id, name, permission_ids, is_owner, created_at, updated_at, version
 		FROM employee_role
-		WHERE id=$1;
+		WHERE id=$1
+			AND deleted_at IS NULL;
 	`
 
 	employeeRole := &EmployeeRole{}
 
-	row := s.db.QueryRow(query, id)
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
 
-	if row == nil {
+	err := row.Scan(&employeeRole.ID, &employeeRole.LocationID, &employeeRole.Name, pq.Array(&employeeRole.PermissionIDs), &employeeRole.IsOwner, &employeeRole.CreatedAt, &employeeRole.UpdatedAt, &employeeRole.Version)
+
+	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 
-	err := row.Scan(&employeeRole.ID, &employeeRole.LocationID, &employeeRole.Name, pq.Array(&employeeRole.PermissionIDs), &employeeRole.CreatedAt, &employeeRole.UpdatedAt)
-
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
 
-	permissions, err := getPermissionsByPermissionIDs(employeeRole.PermissionIDs)
+	permissions, err := GetPermissionsByPermissionIDs(employeeRole.PermissionIDs)
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "failed to get permissions")
@@ -60,53 +68,231 @@ func (s *employeeRoleStore) GetEmployeeRoleByID(ctx context.Context, id string)
 	return employeeRole, nil
 }
 
+// GetEmployeeRolesByLocationID gets EmployeeRoles of a Location
+func (s *employeeRoleStore) GetEmployeeRolesByLocationID(ctx context.Context, locationID string) ([]*EmployeeRole, error) {
+	const op = "app/employeeRoleStore.GetEmployeeRolesByLocationID"
+
+	query := `
+		SELECT id, location_id, name, permission_ids, is_owner, created_at, updated_at, version
+		FROM employee_role
+		WHERE location_id=$1
+			AND deleted_at IS NULL
+		ORDER BY created_at;
+	`
+	employeeRoles := make([]*EmployeeRole, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, locationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		employeeRole := &EmployeeRole{}
+
+		err := rows.Scan(&employeeRole.ID, &employeeRole.LocationID, &employeeRole.Name, pq.Array(&employeeRole.PermissionIDs), &employeeRole.IsOwner, &employeeRole.CreatedAt, &employeeRole.UpdatedAt, &employeeRole.Version)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		permissions, err := GetPermissionsByPermissionIDs(employeeRole.PermissionIDs)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to get permissions")
+		}
+
+		employeeRole.Permissions = permissions
+
+		employeeRoles = append(employeeRoles, employeeRole)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return employeeRoles, nil
+}
+
+// GetEmployeeRolePageByLocationID gets a page of the EmployeeRoles of a Location, filtered and sorted by args
+func (s *employeeRoleStore) GetEmployeeRolePageByLocationID(ctx context.Context, locationID string, args PageArgs) ([]*EmployeeRole, *Page, error) {
+	const op = "app/employeeRoleStore.GetEmployeeRolePageByLocationID"
+
+	query := `
+		SELECT id, location_id, name, permission_ids, is_owner, created_at, updated_at, version
+		FROM employee_role
+		WHERE location_id=$1
+			AND deleted_at IS NULL
+	`
+	employeeRoles := make([]*EmployeeRole, 0)
+
+	start, end, page, err := queryPage(ctx, s.db, query, []interface{}{locationID}, args, func(rows *transaction.Rows) (Cursor, error) {
+		employeeRole := &EmployeeRole{}
+
+		err := rows.Scan(&employeeRole.ID, &employeeRole.LocationID, &employeeRole.Name, pq.Array(&employeeRole.PermissionIDs), &employeeRole.IsOwner, &employeeRole.CreatedAt, &employeeRole.UpdatedAt, &employeeRole.Version)
+
+		if err != nil {
+			return Cursor{}, err
+		}
+
+		permissions, err := GetPermissionsByPermissionIDs(employeeRole.PermissionIDs)
+
+		if err != nil {
+			return Cursor{}, err
+		}
+
+		employeeRole.Permissions = permissions
+
+		employeeRoles = append(employeeRoles, employeeRole)
+
+		return args.CursorOf(employeeRole, employeeRole.CreatedAt, employeeRole.ID), nil
+	})
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "database error")
+	}
+
+	return employeeRoles[start:end], page, nil
+}
+
 // StoreEmployeeRole persists EmployeeRole
 func (s *employeeRoleStore) StoreEmployeeRole(ctx context.Context, employeeRole *EmployeeRole) error {
 	const op = "app/employeeRoleStore.StoreEmployeeRole"
 
 	query := `
-		INSERT INTO employee_role (id, location_id, name, permission_ids, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO employee_role (id, location_id, name, permission_ids, is_owner, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err := s.db.Exec(query, employeeRole.ID, employeeRole.LocationID, employeeRole.Name, pq.Array(employeeRole.PermissionIDs), employeeRole.CreatedAt, employeeRole.UpdatedAt)
+	_, err := transaction.DB(ctx, s.db).Exec(query, employeeRole.ID, employeeRole.LocationID, employeeRole.Name, pq.Array(employeeRole.PermissionIDs), employeeRole.IsOwner, employeeRole.CreatedAt, employeeRole.UpdatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	employeeRole.Version = 1
+
 	return nil
 }
 
-// UpdateEmployeeRole updates EmployeeRole including all fields
+// UpdateEmployeeRole updates EmployeeRole including all fields. It fails with KindPreconditionFailed unless the stored Version is still the same, and increments Version
 func (s *employeeRoleStore) UpdateEmployeeRole(ctx context.Context, employeeRole *EmployeeRole) error {
 	const op = "app/employeeRoleStore.UpdateEmployeeRole"
 
 	query := `
 		UPDATE employee_role
-		SET name=$2, permission_ids=$3, updated_at=$4
-		WHERE id=$1;
+		SET name=$2, permission_ids=$3, updated_at=$4, version=version+1
+		WHERE id=$1
+			AND version=$5;
 	`
 
-	_, err := s.db.Exec(query, employeeRole.ID, employeeRole.Name, pq.Array(employeeRole.PermissionIDs), employeeRole.UpdatedAt)
+	result, err := transaction.DB(ctx, s.db).Exec(query, employeeRole.ID, employeeRole.Name, pq.Array(employeeRole.PermissionIDs), employeeRole.UpdatedAt, employeeRole.Version)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	if count == 0 {
+		return errors.PreconditionFailed(op, "employee role was changed meanwhile")
+	}
+
+	employeeRole.Version++
+
 	return nil
 }
 
-// DeleteEmployeeRole deletes EmployeeRole
+// DeleteEmployeeRole marks EmployeeRole as deleted at DeletedAt. Like updates, it requires and increments Version. It is kept until it is purged
 func (s *employeeRoleStore) DeleteEmployeeRole(ctx context.Context, employeeRole *EmployeeRole) error {
 	const op = "app/employeeRoleStore.DeleteEmployeeRole"
 
+	query := `
+		UPDATE employee_role
+		SET deleted_at=$2, version=version+1
+		WHERE id=$1
+			AND version=$3;
+	`
+
+	result, err := transaction.DB(ctx, s.db).Exec(query, employeeRole.ID, employeeRole.DeletedAt, employeeRole.Version)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	if count == 0 {
+		return errors.PreconditionFailed(op, "employee role was changed meanwhile")
+	}
+
+	employeeRole.Version++
+
+	return nil
+}
+
+// DeleteEmployeeRolesByLocationID marks the EmployeeRoles of a Location as deleted at the time
+func (s *employeeRoleStore) DeleteEmployeeRolesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	const op = "app/employeeRoleStore.DeleteEmployeeRolesByLocationID"
+
+	query := `
+		UPDATE employee_role
+		SET deleted_at=$2
+		WHERE location_id=$1
+			AND deleted_at IS NULL;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// RestoreEmployeeRolesByLocationID unmarks the EmployeeRoles of a Location deleted at the time, i.e. along with it
+func (s *employeeRoleStore) RestoreEmployeeRolesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	const op = "app/employeeRoleStore.RestoreEmployeeRolesByLocationID"
+
+	query := `
+		UPDATE employee_role
+		SET deleted_at=NULL
+		WHERE location_id=$1
+			AND deleted_at=$2;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// PurgeEmployeeRoles permanently deletes EmployeeRoles deleted before the time
+func (s *employeeRoleStore) PurgeEmployeeRoles(ctx context.Context, deletedBefore time.Time) error {
+	const op = "app/employeeRoleStore.PurgeEmployeeRoles"
+
 	query := `
 		DELETE FROM employee_role
-		WHERE id=$1;
+		WHERE deleted_at < $1;
 	`
 
-	_, err := s.db.Exec(query, employeeRole.ID)
+	_, err := transaction.DB(ctx, s.db).Exec(query, deletedBefore)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
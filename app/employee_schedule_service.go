@@ -0,0 +1,472 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// dateLayout is the format of calendar dates, e.g. in WorkingHoursOverride
+const dateLayout = "2006-01-02"
+
+// minutesPerDay upper bound for minutes of day
+const minutesPerDay = 24 * 60
+
+// WorkingHours is a recurring weekly shift of an employee. Minutes are counted from midnight
+type WorkingHours struct {
+	ID          string       `json:"id"`
+	EmployeeID  string       `json:"employee_id"`
+	Weekday     time.Weekday `json:"weekday"`
+	StartMinute int          `json:"start_minute"`
+	EndMinute   int          `json:"end_minute"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// WorkingHoursOverride replaces the weekly WorkingHours of an employee on a specific date
+type WorkingHoursOverride struct {
+	ID          string    `json:"id"`
+	EmployeeID  string    `json:"employee_id"`
+	Date        time.Time `json:"date"`
+	StartMinute int       `json:"start_minute"`
+	EndMinute   int       `json:"end_minute"`
+	IsDayOff    bool      `json:"is_day_off"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TimeOff blocks an employee from being booked, e.g. vacation or sick leave
+type TimeOff struct {
+	ID         string    `json:"id"`
+	EmployeeID string    `json:"employee_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// EmployeeScheduleService ...
+type EmployeeScheduleService struct {
+	employeeScheduleStore EmployeeScheduleStore
+	employeeStore         EmployeeStore
+}
+
+// NewEmployeeScheduleService constructor for EmployeeScheduleService
+func NewEmployeeScheduleService(employeeScheduleStore EmployeeScheduleStore, employeeStore EmployeeStore) EmployeeScheduleService {
+	return EmployeeScheduleService{employeeScheduleStore: employeeScheduleStore, employeeStore: employeeStore}
+}
+
+// getEmployee gets the employee, which must belong to the location. Schedules of employees of other locations are not
+// found
+func (s *EmployeeScheduleService) getEmployee(ctx context.Context, locationID string, employeeID string) (*Employee, error) {
+	const op = "app/employeeScheduleService.getEmployee"
+
+	employee, err := s.employeeStore.GetEmployeeByID(ctx, employeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee by id")
+	}
+
+	if employee == nil || employee.LocationID != locationID {
+		return nil, errors.NotFound(op)
+	}
+
+	return employee, nil
+}
+
+// validateMinuteRange checks the minutes of the day of the fields prefixed by the path, e.g. working_hours[0].
+func validateMinuteRange(v *errors.Validation, path string, startMinute int, endMinute int) {
+	v.Range(path+"start_minute", startMinute, 0, minutesPerDay-1)
+	v.Range(path+"end_minute", endMinute, 1, minutesPerDay)
+	v.Check(startMinute < endMinute, path+"end_minute", errors.CodeOutOfRange, "end_minute must be after start_minute")
+}
+
+// GetWorkingHours gets the weekly working hours of the employee
+func (s *EmployeeScheduleService) GetWorkingHours(ctx context.Context, locationID string, employeeID string, actor Actor) ([]*WorkingHours, error) {
+	const op = "app/employeeScheduleService.GetWorkingHours"
+
+	err := actor.can(ctx, opReadEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	_, err = s.getEmployee(ctx, locationID, employeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	workingHours, err := s.employeeScheduleStore.GetWorkingHoursByEmployeeID(ctx, employeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get working hours by employee id")
+	}
+
+	return workingHours, nil
+}
+
+// WorkingHoursInput ...
+type WorkingHoursInput struct {
+	Weekday     time.Weekday `json:"weekday"`
+	StartMinute int          `json:"start_minute"`
+	EndMinute   int          `json:"end_minute"`
+}
+
+// SetWorkingHoursInput ...
+type SetWorkingHoursInput struct {
+	WorkingHours []*WorkingHoursInput `json:"working_hours"`
+}
+
+// Validate checks the fields of the input
+func (input *SetWorkingHoursInput) Validate() error {
+	const op = "app/SetWorkingHoursInput.Validate"
+
+	v := &errors.Validation{}
+	for i, wh := range input.WorkingHours {
+		path := fmt.Sprintf("working_hours[%d].", i)
+
+		if wh == nil {
+			v.Add(path[:len(path)-1], errors.CodeRequired, "working hours are required")
+			continue
+		}
+
+		v.Range(path+"weekday", int(wh.Weekday), int(time.Sunday), int(time.Saturday))
+		validateMinuteRange(v, path, wh.StartMinute, wh.EndMinute)
+	}
+
+	return v.Err(op)
+}
+
+// SetWorkingHours replaces the weekly working hours of the employee
+func (s *EmployeeScheduleService) SetWorkingHours(ctx context.Context, locationID string, employeeID string, input *SetWorkingHoursInput, actor Actor) ([]*WorkingHours, error) {
+	const op = "app/employeeScheduleService.SetWorkingHours"
+
+	err := actor.can(ctx, opUpdateEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	_, err = s.getEmployee(ctx, locationID, employeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	now := time.Now()
+	workingHours := []*WorkingHours{}
+
+	for _, wh := range input.WorkingHours {
+		workingHours = append(workingHours, &WorkingHours{
+			ID:          uuid.Must(uuid.New(), nil).String(),
+			EmployeeID:  employeeID,
+			Weekday:     wh.Weekday,
+			StartMinute: wh.StartMinute,
+			EndMinute:   wh.EndMinute,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+
+	sort.Slice(workingHours, func(i, j int) bool {
+		if workingHours[i].Weekday != workingHours[j].Weekday {
+			return workingHours[i].Weekday < workingHours[j].Weekday
+		}
+		return workingHours[i].StartMinute < workingHours[j].StartMinute
+	})
+
+	for i := 1; i < len(workingHours); i++ {
+		prev, cur := workingHours[i-1], workingHours[i]
+
+		if prev.Weekday == cur.Weekday && cur.StartMinute < prev.EndMinute {
+			return nil, errors.InvalidField(op, "working_hours", errors.CodeInvalidValue, fmt.Sprintf("working hours overlap on weekday %d", cur.Weekday))
+		}
+	}
+
+	err = s.employeeScheduleStore.ReplaceWorkingHours(ctx, employeeID, workingHours)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to replace working hours")
+	}
+
+	return workingHours, nil
+}
+
+// GetWorkingHoursOverrides gets the working hours overrides of the employee between the dates, inclusive
+func (s *EmployeeScheduleService) GetWorkingHoursOverrides(ctx context.Context, locationID string, employeeID string, startDate time.Time, endDate time.Time, actor Actor) ([]*WorkingHoursOverride, error) {
+	const op = "app/employeeScheduleService.GetWorkingHoursOverrides"
+
+	err := actor.can(ctx, opReadEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	_, err = s.getEmployee(ctx, locationID, employeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	overrides, err := s.employeeScheduleStore.GetWorkingHoursOverridesByEmployeeIDAndDateRange(ctx, employeeID, startDate, endDate)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get working hours overrides")
+	}
+
+	return overrides, nil
+}
+
+// CreateWorkingHoursOverrideInput ...
+type CreateWorkingHoursOverrideInput struct {
+	LocationID  string `json:"location_id"`
+	EmployeeID  string `json:"employee_id"`
+	Date        string `json:"date"`
+	StartMinute int    `json:"start_minute"`
+	EndMinute   int    `json:"end_minute"`
+	IsDayOff    bool   `json:"is_day_off"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateWorkingHoursOverrideInput) Validate() error {
+	const op = "app/CreateWorkingHoursOverrideInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("employee_id", input.EmployeeID)
+
+	_, err := time.Parse(dateLayout, input.Date)
+
+	v.Check(err == nil, "date", errors.CodeInvalidFormat, "date must be in YYYY-MM-DD format")
+
+	if input.IsDayOff == false {
+		validateMinuteRange(v, "", input.StartMinute, input.EndMinute)
+	}
+
+	return v.Err(op)
+}
+
+// CreateWorkingHoursOverride changes the working hours of the employee on a specific date
+func (s *EmployeeScheduleService) CreateWorkingHoursOverride(ctx context.Context, input *CreateWorkingHoursOverrideInput, actor Actor) (*WorkingHoursOverride, error) {
+	const op = "app/employeeScheduleService.CreateWorkingHoursOverride"
+
+	err := actor.can(ctx, opUpdateEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	_, err = s.getEmployee(ctx, input.LocationID, input.EmployeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	date, err := time.Parse(dateLayout, input.Date)
+
+	if err != nil {
+		return nil, errors.InvalidField(op, "date", errors.CodeInvalidFormat, "date must be in YYYY-MM-DD format")
+	}
+
+	now := time.Now()
+
+	override := &WorkingHoursOverride{
+		ID:          uuid.Must(uuid.New(), nil).String(),
+		EmployeeID:  input.EmployeeID,
+		Date:        date,
+		StartMinute: input.StartMinute,
+		EndMinute:   input.EndMinute,
+		IsDayOff:    input.IsDayOff,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if override.IsDayOff {
+		override.StartMinute = 0
+		override.EndMinute = 0
+	}
+
+	err = s.employeeScheduleStore.StoreWorkingHoursOverride(ctx, override)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to store working hours override")
+	}
+
+	return override, nil
+}
+
+// DeleteWorkingHoursOverride ...
+func (s *EmployeeScheduleService) DeleteWorkingHoursOverride(ctx context.Context, locationID string, id string, actor Actor) (*WorkingHoursOverride, error) {
+	const op = "app/employeeScheduleService.DeleteWorkingHoursOverride"
+
+	err := actor.can(ctx, opUpdateEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	override, err := s.employeeScheduleStore.GetWorkingHoursOverrideByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get working hours override by id")
+	}
+
+	if override == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	_, err = s.getEmployee(ctx, locationID, override.EmployeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	err = s.employeeScheduleStore.DeleteWorkingHoursOverride(ctx, override)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to delete working hours override")
+	}
+
+	return override, nil
+}
+
+// GetTimeOffs gets time offs of the employee overlapping the time range
+func (s *EmployeeScheduleService) GetTimeOffs(ctx context.Context, locationID string, employeeID string, startTime time.Time, endTime time.Time, actor Actor) ([]*TimeOff, error) {
+	const op = "app/employeeScheduleService.GetTimeOffs"
+
+	err := actor.can(ctx, opReadEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	_, err = s.getEmployee(ctx, locationID, employeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	timeOffs, err := s.employeeScheduleStore.GetTimeOffsByEmployeeIDAndTimeRange(ctx, employeeID, startTime, endTime)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get time offs")
+	}
+
+	return timeOffs, nil
+}
+
+// CreateTimeOffInput ...
+type CreateTimeOffInput struct {
+	LocationID string    `json:"location_id"`
+	EmployeeID string    `json:"employee_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Reason     string    `json:"reason"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateTimeOffInput) Validate() error {
+	const op = "app/CreateTimeOffInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("employee_id", input.EmployeeID)
+	v.Check(!input.StartTime.IsZero(), "start_time", errors.CodeRequired, "start_time is required")
+	v.Check(input.StartTime.Before(input.EndTime), "end_time", errors.CodeOutOfRange, "start time must be before end time")
+
+	return v.Err(op)
+}
+
+// CreateTimeOff ...
+func (s *EmployeeScheduleService) CreateTimeOff(ctx context.Context, input *CreateTimeOffInput, actor Actor) (*TimeOff, error) {
+	const op = "app/employeeScheduleService.CreateTimeOff"
+
+	err := actor.can(ctx, opUpdateEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	_, err = s.getEmployee(ctx, input.LocationID, input.EmployeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	now := time.Now()
+
+	timeOff := &TimeOff{
+		ID:         uuid.Must(uuid.New(), nil).String(),
+		EmployeeID: input.EmployeeID,
+		StartTime:  input.StartTime,
+		EndTime:    input.EndTime,
+		Reason:     input.Reason,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	err = s.employeeScheduleStore.StoreTimeOff(ctx, timeOff)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to store time off")
+	}
+
+	return timeOff, nil
+}
+
+// DeleteTimeOff ...
+func (s *EmployeeScheduleService) DeleteTimeOff(ctx context.Context, locationID string, id string, actor Actor) (*TimeOff, error) {
+	const op = "app/employeeScheduleService.DeleteTimeOff"
+
+	err := actor.can(ctx, opUpdateEmployeeSchedule)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	timeOff, err := s.employeeScheduleStore.GetTimeOffByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get time off by id")
+	}
+
+	if timeOff == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	_, err = s.getEmployee(ctx, locationID, timeOff.EmployeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	err = s.employeeScheduleStore.DeleteTimeOff(ctx, timeOff)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to delete time off")
+	}
+
+	return timeOff, nil
+}
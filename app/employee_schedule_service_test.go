@@ -0,0 +1,224 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+)
+
+func TestSetWorkingHoursHappyPath(t *testing.T) {
+	employeeScheduleStore := memstore.NewEmployeeScheduleStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeScheduleService := app.NewEmployeeScheduleService(employeeScheduleStore, employeeStore)
+	actor := app.MockActor
+
+	employee := &app.Employee{ID: "1", LocationID: "1", Name: "employee1"}
+
+	err := employeeStore.StoreEmployee(context.Background(), employee)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should set working hours", func(t *testing.T) {
+		input := &app.SetWorkingHoursInput{
+			WorkingHours: []*app.WorkingHoursInput{
+				{Weekday: time.Tuesday, StartMinute: 14 * 60, EndMinute: 18 * 60},
+				{Weekday: time.Monday, StartMinute: 9 * 60, EndMinute: 17 * 60},
+				{Weekday: time.Tuesday, StartMinute: 9 * 60, EndMinute: 12 * 60},
+			},
+		}
+		_, err := employeeScheduleService.SetWorkingHours(context.Background(), employee.LocationID, employee.ID, input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		workingHours, err := employeeScheduleService.GetWorkingHours(context.Background(), employee.LocationID, employee.ID, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(workingHours) != 3 {
+			t.Errorf("expected 3 working hours, got %d", len(workingHours))
+			return
+		}
+
+		if workingHours[0].Weekday != time.Monday || workingHours[1].StartMinute != 9*60 {
+			t.Error("working hours should be sorted by weekday and start")
+		}
+	})
+
+	t.Run("should not set overlapping working hours", func(t *testing.T) {
+		input := &app.SetWorkingHoursInput{
+			WorkingHours: []*app.WorkingHoursInput{
+				{Weekday: time.Monday, StartMinute: 9 * 60, EndMinute: 12 * 60},
+				{Weekday: time.Monday, StartMinute: 11 * 60, EndMinute: 15 * 60},
+			},
+		}
+		_, err := employeeScheduleService.SetWorkingHours(context.Background(), employee.LocationID, employee.ID, input, actor)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Error("error should be invalid kind")
+		}
+	})
+
+	t.Run("should not set working hours past midnight", func(t *testing.T) {
+		input := &app.SetWorkingHoursInput{
+			WorkingHours: []*app.WorkingHoursInput{
+				{Weekday: time.Monday, StartMinute: 20 * 60, EndMinute: 25 * 60},
+			},
+		}
+		_, err := employeeScheduleService.SetWorkingHours(context.Background(), employee.LocationID, employee.ID, input, actor)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Error("error should be invalid kind")
+		}
+	})
+}
+
+func TestCreateWorkingHoursOverrideHappyPath(t *testing.T) {
+	employeeScheduleStore := memstore.NewEmployeeScheduleStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeScheduleService := app.NewEmployeeScheduleService(employeeScheduleStore, employeeStore)
+	actor := app.MockActor
+
+	employee := &app.Employee{ID: "1", LocationID: "1", Name: "employee1"}
+
+	err := employeeStore.StoreEmployee(context.Background(), employee)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should create day off", func(t *testing.T) {
+		input := &app.CreateWorkingHoursOverrideInput{
+			LocationID:  employee.LocationID,
+			EmployeeID:  employee.ID,
+			Date:        "2020-01-01",
+			StartMinute: 9 * 60,
+			EndMinute:   17 * 60,
+			IsDayOff:    true,
+		}
+		override, err := employeeScheduleService.CreateWorkingHoursOverride(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if override.StartMinute != 0 || override.EndMinute != 0 {
+			t.Error("day off should not have working hours")
+		}
+	})
+
+	t.Run("should not create override with invalid date", func(t *testing.T) {
+		input := &app.CreateWorkingHoursOverrideInput{
+			LocationID:  employee.LocationID,
+			EmployeeID:  employee.ID,
+			Date:        "01/01/2020",
+			StartMinute: 9 * 60,
+			EndMinute:   17 * 60,
+		}
+		_, err := employeeScheduleService.CreateWorkingHoursOverride(context.Background(), input, actor)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Error("error should be invalid kind")
+		}
+	})
+}
+
+func TestCreateTimeOffHappyPath(t *testing.T) {
+	employeeScheduleStore := memstore.NewEmployeeScheduleStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeScheduleService := app.NewEmployeeScheduleService(employeeScheduleStore, employeeStore)
+	actor := app.MockActor
+
+	employee := &app.Employee{ID: "1", LocationID: "1", Name: "employee1"}
+
+	err := employeeStore.StoreEmployee(context.Background(), employee)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should create and delete time off", func(t *testing.T) {
+		input := &app.CreateTimeOffInput{
+			LocationID: employee.LocationID,
+			EmployeeID: employee.ID,
+			StartTime:  startTime,
+			EndTime:    startTime.AddDate(0, 0, 7),
+			Reason:     "vacation",
+		}
+		timeOff, err := employeeScheduleService.CreateTimeOff(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		_, err = employeeScheduleService.DeleteTimeOff(context.Background(), employee.LocationID, timeOff.ID, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+}
+
+func TestScheduleOfEmployeeOfOtherLocation(t *testing.T) {
+	employeeScheduleStore := memstore.NewEmployeeScheduleStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeScheduleService := app.NewEmployeeScheduleService(employeeScheduleStore, employeeStore)
+	actor := app.MockActor
+	employee := &app.Employee{ID: "2", LocationID: "1", Name: "employee2"}
+	startTime := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	employeeStore.StoreEmployee(context.Background(), employee)
+
+	timeOff, err := employeeScheduleService.CreateTimeOff(context.Background(), &app.CreateTimeOffInput{LocationID: "1", EmployeeID: employee.ID, StartTime: startTime, EndTime: startTime.Add(time.Hour)}, actor)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = employeeScheduleService.GetWorkingHours(context.Background(), "2", employee.ID, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected working hours through other location not to be found, got %v", err)
+	}
+
+	_, err = employeeScheduleService.SetWorkingHours(context.Background(), "2", employee.ID, &app.SetWorkingHoursInput{}, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected setting working hours through other location to be not found, got %v", err)
+	}
+
+	_, err = employeeScheduleService.GetTimeOffs(context.Background(), "2", employee.ID, startTime, startTime.Add(time.Hour), actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected time offs through other location not to be found, got %v", err)
+	}
+
+	_, err = employeeScheduleService.CreateTimeOff(context.Background(), &app.CreateTimeOffInput{LocationID: "2", EmployeeID: employee.ID, StartTime: startTime, EndTime: startTime.Add(time.Hour)}, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected time off through other location to be not found, got %v", err)
+	}
+
+	_, err = employeeScheduleService.DeleteTimeOff(context.Background(), "2", timeOff.ID, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected deleting time off through other location to be not found, got %v", err)
+	}
+}
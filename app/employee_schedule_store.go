@@ -0,0 +1,346 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// EmployeeScheduleStore ...
+type EmployeeScheduleStore interface {
+	GetWorkingHoursByEmployeeID(ctx context.Context, employeeID string) ([]*WorkingHours, error)
+	ReplaceWorkingHours(ctx context.Context, employeeID string, workingHours []*WorkingHours) error
+	GetWorkingHoursOverridesByEmployeeIDAndDateRange(ctx context.Context, employeeID string, startDate time.Time, endDate time.Time) ([]*WorkingHoursOverride, error)
+	GetWorkingHoursOverrideByID(ctx context.Context, id string) (*WorkingHoursOverride, error)
+	StoreWorkingHoursOverride(ctx context.Context, override *WorkingHoursOverride) error
+	DeleteWorkingHoursOverride(ctx context.Context, override *WorkingHoursOverride) error
+	GetTimeOffsByEmployeeIDAndTimeRange(ctx context.Context, employeeID string, startTime time.Time, endTime time.Time) ([]*TimeOff, error)
+	GetTimeOffByID(ctx context.Context, id string) (*TimeOff, error)
+	StoreTimeOff(ctx context.Context, timeOff *TimeOff) error
+	DeleteTimeOff(ctx context.Context, timeOff *TimeOff) error
+	PurgeEmployeeSchedulesByEmployeeIDs(ctx context.Context, employeeIDs []string) error
+}
+
+type employeeScheduleStore struct {
+	db *sql.DB
+}
+
+// NewEmployeeScheduleStore ...
+func NewEmployeeScheduleStore(db *sql.DB) EmployeeScheduleStore {
+	return &employeeScheduleStore{db: db}
+}
+
+// GetWorkingHoursByEmployeeID gets weekly WorkingHours of an Employee
+func (s *employeeScheduleStore) GetWorkingHoursByEmployeeID(ctx context.Context, employeeID string) ([]*WorkingHours, error) {
+	const op = "app/employeeScheduleStore.GetWorkingHoursByEmployeeID"
+
+	query := `
+		SELECT id, employee_id, weekday, start_minute, end_minute, created_at, updated_at
+		FROM working_hours
+		WHERE employee_id=$1
+		ORDER BY weekday, start_minute;
+	`
+	workingHours := make([]*WorkingHours, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, employeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		wh := &WorkingHours{}
+
+		err := rows.Scan(&wh.ID, &wh.EmployeeID, &wh.Weekday, &wh.StartMinute, &wh.EndMinute, &wh.CreatedAt, &wh.UpdatedAt)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		workingHours = append(workingHours, wh)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return workingHours, nil
+}
+
+// ReplaceWorkingHours replaces the weekly WorkingHours of an Employee
+func (s *employeeScheduleStore) ReplaceWorkingHours(ctx context.Context, employeeID string, workingHours []*WorkingHours) error {
+	const op = "app/employeeScheduleStore.ReplaceWorkingHours"
+
+	return transaction.NewTransactor(s.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		query := `
+			DELETE FROM working_hours
+			WHERE employee_id=$1;
+		`
+
+		_, err := transaction.DB(ctx, s.db).Exec(query, employeeID)
+
+		if err != nil {
+			return errors.Wrap(op, err, "database error")
+		}
+
+		query = `
+			INSERT INTO working_hours (id, employee_id, weekday, start_minute, end_minute, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`
+
+		for _, wh := range workingHours {
+			_, err := transaction.DB(ctx, s.db).Exec(query, wh.ID, wh.EmployeeID, wh.Weekday, wh.StartMinute, wh.EndMinute, wh.CreatedAt, wh.UpdatedAt)
+
+			if err != nil {
+				return errors.Wrap(op, err, "database error")
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetWorkingHoursOverridesByEmployeeIDAndDateRange gets WorkingHoursOverrides between the dates, inclusive
+func (s *employeeScheduleStore) GetWorkingHoursOverridesByEmployeeIDAndDateRange(ctx context.Context, employeeID string, startDate time.Time, endDate time.Time) ([]*WorkingHoursOverride, error) {
+	const op = "app/employeeScheduleStore.GetWorkingHoursOverridesByEmployeeIDAndDateRange"
+
+	query := `
+		SELECT id, employee_id, date, start_minute, end_minute, is_day_off, created_at, updated_at
+		FROM working_hours_override
+		WHERE employee_id=$1
+			AND date BETWEEN $2::date AND $3::date
+		ORDER BY date, start_minute;
+	`
+	overrides := make([]*WorkingHoursOverride, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, employeeID, startDate.Format(dateLayout), endDate.Format(dateLayout))
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		override := &WorkingHoursOverride{}
+
+		err := rows.Scan(&override.ID, &override.EmployeeID, &override.Date, &override.StartMinute, &override.EndMinute, &override.IsDayOff, &override.CreatedAt, &override.UpdatedAt)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return overrides, nil
+}
+
+// GetWorkingHoursOverrideByID gets WorkingHoursOverride by ID
+func (s *employeeScheduleStore) GetWorkingHoursOverrideByID(ctx context.Context, id string) (*WorkingHoursOverride, error) {
+	const op = "app/employeeScheduleStore.GetWorkingHoursOverrideByID"
+
+	query := `
+		SELECT id, employee_id, date, start_minute, end_minute, is_day_off, created_at, updated_at
+		FROM working_hours_override
+		WHERE id=$1;
+	`
+
+	override := &WorkingHoursOverride{}
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	err := row.Scan(&override.ID, &override.EmployeeID, &override.Date, &override.StartMinute, &override.EndMinute, &override.IsDayOff, &override.CreatedAt, &override.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return override, nil
+}
+
+// StoreWorkingHoursOverride persists WorkingHoursOverride
+func (s *employeeScheduleStore) StoreWorkingHoursOverride(ctx context.Context, override *WorkingHoursOverride) error {
+	const op = "app/employeeScheduleStore.StoreWorkingHoursOverride"
+
+	query := `
+		INSERT INTO working_hours_override (id, employee_id, date, start_minute, end_minute, is_day_off, created_at, updated_at)
+		VALUES ($1, $2, $3::date, $4, $5, $6, $7, $8)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, override.ID, override.EmployeeID, override.Date.Format(dateLayout), override.StartMinute, override.EndMinute, override.IsDayOff, override.CreatedAt, override.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// DeleteWorkingHoursOverride deletes WorkingHoursOverride
+func (s *employeeScheduleStore) DeleteWorkingHoursOverride(ctx context.Context, override *WorkingHoursOverride) error {
+	const op = "app/employeeScheduleStore.DeleteWorkingHoursOverride"
+
+	query := `
+		DELETE FROM working_hours_override
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, override.ID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// GetTimeOffsByEmployeeIDAndTimeRange gets TimeOffs of an Employee overlapping the time range
+func (s *employeeScheduleStore) GetTimeOffsByEmployeeIDAndTimeRange(ctx context.Context, employeeID string, startTime time.Time, endTime time.Time) ([]*TimeOff, error) {
+	const op = "app/employeeScheduleStore.GetTimeOffsByEmployeeIDAndTimeRange"
+
+	query := `
+		SELECT id, employee_id, start_time, end_time, reason, created_at, updated_at
+		FROM time_off
+		WHERE employee_id=$1
+			AND start_time < $3
+			AND end_time > $2
+		ORDER BY start_time;
+	`
+	timeOffs := make([]*TimeOff, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, employeeID, startTime, endTime)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		timeOff := &TimeOff{}
+
+		err := rows.Scan(&timeOff.ID, &timeOff.EmployeeID, &timeOff.StartTime, &timeOff.EndTime, &timeOff.Reason, &timeOff.CreatedAt, &timeOff.UpdatedAt)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		timeOffs = append(timeOffs, timeOff)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return timeOffs, nil
+}
+
+// GetTimeOffByID gets TimeOff by ID
+func (s *employeeScheduleStore) GetTimeOffByID(ctx context.Context, id string) (*TimeOff, error) {
+	const op = "app/employeeScheduleStore.GetTimeOffByID"
+
+	query := `
+		SELECT id, employee_id, start_time, end_time, reason, created_at, updated_at
+		FROM time_off
+		WHERE id=$1;
+	`
+
+	timeOff := &TimeOff{}
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	err := row.Scan(&timeOff.ID, &timeOff.EmployeeID, &timeOff.StartTime, &timeOff.EndTime, &timeOff.Reason, &timeOff.CreatedAt, &timeOff.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return timeOff, nil
+}
+
+// StoreTimeOff persists TimeOff
+func (s *employeeScheduleStore) StoreTimeOff(ctx context.Context, timeOff *TimeOff) error {
+	const op = "app/employeeScheduleStore.StoreTimeOff"
+
+	query := `
+		INSERT INTO time_off (id, employee_id, start_time, end_time, reason, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, timeOff.ID, timeOff.EmployeeID, timeOff.StartTime, timeOff.EndTime, timeOff.Reason, timeOff.CreatedAt, timeOff.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// DeleteTimeOff deletes TimeOff
+func (s *employeeScheduleStore) DeleteTimeOff(ctx context.Context, timeOff *TimeOff) error {
+	const op = "app/employeeScheduleStore.DeleteTimeOff"
+
+	query := `
+		DELETE FROM time_off
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, timeOff.ID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// PurgeEmployeeSchedulesByEmployeeIDs permanently deletes the working hours, working hours overrides and time offs of the Employees
+func (s *employeeScheduleStore) PurgeEmployeeSchedulesByEmployeeIDs(ctx context.Context, employeeIDs []string) error {
+	const op = "app/employeeScheduleStore.PurgeEmployeeSchedulesByEmployeeIDs"
+
+	if len(employeeIDs) == 0 {
+		return nil
+	}
+
+	placeholder, args := makeIDsArgs(employeeIDs)
+
+	for _, table := range []string{"working_hours", "working_hours_override", "time_off"} {
+		query := fmt.Sprintf(`
+			DELETE FROM %s
+			WHERE employee_id IN (%s);
+		`, table, placeholder)
+
+		_, err := transaction.DB(ctx, s.db).Exec(query, args...)
+
+		if err != nil {
+			return errors.Wrap(op, err, "database error")
+		}
+	}
+
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/patch"
+	"github.com/minheq/kedul_server_main/query"
 )
 
 // Employee ...
@@ -20,6 +22,15 @@ type Employee struct {
 	EmployeeRoleID string    `json:"employee_role_id"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	DeletedAt      time.Time `json:"deleted_at"`
+	Version        int       `json:"version"`
+}
+
+// EmployeeFields are the fields lists of Employees can be filtered and sorted by
+var EmployeeFields = query.Fields{
+	{Name: "name", Column: "name", Type: query.String, Value: func(record interface{}) interface{} { return record.(*Employee).Name }},
+	{Name: "employee_role_id", Column: "employee_role_id::text", Type: query.String, Value: func(record interface{}) interface{} { return record.(*Employee).EmployeeRoleID }},
+	{Name: "created_at", Column: "created_at", Type: query.Time, Value: func(record interface{}) interface{} { return record.(*Employee).CreatedAt }},
 }
 
 // EmployeeService ...
@@ -33,30 +44,101 @@ func NewEmployeeService(employeeStore EmployeeStore, employeeRoleStore EmployeeR
 	return EmployeeService{employeeStore: employeeStore, employeeRoleStore: employeeRoleStore}
 }
 
+// GetEmployeesByLocationID gets a page of the employees of the location
+func (s *EmployeeService) GetEmployeesByLocationID(ctx context.Context, locationID string, args PageArgs, actor Actor) ([]*Employee, *Page, error) {
+	const op = "app/employeeService.GetEmployeesByLocationID"
+
+	err := actor.can(ctx, opReadEmployee)
+
+	if err != nil {
+		return nil, nil, errors.Forbidden(op, err)
+	}
+
+	err = args.Validate()
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
+	}
+
+	employees, page, err := s.employeeStore.GetEmployeePageByLocationID(ctx, locationID, args)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "failed to get employee page")
+	}
+
+	return employees, page, nil
+}
+
 // GetEmployeeByID ...
-func (s *EmployeeService) GetEmployeeByID(ctx context.Context, id string, actor Actor) (*Employee, error) {
+func (s *EmployeeService) GetEmployeeByID(ctx context.Context, locationID string, id string, actor Actor) (*Employee, error) {
 	const op = "app/employeeService.GetEmployeeByID"
 
 	err := actor.can(ctx, opReadEmployee)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
 	}
 
+	employee, err := s.getEmployee(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	return employee, nil
+}
+
+// getEmployee gets the employee, which must belong to the location. Employees of other locations are not found
+func (s *EmployeeService) getEmployee(ctx context.Context, locationID string, id string) (*Employee, error) {
+	const op = "app/employeeService.getEmployee"
+
 	employee, err := s.employeeStore.GetEmployeeByID(ctx, id)
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "failed to get employee by id")
 	}
 
+	if employee == nil || employee.LocationID != locationID {
+		return nil, errors.NotFound(op)
+	}
+
 	return employee, nil
 }
 
+// getEmployeeRole gets the employee role, which must belong to the location
+func (s *EmployeeService) getEmployeeRole(ctx context.Context, locationID string, employeeRoleID string) (*EmployeeRole, error) {
+	const op = "app/employeeService.getEmployeeRole"
+
+	employeeRole, err := s.employeeRoleStore.GetEmployeeRoleByID(ctx, employeeRoleID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee role by id")
+	}
+
+	if employeeRole == nil || employeeRole.LocationID != locationID {
+		return nil, errors.InvalidField(op, "employee_role_id", errors.CodeInvalidValue, "employee role not found in location")
+	}
+
+	return employeeRole, nil
+}
+
 // CreateEmployeeInput ...
 type CreateEmployeeInput struct {
 	LocationID     string `json:"location_id"`
 	Name           string `json:"name"`
 	ProfileImageID string `json:"profile_image_id"`
+	EmployeeRoleID string `json:"employee_role_id"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateEmployeeInput) Validate() error {
+	const op = "app/CreateEmployeeInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("name", input.Name)
+	v.Required("employee_role_id", input.EmployeeRoleID)
+
+	return v.Err(op)
 }
 
 // CreateEmployee creates employee
@@ -66,20 +148,33 @@ func (s *EmployeeService) CreateEmployee(ctx context.Context, input *CreateEmplo
 	err := actor.can(ctx, opCreateEmployee)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
 	}
 
 	now := time.Now()
 	id := uuid.Must(uuid.New(), nil).String()
 
-	if input.Name == "" {
-		return nil, errors.Invalid(op, "name field required")
+	employeeRole, err := s.getEmployeeRole(ctx, input.LocationID, input.EmployeeRoleID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid employee role")
+	}
+
+	if employeeRole.IsOwner {
+		return nil, errors.Invalid(op, "cannot give owner role").WithCode(errors.CodeOwnerRoleImmutable)
 	}
 
 	employee := &Employee{
 		ID:             id,
 		LocationID:     input.LocationID,
 		ProfileImageID: input.ProfileImageID,
+		EmployeeRoleID: input.EmployeeRoleID,
 		Name:           strings.TrimSpace(input.Name),
 		CreatedAt:      now,
 		UpdatedAt:      now,
@@ -98,64 +193,142 @@ func (s *EmployeeService) CreateEmployee(ctx context.Context, input *CreateEmplo
 type UpdateEmployeeInput struct {
 	Name           string `json:"name"`
 	ProfileImageID string `json:"profile_image_id"`
+	EmployeeRoleID string `json:"employee_role_id"`
+}
+
+// Validate checks the fields of the input
+func (input *UpdateEmployeeInput) Validate() error {
+	const op = "app/UpdateEmployeeInput.Validate"
+
+	v := &errors.Validation{}
+	v.NotBlank("name", input.Name)
+
+	return v.Err(op)
 }
 
-// UpdateEmployee updates employee
-func (s *EmployeeService) UpdateEmployee(ctx context.Context, id string, input *UpdateEmployeeInput, actor Actor) (*Employee, error) {
+// UpdateEmployee updates employee. Empty fields of the input are left unchanged
+func (s *EmployeeService) UpdateEmployee(ctx context.Context, locationID string, id string, version int, input *UpdateEmployeeInput, actor Actor) (*Employee, error) {
 	const op = "app/employeeService.UpdateEmployee"
 
+	err := input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	employee, err := s.PatchEmployee(ctx, locationID, id, version, &PatchEmployeeInput{Name: patch.Changed(input.Name), ProfileImageID: patch.Changed(input.ProfileImageID), EmployeeRoleID: patch.Changed(input.EmployeeRoleID)}, actor)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to patch employee")
+	}
+
+	return employee, nil
+}
+
+// PatchEmployeeInput is a merge patch of an employee. The name and the employee role cannot be removed
+type PatchEmployeeInput struct {
+	Name           patch.String `json:"name"`
+	ProfileImageID patch.String `json:"profile_image_id"`
+	EmployeeRoleID patch.String `json:"employee_role_id"`
+}
+
+// Validate checks the fields of the input
+func (input *PatchEmployeeInput) Validate() error {
+	const op = "app/PatchEmployeeInput.Validate"
+
+	v := &errors.Validation{}
+	input.Name.Required(v, "name")
+	input.ProfileImageID.Optional(v, "profile_image_id")
+	input.EmployeeRoleID.Required(v, "employee_role_id")
+
+	return v.Err(op)
+}
+
+// PatchEmployee changes the fields of employee in the input, and removes those that are null, unless version is set and outdated
+func (s *EmployeeService) PatchEmployee(ctx context.Context, locationID string, id string, version int, input *PatchEmployeeInput, actor Actor) (*Employee, error) {
+	const op = "app/employeeService.PatchEmployee"
+
 	err := actor.can(ctx, opUpdateEmployee)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
 	}
 
-	employee, err := s.employeeStore.GetEmployeeByID(ctx, id)
+	err = input.Validate()
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get employee by id")
+		return nil, errors.Wrap(op, err, "invalid input")
 	}
 
-	if employee == nil {
-		return nil, errors.NotFound(op)
+	employee, err := s.getEmployee(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee")
+	}
+
+	if version != 0 && employee.Version != version {
+		return nil, errors.PreconditionFailed(op, "employee was changed meanwhile")
 	}
 
 	employee.UpdatedAt = time.Now()
 
-	if input.Name != "" {
-		employee.Name = strings.TrimSpace(input.Name)
+	if input.Name.Set {
+		employee.Name = strings.TrimSpace(input.Name.Value)
 	}
-	if input.ProfileImageID != "" {
-		employee.ProfileImageID = input.ProfileImageID
+	if input.ProfileImageID.Set {
+		employee.ProfileImageID = input.ProfileImageID.Value
+	}
+	if input.EmployeeRoleID.Set && input.EmployeeRoleID.Value != employee.EmployeeRoleID {
+		currentEmployeeRole, err := s.getEmployeeRole(ctx, employee.LocationID, employee.EmployeeRoleID)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to get current employee role")
+		}
+
+		if currentEmployeeRole.IsOwner {
+			return nil, errors.Invalid(op, "cannot change role of owner").WithCode(errors.CodeOwnerRoleImmutable)
+		}
+
+		employeeRole, err := s.getEmployeeRole(ctx, employee.LocationID, input.EmployeeRoleID.Value)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "invalid employee role")
+		}
+
+		if employeeRole.IsOwner {
+			return nil, errors.Invalid(op, "cannot give owner role").WithCode(errors.CodeOwnerRoleImmutable)
+		}
+
+		employee.EmployeeRoleID = input.EmployeeRoleID.Value
 	}
 
 	err = s.employeeStore.UpdateEmployee(ctx, employee)
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update employee")
+		return nil, errors.Wrap(op, err, "failed to update employee")
 	}
 
 	return employee, nil
 }
 
 // DeleteEmployee updates employee
-func (s *EmployeeService) DeleteEmployee(ctx context.Context, id string, actor Actor) (*Employee, error) {
+func (s *EmployeeService) DeleteEmployee(ctx context.Context, locationID string, id string, version int, actor Actor) (*Employee, error) {
 	const op = "app/employeeService.DeleteEmployee"
 
 	err := actor.can(ctx, opDeleteEmployee)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
 	}
 
-	employee, err := s.employeeStore.GetEmployeeByID(ctx, id)
+	employee, err := s.getEmployee(ctx, locationID, id)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get employee by id")
+		return nil, errors.Wrap(op, err, "failed to get employee")
 	}
 
-	if employee == nil {
-		return nil, errors.NotFound(op)
+	if version != 0 && employee.Version != version {
+		return nil, errors.PreconditionFailed(op, "employee was changed meanwhile")
 	}
 
 	employeeRole, err := s.employeeRoleStore.GetEmployeeRoleByID(ctx, employee.EmployeeRoleID)
@@ -168,14 +341,16 @@ func (s *EmployeeService) DeleteEmployee(ctx context.Context, id string, actor A
 		return nil, errors.Unexpected(op, fmt.Errorf("employee has invalid employeeRoleID=%s", employee.EmployeeRoleID), "employee role not found")
 	}
 
-	if employeeRole.Name == "owner" {
-		return nil, errors.Invalid(op, "cannot delete user with owner role")
+	if employeeRole.IsOwner {
+		return nil, errors.Invalid(op, "cannot delete user with owner role").WithCode(errors.CodeOwnerRoleImmutable)
 	}
 
+	employee.DeletedAt = time.Now()
+
 	err = s.employeeStore.DeleteEmployee(ctx, employee)
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update employee")
+		return nil, errors.Wrap(op, err, "failed to update employee")
 	}
 
 	return employee, nil
@@ -1,95 +1,40 @@
-package app
+package app_test
 
 import (
 	"context"
 	"testing"
-)
-
-type mockEmployeeStore struct {
-	employees []*Employee
-}
-
-func (s *mockEmployeeStore) GetEmployeesByUserID(ctx context.Context, userID string) ([]*Employee, error) {
-	employees := make([]*Employee, 0)
-
-	for _, e := range s.employees {
-		if e.UserID == userID {
-			employees = append(employees, e)
-		}
-	}
-
-	return employees, nil
-}
-
-func (s *mockEmployeeStore) GetEmployeesByEmployeeRoleID(ctx context.Context, employeeRoleID string) ([]*Employee, error) {
-	employees := make([]*Employee, 0)
-
-	for _, e := range s.employees {
-		if e.EmployeeRoleID == employeeRoleID {
-			employees = append(employees, e)
-		}
-	}
-
-	return employees, nil
-}
-
-func (s *mockEmployeeStore) GetEmployeeByUserIDAndLocationID(ctx context.Context, userID string, locationID string) (*Employee, error) {
-	for _, e := range s.employees {
-		if e.UserID == userID && e.LocationID == locationID {
-			return e, nil
-		}
-	}
-
-	return nil, nil
-}
-
-func (s *mockEmployeeStore) GetEmployeeByID(ctx context.Context, id string) (*Employee, error) {
-	for _, e := range s.employees {
-		if e.ID == id {
-			return e, nil
-		}
-	}
-
-	return nil, nil
-}
 
-func (s *mockEmployeeStore) StoreEmployee(ctx context.Context, employee *Employee) error {
-	s.employees = append(s.employees, employee)
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+	"github.com/minheq/kedul_server_main/patch"
+)
 
-	return nil
-}
+func TestCreateEmployeeHappyPath(t *testing.T) {
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeService := app.NewEmployeeService(employeeStore, employeeRoleStore)
+	actor := app.MockActor
 
-func (s *mockEmployeeStore) UpdateEmployee(ctx context.Context, employee *Employee) error {
-	for i, e := range s.employees {
-		if e.ID == employee.ID {
-			s.employees[i] = employee
-			break
-		}
+	employeeRole := &app.EmployeeRole{
+		ID:            "1",
+		LocationID:    "1",
+		Name:          "employee_role1",
+		PermissionIDs: []string{},
 	}
 
-	return nil
-}
+	err := employeeRoleStore.StoreEmployeeRole(context.Background(), employeeRole)
 
-func (s *mockEmployeeStore) DeleteEmployee(ctx context.Context, employee *Employee) error {
-	for i, e := range s.employees {
-		if e.ID == employee.ID {
-			s.employees = append(s.employees[:i], s.employees[i+1:]...)
-			break
-		}
+	if err != nil {
+		t.Error(err)
+		return
 	}
 
-	return nil
-}
-
-func TestCreateEmployeeHappyPath(t *testing.T) {
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	employeeService := NewEmployeeService(employeeStore, employeeRoleStore)
-	actor := &mockActor{}
-
 	t.Run("should create employee", func(t *testing.T) {
-		input := &CreateEmployeeInput{
-			Name: "employee1",
+		input := &app.CreateEmployeeInput{
+			LocationID:     employeeRole.LocationID,
+			Name:           "employee1",
+			EmployeeRoleID: employeeRole.ID,
 		}
 		_, err := employeeService.CreateEmployee(context.Background(), input, actor)
 
@@ -98,19 +43,32 @@ func TestCreateEmployeeHappyPath(t *testing.T) {
 			return
 		}
 	})
+
+	t.Run("should not create employee with role of other location", func(t *testing.T) {
+		input := &app.CreateEmployeeInput{
+			LocationID:     "2",
+			Name:           "employee2",
+			EmployeeRoleID: employeeRole.ID,
+		}
+		_, err := employeeService.CreateEmployee(context.Background(), input, actor)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Error("error should be invalid kind")
+		}
+	})
 }
 
 func TestUpdateEmployeeHappyPath(t *testing.T) {
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	employeeService := NewEmployeeService(employeeStore, employeeRoleStore)
-	actor := &mockActor{}
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeService := app.NewEmployeeService(employeeStore, employeeRoleStore)
+	actor := app.MockActor
 
-	location := &Location{
+	location := &app.Location{
 		ID:   "1",
 		Name: "location1",
 	}
-	employee := &Employee{
+	employee := &app.Employee{
 		ID:         "1",
 		LocationID: location.ID,
 		Name:       "employee2",
@@ -124,28 +82,61 @@ func TestUpdateEmployeeHappyPath(t *testing.T) {
 	}
 
 	t.Run("should update employee", func(t *testing.T) {
-		input := &UpdateEmployeeInput{
+		input := &app.UpdateEmployeeInput{
 			Name: "employee3",
 		}
-		_, err := employeeService.UpdateEmployee(context.Background(), employee.ID, input, actor)
+		_, err := employeeService.UpdateEmployee(context.Background(), employee.LocationID, employee.ID, 0, input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+}
+
+func TestPatchEmployee(t *testing.T) {
+	employeeStore := memstore.NewEmployeeStore()
+	employeeService := app.NewEmployeeService(employeeStore, memstore.NewEmployeeRoleStore())
+	employee := &app.Employee{ID: "5", LocationID: "1", Name: "employee5", ProfileImageID: "image", EmployeeRoleID: "1"}
+
+	employeeStore.StoreEmployee(context.Background(), employee)
+
+	t.Run("should remove null fields and leave absent fields unchanged", func(t *testing.T) {
+		input := &app.PatchEmployeeInput{Name: patch.Value(" employee6 "), ProfileImageID: patch.Null()}
+
+		got, err := employeeService.PatchEmployee(context.Background(), employee.LocationID, employee.ID, 0, input, app.MockActor)
 
 		if err != nil {
 			t.Error(err)
 			return
 		}
+
+		if got.Name != "employee6" || got.ProfileImageID != "" || got.EmployeeRoleID != "1" {
+			t.Errorf("expected name employee6 without profile image and with the same role, got %+v", got)
+		}
+	})
+
+	t.Run("should not remove employee role", func(t *testing.T) {
+		input := &app.PatchEmployeeInput{EmployeeRoleID: patch.Null()}
+
+		_, err := employeeService.PatchEmployee(context.Background(), employee.LocationID, employee.ID, 0, input, app.MockActor)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Errorf("expected removing employee role to be invalid, got %v", err)
+		}
 	})
 }
 
 func TestDeleteEmployeeHappyPath(t *testing.T) {
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	employeeService := NewEmployeeService(employeeStore, employeeRoleStore)
-	location := &Location{
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeService := app.NewEmployeeService(employeeStore, employeeRoleStore)
+	location := &app.Location{
 		ID:   "2",
 		Name: "location2",
 	}
 
-	employeeRole := &EmployeeRole{
+	employeeRole := &app.EmployeeRole{
 		ID:            "1",
 		LocationID:    location.ID,
 		Name:          "employee_role1",
@@ -159,13 +150,13 @@ func TestDeleteEmployeeHappyPath(t *testing.T) {
 		return
 	}
 
-	employee := &Employee{
+	employee := &app.Employee{
 		ID:             "4",
 		LocationID:     location.ID,
 		Name:           "employee4",
 		EmployeeRoleID: employeeRole.ID,
 	}
-	actor := &mockActor{}
+	actor := app.MockActor
 
 	err = employeeStore.StoreEmployee(context.Background(), employee)
 
@@ -175,7 +166,7 @@ func TestDeleteEmployeeHappyPath(t *testing.T) {
 	}
 
 	t.Run("should delete employee", func(t *testing.T) {
-		_, err := employeeService.DeleteEmployee(context.Background(), employee.ID, actor)
+		_, err := employeeService.DeleteEmployee(context.Background(), employee.LocationID, employee.ID, 0, actor)
 
 		if err != nil {
 			t.Error(err)
@@ -183,3 +174,80 @@ func TestDeleteEmployeeHappyPath(t *testing.T) {
 		}
 	})
 }
+
+func TestEmployeeOfOtherLocation(t *testing.T) {
+	employeeStore := memstore.NewEmployeeStore()
+	employeeService := app.NewEmployeeService(employeeStore, memstore.NewEmployeeRoleStore())
+	employee := &app.Employee{ID: "6", LocationID: "1", Name: "employee6", EmployeeRoleID: "1"}
+	actor := app.MockActor
+
+	employeeStore.StoreEmployee(context.Background(), employee)
+
+	t.Run("should not get employee through other location", func(t *testing.T) {
+		_, err := employeeService.GetEmployeeByID(context.Background(), "2", employee.ID, actor)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Errorf("expected employee of other location not to be found, got %v", err)
+		}
+	})
+
+	t.Run("should not patch or delete employee through other location", func(t *testing.T) {
+		_, err := employeeService.PatchEmployee(context.Background(), "2", employee.ID, 0, &app.PatchEmployeeInput{Name: patch.Value("renamed")}, actor)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Errorf("expected patch through other location to be not found, got %v", err)
+		}
+
+		_, err = employeeService.DeleteEmployee(context.Background(), "2", employee.ID, 0, actor)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Errorf("expected delete through other location to be not found, got %v", err)
+		}
+
+		storedEmployee, err := employeeStore.GetEmployeeByID(context.Background(), employee.ID)
+
+		if err != nil || storedEmployee == nil || storedEmployee.Name != "employee6" {
+			t.Errorf("expected employee to be unchanged, got %+v", storedEmployee)
+		}
+	})
+}
+
+func TestGiveOwnerRole(t *testing.T) {
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	employeeService := app.NewEmployeeService(employeeStore, employeeRoleStore)
+	ownerRole := &app.EmployeeRole{ID: "8", LocationID: "1", Name: "owner", PermissionIDs: []string{}, IsOwner: true}
+	role := &app.EmployeeRole{ID: "9", LocationID: "1", Name: "role9", PermissionIDs: []string{}}
+	employee := &app.Employee{ID: "9", LocationID: "1", Name: "employee9", EmployeeRoleID: role.ID}
+	actor := app.MockActor
+
+	employeeRoleStore.StoreEmployeeRole(context.Background(), ownerRole)
+	employeeRoleStore.StoreEmployeeRole(context.Background(), role)
+	employeeStore.StoreEmployee(context.Background(), employee)
+
+	t.Run("should not create employee with owner role", func(t *testing.T) {
+		input := &app.CreateEmployeeInput{LocationID: "1", Name: "employee10", EmployeeRoleID: ownerRole.ID}
+
+		_, err := employeeService.CreateEmployee(context.Background(), input, actor)
+
+		if errors.ErrorCode(err) != errors.CodeOwnerRoleImmutable {
+			t.Errorf("expected owner role to be refused, got %v", err)
+		}
+	})
+
+	t.Run("should not give owner role to employee", func(t *testing.T) {
+		input := &app.PatchEmployeeInput{EmployeeRoleID: patch.Value(ownerRole.ID)}
+
+		_, err := employeeService.PatchEmployee(context.Background(), employee.LocationID, employee.ID, 0, input, actor)
+
+		if errors.ErrorCode(err) != errors.CodeOwnerRoleImmutable {
+			t.Errorf("expected owner role to be refused, got %v", err)
+		}
+
+		storedEmployee, err := employeeStore.GetEmployeeByID(context.Background(), employee.ID)
+
+		if err != nil || storedEmployee.EmployeeRoleID != role.ID {
+			t.Errorf("expected employee to keep role %s, got %+v", role.ID, storedEmployee)
+		}
+	})
+}
@@ -3,19 +3,26 @@ package app
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 // EmployeeStore ...
 type EmployeeStore interface {
 	GetEmployeesByUserID(ctx context.Context, userID string) ([]*Employee, error)
+	GetEmployeesByLocationID(ctx context.Context, locationID string) ([]*Employee, error)
+	GetEmployeePageByLocationID(ctx context.Context, locationID string, args PageArgs) ([]*Employee, *Page, error)
 	GetEmployeesByEmployeeRoleID(ctx context.Context, employeeRoleID string) ([]*Employee, error)
 	GetEmployeeByUserIDAndLocationID(ctx context.Context, userID string, locationID string) (*Employee, error)
 	GetEmployeeByID(ctx context.Context, id string) (*Employee, error)
 	StoreEmployee(ctx context.Context, employee *Employee) error
 	UpdateEmployee(ctx context.Context, employee *Employee) error
 	DeleteEmployee(ctx context.Context, employee *Employee) error
+	DeleteEmployeesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error
+	RestoreEmployeesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error
+	PurgeEmployees(ctx context.Context, deletedBefore time.Time) ([]string, error)
 }
 
 type employeeStore struct {
@@ -27,27 +34,85 @@ func NewEmployeeStore(db *sql.DB) EmployeeStore {
 	return &employeeStore{db: db}
 }
 
-// GetEmployeeByUserIDAndLocationID gets Employee by UserID and LocationID
+// scanEmployee scans a row of employee columns. UserID is NULL until the employee joins with their account
+func scanEmployee(row rowScanner) (*Employee, error) {
+	employee := &Employee{}
+	userID := sql.NullString{}
+
+	err := row.Scan(&employee.ID, &employee.LocationID, &employee.Name, &userID, &employee.EmployeeRoleID, &employee.ProfileImageID, &employee.CreatedAt, &employee.UpdatedAt, &employee.Version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	employee.UserID = userID.String
+
+	return employee, nil
+}
+
+// GetEmployeesByUserID gets Employees by UserID
 func (s *employeeStore) GetEmployeesByUserID(ctx context.Context, userID string) ([]*Employee, error) {
 	const op = "app/employeeStore.GetEmployeesByUserID"
 
 	query := `
-		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at
+		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at, version
 		FROM employee
-		WHERE user_id=$1;
+		WHERE user_id=$1
+			AND deleted_at IS NULL
+		ORDER BY created_at;
 	`
 	employees := make([]*Employee, 0)
 
-	rows, err := s.db.Query(query, userID)
+	rows, err := transaction.DB(ctx, s.db).Query(query, userID)
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
-		employee := &Employee{}
+		employee, err := scanEmployee(rows)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		employees = append(employees, employee)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return employees, nil
+}
+
+// GetEmployeesByLocationID gets Employees working at the Location
+func (s *employeeStore) GetEmployeesByLocationID(ctx context.Context, locationID string) ([]*Employee, error) {
+	const op = "app/employeeStore.GetEmployeesByLocationID"
+
+	query := `
+		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at, version
+		FROM employee
+		WHERE location_id=$1
+			AND deleted_at IS NULL
+		ORDER BY created_at;
+	`
+	employees := make([]*Employee, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, locationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
 
-		err := rows.Scan(&employee.ID, &employee.LocationID, &employee.Name, &employee.UserID, &employee.EmployeeRoleID, &employee.ProfileImageID, &employee.CreatedAt, &employee.UpdatedAt)
+	defer rows.Close()
+
+	for rows.Next() {
+		employee, err := scanEmployee(rows)
 
 		if err != nil {
 			return nil, errors.Wrap(op, err, "row scan error")
@@ -65,27 +130,60 @@ func (s *employeeStore) GetEmployeesByUserID(ctx context.Context, userID string)
 	return employees, nil
 }
 
-// GetEmployeeByUserIDAndLocationID gets Employee by UserID and LocationID
+// GetEmployeePageByLocationID gets a page of the Employees of a Location, filtered and sorted by args
+func (s *employeeStore) GetEmployeePageByLocationID(ctx context.Context, locationID string, args PageArgs) ([]*Employee, *Page, error) {
+	const op = "app/employeeStore.GetEmployeePageByLocationID"
+
+	query := `
+		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at, version
+		FROM employee
+		WHERE location_id=$1
+			AND deleted_at IS NULL
+	`
+	employees := make([]*Employee, 0)
+
+	start, end, page, err := queryPage(ctx, s.db, query, []interface{}{locationID}, args, func(rows *transaction.Rows) (Cursor, error) {
+		employee, err := scanEmployee(rows)
+
+		if err != nil {
+			return Cursor{}, err
+		}
+
+		employees = append(employees, employee)
+
+		return args.CursorOf(employee, employee.CreatedAt, employee.ID), nil
+	})
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "database error")
+	}
+
+	return employees[start:end], page, nil
+}
+
+// GetEmployeesByEmployeeRoleID gets Employees by EmployeeRoleID
 func (s *employeeStore) GetEmployeesByEmployeeRoleID(ctx context.Context, employeeRoleID string) ([]*Employee, error) {
 	const op = "app/employeeStore.GetEmployeesByEmployeeRoleID"
 
 	query := `
-		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at
+		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at, version
 		FROM employee
-		WHERE employee_role_id=$1;
+		WHERE employee_role_id=$1
+			AND deleted_at IS NULL
+		ORDER BY created_at;
 	`
 	employees := make([]*Employee, 0)
 
-	rows, err := s.db.Query(query, employeeRoleID)
+	rows, err := transaction.DB(ctx, s.db).Query(query, employeeRoleID)
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
 
-	for rows.Next() {
-		employee := &Employee{}
+	defer rows.Close()
 
-		err = rows.Scan(&employee.ID, &employee.LocationID, &employee.Name, &employee.UserID, &employee.EmployeeRoleID, &employee.ProfileImageID, &employee.CreatedAt, &employee.UpdatedAt)
+	for rows.Next() {
+		employee, err := scanEmployee(rows)
 
 		if err != nil {
 			return nil, errors.Wrap(op, err, "row scan error")
@@ -105,25 +203,22 @@ func (s *employeeStore) GetEmployeesByEmployeeRoleID(ctx context.Context, employ
 
 // GetEmployeeByUserIDAndLocationID gets Employee by UserID and LocationID
 func (s *employeeStore) GetEmployeeByUserIDAndLocationID(ctx context.Context, userID string, locationID string) (*Employee, error) {
-	const op = "app/employeeStore.GetEmployeeByID"
+	const op = "app/employeeStore.GetEmployeeByUserIDAndLocationID"
 
 	query := `
-		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at
+		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at, version
 		FROM employee
 		WHERE user_id=$1
-			AND location_id=$2;
+			AND location_id=$2
+			AND deleted_at IS NULL;
 	`
 
-	employee := &Employee{}
-
-	row := s.db.QueryRow(query, userID, locationID)
+	employee, err := scanEmployee(transaction.DB(ctx, s.db).QueryRow(query, userID, locationID))
 
-	if row == nil {
+	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 
-	err := row.Scan(&employee.ID, &employee.LocationID, &employee.Name, &employee.UserID, &employee.EmployeeRoleID, &employee.ProfileImageID, &employee.CreatedAt, &employee.UpdatedAt)
-
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
@@ -136,21 +231,18 @@ func (s *employeeStore) GetEmployeeByID(ctx context.Context, id string) (*Employ
 	const op = "app/employeeStore.GetEmployeeByID"
 
 	query := `
-		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at
+		SELECT id, location_id, name, user_id, employee_role_id, profile_image_id, created_at, updated_at, version
 		FROM employee
-		WHERE id=$1;
+		WHERE id=$1
+			AND deleted_at IS NULL;
 	`
 
-	employee := &Employee{}
-
-	row := s.db.QueryRow(query, id)
+	employee, err := scanEmployee(transaction.DB(ctx, s.db).QueryRow(query, id))
 
-	if row == nil {
+	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 
-	err := row.Scan(&employee.ID, &employee.LocationID, &employee.Name, &employee.UserID, &employee.EmployeeRoleID, &employee.ProfileImageID, &employee.CreatedAt, &employee.UpdatedAt)
-
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
@@ -167,48 +259,136 @@ func (s *employeeStore) StoreEmployee(ctx context.Context, employee *Employee) e
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := s.db.Exec(query, employee.ID, employee.LocationID, employee.UserID, employee.Name, employee.EmployeeRoleID, employee.ProfileImageID, employee.CreatedAt, employee.UpdatedAt)
+	_, err := transaction.DB(ctx, s.db).Exec(query, employee.ID, employee.LocationID, toNullString(employee.UserID), employee.Name, employee.EmployeeRoleID, employee.ProfileImageID, employee.CreatedAt, employee.UpdatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	employee.Version = 1
+
 	return nil
 }
 
-// UpdateEmployee updates Employee including all fields
+// UpdateEmployee updates Employee including all fields. It fails with KindPreconditionFailed unless the stored Version is still the same, and increments Version
 func (s *employeeStore) UpdateEmployee(ctx context.Context, employee *Employee) error {
 	const op = "app/employeeStore.UpdateEmployee"
 
 	query := `
 		UPDATE employee
-		SET user_id=$2, name=$3, employee_role_id=$4, profile_image_id=$5, updated_at=$6
-		WHERE id=$1;
+		SET user_id=$2, name=$3, employee_role_id=$4, profile_image_id=$5, updated_at=$6, version=version+1
+		WHERE id=$1
+			AND version=$7;
 	`
 
-	_, err := s.db.Exec(query, employee.ID, employee.UserID, employee.Name, employee.EmployeeRoleID, employee.ProfileImageID, employee.UpdatedAt)
+	result, err := transaction.DB(ctx, s.db).Exec(query, employee.ID, toNullString(employee.UserID), employee.Name, employee.EmployeeRoleID, employee.ProfileImageID, employee.UpdatedAt, employee.Version)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	if count == 0 {
+		return errors.PreconditionFailed(op, "employee was changed meanwhile")
+	}
+
+	employee.Version++
+
 	return nil
 }
 
-// DeleteEmployee deletes Employee
+// DeleteEmployee marks Employee as deleted at DeletedAt. Like updates, it requires and increments Version. It is kept until it is purged
 func (s *employeeStore) DeleteEmployee(ctx context.Context, employee *Employee) error {
 	const op = "app/employeeStore.DeleteEmployee"
 
 	query := `
-		DELETE FROM employee
-		WHERE id=$1;
+		UPDATE employee
+		SET deleted_at=$2, version=version+1
+		WHERE id=$1
+			AND version=$3;
 	`
 
-	_, err := s.db.Exec(query, employee.ID)
+	result, err := transaction.DB(ctx, s.db).Exec(query, employee.ID, employee.DeletedAt, employee.Version)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	if count == 0 {
+		return errors.PreconditionFailed(op, "employee was changed meanwhile")
+	}
+
+	employee.Version++
+
 	return nil
 }
+
+// DeleteEmployeesByLocationID marks the Employees of a Location as deleted at the time
+func (s *employeeStore) DeleteEmployeesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	const op = "app/employeeStore.DeleteEmployeesByLocationID"
+
+	query := `
+		UPDATE employee
+		SET deleted_at=$2
+		WHERE location_id=$1
+			AND deleted_at IS NULL;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// RestoreEmployeesByLocationID unmarks the Employees of a Location deleted at the time, i.e. along with it
+func (s *employeeStore) RestoreEmployeesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	const op = "app/employeeStore.RestoreEmployeesByLocationID"
+
+	query := `
+		UPDATE employee
+		SET deleted_at=NULL
+		WHERE location_id=$1
+			AND deleted_at=$2;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// PurgeEmployees permanently deletes Employees deleted before the time, and returns their ids
+func (s *employeeStore) PurgeEmployees(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	const op = "app/employeeStore.PurgeEmployees"
+
+	query := `
+		DELETE FROM employee
+		WHERE deleted_at < $1
+		RETURNING id;
+	`
+
+	ids, err := queryIDs(ctx, s.db, query, deletedBefore)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,22 @@
+package app
+
+import "context"
+
+// The tests of the services are in package app_test, so that they run against the memory stores of memstore,
+// which imports app. These are the unexported parts of app they use
+
+type mockActor struct{}
+
+func (m *mockActor) can(ctx context.Context, operation Operation) error {
+	return nil
+}
+
+// MockActor is allowed every operation
+var MockActor Actor = &mockActor{}
+
+var (
+	PermManageLocation      = permManageLocation
+	CalculateAvailableSlots = calculateAvailableSlots
+	StartOfDay              = startOfDay
+	BusinessSMSTypes        = businessSMSTypes
+)
@@ -0,0 +1,371 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/phone"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// invitationExpiry is how long an invitation can be accepted for
+const invitationExpiry = 7 * 24 * time.Hour
+
+// Invitation statuses
+const (
+	InvitationStatusPending  = "PENDING"
+	InvitationStatusAccepted = "ACCEPTED"
+	InvitationStatusRevoked  = "REVOKED"
+)
+
+// Invitation lets the owner of the phone number join a location as the employee once they log in
+type Invitation struct {
+	ID               string    `json:"id"`
+	LocationID       string    `json:"location_id"`
+	EmployeeID       string    `json:"employee_id"`
+	EmployeeRoleID   string    `json:"employee_role_id"`
+	PhoneNumber      string    `json:"phone_number"`
+	CountryCode      string    `json:"country_code"`
+	Status           string    `json:"status"`
+	InvitedByUserID  string    `json:"invited_by_user_id"`
+	AcceptedByUserID string    `json:"accepted_by_user_id"`
+	ExpiredAt        time.Time `json:"expired_at"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// IsPending checks whether the invitation can still be accepted
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.Status == InvitationStatusPending && now.Before(i.ExpiredAt)
+}
+
+// InvitationService ...
+type InvitationService struct {
+	invitationStore   InvitationStore
+	employeeStore     EmployeeStore
+	employeeRoleStore EmployeeRoleStore
+	locationStore     LocationStore
+	businessStore     BusinessStore
+	smsSender         phone.SMSSender
+	smsTemplates      *phone.SMSTemplates
+	transactor        transaction.Transactor
+}
+
+// NewInvitationService constructor for InvitationService
+func NewInvitationService(invitationStore InvitationStore, employeeStore EmployeeStore, employeeRoleStore EmployeeRoleStore, locationStore LocationStore, businessStore BusinessStore, smsSender phone.SMSSender, smsTemplates *phone.SMSTemplates, transactor transaction.Transactor) InvitationService {
+	return InvitationService{invitationStore: invitationStore, employeeStore: employeeStore, employeeRoleStore: employeeRoleStore, locationStore: locationStore, businessStore: businessStore, smsSender: smsSender, smsTemplates: smsTemplates, transactor: transactor}
+}
+
+// GetInvitationsByLocationID ...
+func (s *InvitationService) GetInvitationsByLocationID(ctx context.Context, locationID string, actor Actor) ([]*Invitation, error) {
+	const op = "app/invitationService.GetInvitationsByLocationID"
+
+	err := actor.can(ctx, opReadInvitation)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	invitations, err := s.invitationStore.GetInvitationsByLocationID(ctx, locationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get invitations by location id")
+	}
+
+	return invitations, nil
+}
+
+// CreateInvitationInput ...
+type CreateInvitationInput struct {
+	LocationID     string `json:"location_id"`
+	EmployeeID     string `json:"employee_id"`
+	EmployeeRoleID string `json:"employee_role_id"`
+	PhoneNumber    string `json:"phone_number"`
+	CountryCode    string `json:"country_code"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateInvitationInput) Validate() error {
+	const op = "app/CreateInvitationInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("employee_id", input.EmployeeID)
+	phone.ValidatePhoneNumber(v, "phone_number", input.PhoneNumber, input.CountryCode)
+
+	return v.Err(op)
+}
+
+// CreateInvitation invites the phone number to join as the employee and notifies it by sms. Previous pending invitations for the employee are revoked
+func (s *InvitationService) CreateInvitation(ctx context.Context, input *CreateInvitationInput, actor Actor, currentUser *auth.User) (*Invitation, error) {
+	const op = "app/invitationService.CreateInvitation"
+
+	err := actor.can(ctx, opCreateInvitation)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	formattedPhoneNumber, err := phone.FormatPhoneNumber(input.PhoneNumber, input.CountryCode)
+
+	if err != nil {
+		return nil, errors.InvalidField(op, "phone_number", errors.CodeInvalidFormat, "invalid phone number")
+	}
+
+	countryCode := phone.CountryCodeOf(formattedPhoneNumber, input.CountryCode)
+
+	location, err := s.locationStore.GetLocationByID(ctx, input.LocationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get location by id")
+	}
+
+	if location == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	employee, err := s.employeeStore.GetEmployeeByID(ctx, input.EmployeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee by id")
+	}
+
+	if employee == nil || employee.LocationID != location.ID {
+		return nil, errors.InvalidField(op, "employee_id", errors.CodeInvalidValue, "employee not found in location")
+	}
+
+	if employee.UserID != "" {
+		return nil, errors.Conflict(op, "employee already joined").WithCode(errors.CodeEmployeeAlreadyJoined)
+	}
+
+	employeeRoleID := input.EmployeeRoleID
+
+	if employeeRoleID == "" {
+		employeeRoleID = employee.EmployeeRoleID
+	}
+
+	employeeRole, err := s.employeeRoleStore.GetEmployeeRoleByID(ctx, employeeRoleID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee role by id")
+	}
+
+	if employeeRole == nil || employeeRole.LocationID != location.ID {
+		return nil, errors.InvalidField(op, "employee_role_id", errors.CodeInvalidValue, "employee role not found in location")
+	}
+
+	if employeeRole.IsOwner {
+		return nil, errors.Invalid(op, "cannot invite with owner role").WithCode(errors.CodeOwnerRoleImmutable)
+	}
+
+	now := time.Now()
+
+	invitation := &Invitation{
+		ID:              uuid.Must(uuid.New(), nil).String(),
+		LocationID:      location.ID,
+		EmployeeID:      employee.ID,
+		EmployeeRoleID:  employeeRole.ID,
+		PhoneNumber:     formattedPhoneNumber,
+		CountryCode:     countryCode,
+		Status:          InvitationStatusPending,
+		InvitedByUserID: currentUser.ID,
+		ExpiredAt:       now.Add(invitationExpiry),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.revokePendingInvitations(ctx, employee.ID)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to revoke previous invitations")
+		}
+
+		return s.invitationStore.StoreInvitation(ctx, invitation)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to store invitation")
+	}
+
+	business, err := s.businessStore.GetBusinessByID(ctx, location.BusinessID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business by id")
+	}
+
+	if business == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	text, err := s.smsTemplates.Render(ctx, business.ID, phone.SMSTypeInvitation, invitation.CountryCode, map[string]string{
+		"business": business.Name,
+		"location": location.Name,
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to render sms")
+	}
+
+	err = s.smsSender.SendSMS(ctx, invitation.PhoneNumber, invitation.CountryCode, text)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to send sms")
+	}
+
+	return invitation, nil
+}
+
+func (s *InvitationService) revokePendingInvitations(ctx context.Context, employeeID string) error {
+	const op = "app/invitationService.revokePendingInvitations"
+
+	invitations, err := s.invitationStore.GetPendingInvitationsByEmployeeID(ctx, employeeID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to get pending invitations by employee id")
+	}
+
+	for _, invitation := range invitations {
+		invitation.Status = InvitationStatusRevoked
+		invitation.UpdatedAt = time.Now()
+
+		err = s.invitationStore.UpdateInvitation(ctx, invitation)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to update invitation")
+		}
+	}
+
+	return nil
+}
+
+// RevokeInvitation prevents pending invitation from being accepted
+func (s *InvitationService) RevokeInvitation(ctx context.Context, locationID string, id string, actor Actor) (*Invitation, error) {
+	const op = "app/invitationService.RevokeInvitation"
+
+	err := actor.can(ctx, opRevokeInvitation)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	invitation, err := s.invitationStore.GetInvitationByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get invitation by id")
+	}
+
+	if invitation == nil || invitation.LocationID != locationID {
+		return nil, errors.NotFound(op)
+	}
+
+	if invitation.Status != InvitationStatusPending {
+		return nil, errors.Conflict(op, fmt.Sprintf("cannot revoke %s invitation", invitation.Status)).WithCode(errors.CodeInvitationNotPending)
+	}
+
+	invitation.Status = InvitationStatusRevoked
+	invitation.UpdatedAt = time.Now()
+
+	err = s.invitationStore.UpdateInvitation(ctx, invitation)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to update invitation")
+	}
+
+	return invitation, nil
+}
+
+// GetPendingInvitations gets invitations sent to the verified phone number of the current user
+func (s *InvitationService) GetPendingInvitations(ctx context.Context, currentUser *auth.User) ([]*Invitation, error) {
+	const op = "app/invitationService.GetPendingInvitations"
+
+	if currentUser.IsPhoneNumberVerified == false {
+		return []*Invitation{}, nil
+	}
+
+	invitations, err := s.invitationStore.GetPendingInvitationsByPhoneNumber(ctx, currentUser.PhoneNumber, currentUser.CountryCode, time.Now())
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get pending invitations by phone number")
+	}
+
+	return invitations, nil
+}
+
+// AcceptInvitation links the employee to the current user, given the invitation was sent to their phone number
+func (s *InvitationService) AcceptInvitation(ctx context.Context, id string, currentUser *auth.User) (*Invitation, error) {
+	const op = "app/invitationService.AcceptInvitation"
+
+	invitation, err := s.invitationStore.GetInvitationByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get invitation by id")
+	}
+
+	if invitation == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	if currentUser.IsPhoneNumberVerified == false || invitation.PhoneNumber != currentUser.PhoneNumber || invitation.CountryCode != currentUser.CountryCode {
+		return nil, errors.Forbidden(op, fmt.Errorf("invitation not sent to current user"))
+	}
+
+	now := time.Now()
+
+	if invitation.IsPending(now) == false {
+		return nil, errors.Conflict(op, "invitation expired or no longer pending").WithCode(errors.CodeInvitationNotPending)
+	}
+
+	existingEmployee, err := s.employeeStore.GetEmployeeByUserIDAndLocationID(ctx, currentUser.ID, invitation.LocationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee by user id and location id")
+	}
+
+	if existingEmployee != nil {
+		return nil, errors.Conflict(op, "already an employee of the location").WithCode(errors.CodeAlreadyEmployee)
+	}
+
+	employee, err := s.employeeStore.GetEmployeeByID(ctx, invitation.EmployeeID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get employee by id")
+	}
+
+	if employee == nil || employee.UserID != "" {
+		return nil, errors.Conflict(op, "employee no longer available").WithCode(errors.CodeEmployeeUnavailable)
+	}
+
+	employee.UserID = currentUser.ID
+	employee.EmployeeRoleID = invitation.EmployeeRoleID
+	employee.UpdatedAt = now
+
+	invitation.Status = InvitationStatusAccepted
+	invitation.AcceptedByUserID = currentUser.ID
+	invitation.UpdatedAt = now
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.employeeStore.UpdateEmployee(ctx, employee)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to update employee")
+		}
+
+		return s.invitationStore.UpdateInvitation(ctx, invitation)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to accept invitation")
+	}
+
+	return invitation, nil
+}
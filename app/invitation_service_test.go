@@ -0,0 +1,235 @@
+package app_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+	"github.com/minheq/kedul_server_main/phone"
+)
+
+type mockSMSSender struct {
+	PhoneNumber string
+	Text        string
+}
+
+func (s *mockSMSSender) SendSMS(ctx context.Context, phoneNumber string, countryCode string, text string) error {
+	s.PhoneNumber = phoneNumber
+	s.Text = text
+	return nil
+}
+
+func setupInvitationFixtures(t *testing.T, businessStore app.BusinessStore, employeeStore app.EmployeeStore, employeeRoleStore app.EmployeeRoleStore, locationStore app.LocationStore) (*app.Location, *app.Employee, *app.EmployeeRole) {
+	err := businessStore.StoreBusiness(context.Background(), &app.Business{ID: "1", UserID: "1", Name: "business1"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := &app.Location{ID: "1", BusinessID: "1", Name: "location1"}
+
+	err = locationStore.StoreLocation(context.Background(), location)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	employeeRole := &app.EmployeeRole{ID: "1", LocationID: location.ID, Name: "specialist", PermissionIDs: []string{}}
+
+	err = employeeRoleStore.StoreEmployeeRole(context.Background(), employeeRole)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	employee := &app.Employee{ID: "1", LocationID: location.ID, Name: "employee1", EmployeeRoleID: employeeRole.ID}
+
+	err = employeeStore.StoreEmployee(context.Background(), employee)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return location, employee, employeeRole
+}
+
+func TestInvitationHappyPath(t *testing.T) {
+	invitationStore := memstore.NewInvitationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	locationStore := memstore.NewLocationStore()
+	businessStore := memstore.NewBusinessStore()
+	smsSender := &mockSMSSender{}
+	invitationService := app.NewInvitationService(invitationStore, employeeStore, employeeRoleStore, locationStore, businessStore, smsSender, phone.NewSMSTemplates(memstore.NewSMSTemplateStore()), memstore.NewTransactor())
+	actor := app.MockActor
+	manager := auth.NewUser("+84900000000", "VN")
+
+	location, employee, employeeRole := setupInvitationFixtures(t, businessStore, employeeStore, employeeRoleStore, locationStore)
+
+	user := auth.NewUser("+84901234567", "VN")
+	user.IsPhoneNumberVerified = true
+
+	invitation := &app.Invitation{}
+
+	t.Run("should create invitation and send sms", func(t *testing.T) {
+		input := &app.CreateInvitationInput{
+			LocationID:     location.ID,
+			EmployeeID:     employee.ID,
+			EmployeeRoleID: employeeRole.ID,
+			PhoneNumber:    "0901234567",
+			CountryCode:    "VN",
+		}
+		var err error
+		invitation, err = invitationService.CreateInvitation(context.Background(), input, actor, manager)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if smsSender.PhoneNumber != user.PhoneNumber {
+			t.Error("sms should be sent to the invited phone number")
+		}
+
+		if !strings.Contains(smsSender.Text, "business1") || !strings.Contains(smsSender.Text, location.Name) {
+			t.Errorf("sms should name the business and location, got %s", smsSender.Text)
+		}
+	})
+
+	t.Run("should list pending invitations of user", func(t *testing.T) {
+		invitations, err := invitationService.GetPendingInvitations(context.Background(), user)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(invitations) != 1 {
+			t.Errorf("expected 1 pending invitation, got %d", len(invitations))
+		}
+	})
+
+	t.Run("should not accept invitation sent to other phone number", func(t *testing.T) {
+		otherUser := auth.NewUser("+84907654321", "VN")
+		otherUser.IsPhoneNumberVerified = true
+
+		_, err := invitationService.AcceptInvitation(context.Background(), invitation.ID, otherUser)
+
+		if errors.Is(errors.KindForbidden, err) == false {
+			t.Error("error should be forbidden kind")
+		}
+	})
+
+	t.Run("should accept invitation", func(t *testing.T) {
+		_, err := invitationService.AcceptInvitation(context.Background(), invitation.ID, user)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		linkedEmployee, err := employeeStore.GetEmployeeByID(context.Background(), employee.ID)
+
+		if err != nil || linkedEmployee.UserID != user.ID {
+			t.Error("employee should be linked to user")
+		}
+	})
+
+	t.Run("should not accept invitation twice", func(t *testing.T) {
+		_, err := invitationService.AcceptInvitation(context.Background(), invitation.ID, user)
+
+		if errors.Is(errors.KindConflict, err) == false {
+			t.Error("error should be conflict kind")
+		}
+	})
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	invitationStore := memstore.NewInvitationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	locationStore := memstore.NewLocationStore()
+	businessStore := memstore.NewBusinessStore()
+	smsSender := &mockSMSSender{}
+	invitationService := app.NewInvitationService(invitationStore, employeeStore, employeeRoleStore, locationStore, businessStore, smsSender, phone.NewSMSTemplates(memstore.NewSMSTemplateStore()), memstore.NewTransactor())
+	actor := app.MockActor
+
+	location, employee, employeeRole := setupInvitationFixtures(t, businessStore, employeeStore, employeeRoleStore, locationStore)
+
+	user := auth.NewUser("+84901234567", "VN")
+	user.IsPhoneNumberVerified = true
+
+	now := time.Now()
+
+	revokedInvitation := &app.Invitation{
+		ID:             "1",
+		LocationID:     location.ID,
+		EmployeeID:     employee.ID,
+		EmployeeRoleID: employeeRole.ID,
+		PhoneNumber:    user.PhoneNumber,
+		CountryCode:    user.CountryCode,
+		Status:         app.InvitationStatusPending,
+		ExpiredAt:      now.Add(time.Hour),
+	}
+
+	expiredInvitation := &app.Invitation{
+		ID:             "2",
+		LocationID:     location.ID,
+		EmployeeID:     employee.ID,
+		EmployeeRoleID: employeeRole.ID,
+		PhoneNumber:    user.PhoneNumber,
+		CountryCode:    user.CountryCode,
+		Status:         app.InvitationStatusPending,
+		ExpiredAt:      now.Add(-time.Hour),
+	}
+
+	for _, invitation := range []*app.Invitation{revokedInvitation, expiredInvitation} {
+		err := invitationStore.StoreInvitation(context.Background(), invitation)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("should not revoke invitation through other location", func(t *testing.T) {
+		_, err := invitationService.RevokeInvitation(context.Background(), "other", revokedInvitation.ID, actor)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Errorf("expected invitation of other location not to be found, got %v", err)
+		}
+
+		invitation, err := invitationStore.GetInvitationByID(context.Background(), revokedInvitation.ID)
+
+		if err != nil || invitation.Status != app.InvitationStatusPending {
+			t.Error("invitation should stay pending")
+		}
+	})
+
+	t.Run("should not accept revoked invitation", func(t *testing.T) {
+		_, err := invitationService.RevokeInvitation(context.Background(), location.ID, revokedInvitation.ID, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		_, err = invitationService.AcceptInvitation(context.Background(), revokedInvitation.ID, user)
+
+		if errors.Is(errors.KindConflict, err) == false {
+			t.Error("error should be conflict kind")
+		}
+	})
+
+	t.Run("should not accept expired invitation", func(t *testing.T) {
+		_, err := invitationService.AcceptInvitation(context.Background(), expiredInvitation.ID, user)
+
+		if errors.Is(errors.KindConflict, err) == false {
+			t.Error("error should be conflict kind")
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// InvitationStore ...
+type InvitationStore interface {
+	GetInvitationsByLocationID(ctx context.Context, locationID string) ([]*Invitation, error)
+	GetPendingInvitationsByEmployeeID(ctx context.Context, employeeID string) ([]*Invitation, error)
+	GetPendingInvitationsByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string, now time.Time) ([]*Invitation, error)
+	GetInvitationByID(ctx context.Context, id string) (*Invitation, error)
+	StoreInvitation(ctx context.Context, invitation *Invitation) error
+	UpdateInvitation(ctx context.Context, invitation *Invitation) error
+	PurgeInvitationsByEmployeeIDs(ctx context.Context, employeeIDs []string) error
+}
+
+type invitationStore struct {
+	db *sql.DB
+}
+
+// NewInvitationStore ...
+func NewInvitationStore(db *sql.DB) InvitationStore {
+	return &invitationStore{db: db}
+}
+
+const invitationColumns = "id, location_id, employee_id, employee_role_id, phone_number, country_code, status, invited_by_user_id, accepted_by_user_id, expired_at, created_at, updated_at"
+
+func scanInvitation(row rowScanner) (*Invitation, error) {
+	invitation := &Invitation{}
+	acceptedByUserID := sql.NullString{}
+
+	err := row.Scan(&invitation.ID, &invitation.LocationID, &invitation.EmployeeID, &invitation.EmployeeRoleID, &invitation.PhoneNumber, &invitation.CountryCode, &invitation.Status, &invitation.InvitedByUserID, &acceptedByUserID, &invitation.ExpiredAt, &invitation.CreatedAt, &invitation.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.AcceptedByUserID = acceptedByUserID.String
+
+	return invitation, nil
+}
+
+func (s *invitationStore) queryInvitations(ctx context.Context, op string, query string, args ...interface{}) ([]*Invitation, error) {
+	invitations := make([]*Invitation, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, args...)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		invitations = append(invitations, invitation)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return invitations, nil
+}
+
+// GetInvitationsByLocationID gets all Invitations of a Location, newest first
+func (s *invitationStore) GetInvitationsByLocationID(ctx context.Context, locationID string) ([]*Invitation, error) {
+	const op = "app/invitationStore.GetInvitationsByLocationID"
+
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM invitation
+		WHERE location_id=$1
+		ORDER BY created_at DESC;
+	`
+
+	return s.queryInvitations(ctx, op, query, locationID)
+}
+
+// GetPendingInvitationsByEmployeeID gets pending Invitations of an Employee, including expired ones
+func (s *invitationStore) GetPendingInvitationsByEmployeeID(ctx context.Context, employeeID string) ([]*Invitation, error) {
+	const op = "app/invitationStore.GetPendingInvitationsByEmployeeID"
+
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM invitation
+		WHERE employee_id=$1
+			AND status=$2;
+	`
+
+	return s.queryInvitations(ctx, op, query, employeeID, InvitationStatusPending)
+}
+
+// GetPendingInvitationsByPhoneNumber gets pending Invitations sent to the phone number which are not expired at now
+func (s *invitationStore) GetPendingInvitationsByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string, now time.Time) ([]*Invitation, error) {
+	const op = "app/invitationStore.GetPendingInvitationsByPhoneNumber"
+
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM invitation
+		WHERE phone_number=$1
+			AND country_code=$2
+			AND status=$3
+			AND expired_at > $4
+		ORDER BY created_at DESC;
+	`
+
+	return s.queryInvitations(ctx, op, query, phoneNumber, countryCode, InvitationStatusPending, now)
+}
+
+// GetInvitationByID gets Invitation by ID
+func (s *invitationStore) GetInvitationByID(ctx context.Context, id string) (*Invitation, error) {
+	const op = "app/invitationStore.GetInvitationByID"
+
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM invitation
+		WHERE id=$1;
+	`
+
+	invitation, err := scanInvitation(transaction.DB(ctx, s.db).QueryRow(query, id))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return invitation, nil
+}
+
+// StoreInvitation persists Invitation
+func (s *invitationStore) StoreInvitation(ctx context.Context, invitation *Invitation) error {
+	const op = "app/invitationStore.StoreInvitation"
+
+	query := `
+		INSERT INTO invitation (` + invitationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, invitation.ID, invitation.LocationID, invitation.EmployeeID, invitation.EmployeeRoleID, invitation.PhoneNumber, invitation.CountryCode, invitation.Status, invitation.InvitedByUserID, toNullString(invitation.AcceptedByUserID), invitation.ExpiredAt, invitation.CreatedAt, invitation.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// UpdateInvitation updates status of Invitation
+func (s *invitationStore) UpdateInvitation(ctx context.Context, invitation *Invitation) error {
+	const op = "app/invitationStore.UpdateInvitation"
+
+	query := `
+		UPDATE invitation
+		SET status=$2, accepted_by_user_id=$3, updated_at=$4
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, invitation.ID, invitation.Status, toNullString(invitation.AcceptedByUserID), invitation.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// PurgeInvitationsByEmployeeIDs permanently deletes the Invitations of the Employees
+func (s *invitationStore) PurgeInvitationsByEmployeeIDs(ctx context.Context, employeeIDs []string) error {
+	const op = "app/invitationStore.PurgeInvitationsByEmployeeIDs"
+
+	if len(employeeIDs) == 0 {
+		return nil
+	}
+
+	placeholder, args := makeIDsArgs(employeeIDs)
+
+	query := fmt.Sprintf(`
+		DELETE FROM invitation
+		WHERE employee_id IN (%s);
+	`, placeholder)
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, args...)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
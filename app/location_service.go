@@ -9,6 +9,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/minheq/kedul_server_main/auth"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/patch"
+	"github.com/minheq/kedul_server_main/query"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 var (
@@ -16,6 +19,9 @@ var (
 		permManageLocation.ID,
 		permManageEmployeeRole.ID,
 		permManageEmployee.ID,
+		permManageService.ID,
+		permManageAppointment.ID,
+		permManageClient.ID,
 	}
 	defaultAdminRolePermissionIDs        = []string{}
 	defaultManagerRolePermissionIDs      = []string{}
@@ -23,14 +29,27 @@ var (
 	defaultSpecialistRolePermissionIDs   = []string{}
 )
 
+// defaultTimeZone is the time zone of locations created without one. Time zones are IANA names, e.g. Asia/Ho_Chi_Minh,
+// and working hours and dates of a location are in its time zone
+const defaultTimeZone = "UTC"
+
 // Location ...
 type Location struct {
 	ID             string    `json:"id"`
 	BusinessID     string    `json:"business_id"`
 	Name           string    `json:"name"`
 	ProfileImageID string    `json:"profile_image_id"`
+	TimeZone       string    `json:"time_zone"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	DeletedAt      time.Time `json:"deleted_at"`
+	Version        int       `json:"version"`
+}
+
+// LocationFields are the fields lists of Locations can be filtered and sorted by
+var LocationFields = query.Fields{
+	{Name: "name", Column: "name", Type: query.String, Value: func(record interface{}) interface{} { return record.(*Location).Name }},
+	{Name: "created_at", Column: "created_at", Type: query.Time, Value: func(record interface{}) interface{} { return record.(*Location).CreatedAt }},
 }
 
 // LocationService ...
@@ -39,11 +58,12 @@ type LocationService struct {
 	locationStore     LocationStore
 	employeeStore     EmployeeStore
 	employeeRoleStore EmployeeRoleStore
+	transactor        transaction.Transactor
 }
 
 // NewLocationService constructor for AuthService
-func NewLocationService(businessStore BusinessStore, locationStore LocationStore, employeeStore EmployeeStore, employeeRoleStore EmployeeRoleStore) LocationService {
-	return LocationService{businessStore: businessStore, locationStore: locationStore, employeeStore: employeeStore, employeeRoleStore: employeeRoleStore}
+func NewLocationService(businessStore BusinessStore, locationStore LocationStore, employeeStore EmployeeStore, employeeRoleStore EmployeeRoleStore, transactor transaction.Transactor) LocationService {
+	return LocationService{businessStore: businessStore, locationStore: locationStore, employeeStore: employeeStore, employeeRoleStore: employeeRoleStore, transactor: transactor}
 }
 
 // GetLocationByID ...
@@ -56,21 +76,31 @@ func (s *LocationService) GetLocationByID(ctx context.Context, id string, actor
 		return nil, errors.Wrap(op, err, "failed to get location by id")
 	}
 
+	if location == nil {
+		return nil, errors.NotFound(op)
+	}
+
 	return location, nil
 }
 
-// GetLocationsByUserIDAndBusinessID ...
-func (s *LocationService) GetLocationsByUserIDAndBusinessID(ctx context.Context, userID string, businessID string, currentUser *auth.User) ([]*Location, error) {
+// GetLocationsByUserIDAndBusinessID gets a page of the locations of the business the user is an employee of
+func (s *LocationService) GetLocationsByUserIDAndBusinessID(ctx context.Context, userID string, businessID string, args PageArgs, currentUser *auth.User) ([]*Location, *Page, error) {
 	const op = "app/locationService.GetLocationsByUserID"
 
 	if userID != currentUser.ID {
-		return nil, errors.Unauthorized(op, fmt.Errorf("current user not owner"))
+		return nil, nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
+	}
+
+	err := args.Validate()
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
 	}
 
 	userAsEmployeeList, err := s.employeeStore.GetEmployeesByUserID(ctx, userID)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get employees by user id")
+		return nil, nil, errors.Wrap(op, err, "failed to get employees by user id")
 	}
 
 	locationIDs := []string{}
@@ -79,21 +109,33 @@ func (s *LocationService) GetLocationsByUserIDAndBusinessID(ctx context.Context,
 		locationIDs = append(locationIDs, employee.LocationID)
 	}
 
-	locations, err := s.locationStore.GetLocationsByIDs(ctx, locationIDs)
+	locations, page, err := s.locationStore.GetLocationPageByBusinessIDAndIDs(ctx, businessID, locationIDs, args)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to get locations by location ids")
+		return nil, nil, errors.Wrap(op, err, "failed to get location page")
 	}
 
-	businessLocations := []*Location{}
+	return locations, page, nil
+}
 
-	for _, location := range locations {
-		if location.BusinessID == businessID {
-			businessLocations = append(businessLocations, location)
-		}
+// validateTimeZone checks that the time zone is known, unless it is blank
+func validateTimeZone(v *errors.Validation, field string, timeZone string) {
+	if strings.TrimSpace(timeZone) == "" {
+		return
 	}
 
-	return businessLocations, nil
+	_, err := time.LoadLocation(timeZone)
+
+	v.Check(err == nil && timeZone != "Local", field, errors.CodeInvalidValue, fmt.Sprintf("unknown time zone %s", timeZone))
+}
+
+// loadTimeZone returns the time zone of the location
+func (location *Location) loadTimeZone() (*time.Location, error) {
+	if location.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	return time.LoadLocation(location.TimeZone)
 }
 
 // CreateLocationInput ...
@@ -101,53 +143,40 @@ type CreateLocationInput struct {
 	BusinessID     string `json:"business_id"`
 	Name           string `json:"name"`
 	ProfileImageID string `json:"profile_image_id"`
+	// TimeZone defaults to UTC
+	TimeZone string `json:"time_zone"`
 }
 
-// CreateLocation creates location
-func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocationInput, currentUser *auth.User) (*Location, error) {
-	const op = "app/locationService.CreateLocation"
+// Validate checks the fields of the input
+func (input *CreateLocationInput) Validate() error {
+	const op = "app/CreateLocationInput.Validate"
 
-	businesses, err := s.businessStore.GetBusinessesByUserID(ctx, currentUser.ID)
+	v := &errors.Validation{}
+	v.Required("business_id", input.BusinessID)
+	v.Required("name", input.Name)
+	validateTimeZone(v, "time_zone", input.TimeZone)
 
-	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to to get businesses by user id")
-	}
-
-	isOwner := false
-
-	for _, business := range businesses {
-		if business.ID == input.BusinessID {
-			isOwner = true
-		}
-	}
-
-	if isOwner == false {
-		return nil, errors.Unauthorized(op, fmt.Errorf("current user not owner"))
-	}
-
-	now := time.Now()
+	return v.Err(op)
+}
 
-	location := &Location{
-		ID:         uuid.Must(uuid.New(), nil).String(),
-		BusinessID: input.BusinessID,
-		Name:       input.Name,
-		CreatedAt:  now,
-		UpdatedAt:  now,
-	}
+// storeLocation stores the location with its default employee roles, and the current user as the owner employee
+func (s *LocationService) storeLocation(ctx context.Context, location *Location, currentUser *auth.User) error {
+	const op = "app/locationService.storeLocation"
 
-	err = s.locationStore.StoreLocation(ctx, location)
+	err := s.locationStore.StoreLocation(ctx, location)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to locationStore location")
+		return errors.Wrap(op, err, "failed to store location")
 	}
 
 	ownerRole := &EmployeeRole{
 		ID:            uuid.Must(uuid.New(), nil).String(),
 		LocationID:    location.ID,
-		Name:          "admin",
+		Name:          ownerRoleName,
 		PermissionIDs: defaultOwnerRolePermissionIDs,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		IsOwner:       true,
+		CreatedAt:     location.CreatedAt,
+		UpdatedAt:     location.CreatedAt,
 	}
 
 	adminRole := &EmployeeRole{
@@ -155,8 +184,8 @@ func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocat
 		LocationID:    location.ID,
 		Name:          "admin",
 		PermissionIDs: defaultAdminRolePermissionIDs,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		CreatedAt:     location.CreatedAt,
+		UpdatedAt:     location.CreatedAt,
 	}
 
 	managerRole := &EmployeeRole{
@@ -164,8 +193,8 @@ func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocat
 		LocationID:    location.ID,
 		Name:          "manager",
 		PermissionIDs: defaultManagerRolePermissionIDs,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		CreatedAt:     location.CreatedAt,
+		UpdatedAt:     location.CreatedAt,
 	}
 
 	receptionistRole := &EmployeeRole{
@@ -173,8 +202,8 @@ func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocat
 		LocationID:    location.ID,
 		Name:          "receptionist",
 		PermissionIDs: defaultReceptionistRolePermissionIDs,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		CreatedAt:     location.CreatedAt,
+		UpdatedAt:     location.CreatedAt,
 	}
 
 	specialistRole := &EmployeeRole{
@@ -182,33 +211,38 @@ func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocat
 		LocationID:    location.ID,
 		Name:          "specialist",
 		PermissionIDs: defaultSpecialistRolePermissionIDs,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		CreatedAt:     location.CreatedAt,
+		UpdatedAt:     location.CreatedAt,
 	}
 
 	err = s.employeeRoleStore.StoreEmployeeRole(ctx, ownerRole)
+
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to create default owner role")
+		return errors.Wrap(op, err, "failed to create default owner role")
 	}
 
 	err = s.employeeRoleStore.StoreEmployeeRole(ctx, adminRole)
+
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to create default admin role")
+		return errors.Wrap(op, err, "failed to create default admin role")
 	}
 
 	err = s.employeeRoleStore.StoreEmployeeRole(ctx, managerRole)
+
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to create default manager role")
+		return errors.Wrap(op, err, "failed to create default manager role")
 	}
 
 	err = s.employeeRoleStore.StoreEmployeeRole(ctx, receptionistRole)
+
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to create default receptionist role")
+		return errors.Wrap(op, err, "failed to create default receptionist role")
 	}
 
 	err = s.employeeRoleStore.StoreEmployeeRole(ctx, specialistRole)
+
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to create default employee role")
+		return errors.Wrap(op, err, "failed to create default employee role")
 	}
 
 	owner := &Employee{
@@ -217,14 +251,70 @@ func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocat
 		Name:           currentUser.FullName,
 		EmployeeRoleID: ownerRole.ID,
 		UserID:         currentUser.ID,
-		CreatedAt:      now,
-		UpdatedAt:      now,
+		CreatedAt:      location.CreatedAt,
+		UpdatedAt:      location.CreatedAt,
 	}
 
 	err = s.employeeStore.StoreEmployee(ctx, owner)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to create location owner")
+		return errors.Wrap(op, err, "failed to create location owner")
+	}
+
+	return nil
+}
+
+// CreateLocation creates location
+func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocationInput, currentUser *auth.User) (*Location, error) {
+	const op = "app/locationService.CreateLocation"
+
+	err := input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	businesses, err := s.businessStore.GetBusinessesByUserID(ctx, currentUser.ID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to to get businesses by user id")
+	}
+
+	isOwner := false
+
+	for _, business := range businesses {
+		if business.ID == input.BusinessID {
+			isOwner = true
+		}
+	}
+
+	if isOwner == false {
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
+	}
+
+	now := time.Now()
+
+	timeZone := strings.TrimSpace(input.TimeZone)
+
+	if timeZone == "" {
+		timeZone = defaultTimeZone
+	}
+
+	location := &Location{
+		ID:         uuid.Must(uuid.New(), nil).String(),
+		BusinessID: input.BusinessID,
+		Name:       input.Name,
+		TimeZone:   timeZone,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.storeLocation(ctx, location, currentUser)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to store location")
 	}
 
 	return location, nil
@@ -234,16 +324,73 @@ func (s *LocationService) CreateLocation(ctx context.Context, input *CreateLocat
 type UpdateLocationInput struct {
 	Name           string `json:"name"`
 	ProfileImageID string `json:"profile_image_id"`
+	TimeZone       string `json:"time_zone"`
+}
+
+// Validate checks the fields of the input
+func (input *UpdateLocationInput) Validate() error {
+	const op = "app/UpdateLocationInput.Validate"
+
+	v := &errors.Validation{}
+	v.NotBlank("name", input.Name)
+	validateTimeZone(v, "time_zone", input.TimeZone)
+
+	return v.Err(op)
 }
 
-// UpdateLocation updates location
-func (s *LocationService) UpdateLocation(ctx context.Context, id string, input *UpdateLocationInput, actor Actor) (*Location, error) {
+// UpdateLocation updates location. Empty fields of the input are left unchanged
+func (s *LocationService) UpdateLocation(ctx context.Context, id string, version int, input *UpdateLocationInput, actor Actor) (*Location, error) {
 	const op = "app/locationService.UpdateLocation"
 
+	err := input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	location, err := s.PatchLocation(ctx, id, version, &PatchLocationInput{Name: patch.Changed(input.Name), ProfileImageID: patch.Changed(input.ProfileImageID), TimeZone: patch.Changed(input.TimeZone)}, actor)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to patch location")
+	}
+
+	return location, nil
+}
+
+// PatchLocationInput is a merge patch of a location. The name and the time zone cannot be removed
+type PatchLocationInput struct {
+	Name           patch.String `json:"name"`
+	ProfileImageID patch.String `json:"profile_image_id"`
+	TimeZone       patch.String `json:"time_zone"`
+}
+
+// Validate checks the fields of the input
+func (input *PatchLocationInput) Validate() error {
+	const op = "app/PatchLocationInput.Validate"
+
+	v := &errors.Validation{}
+	input.Name.Required(v, "name")
+	input.ProfileImageID.Optional(v, "profile_image_id")
+	input.TimeZone.Required(v, "time_zone")
+	validateTimeZone(v, "time_zone", input.TimeZone.Value)
+
+	return v.Err(op)
+}
+
+// PatchLocation changes the fields of location in the input, and removes those that are null. A version other than 0 must match the Version of the location, so that concurrent changes are not overwritten
+func (s *LocationService) PatchLocation(ctx context.Context, id string, version int, input *PatchLocationInput, actor Actor) (*Location, error) {
+	const op = "app/locationService.PatchLocation"
+
 	err := actor.can(ctx, opUpdateLocation)
 
 	if err != nil {
-		return nil, errors.Unauthorized(op, err)
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
 	}
 
 	location, err := s.locationStore.GetLocationByID(ctx, id)
@@ -256,25 +403,32 @@ func (s *LocationService) UpdateLocation(ctx context.Context, id string, input *
 		return nil, errors.NotFound(op)
 	}
 
+	if version != 0 && location.Version != version {
+		return nil, errors.PreconditionFailed(op, "location was changed meanwhile")
+	}
+
 	location.UpdatedAt = time.Now()
-	if input.Name != "" {
-		location.Name = strings.TrimSpace(input.Name)
+	if input.Name.Set {
+		location.Name = strings.TrimSpace(input.Name.Value)
 	}
-	if input.ProfileImageID != "" {
-		location.ProfileImageID = input.ProfileImageID
+	if input.ProfileImageID.Set {
+		location.ProfileImageID = input.ProfileImageID.Value
+	}
+	if input.TimeZone.Set {
+		location.TimeZone = input.TimeZone.Value
 	}
 
 	err = s.locationStore.UpdateLocation(ctx, location)
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update location")
+		return nil, errors.Wrap(op, err, "failed to update location")
 	}
 
 	return location, nil
 }
 
-// DeleteLocation updates location
-func (s *LocationService) DeleteLocation(ctx context.Context, id string, currentUser *auth.User) (*Location, error) {
+// DeleteLocation marks location as deleted along with its employees and employee roles
+func (s *LocationService) DeleteLocation(ctx context.Context, id string, version int, currentUser *auth.User) (*Location, error) {
 	const op = "app/locationService.DeleteLocation"
 
 	location, err := s.locationStore.GetLocationByID(ctx, id)
@@ -302,13 +456,95 @@ func (s *LocationService) DeleteLocation(ctx context.Context, id string, current
 	}
 
 	if isOwner == false {
-		return nil, errors.Unauthorized(op, fmt.Errorf("current user not owner"))
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
+	}
+
+	if version != 0 && location.Version != version {
+		return nil, errors.PreconditionFailed(op, "location was changed meanwhile")
 	}
 
-	err = s.locationStore.DeleteLocation(ctx, location)
+	location.DeletedAt = time.Now()
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := deleteLocationChildren(ctx, s.employeeStore, s.employeeRoleStore, location.ID, location.DeletedAt)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to delete location children")
+		}
+
+		err = s.locationStore.DeleteLocation(ctx, location)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to delete location")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to delete location")
+	}
+
+	return location, nil
+}
+
+// RestoreLocation restores deleted location along with the employees and employee roles deleted with it
+func (s *LocationService) RestoreLocation(ctx context.Context, id string, currentUser *auth.User) (*Location, error) {
+	const op = "app/locationService.RestoreLocation"
+
+	location, err := s.locationStore.GetDeletedLocationByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get deleted location by id")
+	}
+
+	if location == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	business, err := s.businessStore.GetBusinessByID(ctx, location.BusinessID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business by id")
+	}
+
+	if business == nil {
+		return nil, errors.Conflict(op, fmt.Sprintf("business=%s of location is deleted", location.BusinessID)).WithCode(errors.CodeBusinessDeleted)
+	}
+
+	if business.UserID != currentUser.ID {
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
+	}
+
+	now := time.Now()
+
+	if !isRestorable(location.DeletedAt, now) {
+		return nil, errors.NotFound(op)
+	}
+
+	deletedAt := location.DeletedAt
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := restoreLocationChildren(ctx, s.employeeStore, s.employeeRoleStore, location.ID, deletedAt)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to restore location children")
+		}
+
+		location.UpdatedAt = now
+		location.DeletedAt = time.Time{}
+
+		err = s.locationStore.RestoreLocation(ctx, location)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to restore location")
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update location")
+		return nil, errors.Wrap(op, err, "failed to restore location")
 	}
 
 	return location, nil
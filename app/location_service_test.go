@@ -1,77 +1,26 @@
-package app
+package app_test
 
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/minheq/kedul_server_main/app"
 	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+	"github.com/minheq/kedul_server_main/patch"
 )
 
-type mockLocationStore struct {
-	locations []*Location
-}
-
-func (s *mockLocationStore) GetLocationsByIDs(ctx context.Context, ids []string) ([]*Location, error) {
-	locations := []*Location{}
-
-	for _, l := range s.locations {
-		for _, id := range ids {
-			if l.ID == id {
-				locations = append(locations, l)
-			}
-		}
-	}
-
-	return locations, nil
-}
-
-func (s *mockLocationStore) GetLocationByID(ctx context.Context, id string) (*Location, error) {
-	for _, l := range s.locations {
-		if l.ID == id {
-			return l, nil
-		}
-	}
-
-	return nil, nil
-}
-
-func (s *mockLocationStore) StoreLocation(ctx context.Context, location *Location) error {
-	s.locations = append(s.locations, location)
-
-	return nil
-}
-
-func (s *mockLocationStore) UpdateLocation(ctx context.Context, location *Location) error {
-	for i, l := range s.locations {
-		if l.ID == location.ID {
-			s.locations[i] = location
-			break
-		}
-	}
-
-	return nil
-}
-
-func (s *mockLocationStore) DeleteLocation(ctx context.Context, location *Location) error {
-	for i, l := range s.locations {
-		if l.ID == location.ID {
-			s.locations = append(s.locations[:i], s.locations[i+1:]...)
-			break
-		}
-	}
-
-	return nil
-}
-
 func TestCreateLocationHappyPath(t *testing.T) {
-	businessStore := &mockBusinessStore{}
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	locationStore := &mockLocationStore{}
-	locationService := NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore)
+	businessStore := memstore.NewBusinessStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	locationStore := memstore.NewLocationStore()
+	locationService := app.NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
 
 	currentUser := &auth.User{ID: "1"}
-	business := &Business{
+	business := &app.Business{
 		ID:     "1",
 		UserID: currentUser.ID,
 		Name:   "business1",
@@ -85,32 +34,36 @@ func TestCreateLocationHappyPath(t *testing.T) {
 	}
 
 	t.Run("should create location", func(t *testing.T) {
-		input := &CreateLocationInput{
+		input := &app.CreateLocationInput{
 			BusinessID: business.ID,
 			Name:       "location1",
 		}
-		_, err := locationService.CreateLocation(context.Background(), input, currentUser)
+		got, err := locationService.CreateLocation(context.Background(), input, currentUser)
 
 		if err != nil {
 			t.Error(err)
 			return
 		}
+
+		if got.TimeZone != "UTC" {
+			t.Errorf("expected time zone UTC by default, got %s", got.TimeZone)
+		}
 	})
 }
 
 func TestUpdateLocationHappyPath(t *testing.T) {
-	businessStore := &mockBusinessStore{}
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	locationStore := &mockLocationStore{}
-	locationService := NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore)
-	actor := &mockActor{}
-
-	business := &Business{
+	businessStore := memstore.NewBusinessStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	locationStore := memstore.NewLocationStore()
+	locationService := app.NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
+	actor := app.MockActor
+
+	business := &app.Business{
 		ID:   "1",
 		Name: "business1",
 	}
-	location := &Location{
+	location := &app.Location{
 		BusinessID: business.ID,
 		Name:       "location2",
 	}
@@ -123,27 +76,98 @@ func TestUpdateLocationHappyPath(t *testing.T) {
 	}
 
 	t.Run("should update location", func(t *testing.T) {
-		input := &UpdateLocationInput{
+		input := &app.UpdateLocationInput{
 			Name: "location3",
 		}
-		_, err := locationService.UpdateLocation(context.Background(), location.ID, input, actor)
+		_, err := locationService.UpdateLocation(context.Background(), location.ID, 0, input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+}
+
+func TestPatchLocation(t *testing.T) {
+	locationStore := memstore.NewLocationStore()
+	locationService := app.NewLocationService(memstore.NewBusinessStore(), locationStore, memstore.NewEmployeeStore(), memstore.NewEmployeeRoleStore(), memstore.NewTransactor())
+	location := &app.Location{ID: "5", BusinessID: "1", Name: "location5", ProfileImageID: "image"}
+
+	locationStore.StoreLocation(context.Background(), location)
+
+	t.Run("should remove null fields and leave absent fields unchanged", func(t *testing.T) {
+		input := &app.PatchLocationInput{ProfileImageID: patch.Null()}
+
+		got, err := locationService.PatchLocation(context.Background(), location.ID, 0, input, app.MockActor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if got.Name != "location5" || got.ProfileImageID != "" {
+			t.Errorf("expected name location5 without profile image, got %+v", got)
+		}
+	})
+
+	t.Run("should reject blank profile image", func(t *testing.T) {
+		input := &app.PatchLocationInput{ProfileImageID: patch.Value("")}
+
+		_, err := locationService.PatchLocation(context.Background(), location.ID, 0, input, app.MockActor)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Errorf("expected blank profile image to be invalid, got %v", err)
+		}
+	})
+
+	t.Run("should reject unknown time zone", func(t *testing.T) {
+		input := &app.PatchLocationInput{TimeZone: patch.Value("Asia/Saigon City")}
+
+		_, err := locationService.PatchLocation(context.Background(), location.ID, 0, input, app.MockActor)
+
+		if errors.ErrorCode(err) != errors.CodeValidationFailed || errors.ErrorViolations(err)[0].Field != "time_zone" {
+			t.Errorf("expected unknown time zone to be invalid, got %v", err)
+		}
+	})
+
+	t.Run("should not patch location changed since the version", func(t *testing.T) {
+		input := &app.PatchLocationInput{Name: patch.Value("location6")}
+
+		current, err := locationStore.GetLocationByID(context.Background(), location.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		_, err = locationService.PatchLocation(context.Background(), location.ID, current.Version-1, input, app.MockActor)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected outdated version to fail with precondition failed, got %v", err)
+		}
+
+		got, err := locationService.PatchLocation(context.Background(), location.ID, current.Version, input, app.MockActor)
 
 		if err != nil {
 			t.Error(err)
 			return
 		}
+
+		if got.Name != "location6" {
+			t.Errorf("expected name location6, got %+v", got)
+		}
 	})
 }
 
 func TestDeleteLocationHappyPath(t *testing.T) {
-	businessStore := &mockBusinessStore{}
-	employeeStore := &mockEmployeeStore{}
-	employeeRoleStore := &mockEmployeeRoleStore{}
-	locationStore := &mockLocationStore{}
-	locationService := NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore)
+	businessStore := memstore.NewBusinessStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	locationStore := memstore.NewLocationStore()
+	locationService := app.NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
 	currentUser := &auth.User{ID: "1"}
 
-	business := &Business{
+	business := &app.Business{
 		ID:     "2",
 		Name:   "business2",
 		UserID: currentUser.ID,
@@ -155,7 +179,7 @@ func TestDeleteLocationHappyPath(t *testing.T) {
 		return
 	}
 
-	location := &Location{
+	location := &app.Location{
 		BusinessID: business.ID,
 		Name:       "location4",
 	}
@@ -168,7 +192,7 @@ func TestDeleteLocationHappyPath(t *testing.T) {
 	}
 
 	t.Run("should update location", func(t *testing.T) {
-		_, err := locationService.DeleteLocation(context.Background(), location.ID, currentUser)
+		_, err := locationService.DeleteLocation(context.Background(), location.ID, 0, currentUser)
 
 		if err != nil {
 			t.Error(err)
@@ -176,3 +200,69 @@ func TestDeleteLocationHappyPath(t *testing.T) {
 		}
 	})
 }
+
+func TestRestoreLocation(t *testing.T) {
+	businessStore := memstore.NewBusinessStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	locationStore := memstore.NewLocationStore()
+	locationService := app.NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore, memstore.NewTransactor())
+	currentUser := &auth.User{ID: "1"}
+
+	business := &app.Business{ID: "3", Name: "business3", UserID: currentUser.ID}
+	deletedBusiness := &app.Business{ID: "4", Name: "business4", UserID: currentUser.ID, DeletedAt: time.Now()}
+	location := &app.Location{ID: "5", BusinessID: business.ID, Name: "location5"}
+	employee := &app.Employee{ID: "5", LocationID: location.ID}
+	locationOfDeletedBusiness := &app.Location{ID: "6", BusinessID: deletedBusiness.ID, Name: "location6", DeletedAt: deletedBusiness.DeletedAt}
+
+	businessStore.StoreBusiness(context.Background(), business)
+	businessStore.StoreBusiness(context.Background(), deletedBusiness)
+	locationStore.StoreLocation(context.Background(), location)
+	locationStore.StoreLocation(context.Background(), locationOfDeletedBusiness)
+	employeeStore.StoreEmployee(context.Background(), employee)
+
+	t.Run("should restore location with its employees", func(t *testing.T) {
+		_, err := locationService.DeleteLocation(context.Background(), location.ID, 0, currentUser)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		storedEmployee, _ := employeeStore.GetEmployeeByID(context.Background(), employee.ID)
+
+		if storedEmployee != nil {
+			t.Error("employee should be deleted with location")
+		}
+
+		_, err = locationService.RestoreLocation(context.Background(), location.ID, currentUser)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		storedLocation, _ := locationStore.GetLocationByID(context.Background(), location.ID)
+		storedEmployee, _ = employeeStore.GetEmployeeByID(context.Background(), employee.ID)
+
+		if storedLocation == nil || storedEmployee == nil {
+			t.Error("location and employee should be restored")
+		}
+	})
+
+	t.Run("should not restore location that is not deleted", func(t *testing.T) {
+		_, err := locationService.RestoreLocation(context.Background(), location.ID, currentUser)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Errorf("expected not found, got %v", err)
+		}
+	})
+
+	t.Run("should not restore location of deleted business", func(t *testing.T) {
+		_, err := locationService.RestoreLocation(context.Background(), locationOfDeletedBusiness.ID, currentUser)
+
+		if errors.ErrorCode(err) != errors.CodeBusinessDeleted {
+			t.Errorf("expected %s, got %v", errors.CodeBusinessDeleted, err)
+		}
+	})
+}
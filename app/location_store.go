@@ -4,17 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 // LocationStore ...
 type LocationStore interface {
 	GetLocationsByIDs(ctx context.Context, ids []string) ([]*Location, error)
+	GetLocationsByBusinessID(ctx context.Context, businessID string) ([]*Location, error)
+	GetLocationPageByBusinessIDAndIDs(ctx context.Context, businessID string, ids []string, args PageArgs) ([]*Location, *Page, error)
 	GetLocationByID(ctx context.Context, id string) (*Location, error)
 	StoreLocation(ctx context.Context, location *Location) error
 	UpdateLocation(ctx context.Context, location *Location) error
 	DeleteLocation(ctx context.Context, location *Location) error
+	DeleteLocationsByBusinessID(ctx context.Context, businessID string, deletedAt time.Time) error
+	GetDeletedLocationByID(ctx context.Context, id string) (*Location, error)
+	RestoreLocation(ctx context.Context, location *Location) error
+	RestoreLocationsByBusinessID(ctx context.Context, businessID string, deletedAt time.Time) error
+	PurgeLocations(ctx context.Context, deletedBefore time.Time) ([]string, error)
 }
 
 type locationStore struct {
@@ -26,6 +35,38 @@ func NewLocationStore(db *sql.DB) LocationStore {
 	return &locationStore{db: db}
 }
 
+func (s *locationStore) queryLocations(ctx context.Context, op string, query string, args ...interface{}) ([]*Location, error) {
+	rows, err := transaction.DB(ctx, s.db).Query(query, args...)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	locations := make([]*Location, 0)
+
+	for rows.Next() {
+		location := &Location{}
+
+		err := rows.Scan(&location.ID, &location.BusinessID, &location.Name, &location.ProfileImageID, &location.TimeZone, &location.CreatedAt, &location.UpdatedAt, &location.Version)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		locations = append(locations, location)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return locations, nil
+}
+
 // GetLocationsByIDs ...
 func (s *locationStore) GetLocationsByIDs(ctx context.Context, ids []string) ([]*Location, error) {
 	const op = "app/locationStore.GetLocationsByIDs"
@@ -37,38 +78,71 @@ func (s *locationStore) GetLocationsByIDs(ctx context.Context, ids []string) ([]
 	placeholder, args := makeIDsArgs(ids)
 
 	query := fmt.Sprintf(`
-		SELECT id, business_id, name, profile_image_id, created_at, updated_at
+		SELECT id, business_id, name, profile_image_id, time_zone, created_at, updated_at, version
 		FROM location
 		WHERE id IN (%s)
+			AND deleted_at IS NULL
+		ORDER BY created_at;
 	`, placeholder)
 
-	rows, err := s.db.Query(query, args...)
+	return s.queryLocations(ctx, op, query, args...)
+}
 
-	if err != nil {
-		return nil, errors.Wrap(op, err, "database error")
+// GetLocationsByBusinessID gets Locations of a Business, oldest first
+func (s *locationStore) GetLocationsByBusinessID(ctx context.Context, businessID string) ([]*Location, error) {
+	const op = "app/locationStore.GetLocationsByBusinessID"
+
+	query := `
+		SELECT id, business_id, name, profile_image_id, time_zone, created_at, updated_at, version
+		FROM location
+		WHERE business_id=$1
+			AND deleted_at IS NULL
+		ORDER BY created_at;
+	`
+
+	return s.queryLocations(ctx, op, query, businessID)
+}
+
+// GetLocationPageByBusinessIDAndIDs gets a page of the Locations of a Business with one of the IDs, filtered and sorted by args
+func (s *locationStore) GetLocationPageByBusinessIDAndIDs(ctx context.Context, businessID string, ids []string, args PageArgs) ([]*Location, *Page, error) {
+	const op = "app/locationStore.GetLocationPageByBusinessIDAndIDs"
+
+	if len(ids) == 0 {
+		return []*Location{}, newPage(args, nil, false, 0), nil
 	}
 
+	placeholder, params := makeIDsArgs(ids)
+	params = append(params, businessID)
+
+	query := fmt.Sprintf(`
+		SELECT id, business_id, name, profile_image_id, time_zone, created_at, updated_at, version
+		FROM location
+		WHERE id IN (%s)
+			AND business_id=$%d
+			AND deleted_at IS NULL
+	`, placeholder, len(params))
+
 	locations := make([]*Location, 0)
 
-	for rows.Next() {
+	start, end, page, err := queryPage(ctx, s.db, query, params, args, func(rows *transaction.Rows) (Cursor, error) {
 		location := &Location{}
 
-		err := rows.Scan(&location.ID, &location.BusinessID, &location.Name, &location.ProfileImageID, &location.CreatedAt, &location.UpdatedAt)
+		err := rows.Scan(&location.ID, &location.BusinessID, &location.Name, &location.ProfileImageID, &location.TimeZone, &location.CreatedAt, &location.UpdatedAt, &location.Version)
 
 		if err != nil {
-			return nil, errors.Wrap(op, err, "row scan error")
+			return Cursor{}, err
 		}
 
 		locations = append(locations, location)
-	}
 
-	err = rows.Err()
+		return args.CursorOf(location, location.CreatedAt, location.ID), nil
+	})
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "database error")
+		return nil, nil, errors.Wrap(op, err, "database error")
 	}
 
-	return locations, nil
+	return locations[start:end], page, nil
 }
 
 // GetLocationByID gets Location by ID
@@ -76,21 +150,22 @@ func (s *locationStore) GetLocationByID(ctx context.Context, id string) (*Locati
 	const op = "app/locationStore.GetLocationByID"
 
 	query := `
-		SELECT id, business_id, name, profile_image_id, created_at, updated_at
+		SELECT id, business_id, name, profile_image_id, time_zone, created_at, updated_at, version
 		FROM location
-		WHERE id=$1;
+		WHERE id=$1
+			AND deleted_at IS NULL;
 	`
 
 	var location Location
 
-	row := s.db.QueryRow(query, id)
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
 
-	if row == nil {
+	err := row.Scan(&location.ID, &location.BusinessID, &location.Name, &location.ProfileImageID, &location.TimeZone, &location.CreatedAt, &location.UpdatedAt, &location.Version)
+
+	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 
-	err := row.Scan(&location.ID, &location.BusinessID, &location.Name, &location.ProfileImageID, &location.CreatedAt, &location.UpdatedAt)
-
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
@@ -103,48 +178,164 @@ func (s *locationStore) StoreLocation(ctx context.Context, location *Location) e
 	const op = "app/locationStore.StoreLocation"
 
 	query := `
-		INSERT INTO location (id, business_id, name, profile_image_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO location (id, business_id, name, profile_image_id, time_zone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err := s.db.Exec(query, location.ID, location.BusinessID, location.Name, location.ProfileImageID, location.CreatedAt, location.UpdatedAt)
+	_, err := transaction.DB(ctx, s.db).Exec(query, location.ID, location.BusinessID, location.Name, location.ProfileImageID, location.TimeZone, location.CreatedAt, location.UpdatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	location.Version = 1
+
 	return nil
 }
 
-// UpdateLocation updates Location including all fields
+// UpdateLocation updates Location including all fields. It fails with KindPreconditionFailed unless the stored Version is still the same, and increments Version
 func (s *locationStore) UpdateLocation(ctx context.Context, location *Location) error {
 	const op = "app/locationStore.UpdateLocation"
 
 	query := `
 		UPDATE location
-		SET name=$2, profile_image_id=$3, updated_at=$4
-		WHERE id=$1;
+		SET name=$2, profile_image_id=$3, time_zone=$4, updated_at=$5, version=version+1
+		WHERE id=$1
+			AND version=$6;
 	`
 
-	_, err := s.db.Exec(query, location.ID, location.Name, location.ProfileImageID, location.UpdatedAt)
+	result, err := transaction.DB(ctx, s.db).Exec(query, location.ID, location.Name, location.ProfileImageID, location.TimeZone, location.UpdatedAt, location.Version)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
 	}
 
+	count, err := result.RowsAffected()
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	if count == 0 {
+		return errors.PreconditionFailed(op, "location was changed meanwhile")
+	}
+
+	location.Version++
+
 	return nil
 }
 
-// DeleteLocation deletes Location
+// DeleteLocation marks Location as deleted at DeletedAt. Like updates, it requires and increments Version. It can be restored until it is purged
 func (s *locationStore) DeleteLocation(ctx context.Context, location *Location) error {
 	const op = "app/locationStore.DeleteLocation"
 
 	query := `
-		DELETE FROM location
+		UPDATE location
+		SET deleted_at=$2, version=version+1
+		WHERE id=$1
+			AND version=$3;
+	`
+
+	result, err := transaction.DB(ctx, s.db).Exec(query, location.ID, location.DeletedAt, location.Version)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	if count == 0 {
+		return errors.PreconditionFailed(op, "location was changed meanwhile")
+	}
+
+	location.Version++
+
+	return nil
+}
+
+// DeleteLocationsByBusinessID marks the Locations of a Business as deleted at the time
+func (s *locationStore) DeleteLocationsByBusinessID(ctx context.Context, businessID string, deletedAt time.Time) error {
+	const op = "app/locationStore.DeleteLocationsByBusinessID"
+
+	query := `
+		UPDATE location
+		SET deleted_at=$2
+		WHERE business_id=$1
+			AND deleted_at IS NULL;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, businessID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// GetDeletedLocationByID gets Location by ID when it is deleted
+func (s *locationStore) GetDeletedLocationByID(ctx context.Context, id string) (*Location, error) {
+	const op = "app/locationStore.GetDeletedLocationByID"
+
+	query := `
+		SELECT id, business_id, name, profile_image_id, time_zone, created_at, updated_at, version, deleted_at
+		FROM location
+		WHERE id=$1
+			AND deleted_at IS NOT NULL;
+	`
+
+	var location Location
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	err := row.Scan(&location.ID, &location.BusinessID, &location.Name, &location.ProfileImageID, &location.TimeZone, &location.CreatedAt, &location.UpdatedAt, &location.Version, &location.DeletedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return &location, nil
+}
+
+// RestoreLocation unmarks deleted Location
+func (s *locationStore) RestoreLocation(ctx context.Context, location *Location) error {
+	const op = "app/locationStore.RestoreLocation"
+
+	query := `
+		UPDATE location
+		SET deleted_at=NULL, updated_at=$2
 		WHERE id=$1;
 	`
 
-	_, err := s.db.Exec(query, location.ID)
+	_, err := transaction.DB(ctx, s.db).Exec(query, location.ID, location.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// RestoreLocationsByBusinessID unmarks the Locations of a Business deleted at the time, i.e. along with it
+func (s *locationStore) RestoreLocationsByBusinessID(ctx context.Context, businessID string, deletedAt time.Time) error {
+	const op = "app/locationStore.RestoreLocationsByBusinessID"
+
+	query := `
+		UPDATE location
+		SET deleted_at=NULL
+		WHERE business_id=$1
+			AND deleted_at=$2;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, businessID, deletedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
@@ -152,3 +343,22 @@ func (s *locationStore) DeleteLocation(ctx context.Context, location *Location)
 
 	return nil
 }
+
+// PurgeLocations permanently deletes Locations deleted before the time, and returns their ids
+func (s *locationStore) PurgeLocations(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	const op = "app/locationStore.PurgeLocations"
+
+	query := `
+		DELETE FROM location
+		WHERE deleted_at < $1
+		RETURNING id;
+	`
+
+	ids, err := queryIDs(ctx, s.db, query, deletedBefore)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return ids, nil
+}
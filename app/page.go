@@ -0,0 +1,246 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/query"
+)
+
+// Sizes of pages when first or last are not given, and at most
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Cursor is the position of a record in a list ordered by a sort field, then creation time, then ID. Clients get it
+// encoded as an opaque string
+type Cursor struct {
+	// Sort is the key of the sort of the list, empty when it is ordered by creation
+	Sort string `json:"s,omitempty"`
+	// Value is the value of the sort field of the record, formatted by query.Format
+	Value     string    `json:"v,omitempty"`
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// Before reports whether the record at c comes before the record at other in the list
+func (c Cursor) Before(other Cursor) bool {
+	if c.Value != other.Value {
+		return (c.Value < other.Value) != strings.HasPrefix(c.Sort, "-")
+	}
+
+	if c.CreatedAt.Equal(other.CreatedAt) {
+		return c.ID < other.ID
+	}
+
+	return c.CreatedAt.Before(other.CreatedAt)
+}
+
+// String encodes the cursor
+func (c Cursor) String() string {
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor decodes a cursor encoded by Cursor.String
+func ParseCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	cursor := Cursor{}
+	err = json.Unmarshal(b, &cursor)
+
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	if cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+// PageArgs selects a page of a list: the First records After a cursor, or the Last records Before a cursor.
+// Without First and Last, the page has DefaultPageSize records. Filter and Sort select and order the list, which is
+// ordered by creation without Sort
+type PageArgs struct {
+	First  int
+	After  string
+	Last   int
+	Before string
+	Filter query.Filter
+	Sort   *query.Sort
+}
+
+// Validate checks the fields of the args
+func (args *PageArgs) Validate() error {
+	const op = "app/PageArgs.Validate"
+
+	v := &errors.Validation{}
+	v.Range("first", args.First, 0, MaxPageSize)
+	v.Range("last", args.Last, 0, MaxPageSize)
+	v.Check(args.First == 0 || args.Last == 0, "last", errors.CodeInvalidValue, "first and last cannot be combined")
+	v.Check(args.After == "" || !args.backward(), "after", errors.CodeInvalidValue, "after cannot be combined with last or before")
+
+	if args.After != "" {
+		args.checkCursor(v, "after", args.After)
+	}
+
+	if args.Before != "" {
+		args.checkCursor(v, "before", args.Before)
+	}
+
+	return v.Err(op)
+}
+
+// checkCursor checks that s is a valid cursor of a list with the sort of the args
+func (args *PageArgs) checkCursor(v *errors.Validation, field string, s string) {
+	cursor, err := ParseCursor(s)
+
+	if err != nil {
+		v.Add(field, errors.CodeInvalidFormat, field+" is not a valid cursor")
+		return
+	}
+
+	if cursor.Sort != args.Sort.Key() {
+		v.Add(field, errors.CodeInvalidValue, field+" is a cursor of a list with another sort")
+		return
+	}
+
+	if args.Sort != nil {
+		_, err = query.Parse(args.Sort.Field, cursor.Value)
+		v.Check(err == nil, field, errors.CodeInvalidFormat, field+" is not a valid cursor")
+	}
+}
+
+// CursorOf returns the cursor of a record in the list sorted by the args
+func (args *PageArgs) CursorOf(record interface{}, createdAt time.Time, id string) Cursor {
+	cursor := Cursor{CreatedAt: createdAt, ID: id}
+
+	if args.Sort != nil {
+		cursor.Sort = args.Sort.Key()
+		cursor.Value = query.Format(args.Sort.Field, args.Sort.Field.Value(record))
+	}
+
+	return cursor
+}
+
+// backward reports whether the page is counted from the end of the list
+func (args *PageArgs) backward() bool {
+	return args.Last > 0 || (args.First == 0 && args.Before != "")
+}
+
+// size returns the number of records of the page
+func (args *PageArgs) size() int {
+	if args.First > 0 {
+		return args.First
+	}
+
+	if args.Last > 0 {
+		return args.Last
+	}
+
+	return DefaultPageSize
+}
+
+// cursor returns the cursor the page starts after, or ends before when it is counted backward
+func (args *PageArgs) cursor() (*Cursor, error) {
+	s := args.After
+
+	if args.backward() {
+		s = args.Before
+	}
+
+	if s == "" {
+		return nil, nil
+	}
+
+	cursor, err := ParseCursor(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// PageInfo tells whether records come before and after a page, and the cursors of its first and last records
+type PageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     string
+	EndCursor       string
+}
+
+// Page describes a page of a list
+type Page struct {
+	TotalCount int
+	PageInfo   PageInfo
+}
+
+// newPage describes the page of records at cursors, in list order. more tells whether records follow the page in the
+// direction it is counted
+func newPage(args PageArgs, cursors []Cursor, more bool, totalCount int) *Page {
+	page := &Page{TotalCount: totalCount}
+
+	if args.backward() {
+		page.PageInfo.HasPreviousPage = more
+		page.PageInfo.HasNextPage = args.Before != ""
+	} else {
+		page.PageInfo.HasNextPage = more
+		page.PageInfo.HasPreviousPage = args.After != ""
+	}
+
+	if len(cursors) > 0 {
+		page.PageInfo.StartCursor = cursors[0].String()
+		page.PageInfo.EndCursor = cursors[len(cursors)-1].String()
+	}
+
+	return page
+}
+
+// Paginate selects the page described by args of a whole list, given by the cursors of its records in list order.
+// It returns the range of the page in the list, for stores that hold their records in memory
+func Paginate(cursors []Cursor, args PageArgs) (int, int, *Page, error) {
+	cursor, err := args.cursor()
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	start, end := 0, len(cursors)
+
+	if cursor != nil && args.backward() {
+		end = 0
+
+		for end < len(cursors) && cursors[end].Before(*cursor) {
+			end++
+		}
+	}
+
+	if cursor != nil && !args.backward() {
+		for start < len(cursors) && !cursor.Before(cursors[start]) {
+			start++
+		}
+	}
+
+	more := end-start > args.size()
+
+	if more && args.backward() {
+		start = end - args.size()
+	} else if more {
+		end = start + args.size()
+	}
+
+	return start, end, newPage(args, cursors[start:end], more, len(cursors)), nil
+}
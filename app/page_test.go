@@ -0,0 +1,200 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/query"
+)
+
+func newCursors(n int) []Cursor {
+	createdAt := time.Date(2020, time.January, 1, 9, 0, 0, 0, time.UTC)
+	cursors := []Cursor{}
+
+	for i := 0; i < n; i++ {
+		// Every other record shares its creation time with the previous one, so that ties are ordered by ID
+		cursors = append(cursors, Cursor{CreatedAt: createdAt.Add(time.Duration(i/2) * time.Minute), ID: fmt.Sprintf("id%d", i)})
+	}
+
+	return cursors
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("should parse encoded cursor", func(t *testing.T) {
+		cursor := Cursor{CreatedAt: time.Date(2020, time.January, 1, 9, 0, 0, 123456789, time.UTC), ID: "1"}
+
+		got, err := ParseCursor(cursor.String())
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !got.CreatedAt.Equal(cursor.CreatedAt) || got.ID != cursor.ID {
+			t.Errorf("expected %+v, got %+v", cursor, got)
+		}
+	})
+
+	t.Run("should reject invalid cursor", func(t *testing.T) {
+		for _, s := range []string{"!", "bm90IGEgY3Vyc29y", Cursor{}.String()[:4]} {
+			_, err := ParseCursor(s)
+
+			if err == nil {
+				t.Errorf("expected error for cursor %q", s)
+			}
+		}
+	})
+}
+
+func TestPageArgsValidate(t *testing.T) {
+	cursor := newCursors(1)[0].String()
+
+	for _, args := range []PageArgs{
+		{First: -1},
+		{First: MaxPageSize + 1},
+		{First: 1, Last: 1},
+		{Last: 1, After: cursor},
+		{After: cursor, Before: cursor},
+		{After: "invalid"},
+	} {
+		err := args.Validate()
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Errorf("expected args %+v to be invalid, got %v", args, err)
+		}
+	}
+
+	sort := &query.Sort{Field: LocationFields[0], Desc: true}
+	sortedCursor := Cursor{Sort: sort.Key(), Value: "Salon", CreatedAt: time.Now(), ID: "1"}.String()
+
+	for _, args := range []PageArgs{{First: 1, After: cursor, Sort: sort}, {First: 1, After: sortedCursor}} {
+		err := args.Validate()
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Errorf("expected cursor of another sort to be invalid, got %v", err)
+		}
+	}
+
+	for _, args := range []PageArgs{{}, {First: 1, After: cursor}, {Last: 1, Before: cursor}, {Before: cursor}, {After: sortedCursor, Sort: sort}} {
+		err := args.Validate()
+
+		if err != nil {
+			t.Errorf("expected args %+v to be valid, got %v", args, err)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	cursors := newCursors(5)
+
+	t.Run("should page forward", func(t *testing.T) {
+		ids := []string{}
+		args := PageArgs{First: 2}
+
+		for {
+			start, end, page, err := Paginate(cursors, args)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if page.TotalCount != len(cursors) {
+				t.Errorf("expected total count %d, got %d", len(cursors), page.TotalCount)
+			}
+
+			for _, c := range cursors[start:end] {
+				ids = append(ids, c.ID)
+			}
+
+			if !page.PageInfo.HasNextPage {
+				break
+			}
+
+			args.After = page.PageInfo.EndCursor
+		}
+
+		if fmt.Sprint(ids) != "[id0 id1 id2 id3 id4]" {
+			t.Errorf("expected every record once in order, got %v", ids)
+		}
+	})
+
+	t.Run("should page backward", func(t *testing.T) {
+		start, end, page, err := Paginate(cursors, PageArgs{Last: 2})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if start != 3 || end != 5 || !page.PageInfo.HasPreviousPage || page.PageInfo.HasNextPage {
+			t.Errorf("expected last 2 records with previous page, got %d:%d %+v", start, end, page.PageInfo)
+		}
+
+		start, end, page, err = Paginate(cursors, PageArgs{Last: 2, Before: page.PageInfo.StartCursor})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if start != 1 || end != 3 || !page.PageInfo.HasPreviousPage || !page.PageInfo.HasNextPage {
+			t.Errorf("expected records 1 and 2 with previous and next pages, got %d:%d %+v", start, end, page.PageInfo)
+		}
+	})
+
+	t.Run("should page sorted list", func(t *testing.T) {
+		args := PageArgs{First: 2, Sort: &query.Sort{Field: LocationFields[0], Desc: true}}
+		locations := []*Location{}
+		sorted := []Cursor{}
+
+		for i, c := range cursors {
+			// Names repeat, so that records with the same name are ordered by creation
+			locations = append(locations, &Location{ID: c.ID, Name: fmt.Sprintf("Salon %d", i%2), CreatedAt: c.CreatedAt})
+			sorted = append(sorted, args.CursorOf(locations[i], c.CreatedAt, c.ID))
+		}
+
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+		ids := []string{}
+
+		for {
+			start, end, page, err := Paginate(sorted, args)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			for _, c := range sorted[start:end] {
+				ids = append(ids, c.ID)
+			}
+
+			if !page.PageInfo.HasNextPage {
+				break
+			}
+
+			args.After = page.PageInfo.EndCursor
+		}
+
+		if fmt.Sprint(ids) != "[id1 id3 id0 id2 id4]" {
+			t.Errorf("expected records by name descending, then oldest first, got %v", ids)
+		}
+	})
+
+	t.Run("should page empty list", func(t *testing.T) {
+		start, end, page, err := Paginate([]Cursor{}, PageArgs{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if start != 0 || end != 0 || page.PageInfo.HasNextPage || page.PageInfo.StartCursor != "" {
+			t.Errorf("expected empty page, got %d:%d %+v", start, end, page.PageInfo)
+		}
+	})
+}
@@ -8,27 +8,51 @@ import (
 )
 
 var (
-	opUpdateLocation     = Operation{Name: "update_location"}
-	opCreateEmployeeRole = Operation{Name: "create_employee_role"}
-	opReadEmployeeRole   = Operation{Name: "read_employee_role"}
-	opUpdateEmployeeRole = Operation{Name: "update_employee_role"}
-	opDeleteEmployeeRole = Operation{Name: "delete_employee_role"}
-	opCreateEmployee     = Operation{Name: "create_employee"}
-	opReadEmployee       = Operation{Name: "read_employee"}
-	opUpdateEmployee     = Operation{Name: "update_employee"}
-	opDeleteEmployee     = Operation{Name: "delete_employee"}
+	opUpdateLocation         = Operation{Name: "update_location"}
+	opCreateEmployeeRole     = Operation{Name: "create_employee_role"}
+	opReadEmployeeRole       = Operation{Name: "read_employee_role"}
+	opUpdateEmployeeRole     = Operation{Name: "update_employee_role"}
+	opDeleteEmployeeRole     = Operation{Name: "delete_employee_role"}
+	opCreateEmployee         = Operation{Name: "create_employee"}
+	opReadEmployee           = Operation{Name: "read_employee"}
+	opUpdateEmployee         = Operation{Name: "update_employee"}
+	opDeleteEmployee         = Operation{Name: "delete_employee"}
+	opCreateInvitation       = Operation{Name: "create_invitation"}
+	opReadInvitation         = Operation{Name: "read_invitation"}
+	opRevokeInvitation       = Operation{Name: "revoke_invitation"}
+	opReadEmployeeSchedule   = Operation{Name: "read_employee_schedule"}
+	opUpdateEmployeeSchedule = Operation{Name: "update_employee_schedule"}
+	opCreateService          = Operation{Name: "create_service"}
+	opReadService            = Operation{Name: "read_service"}
+	opUpdateService          = Operation{Name: "update_service"}
+	opDeleteService          = Operation{Name: "delete_service"}
+	opCreateAppointment      = Operation{Name: "create_appointment"}
+	opReadAppointment        = Operation{Name: "read_appointment"}
+	opUpdateAppointment      = Operation{Name: "update_appointment"}
+	opDeleteAppointment      = Operation{Name: "delete_appointment"}
+	opReadAvailability       = Operation{Name: "read_availability"}
+	opCreateClient           = Operation{Name: "create_client"}
+	opReadClient             = Operation{Name: "read_client"}
+	opUpdateClient           = Operation{Name: "update_client"}
+	opDeleteClient           = Operation{Name: "delete_client"}
 )
 
 var (
 	permManageLocation     = Permission{ID: "1", Name: "manage_location", Operations: []Operation{opUpdateLocation}}
 	permManageEmployeeRole = Permission{ID: "2", Name: "manage_employee_role", Operations: []Operation{opCreateEmployeeRole, opReadEmployeeRole, opUpdateEmployeeRole, opDeleteEmployeeRole}}
-	permManageEmployee     = Permission{ID: "3", Name: "manage_employee", Operations: []Operation{opCreateEmployee, opReadEmployee, opUpdateEmployee, opDeleteEmployee}}
+	permManageEmployee     = Permission{ID: "3", Name: "manage_employee", Operations: []Operation{opCreateEmployee, opReadEmployee, opUpdateEmployee, opDeleteEmployee, opReadEmployeeSchedule, opUpdateEmployeeSchedule, opCreateInvitation, opReadInvitation, opRevokeInvitation}}
+	permManageService      = Permission{ID: "4", Name: "manage_service", Operations: []Operation{opCreateService, opReadService, opUpdateService, opDeleteService}}
+	permManageAppointment  = Permission{ID: "5", Name: "manage_appointment", Operations: []Operation{opCreateAppointment, opReadAppointment, opUpdateAppointment, opDeleteAppointment, opReadAvailability}}
+	permManageClient       = Permission{ID: "6", Name: "manage_client", Operations: []Operation{opCreateClient, opReadClient, opUpdateClient, opDeleteClient}}
 )
 
 var permissionsTable = map[string]Permission{
 	permManageLocation.ID:     permManageLocation,
 	permManageEmployeeRole.ID: permManageEmployeeRole,
 	permManageEmployee.ID:     permManageEmployee,
+	permManageService.ID:      permManageService,
+	permManageAppointment.ID:  permManageAppointment,
+	permManageClient.ID:       permManageClient,
 }
 
 // PermissionService ...
@@ -64,7 +88,7 @@ func (p *PermissionService) GetEmployeeActor(ctx context.Context, userID string,
 		return nil, errors.Wrap(op, err, "failed to get employee role by id")
 	}
 
-	if employee == nil {
+	if employeeRole == nil {
 		return nil, errors.NotFound(op)
 	}
 
@@ -79,8 +103,18 @@ func (p *PermissionService) GetEmployeeActor(ctx context.Context, userID string,
 	return actor, nil
 }
 
-func getPermissionsByPermissionIDs(permissionIDs []string) ([]Permission, error) {
-	const op = "app/getPermissionsByPermissionIDs"
+// validatePermissionIDs checks that the permissions exist
+func validatePermissionIDs(v *errors.Validation, permissionIDs []string) {
+	for i, permissionID := range permissionIDs {
+		_, ok := permissionsTable[permissionID]
+
+		v.Check(ok, fmt.Sprintf("permission_ids[%d]", i), errors.CodeInvalidValue, fmt.Sprintf("unknown permission %s", permissionID))
+	}
+}
+
+// GetPermissionsByPermissionIDs looks up the permissions of the ids, e.g. to load the permissions of an EmployeeRole
+func GetPermissionsByPermissionIDs(permissionIDs []string) ([]Permission, error) {
+	const op = "app/GetPermissionsByPermissionIDs"
 
 	permissions := []Permission{}
 
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// DeletedRetention is how long deleted businesses and locations can be restored before they are purged
+const DeletedRetention = 30 * 24 * time.Hour
+
+// isRestorable reports whether a record deleted at deletedAt is still within the retention window
+func isRestorable(deletedAt time.Time, now time.Time) bool {
+	return deletedAt.After(now.Add(-DeletedRetention))
+}
+
+// deleteLocationChildren marks the employees and employee roles of a location as deleted along with it
+func deleteLocationChildren(ctx context.Context, employeeStore EmployeeStore, employeeRoleStore EmployeeRoleStore, locationID string, deletedAt time.Time) error {
+	const op = "app/deleteLocationChildren"
+
+	err := employeeStore.DeleteEmployeesByLocationID(ctx, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to delete employees by location id")
+	}
+
+	err = employeeRoleStore.DeleteEmployeeRolesByLocationID(ctx, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to delete employee roles by location id")
+	}
+
+	return nil
+}
+
+// restoreLocationChildren restores the employees and employee roles deleted along with a location
+func restoreLocationChildren(ctx context.Context, employeeStore EmployeeStore, employeeRoleStore EmployeeRoleStore, locationID string, deletedAt time.Time) error {
+	const op = "app/restoreLocationChildren"
+
+	err := employeeStore.RestoreEmployeesByLocationID(ctx, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to restore employees by location id")
+	}
+
+	err = employeeRoleStore.RestoreEmployeeRolesByLocationID(ctx, locationID, deletedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to restore employee roles by location id")
+	}
+
+	return nil
+}
+
+// PurgeService ...
+type PurgeService struct {
+	businessStore         BusinessStore
+	locationStore         LocationStore
+	employeeStore         EmployeeStore
+	employeeRoleStore     EmployeeRoleStore
+	serviceStore          ServiceStore
+	appointmentStore      AppointmentStore
+	employeeScheduleStore EmployeeScheduleStore
+	invitationStore       InvitationStore
+	clientStore           ClientStore
+	transactor            transaction.Transactor
+}
+
+// NewPurgeService constructor for PurgeService
+func NewPurgeService(businessStore BusinessStore, locationStore LocationStore, employeeStore EmployeeStore, employeeRoleStore EmployeeRoleStore, serviceStore ServiceStore, appointmentStore AppointmentStore, employeeScheduleStore EmployeeScheduleStore, invitationStore InvitationStore, clientStore ClientStore, transactor transaction.Transactor) PurgeService {
+	return PurgeService{businessStore: businessStore, locationStore: locationStore, employeeStore: employeeStore, employeeRoleStore: employeeRoleStore, serviceStore: serviceStore, appointmentStore: appointmentStore, employeeScheduleStore: employeeScheduleStore, invitationStore: invitationStore, clientStore: clientStore, transactor: transactor}
+}
+
+// PurgeDeleted permanently deletes businesses, locations, employees and employee roles deleted longer than DeletedRetention
+// before now, along with the records that belong to them: the appointments, working hours, time offs and invitations
+// of the employees, the services of the locations and the clients of the businesses
+func (s *PurgeService) PurgeDeleted(ctx context.Context, now time.Time) error {
+	const op = "app/purgeService.PurgeDeleted"
+
+	deletedBefore := now.Add(-DeletedRetention)
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		employeeIDs, err := s.employeeStore.PurgeEmployees(ctx, deletedBefore)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge employees")
+		}
+
+		err = s.appointmentStore.PurgeAppointmentsByEmployeeIDs(ctx, employeeIDs)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge appointments by employee ids")
+		}
+
+		err = s.employeeScheduleStore.PurgeEmployeeSchedulesByEmployeeIDs(ctx, employeeIDs)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge employee schedules by employee ids")
+		}
+
+		err = s.invitationStore.PurgeInvitationsByEmployeeIDs(ctx, employeeIDs)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge invitations by employee ids")
+		}
+
+		err = s.employeeRoleStore.PurgeEmployeeRoles(ctx, deletedBefore)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge employee roles")
+		}
+
+		locationIDs, err := s.locationStore.PurgeLocations(ctx, deletedBefore)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge locations")
+		}
+
+		err = s.serviceStore.PurgeServicesByLocationIDs(ctx, locationIDs)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge services by location ids")
+		}
+
+		businessIDs, err := s.businessStore.PurgeBusinesses(ctx, deletedBefore)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge businesses")
+		}
+
+		err = s.clientStore.PurgeClientsByBusinessIDs(ctx, businessIDs)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to purge clients by business ids")
+		}
+
+		return nil
+	})
+}
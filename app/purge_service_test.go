@@ -0,0 +1,112 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/memstore"
+)
+
+func TestPurgeDeleted(t *testing.T) {
+	businessStore := memstore.NewBusinessStore()
+	locationStore := memstore.NewLocationStore()
+	employeeStore := memstore.NewEmployeeStore()
+	employeeRoleStore := memstore.NewEmployeeRoleStore()
+	serviceStore := memstore.NewServiceStore()
+	appointmentStore := memstore.NewAppointmentStore()
+	employeeScheduleStore := memstore.NewEmployeeScheduleStore()
+	invitationStore := memstore.NewInvitationStore()
+	clientStore := memstore.NewClientStore()
+	purgeService := app.NewPurgeService(businessStore, locationStore, employeeStore, employeeRoleStore, serviceStore, appointmentStore, employeeScheduleStore, invitationStore, clientStore, memstore.NewTransactor())
+	now := time.Now()
+
+	expired := now.Add(-app.DeletedRetention - time.Minute)
+	recent := now.Add(-time.Minute)
+
+	businessStore.StoreBusiness(context.Background(), &app.Business{ID: "1", DeletedAt: expired})
+	businessStore.StoreBusiness(context.Background(), &app.Business{ID: "2", DeletedAt: recent})
+	businessStore.StoreBusiness(context.Background(), &app.Business{ID: "3"})
+	locationStore.StoreLocation(context.Background(), &app.Location{ID: "1", BusinessID: "1", DeletedAt: expired})
+	employeeStore.StoreEmployee(context.Background(), &app.Employee{ID: "1", LocationID: "1", DeletedAt: expired})
+	employeeRoleStore.StoreEmployeeRole(context.Background(), &app.EmployeeRole{ID: "1", LocationID: "1", DeletedAt: expired})
+	locationStore.StoreLocation(context.Background(), &app.Location{ID: "2", BusinessID: "3"})
+	employeeStore.StoreEmployee(context.Background(), &app.Employee{ID: "2", LocationID: "2"})
+
+	for _, id := range []string{"1", "2"} {
+		serviceStore.StoreService(context.Background(), &app.Service{ID: id, LocationID: id})
+		appointmentStore.StoreAppointment(context.Background(), &app.Appointment{ID: id, LocationID: id, EmployeeID: id})
+		employeeScheduleStore.ReplaceWorkingHours(context.Background(), id, []*app.WorkingHours{{ID: id, EmployeeID: id}})
+		employeeScheduleStore.StoreWorkingHoursOverride(context.Background(), &app.WorkingHoursOverride{ID: id, EmployeeID: id})
+		employeeScheduleStore.StoreTimeOff(context.Background(), &app.TimeOff{ID: id, EmployeeID: id})
+		invitationStore.StoreInvitation(context.Background(), &app.Invitation{ID: id, LocationID: id, EmployeeID: id})
+	}
+
+	clientStore.StoreClient(context.Background(), &app.Client{ID: "1", BusinessID: "1"})
+	clientStore.StoreClient(context.Background(), &app.Client{ID: "2", BusinessID: "3"})
+
+	t.Run("should purge records deleted before retention", func(t *testing.T) {
+		err := purgeService.PurgeDeleted(context.Background(), now)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		for _, id := range []string{"2", "3"} {
+			business, err := businessStore.GetBusinessByID(context.Background(), id)
+
+			if err == nil && business == nil {
+				business, err = businessStore.GetDeletedBusinessByID(context.Background(), id)
+			}
+
+			if err != nil || business == nil {
+				t.Errorf("expected business id=%s to be kept", id)
+			}
+		}
+
+		business, _ := businessStore.GetDeletedBusinessByID(context.Background(), "1")
+		location, _ := locationStore.GetDeletedLocationByID(context.Background(), "1")
+
+		if business != nil || location != nil {
+			t.Error("expired business and location should be purged")
+		}
+
+		employeeStore.RestoreEmployeesByLocationID(context.Background(), "1", expired)
+		employeeRoleStore.RestoreEmployeeRolesByLocationID(context.Background(), "1", expired)
+
+		employee, _ := employeeStore.GetEmployeeByID(context.Background(), "1")
+		employeeRole, _ := employeeRoleStore.GetEmployeeRoleByID(context.Background(), "1")
+
+		if employee != nil || employeeRole != nil {
+			t.Error("expired employees and employee roles should be purged")
+		}
+	})
+
+	t.Run("should purge records of purged employees, locations and businesses", func(t *testing.T) {
+		err := purgeService.PurgeDeleted(context.Background(), now)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		for _, id := range []string{"1", "2"} {
+			service, _ := serviceStore.GetServiceByID(context.Background(), id)
+			appointment, _ := appointmentStore.GetAppointmentByID(context.Background(), id)
+			workingHours, _ := employeeScheduleStore.GetWorkingHoursByEmployeeID(context.Background(), id)
+			override, _ := employeeScheduleStore.GetWorkingHoursOverrideByID(context.Background(), id)
+			timeOff, _ := employeeScheduleStore.GetTimeOffByID(context.Background(), id)
+			invitation, _ := invitationStore.GetInvitationByID(context.Background(), id)
+			client, _ := clientStore.GetClientByID(context.Background(), id)
+
+			purged := id == "1"
+
+			if (service == nil) != purged || (appointment == nil) != purged || (len(workingHours) == 0) != purged ||
+				(override == nil) != purged || (timeOff == nil) != purged || (invitation == nil) != purged || (client == nil) != purged {
+				t.Errorf("expected records id=%s to be purged=%v", id, purged)
+			}
+		}
+	})
+}
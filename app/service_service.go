@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// Service is an offering of a location, e.g. haircut or manicure
+type Service struct {
+	ID          string    `json:"id"`
+	LocationID  string    `json:"location_id"`
+	Name        string    `json:"name"`
+	Duration    int       `json:"duration"` // in minutes
+	Price       int64     `json:"price"`
+	Category    string    `json:"category"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ServiceService ...
+type ServiceService struct {
+	serviceStore ServiceStore
+}
+
+// NewServiceService constructor for ServiceService
+func NewServiceService(serviceStore ServiceStore) ServiceService {
+	return ServiceService{serviceStore: serviceStore}
+}
+
+// GetServicesByLocationID ...
+func (s *ServiceService) GetServicesByLocationID(ctx context.Context, locationID string, actor Actor) ([]*Service, error) {
+	const op = "app/serviceService.GetServicesByLocationID"
+
+	err := actor.can(ctx, opReadService)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	services, err := s.serviceStore.GetServicesByLocationID(ctx, locationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get services by location id")
+	}
+
+	return services, nil
+}
+
+// getService gets the service, which must belong to the location. Services of other locations are not found
+func (s *ServiceService) getService(ctx context.Context, locationID string, id string) (*Service, error) {
+	const op = "app/serviceService.getService"
+
+	service, err := s.serviceStore.GetServiceByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get service by id")
+	}
+
+	if service == nil || service.LocationID != locationID {
+		return nil, errors.NotFound(op)
+	}
+
+	return service, nil
+}
+
+// GetServiceByID ...
+func (s *ServiceService) GetServiceByID(ctx context.Context, locationID string, id string, actor Actor) (*Service, error) {
+	const op = "app/serviceService.GetServiceByID"
+
+	err := actor.can(ctx, opReadService)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	service, err := s.getService(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get service")
+	}
+
+	return service, nil
+}
+
+// CreateServiceInput ...
+type CreateServiceInput struct {
+	LocationID  string `json:"location_id"`
+	Name        string `json:"name"`
+	Duration    int    `json:"duration"`
+	Price       int64  `json:"price"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// Validate checks the fields of the input
+func (input *CreateServiceInput) Validate() error {
+	const op = "app/CreateServiceInput.Validate"
+
+	v := &errors.Validation{}
+	v.Required("name", input.Name)
+	v.Check(input.Duration > 0, "duration", errors.CodeOutOfRange, "duration must be greater than 0")
+	v.Check(input.Price >= 0, "price", errors.CodeOutOfRange, "price must not be negative")
+
+	return v.Err(op)
+}
+
+// CreateService creates service
+func (s *ServiceService) CreateService(ctx context.Context, input *CreateServiceInput, actor Actor) (*Service, error) {
+	const op = "app/serviceService.CreateService"
+
+	err := actor.can(ctx, opCreateService)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	now := time.Now()
+
+	service := &Service{
+		ID:          uuid.Must(uuid.New(), nil).String(),
+		LocationID:  input.LocationID,
+		Name:        strings.TrimSpace(input.Name),
+		Duration:    input.Duration,
+		Price:       input.Price,
+		Category:    strings.TrimSpace(input.Category),
+		Description: input.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err = s.serviceStore.StoreService(ctx, service)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to store service")
+	}
+
+	return service, nil
+}
+
+// UpdateServiceInput ...
+type UpdateServiceInput struct {
+	Name        string `json:"name"`
+	Duration    int    `json:"duration"`
+	Price       int64  `json:"price"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// Validate checks the fields of the input
+func (input *UpdateServiceInput) Validate() error {
+	const op = "app/UpdateServiceInput.Validate"
+
+	v := &errors.Validation{}
+	v.NotBlank("name", input.Name)
+	v.Check(input.Duration >= 0, "duration", errors.CodeOutOfRange, "duration must be greater than 0")
+	v.Check(input.Price >= 0, "price", errors.CodeOutOfRange, "price must not be negative")
+
+	return v.Err(op)
+}
+
+// UpdateService updates service
+func (s *ServiceService) UpdateService(ctx context.Context, locationID string, id string, input *UpdateServiceInput, actor Actor) (*Service, error) {
+	const op = "app/serviceService.UpdateService"
+
+	err := actor.can(ctx, opUpdateService)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	service, err := s.getService(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get service")
+	}
+
+	service.UpdatedAt = time.Now()
+
+	if input.Name != "" {
+		service.Name = strings.TrimSpace(input.Name)
+	}
+	if input.Duration != 0 {
+		service.Duration = input.Duration
+	}
+	if input.Price != 0 {
+		service.Price = input.Price
+	}
+	if input.Category != "" {
+		service.Category = strings.TrimSpace(input.Category)
+	}
+	if input.Description != "" {
+		service.Description = input.Description
+	}
+
+	err = s.serviceStore.UpdateService(ctx, service)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to update service")
+	}
+
+	return service, nil
+}
+
+// DeleteService deletes service
+func (s *ServiceService) DeleteService(ctx context.Context, locationID string, id string, actor Actor) (*Service, error) {
+	const op = "app/serviceService.DeleteService"
+
+	err := actor.can(ctx, opDeleteService)
+
+	if err != nil {
+		return nil, errors.Forbidden(op, err)
+	}
+
+	service, err := s.getService(ctx, locationID, id)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get service")
+	}
+
+	err = s.serviceStore.DeleteService(ctx, service)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to delete service")
+	}
+
+	return service, nil
+}
@@ -0,0 +1,165 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+)
+
+func TestCreateServiceHappyPath(t *testing.T) {
+	serviceStore := memstore.NewServiceStore()
+	serviceService := app.NewServiceService(serviceStore)
+	actor := app.MockActor
+
+	t.Run("should create service", func(t *testing.T) {
+		input := &app.CreateServiceInput{
+			LocationID: "1",
+			Name:       "haircut",
+			Duration:   30,
+			Price:      100000,
+		}
+		_, err := serviceService.CreateService(context.Background(), input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("should not create service without duration", func(t *testing.T) {
+		input := &app.CreateServiceInput{
+			LocationID: "1",
+			Name:       "haircut",
+		}
+		_, err := serviceService.CreateService(context.Background(), input, actor)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Error("error should be invalid kind")
+		}
+	})
+
+	t.Run("should list every invalid field", func(t *testing.T) {
+		input := &app.CreateServiceInput{
+			LocationID: "1",
+			Name:       " ",
+			Price:      -1,
+		}
+		_, err := serviceService.CreateService(context.Background(), input, actor)
+
+		if errors.ErrorCode(err) != errors.CodeValidationFailed {
+			t.Errorf("expected code %s, got %s", errors.CodeValidationFailed, errors.ErrorCode(err))
+		}
+
+		fields := map[string]string{}
+
+		for _, violation := range errors.ErrorViolations(err) {
+			fields[violation.Field] = violation.Code
+		}
+
+		if fields["name"] != errors.CodeRequired || fields["duration"] != errors.CodeOutOfRange || fields["price"] != errors.CodeOutOfRange {
+			t.Errorf("unexpected violations %v", fields)
+		}
+	})
+}
+
+func TestUpdateServiceHappyPath(t *testing.T) {
+	serviceStore := memstore.NewServiceStore()
+	serviceService := app.NewServiceService(serviceStore)
+	actor := app.MockActor
+
+	service := &app.Service{
+		ID:         "1",
+		LocationID: "1",
+		Name:       "service1",
+		Duration:   30,
+	}
+
+	err := serviceStore.StoreService(context.Background(), service)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("should update service", func(t *testing.T) {
+		input := &app.UpdateServiceInput{
+			Name:     "service2",
+			Duration: 45,
+		}
+		updatedService, err := serviceService.UpdateService(context.Background(), service.LocationID, service.ID, input, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if updatedService.Duration != input.Duration {
+			t.Error("service failed to update duration")
+		}
+	})
+}
+
+func TestDeleteServiceHappyPath(t *testing.T) {
+	serviceStore := memstore.NewServiceStore()
+	serviceService := app.NewServiceService(serviceStore)
+	actor := app.MockActor
+
+	service := &app.Service{
+		ID:         "2",
+		LocationID: "1",
+		Name:       "service3",
+		Duration:   30,
+	}
+
+	err := serviceStore.StoreService(context.Background(), service)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("should delete service", func(t *testing.T) {
+		_, err := serviceService.DeleteService(context.Background(), service.LocationID, service.ID, actor)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+}
+
+func TestServiceOfOtherLocation(t *testing.T) {
+	serviceStore := memstore.NewServiceStore()
+	serviceService := app.NewServiceService(serviceStore)
+	actor := app.MockActor
+	service := &app.Service{ID: "3", LocationID: "1", Name: "service4", Duration: 30}
+
+	serviceStore.StoreService(context.Background(), service)
+
+	_, err := serviceService.GetServiceByID(context.Background(), "2", service.ID, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected service of other location not to be found, got %v", err)
+	}
+
+	_, err = serviceService.UpdateService(context.Background(), "2", service.ID, &app.UpdateServiceInput{Duration: 60}, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected update through other location to be not found, got %v", err)
+	}
+
+	_, err = serviceService.DeleteService(context.Background(), "2", service.ID, actor)
+
+	if errors.Is(errors.KindNotFound, err) == false {
+		t.Errorf("expected delete through other location to be not found, got %v", err)
+	}
+
+	storedService, err := serviceStore.GetServiceByID(context.Background(), service.ID)
+
+	if err != nil || storedService == nil || storedService.Duration != 30 {
+		t.Errorf("expected service to be left unchanged, got %+v", storedService)
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// ServiceStore ...
+type ServiceStore interface {
+	GetServicesByLocationID(ctx context.Context, locationID string) ([]*Service, error)
+	GetServiceByID(ctx context.Context, id string) (*Service, error)
+	StoreService(ctx context.Context, service *Service) error
+	UpdateService(ctx context.Context, service *Service) error
+	DeleteService(ctx context.Context, service *Service) error
+	PurgeServicesByLocationIDs(ctx context.Context, locationIDs []string) error
+}
+
+type serviceStore struct {
+	db *sql.DB
+}
+
+// NewServiceStore ...
+func NewServiceStore(db *sql.DB) ServiceStore {
+	return &serviceStore{db: db}
+}
+
+// GetServicesByLocationID gets Services by LocationID
+func (s *serviceStore) GetServicesByLocationID(ctx context.Context, locationID string) ([]*Service, error) {
+	const op = "app/serviceStore.GetServicesByLocationID"
+
+	query := `
+		SELECT id, location_id, name, duration, price, category, description, created_at, updated_at
+		FROM service
+		WHERE location_id=$1
+		ORDER BY name;
+	`
+	services := make([]*Service, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, locationID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		service := &Service{}
+
+		err := rows.Scan(&service.ID, &service.LocationID, &service.Name, &service.Duration, &service.Price, &service.Category, &service.Description, &service.CreatedAt, &service.UpdatedAt)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		services = append(services, service)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return services, nil
+}
+
+// GetServiceByID gets Service by ID
+func (s *serviceStore) GetServiceByID(ctx context.Context, id string) (*Service, error) {
+	const op = "app/serviceStore.GetServiceByID"
+
+	query := `
+		SELECT id, location_id, name, duration, price, category, description, created_at, updated_at
+		FROM service
+		WHERE id=$1;
+	`
+
+	service := &Service{}
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	err := row.Scan(&service.ID, &service.LocationID, &service.Name, &service.Duration, &service.Price, &service.Category, &service.Description, &service.CreatedAt, &service.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return service, nil
+}
+
+// StoreService persists Service
+func (s *serviceStore) StoreService(ctx context.Context, service *Service) error {
+	const op = "app/serviceStore.StoreService"
+
+	query := `
+		INSERT INTO service (id, location_id, name, duration, price, category, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, service.ID, service.LocationID, service.Name, service.Duration, service.Price, service.Category, service.Description, service.CreatedAt, service.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// UpdateService updates Service including all fields
+func (s *serviceStore) UpdateService(ctx context.Context, service *Service) error {
+	const op = "app/serviceStore.UpdateService"
+
+	query := `
+		UPDATE service
+		SET name=$2, duration=$3, price=$4, category=$5, description=$6, updated_at=$7
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, service.ID, service.Name, service.Duration, service.Price, service.Category, service.Description, service.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// DeleteService deletes Service
+func (s *serviceStore) DeleteService(ctx context.Context, service *Service) error {
+	const op = "app/serviceStore.DeleteService"
+
+	query := `
+		DELETE FROM service
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, service.ID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// PurgeServicesByLocationIDs permanently deletes the Services of the Locations
+func (s *serviceStore) PurgeServicesByLocationIDs(ctx context.Context, locationIDs []string) error {
+	const op = "app/serviceStore.PurgeServicesByLocationIDs"
+
+	if len(locationIDs) == 0 {
+		return nil
+	}
+
+	placeholder, args := makeIDsArgs(locationIDs)
+
+	query := fmt.Sprintf(`
+		DELETE FROM service
+		WHERE location_id IN (%s);
+	`, placeholder)
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, args...)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
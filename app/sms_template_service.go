@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/phone"
+)
+
+// businessSMSTypes are the messages sent on behalf of a business. Login and phone number change codes cannot be overridden
+var businessSMSTypes = []string{phone.SMSTypeInvitation, phone.SMSTypeAppointmentReminder}
+
+// SMSTemplate is the template of a message type and locale used by a business
+type SMSTemplate struct {
+	MessageType string    `json:"message_type"`
+	Locale      string    `json:"locale"`
+	Body        string    `json:"body"`
+	IsDefault   bool      `json:"is_default"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SMSTemplateService manages the templates businesses override
+type SMSTemplateService struct {
+	smsTemplateStore phone.SMSTemplateStore
+	businessStore    BusinessStore
+}
+
+// NewSMSTemplateService constructor for SMSTemplateService
+func NewSMSTemplateService(smsTemplateStore phone.SMSTemplateStore, businessStore BusinessStore) SMSTemplateService {
+	return SMSTemplateService{smsTemplateStore: smsTemplateStore, businessStore: businessStore}
+}
+
+func (s *SMSTemplateService) getOwnedBusiness(ctx context.Context, businessID string, currentUser *auth.User) (*Business, error) {
+	const op = "app/smsTemplateService.getOwnedBusiness"
+
+	business, err := s.businessStore.GetBusinessByID(ctx, businessID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business by id")
+	}
+
+	if business == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	if business.UserID != currentUser.ID {
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
+	}
+
+	return business, nil
+}
+
+// validateBusinessSMSType checks that businesses can override the template of the message type and locale
+func validateBusinessSMSType(v *errors.Validation, messageType string, locale string) {
+	isBusinessSMSType := false
+
+	for _, businessSMSType := range businessSMSTypes {
+		if businessSMSType == messageType {
+			isBusinessSMSType = true
+			break
+		}
+	}
+
+	v.Check(isBusinessSMSType, "message_type", errors.CodeInvalidValue, fmt.Sprintf("template of %s cannot be changed", messageType))
+
+	if isBusinessSMSType {
+		_, ok := phone.DefaultSMSTemplate(messageType, locale)
+
+		v.Check(ok, "locale", errors.CodeInvalidValue, fmt.Sprintf("unsupported locale %s", locale))
+	}
+}
+
+// GetSMSTemplates returns the templates of every business message type and locale, overridden or default
+func (s *SMSTemplateService) GetSMSTemplates(ctx context.Context, businessID string, currentUser *auth.User) ([]*SMSTemplate, error) {
+	const op = "app/smsTemplateService.GetSMSTemplates"
+
+	business, err := s.getOwnedBusiness(ctx, businessID, currentUser)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business")
+	}
+
+	overrides, err := s.smsTemplateStore.GetSMSTemplatesByBusinessID(ctx, business.ID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get sms templates by business id")
+	}
+
+	smsTemplates := []*SMSTemplate{}
+
+	for _, messageType := range businessSMSTypes {
+		for _, locale := range phone.SMSTemplateLocales() {
+			body, _ := phone.DefaultSMSTemplate(messageType, locale)
+			smsTemplate := &SMSTemplate{MessageType: messageType, Locale: locale, Body: body, IsDefault: true}
+
+			for _, override := range overrides {
+				if override.MessageType == messageType && override.Locale == locale {
+					smsTemplate.Body = override.Body
+					smsTemplate.IsDefault = false
+					smsTemplate.UpdatedAt = override.UpdatedAt
+				}
+			}
+
+			smsTemplates = append(smsTemplates, smsTemplate)
+		}
+	}
+
+	return smsTemplates, nil
+}
+
+// SetSMSTemplateInput ...
+type SetSMSTemplateInput struct {
+	MessageType string `json:"message_type"`
+	Locale      string `json:"locale"`
+	Body        string `json:"body"`
+}
+
+// Validate checks the fields of the input
+func (input *SetSMSTemplateInput) Validate() error {
+	const op = "app/SetSMSTemplateInput.Validate"
+
+	v := &errors.Validation{}
+	validateBusinessSMSType(v, input.MessageType, input.Locale)
+
+	if !v.Valid() {
+		return v.Err(op)
+	}
+
+	return phone.ValidateSMSTemplate(input.MessageType, input.Body)
+}
+
+// SetSMSTemplate overrides the default template of the message type and locale for the business
+func (s *SMSTemplateService) SetSMSTemplate(ctx context.Context, businessID string, input *SetSMSTemplateInput, currentUser *auth.User) (*SMSTemplate, error) {
+	const op = "app/smsTemplateService.SetSMSTemplate"
+
+	business, err := s.getOwnedBusiness(ctx, businessID, currentUser)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business")
+	}
+
+	err = input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	smsTemplate, err := s.smsTemplateStore.GetSMSTemplate(ctx, business.ID, input.MessageType, input.Locale)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get sms template")
+	}
+
+	if smsTemplate == nil {
+		smsTemplate = phone.NewSMSTemplate(business.ID, input.MessageType, input.Locale, input.Body)
+
+		err = s.smsTemplateStore.StoreSMSTemplate(ctx, smsTemplate)
+	} else {
+		smsTemplate.Body = input.Body
+		smsTemplate.UpdatedAt = time.Now()
+
+		err = s.smsTemplateStore.UpdateSMSTemplate(ctx, smsTemplate)
+	}
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to store sms template")
+	}
+
+	return &SMSTemplate{MessageType: smsTemplate.MessageType, Locale: smsTemplate.Locale, Body: smsTemplate.Body, UpdatedAt: smsTemplate.UpdatedAt}, nil
+}
+
+// ResetSMSTemplate removes the override, so that the default template of the message type and locale is used again
+func (s *SMSTemplateService) ResetSMSTemplate(ctx context.Context, businessID string, messageType string, locale string, currentUser *auth.User) (*SMSTemplate, error) {
+	const op = "app/smsTemplateService.ResetSMSTemplate"
+
+	business, err := s.getOwnedBusiness(ctx, businessID, currentUser)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get business")
+	}
+
+	v := &errors.Validation{}
+	validateBusinessSMSType(v, messageType, locale)
+
+	err = v.Err(op)
+
+	if err != nil {
+		return nil, err
+	}
+
+	smsTemplate, err := s.smsTemplateStore.GetSMSTemplate(ctx, business.ID, messageType, locale)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get sms template")
+	}
+
+	if smsTemplate != nil {
+		err = s.smsTemplateStore.DeleteSMSTemplate(ctx, smsTemplate)
+
+		if err != nil {
+			return nil, errors.Unexpected(op, err, "failed to delete sms template")
+		}
+	}
+
+	body, _ := phone.DefaultSMSTemplate(messageType, locale)
+
+	return &SMSTemplate{MessageType: messageType, Locale: locale, Body: body, IsDefault: true}, nil
+}
@@ -0,0 +1,119 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/memstore"
+	"github.com/minheq/kedul_server_main/phone"
+)
+
+func TestSMSTemplateHappyPath(t *testing.T) {
+	smsTemplateStore := memstore.NewSMSTemplateStore()
+	owner := auth.NewUser("090 000 00 00", "VN")
+	businessStore := memstore.NewBusinessStore()
+	businessStore.StoreBusiness(context.Background(), &app.Business{ID: "1", UserID: owner.ID, Name: "business1"})
+	smsTemplateService := app.NewSMSTemplateService(smsTemplateStore, businessStore)
+	smsTemplates := phone.NewSMSTemplates(smsTemplateStore)
+
+	t.Run("should list default templates", func(t *testing.T) {
+		templates, err := smsTemplateService.GetSMSTemplates(context.Background(), "1", owner)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(templates) != len(app.BusinessSMSTypes)*len(phone.SMSTemplateLocales()) {
+			t.Errorf("unexpected number of templates %d", len(templates))
+		}
+
+		for _, template := range templates {
+			if !template.IsDefault {
+				t.Error("template should be default")
+			}
+		}
+	})
+
+	t.Run("should override template and render it", func(t *testing.T) {
+		input := &app.SetSMSTemplateInput{MessageType: phone.SMSTypeInvitation, Locale: phone.LocaleVietnamese, Body: "{business} mời bạn làm việc tại {location}"}
+
+		_, err := smsTemplateService.SetSMSTemplate(context.Background(), "1", input, owner)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		text, err := smsTemplates.Render(context.Background(), "1", phone.SMSTypeInvitation, "VN", map[string]string{"business": "business1", "location": "location1"})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if text != "business1 mời bạn làm việc tại location1" {
+			t.Errorf("unexpected text %s", text)
+		}
+
+		text, err = smsTemplates.Render(context.Background(), "1", phone.SMSTypeInvitation, "US", map[string]string{"business": "business1", "location": "location1"})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if text != "You have been invited to join location1 at business1. Log in to Kedul with this phone number to accept." {
+			t.Error("other locales should keep the default template")
+		}
+	})
+
+	t.Run("should not override template with unknown variable", func(t *testing.T) {
+		input := &app.SetSMSTemplateInput{MessageType: phone.SMSTypeInvitation, Locale: phone.LocaleEnglish, Body: "Your code is {code}"}
+
+		_, err := smsTemplateService.SetSMSTemplate(context.Background(), "1", input, owner)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Error("error should be invalid kind")
+		}
+	})
+
+	t.Run("should not override login code template", func(t *testing.T) {
+		input := &app.SetSMSTemplateInput{MessageType: phone.SMSTypeLoginCode, Locale: phone.LocaleEnglish, Body: "Code {code}"}
+
+		_, err := smsTemplateService.SetSMSTemplate(context.Background(), "1", input, owner)
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Error("error should be invalid kind")
+		}
+	})
+
+	t.Run("should not override template of other user's business", func(t *testing.T) {
+		otherUser := auth.NewUser("090 111 11 11", "VN")
+		input := &app.SetSMSTemplateInput{MessageType: phone.SMSTypeInvitation, Locale: phone.LocaleEnglish, Body: "Join {business}"}
+
+		_, err := smsTemplateService.SetSMSTemplate(context.Background(), "1", input, otherUser)
+
+		if errors.Is(errors.KindForbidden, err) == false {
+			t.Error("error should be forbidden kind")
+		}
+	})
+
+	t.Run("should reset template to default", func(t *testing.T) {
+		template, err := smsTemplateService.ResetSMSTemplate(context.Background(), "1", phone.SMSTypeInvitation, phone.LocaleVietnamese, owner)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		templates, err := smsTemplateStore.GetSMSTemplatesByBusinessID(context.Background(), "1")
+
+		if err != nil || !template.IsDefault || len(templates) != 0 {
+			t.Error("template should be reset to default")
+		}
+	})
+}
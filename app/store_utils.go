@@ -1,10 +1,22 @@
 package app
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/minheq/kedul_server_main/query"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 func makeIDsArgs(ids []string) (string, []interface{}) {
 	args := make([]interface{}, len(ids))
 
@@ -20,3 +32,200 @@ func makeIDsArgs(ids []string) (string, []interface{}) {
 
 	return strings.Join(params, ", "), args
 }
+
+// queryIDs runs a query returning the ids of rows, e.g. DELETE ... RETURNING id
+func queryIDs(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := transaction.DB(ctx, db).Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]string, 0)
+
+	for rows.Next() {
+		var id string
+
+		err := rows.Scan(&id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// toNullString maps empty strings to NULL, e.g. for nullable foreign keys
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// toNullTime maps zero times to NULL
+func toNullTime(t time.Time) pq.NullTime {
+	return pq.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// escapeLike escapes wildcards so that s is matched literally in LIKE patterns
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// sqlOps are the SQL operators of the ops of query conditions
+var sqlOps = map[query.Op]string{
+	query.OpEq:     "=",
+	query.OpNe:     "<>",
+	query.OpLt:     "<",
+	query.OpLe:     "<=",
+	query.OpGt:     ">",
+	query.OpGe:     ">=",
+	query.OpPrefix: "LIKE",
+}
+
+// reversed are the opposites of comparisons and orders in SQL
+var reversed = map[string]string{">": "<", "<": ">", "ASC": "DESC", "DESC": "ASC"}
+
+// sqlField returns the expression of a field in SQL. Strings are compared in the "C" collation, byte by byte, like in
+// the in-memory stores and in cursors
+func sqlField(field *query.Field) string {
+	if field.Type == query.String {
+		return field.Column + ` COLLATE "C"`
+	}
+
+	return field.Column
+}
+
+// filterQuery returns the query of rows restricted to the rows matching the filter, and its params following params
+func filterQuery(rowsQuery string, params []interface{}, filter query.Filter) (string, []interface{}) {
+	if len(filter) == 0 {
+		return rowsQuery, params
+	}
+
+	conditions := []string{}
+	filterParams := append([]interface{}{}, params...)
+
+	for _, condition := range filter {
+		value := condition.Value
+
+		if condition.Op == query.OpPrefix {
+			value = escapeLike(value.(string)) + "%"
+		}
+
+		filterParams = append(filterParams, value)
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d", sqlField(condition.Field), sqlOps[condition.Op], len(filterParams)))
+	}
+
+	return fmt.Sprintf("SELECT * FROM (%s) AS unfiltered WHERE %s", rowsQuery, strings.Join(conditions, " AND ")), filterParams
+}
+
+// queryPage selects the page described by args of the rows of listQuery, which selects created_at, id and the columns of
+// the fields of args and has the params, and counts all its rows matching args.Filter. scan reads each selected row,
+// in list order, and returns its cursor. One more row than the page is selected to tell whether rows follow it, so the
+// page is the returned range of the scanned rows
+func queryPage(ctx context.Context, db *sql.DB, listQuery string, params []interface{}, args PageArgs, scan func(rows *transaction.Rows) (Cursor, error)) (int, int, *Page, error) {
+	cursor, err := args.cursor()
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	listQuery, params = filterQuery(listQuery, params, args.Filter)
+
+	condition := "TRUE"
+	comparison, order := ">", "ASC"
+	pageParams := append([]interface{}{}, params...)
+	sortOrder, listSortOrder := "", ""
+
+	if args.backward() {
+		comparison, order = "<", "DESC"
+	}
+
+	if cursor != nil {
+		condition = fmt.Sprintf("(created_at, id) %s ($%d, $%d)", comparison, len(pageParams)+1, len(pageParams)+2)
+		pageParams = append(pageParams, cursor.CreatedAt, cursor.ID)
+	}
+
+	if args.Sort != nil {
+		sortComparison, sortDirection, listSortDirection := comparison, order, "ASC"
+
+		if args.Sort.Desc {
+			sortComparison, sortDirection, listSortDirection = reversed[comparison], reversed[order], "DESC"
+		}
+
+		field := sqlField(args.Sort.Field)
+		sortOrder = fmt.Sprintf("%s %s, ", field, sortDirection)
+		listSortOrder = fmt.Sprintf("%s %s, ", field, listSortDirection)
+
+		if cursor != nil {
+			value, err := query.Parse(args.Sort.Field, cursor.Value)
+
+			if err != nil {
+				return 0, 0, nil, err
+			}
+
+			pageParams = append(pageParams, value)
+			condition = fmt.Sprintf("(%s %s $%d OR (%s = $%d AND %s))", field, sortComparison, len(pageParams), field, len(pageParams), condition)
+		}
+	}
+
+	pageParams = append(pageParams, args.size()+1)
+
+	pageQuery := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT * FROM (%s) AS list
+			WHERE %s
+			ORDER BY %screated_at %s, id %s
+			LIMIT $%d
+		) AS page
+		ORDER BY %screated_at, id;
+	`, listQuery, condition, sortOrder, order, order, len(pageParams), listSortOrder)
+
+	rows, err := transaction.DB(ctx, db).Query(pageQuery, pageParams...)
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	defer rows.Close()
+
+	cursors := []Cursor{}
+
+	for rows.Next() {
+		cursor, err := scan(rows)
+
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		cursors = append(cursors, cursor)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	var totalCount int
+
+	err = transaction.DB(ctx, db).QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS list;", listQuery), params...).Scan(&totalCount)
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	start, end := 0, len(cursors)
+	more := len(cursors) > args.size()
+
+	if more && args.backward() {
+		start = 1
+	} else if more {
+		end--
+	}
+
+	return start, end, newPage(args, cursors[start:end], more, totalCount), nil
+}
@@ -2,36 +2,68 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/go-chi/jwtauth"
+	"github.com/google/uuid"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/patch"
 	"github.com/minheq/kedul_server_main/phone"
 	"github.com/minheq/kedul_server_main/random"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
+// accessTokenExpiry is kept short because access tokens cannot be revoked before they expire
+const accessTokenExpiry = 15 * time.Minute
+
+// Verification codes are 6 digits, so guessing is limited per code, per phone number and per IP address
+const (
+	verificationCodeMaxAttempts           = 5
+	verificationCodeResendCooldown        = time.Minute
+	verificationFailureWindow             = time.Hour
+	verificationMaxFailuresPerPhoneNumber = 10
+	verificationMaxFailuresPerIPAddress   = 50
+)
+
+// sessionLastSeenInterval limits how often the last seen time of a session is updated
+const sessionLastSeenInterval = time.Minute
+
+// TokenPair is returned on login and refresh. The refresh token can be exchanged exactly once
+type TokenPair struct {
+	AccessToken          string
+	AccessTokenExpiredAt time.Time
+	RefreshToken         string
+}
+
 // Service handles authentication
 type Service struct {
-	store     Store
-	tokenAuth *jwtauth.JWTAuth
-	smsSender phone.SMSSender
+	store        Store
+	tokenKeys    *TokenKeys
+	smsSender    phone.SMSSender
+	smsTemplates *phone.SMSTemplates
+	transactor   transaction.Transactor
 }
 
 // NewService constructor for AuthService
-func NewService(store Store, tokenAuth *jwtauth.JWTAuth, smsSender phone.SMSSender) Service {
-	return Service{store: store, tokenAuth: tokenAuth, smsSender: smsSender}
+func NewService(store Store, tokenKeys *TokenKeys, smsSender phone.SMSSender, smsTemplates *phone.SMSTemplates, transactor transaction.Transactor) Service {
+	return Service{store: store, tokenKeys: tokenKeys, smsSender: smsSender, smsTemplates: smsTemplates, transactor: transactor}
 }
 
 func (as *Service) createNewVerificationCode(ctx context.Context, user *User, phoneNumber string, countryCode string, verificationCodeType string) (*VerificationCode, error) {
 	const op = "auth/service.createNewVerificationCode"
 
-	err := as.store.DeleteVerificationCodeByPhoneNumber(ctx, phoneNumber, countryCode)
+	pendingVerificationCode, err := as.store.GetVerificationCodeByPhoneNumber(ctx, phoneNumber, countryCode)
 
 	if err != nil {
-		return nil, errors.Wrap(op, err, "failed to remove verification code")
+		return nil, errors.Wrap(op, err, "failed to get verification code by phone number")
+	}
+
+	if pendingVerificationCode != nil && time.Since(pendingVerificationCode.CreatedAt) < verificationCodeResendCooldown {
+		return nil, errors.RateLimited(op, "verification code was sent recently, please wait before requesting a new one").WithCode(errors.CodeVerificationCodeResendTooSoon)
 	}
 
 	verificationID := random.String(50)
@@ -39,7 +71,16 @@ func (as *Service) createNewVerificationCode(ctx context.Context, user *User, ph
 
 	verificationCode := NewVerificationCode(verificationID, code, user.ID, phoneNumber, countryCode, verificationCodeType)
 
-	err = as.store.StoreVerificationCode(ctx, verificationCode)
+	// The pending verification code is replaced, so that only one code per phone number can be guessed at a time
+	err = as.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := as.store.DeleteVerificationCodeByPhoneNumber(ctx, phoneNumber, countryCode)
+
+		if err != nil {
+			return errors.Wrap(op, err, "failed to remove verification code")
+		}
+
+		return as.store.StoreVerificationCode(ctx, verificationCode)
+	})
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "failed to store verification code")
@@ -48,21 +89,105 @@ func (as *Service) createNewVerificationCode(ctx context.Context, user *User, ph
 	return verificationCode, nil
 }
 
-func (as *Service) consumeVerificationCode(ctx context.Context, verificationID string, code string) (*VerificationCode, error) {
+// checkVerificationFailures throttles checking codes of the phone number, or from the IP address, after too many wrong codes
+func (as *Service) checkVerificationFailures(ctx context.Context, phoneNumber string, countryCode string, device *Device) error {
+	const op = "auth/service.checkVerificationFailures"
+
+	since := time.Now().Add(-verificationFailureWindow)
+
+	count, err := as.store.CountVerificationFailuresByIPAddress(ctx, device.IPAddress, since)
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to count verification failures by ip address")
+	}
+
+	if count >= verificationMaxFailuresPerIPAddress {
+		return errors.RateLimited(op, "too many failed attempts, please try again later").WithCode(errors.CodeTooManyVerificationAttempts)
+	}
+
+	if phoneNumber == "" {
+		return nil
+	}
+
+	count, err = as.store.CountVerificationFailuresByPhoneNumber(ctx, phoneNumber, countryCode, since)
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to count verification failures by phone number")
+	}
+
+	if count >= verificationMaxFailuresPerPhoneNumber {
+		return errors.RateLimited(op, "too many failed attempts, please try again later").WithCode(errors.CodeTooManyVerificationAttempts)
+	}
+
+	return nil
+}
+
+func (as *Service) recordVerificationFailure(ctx context.Context, phoneNumber string, countryCode string, device *Device) error {
+	const op = "auth/service.recordVerificationFailure"
+
+	err := as.store.StoreVerificationFailure(ctx, NewVerificationFailure(phoneNumber, countryCode, device.IPAddress))
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to store verification failure")
+	}
+
+	return nil
+}
+
+func (as *Service) consumeVerificationCode(ctx context.Context, verificationID string, code string, device *Device) (*VerificationCode, error) {
 	const op = "auth/service.consumeVerificationCode"
 
-	verificationCode, err := as.store.GetVerificationCodeByIDAndCode(ctx, verificationID, code)
+	verificationCode, err := as.store.GetVerificationCodeByVerificationID(ctx, verificationID)
 
 	if err != nil {
 		return nil, errors.Unexpected(op, err, "failed to get verification code")
 	}
 
 	if verificationCode == nil {
-		return nil, errors.Invalid(op, "verification code invalid")
+		err = as.checkVerificationFailures(ctx, "", "", device)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to check verification failures")
+		}
+
+		err = as.recordVerificationFailure(ctx, "", "", device)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to record verification failure")
+		}
+
+		return nil, errors.InvalidField(op, "code", errors.CodeInvalidValue, "verification code invalid")
+	}
+
+	err = as.checkVerificationFailures(ctx, verificationCode.PhoneNumber, verificationCode.CountryCode, device)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to check verification failures")
 	}
 
 	if verificationCode.ExpiredAt.Before(time.Now()) {
-		return nil, errors.Invalid(op, "verification code expired")
+		return nil, errors.InvalidField(op, "code", errors.CodeExpired, "verification code expired")
+	}
+
+	err = as.store.IncrementVerificationCodeAttempts(ctx, verificationCode)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to increment verification code attempts")
+	}
+
+	// The verification is locked once all attempts are used, even for the right code
+	if verificationCode.Attempts > verificationCodeMaxAttempts {
+		return nil, errors.RateLimited(op, "too many failed attempts, please request a new code").WithCode(errors.CodeTooManyVerificationAttempts)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(verificationCode.Code), []byte(code)) != 1 {
+		err = as.recordVerificationFailure(ctx, verificationCode.PhoneNumber, verificationCode.CountryCode, device)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to record verification failure")
+		}
+
+		return nil, errors.InvalidField(op, "code", errors.CodeInvalidValue, "verification code invalid")
 	}
 
 	err = as.store.DeleteVerificationCodeByID(ctx, verificationCode.ID)
@@ -81,9 +206,11 @@ func (as *Service) LoginVerify(ctx context.Context, phoneNumber string, countryC
 	formattedPhoneNumber, err := phone.FormatPhoneNumber(phoneNumber, countryCode)
 
 	if err != nil {
-		return "", errors.Invalid(op, "invalid phone number")
+		return "", errors.InvalidField(op, "phone_number", errors.CodeInvalidFormat, "invalid phone number")
 	}
 
+	countryCode = phone.CountryCodeOf(formattedPhoneNumber, countryCode)
+
 	user, err := as.store.GetUserByPhoneNumber(ctx, formattedPhoneNumber, countryCode)
 
 	if err != nil {
@@ -104,54 +231,340 @@ func (as *Service) LoginVerify(ctx context.Context, phoneNumber string, countryC
 	verificationCode, err := as.createNewVerificationCode(ctx, user, formattedPhoneNumber, countryCode, "LOGIN")
 
 	if err != nil {
-		return "", errors.Unexpected(op, err, "failed to create new verification code")
+		return "", errors.Wrap(op, err, "failed to create new verification code")
 	}
 
-	err = as.smsSender.SendSMS(formattedPhoneNumber, verificationCode.CountryCode, verificationCode.Code)
+	text, err := as.smsTemplates.Render(ctx, "", phone.SMSTypeLoginCode, verificationCode.CountryCode, map[string]string{"code": verificationCode.Code})
 
 	if err != nil {
-		return "", errors.Unexpected(op, err, "failed to send sms")
+		return "", errors.Wrap(op, err, "failed to render sms")
+	}
+
+	err = as.smsSender.SendSMS(ctx, formattedPhoneNumber, verificationCode.CountryCode, text)
+
+	if err != nil {
+		return "", errors.Wrap(op, err, "failed to send sms")
 	}
 
 	return verificationCode.VerificationID, nil
 }
 
-// LoginCheck returns accessToken given the verificationID and code match the persisted verification code
-func (as *Service) LoginCheck(ctx context.Context, verificationID string, code string) (string, error) {
+// LoginCheck starts a session on the device and returns its tokens and the logged in user given the verificationID and code match the persisted verification code
+func (as *Service) LoginCheck(ctx context.Context, verificationID string, code string, device *Device) (*TokenPair, *User, error) {
 	const op = "auth/service.LoginCheck"
 
-	verificationCode, err := as.consumeVerificationCode(ctx, verificationID, code)
+	verificationCode, err := as.consumeVerificationCode(ctx, verificationID, code, device)
 
 	if err != nil {
-		return "", errors.Wrap(op, err, "failed to consume verification code")
+		return nil, nil, errors.Wrap(op, err, "failed to consume verification code")
 	}
 
 	user, err := as.store.GetUserByID(ctx, verificationCode.UserID)
 
 	if err != nil {
-		return "", errors.Unexpected(op, err, "failed to get user by id")
+		return nil, nil, errors.Unexpected(op, err, "failed to get user by id")
 	}
 
 	if user == nil {
-		return "", errors.NotFound(op)
+		return nil, nil, errors.NotFound(op)
 	}
 
 	user.IsPhoneNumberVerified = true
 	user.UpdatedAt = time.Now()
 
-	err = as.store.UpdateUser(ctx, user)
+	session := NewSession(user.ID, device)
+
+	var tokenPair *TokenPair
+
+	err = as.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := as.store.UpdateUser(ctx, user)
+
+		if err != nil {
+			return errors.Unexpected(op, err, "failed to update user")
+		}
+
+		err = as.store.StoreSession(ctx, session)
+
+		if err != nil {
+			return errors.Unexpected(op, err, "failed to store session")
+		}
+
+		tokenPair, err = as.issueTokenPair(ctx, user.ID, session.ID)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "failed to issue tokens")
+	}
+
+	return tokenPair, user, nil
+}
+
+func (as *Service) issueTokenPair(ctx context.Context, userID string, sessionID string) (*TokenPair, error) {
+	const op = "auth/service.issueTokenPair"
+
+	now := time.Now()
+	expiredAt := now.Add(accessTokenExpiry)
+
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"sid":     sessionID,
+		"iat":     now.Unix(),
+		"exp":     expiredAt.Unix(),
+		"jti":     uuid.Must(uuid.New(), nil).String(),
+	}
+
+	accessToken, err := as.tokenKeys.Encode(claims)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to encode access token")
+	}
+
+	token := random.Token(32)
+	refreshToken := NewRefreshToken(token, userID, sessionID)
+
+	err = as.store.StoreRefreshToken(ctx, refreshToken)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to store refresh token")
+	}
+
+	return &TokenPair{AccessToken: accessToken, AccessTokenExpiredAt: expiredAt, RefreshToken: token}, nil
+}
+
+// RefreshToken exchanges the refresh token for a new pair of tokens. Presenting a token that was already exchanged
+// is treated as theft, and the session is revoked
+func (as *Service) RefreshToken(ctx context.Context, token string, device *Device) (*TokenPair, error) {
+	const op = "auth/service.RefreshToken"
+
+	refreshToken, err := as.store.GetRefreshTokenByTokenHash(ctx, hashRefreshToken(token))
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to get refresh token")
+	}
+
+	if refreshToken == nil {
+		return nil, errors.Unauthorized(op, fmt.Errorf("refresh token invalid")).WithCode(errors.CodeRefreshTokenInvalid)
+	}
+
+	if !refreshToken.RevokedAt.IsZero() {
+		return nil, errors.Unauthorized(op, fmt.Errorf("refresh token revoked")).WithCode(errors.CodeRefreshTokenInvalid)
+	}
+
+	now := time.Now()
+
+	if refreshToken.ExpiredAt.Before(now) {
+		return nil, errors.Unauthorized(op, fmt.Errorf("refresh token expired")).WithCode(errors.CodeRefreshTokenInvalid)
+	}
+
+	session, err := as.store.GetSessionByID(ctx, refreshToken.FamilyID)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to get session by id")
+	}
+
+	if session == nil || session.IsRevoked() {
+		return nil, errors.Unauthorized(op, fmt.Errorf("session revoked")).WithCode(errors.CodeSessionRevoked)
+	}
+
+	rotated := false
+
+	var tokenPair *TokenPair
+
+	// The token is only rotated when its successor is stored, so that a failure does not log the device out
+	err = as.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		rotated, err = as.store.RotateRefreshToken(ctx, refreshToken, now)
+
+		if err != nil {
+			return errors.Unexpected(op, err, "failed to rotate refresh token")
+		}
+
+		if rotated == false {
+			return nil
+		}
+
+		session.UserAgent = device.UserAgent
+		session.IPAddress = device.IPAddress
+		session.LastSeenAt = now
+
+		err = as.store.UpdateSession(ctx, session)
+
+		if err != nil {
+			return errors.Unexpected(op, err, "failed to update session")
+		}
+
+		tokenPair, err = as.issueTokenPair(ctx, refreshToken.UserID, refreshToken.FamilyID)
+
+		return err
+	})
 
 	if err != nil {
-		return "", errors.Unexpected(op, err, "failed to update user")
+		return nil, errors.Wrap(op, err, "failed to issue tokens")
 	}
 
-	_, accessToken, err := as.tokenAuth.Encode(jwt.MapClaims{"user_id": user.ID})
+	if rotated == false {
+		err = as.revokeSession(ctx, session)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to revoke session")
+		}
+
+		return nil, errors.Unauthorized(op, fmt.Errorf("refresh token reused")).WithCode(errors.CodeRefreshTokenInvalid)
+	}
+
+	return tokenPair, nil
+}
+
+// Logout revokes the session the refresh token belongs to
+func (as *Service) Logout(ctx context.Context, token string) error {
+	const op = "auth/service.Logout"
+
+	refreshToken, err := as.store.GetRefreshTokenByTokenHash(ctx, hashRefreshToken(token))
 
 	if err != nil {
-		return "", errors.Unexpected(op, err, "failed to encode access token")
+		return errors.Unexpected(op, err, "failed to get refresh token")
+	}
+
+	if refreshToken == nil {
+		return errors.Unauthorized(op, fmt.Errorf("refresh token invalid")).WithCode(errors.CodeRefreshTokenInvalid)
 	}
 
-	return accessToken, nil
+	session, err := as.store.GetSessionByID(ctx, refreshToken.FamilyID)
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to get session by id")
+	}
+
+	if session == nil {
+		return errors.NotFound(op)
+	}
+
+	err = as.revokeSession(ctx, session)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to revoke session")
+	}
+
+	return nil
+}
+
+// revokeSession revokes the session and its refresh tokens. Access tokens of the session are rejected by VerifySession
+func (as *Service) revokeSession(ctx context.Context, session *Session) error {
+	const op = "auth/service.revokeSession"
+
+	now := time.Now()
+
+	return as.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := as.store.RevokeRefreshTokensByFamilyID(ctx, session.ID, now)
+
+		if err != nil {
+			return errors.Unexpected(op, err, "failed to revoke refresh tokens")
+		}
+
+		if session.IsRevoked() {
+			return nil
+		}
+
+		err = as.store.RevokeSession(ctx, session.ID, now)
+
+		if err != nil {
+			return errors.Unexpected(op, err, "failed to revoke session")
+		}
+
+		session.RevokedAt = now
+
+		return nil
+	})
+}
+
+// SessionIDFromContext returns the session of the access token verified for the request
+func SessionIDFromContext(ctx context.Context) string {
+	_, claims, err := jwtauth.FromContext(ctx)
+
+	if err != nil {
+		return ""
+	}
+
+	sessionID, _ := claims["sid"].(string)
+
+	return sessionID
+}
+
+// VerifySession rejects access tokens whose session was revoked, and records the session as seen
+func (as *Service) VerifySession(ctx context.Context) error {
+	const op = "auth/service.VerifySession"
+
+	sessionID := SessionIDFromContext(ctx)
+
+	if sessionID == "" {
+		return errors.Unauthorized(op, fmt.Errorf("missing session"))
+	}
+
+	session, err := as.store.GetSessionByID(ctx, sessionID)
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to get session by id")
+	}
+
+	if session == nil || session.IsRevoked() {
+		return errors.Unauthorized(op, fmt.Errorf("session revoked")).WithCode(errors.CodeSessionRevoked)
+	}
+
+	now := time.Now()
+
+	// Avoid a write on every request
+	if now.Sub(session.LastSeenAt) < sessionLastSeenInterval {
+		return nil
+	}
+
+	session.LastSeenAt = now
+
+	err = as.store.UpdateSession(ctx, session)
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to update session")
+	}
+
+	return nil
+}
+
+// GetSessions returns the sessions the user is logged in with
+func (as *Service) GetSessions(ctx context.Context, currentUser *User) ([]*Session, error) {
+	const op = "auth/service.GetSessions"
+
+	sessions, err := as.store.GetSessionsByUserID(ctx, currentUser.ID)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to get sessions by user id")
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs the user out of the session, e.g. on a lost device
+func (as *Service) RevokeSession(ctx context.Context, id string, currentUser *User) (*Session, error) {
+	const op = "auth/service.RevokeSession"
+
+	session, err := as.store.GetSessionByID(ctx, id)
+
+	if err != nil {
+		return nil, errors.Unexpected(op, err, "failed to get session by id")
+	}
+
+	if session == nil || session.UserID != currentUser.ID {
+		return nil, errors.NotFound(op)
+	}
+
+	err = as.revokeSession(ctx, session)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to revoke session")
+	}
+
+	return session, nil
 }
 
 // GetCurrentUser ...
@@ -170,6 +583,10 @@ func (as *Service) GetCurrentUser(ctx context.Context) (*User, error) {
 		return nil, errors.Unexpected(op, err, "failed to get user by id")
 	}
 
+	if user == nil {
+		return nil, errors.NotFound(op)
+	}
+
 	return user, nil
 }
 
@@ -180,9 +597,11 @@ func (as *Service) UpdatePhoneNumberVerify(ctx context.Context, phoneNumber stri
 	formattedPhoneNumber, err := phone.FormatPhoneNumber(phoneNumber, countryCode)
 
 	if err != nil {
-		return "", errors.Wrap(op, err, "invalid phone number")
+		return "", errors.InvalidField(op, "phone_number", errors.CodeInvalidFormat, "invalid phone number")
 	}
 
+	countryCode = phone.CountryCodeOf(formattedPhoneNumber, countryCode)
+
 	user, err := as.store.GetUserByPhoneNumber(ctx, formattedPhoneNumber, countryCode)
 
 	if err != nil {
@@ -190,29 +609,35 @@ func (as *Service) UpdatePhoneNumberVerify(ctx context.Context, phoneNumber stri
 	}
 
 	if user != nil {
-		return "", errors.Invalid(op, "user with given phone number already exists")
+		return "", errors.Conflict(op, "user with given phone number already exists").WithCode(errors.CodePhoneNumberTaken)
 	}
 
 	verificationCode, err := as.createNewVerificationCode(ctx, currentUser, formattedPhoneNumber, countryCode, "UPDATE")
 
 	if err != nil {
-		return "", errors.Unexpected(op, err, "failed to create new verification code")
+		return "", errors.Wrap(op, err, "failed to create new verification code")
+	}
+
+	text, err := as.smsTemplates.Render(ctx, "", phone.SMSTypePhoneNumberChange, verificationCode.CountryCode, map[string]string{"code": verificationCode.Code})
+
+	if err != nil {
+		return "", errors.Wrap(op, err, "failed to render sms")
 	}
 
-	err = as.smsSender.SendSMS(formattedPhoneNumber, verificationCode.CountryCode, verificationCode.Code)
+	err = as.smsSender.SendSMS(ctx, formattedPhoneNumber, verificationCode.CountryCode, text)
 
 	if err != nil {
-		return "", errors.Unexpected(op, err, "failed to send sms")
+		return "", errors.Wrap(op, err, "failed to send sms")
 	}
 
 	return verificationCode.VerificationID, nil
 }
 
 // UpdatePhoneNumberCheck ...
-func (as *Service) UpdatePhoneNumberCheck(ctx context.Context, verificationID string, code string, currentUser *User) (*User, error) {
+func (as *Service) UpdatePhoneNumberCheck(ctx context.Context, verificationID string, code string, device *Device, currentUser *User) (*User, error) {
 	const op = "auth/service.UpdatePhoneNumberCheck"
 
-	verificationCode, err := as.consumeVerificationCode(ctx, verificationID, code)
+	verificationCode, err := as.consumeVerificationCode(ctx, verificationID, code, device)
 
 	if err != nil {
 		return nil, errors.Wrap(op, err, "failed to consume verification code")
@@ -229,7 +654,7 @@ func (as *Service) UpdatePhoneNumberCheck(ctx context.Context, verificationID st
 	}
 
 	if currentUser.ID != user.ID {
-		return nil, errors.Unauthorized(op, fmt.Errorf("current user not owner"))
+		return nil, errors.Forbidden(op, fmt.Errorf("current user not owner"))
 	}
 
 	user.UpdatedAt = time.Now()
@@ -251,9 +676,61 @@ type UpdateUserProfileInput struct {
 	ProfileImageID string `json:"image_id"`
 }
 
-// UpdateUserProfile ...
+// Validate checks the fields of the input
+func (input *UpdateUserProfileInput) Validate() error {
+	const op = "auth/UpdateUserProfileInput.Validate"
+
+	v := &errors.Validation{}
+	v.NotBlank("full_name", input.FullName)
+
+	return v.Err(op)
+}
+
+// UpdateUserProfile updates the profile of the current user. Empty fields of the input are left unchanged
 func (as *Service) UpdateUserProfile(ctx context.Context, input *UpdateUserProfileInput, currentUser *User) (*User, error) {
-	const op = "auth/service.UpdatePhoneNumberCheck"
+	const op = "auth/service.UpdateUserProfile"
+
+	err := input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
+
+	user, err := as.PatchUserProfile(ctx, &PatchUserProfileInput{FullName: patch.Changed(input.FullName), ProfileImageID: patch.Changed(input.ProfileImageID)}, currentUser)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to patch user profile")
+	}
+
+	return user, nil
+}
+
+// PatchUserProfileInput is a merge patch of the profile of a user
+type PatchUserProfileInput struct {
+	FullName       patch.String `json:"full_name"`
+	ProfileImageID patch.String `json:"image_id"`
+}
+
+// Validate checks the fields of the input
+func (input *PatchUserProfileInput) Validate() error {
+	const op = "auth/PatchUserProfileInput.Validate"
+
+	v := &errors.Validation{}
+	input.FullName.Optional(v, "full_name")
+	input.ProfileImageID.Optional(v, "image_id")
+
+	return v.Err(op)
+}
+
+// PatchUserProfile changes the fields of the profile of the current user in the input, and removes those that are null
+func (as *Service) PatchUserProfile(ctx context.Context, input *PatchUserProfileInput, currentUser *User) (*User, error) {
+	const op = "auth/service.PatchUserProfile"
+
+	err := input.Validate()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "invalid input")
+	}
 
 	user, err := as.store.GetUserByID(ctx, currentUser.ID)
 
@@ -261,19 +738,23 @@ func (as *Service) UpdateUserProfile(ctx context.Context, input *UpdateUserProfi
 		return nil, errors.Wrap(op, err, "failed to get user by id")
 	}
 
+	if user == nil {
+		return nil, errors.NotFound(op)
+	}
+
 	user.UpdatedAt = time.Now()
 
-	if input.FullName != "" {
-		user.FullName = strings.TrimSpace(input.FullName)
+	if input.FullName.Set {
+		user.FullName = strings.TrimSpace(input.FullName.Value)
 	}
-	if input.ProfileImageID != "" {
-		user.ProfileImageID = input.ProfileImageID
+	if input.ProfileImageID.Set {
+		user.ProfileImageID = input.ProfileImageID.Value
 	}
 
 	err = as.store.UpdateUser(ctx, user)
 
 	if err != nil {
-		return nil, errors.Unexpected(op, err, "failed to update user")
+		return nil, errors.Wrap(op, err, "failed to update user")
 	}
 
 	return user, nil
@@ -2,23 +2,31 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
 	"testing"
 	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/go-chi/jwtauth"
 	"github.com/google/uuid"
 	"github.com/minheq/kedul_server_main/errors"
 	"github.com/minheq/kedul_server_main/phone"
+	"github.com/minheq/kedul_server_main/phone/fakegateway"
 )
 
 type mockAuthStore struct {
-	users             []*User
-	verificationCodes []*VerificationCode
+	users                []*User
+	verificationCodes    []*VerificationCode
+	refreshTokens        []*RefreshToken
+	sessions             []*Session
+	verificationFailures []*VerificationFailure
 }
 
-func (s *mockAuthStore) GetVerificationCodeByIDAndCode(ctx context.Context, verificationID string, code string) (*VerificationCode, error) {
+func (s *mockAuthStore) GetVerificationCodeByVerificationID(ctx context.Context, verificationID string) (*VerificationCode, error) {
 	for _, v := range s.verificationCodes {
-		if v.VerificationID == verificationID && v.Code == code {
+		if v.VerificationID == verificationID {
 			return v, nil
 		}
 	}
@@ -26,6 +34,52 @@ func (s *mockAuthStore) GetVerificationCodeByIDAndCode(ctx context.Context, veri
 	return nil, nil
 }
 
+func (s *mockAuthStore) GetVerificationCodeByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) (*VerificationCode, error) {
+	for _, v := range s.verificationCodes {
+		if v.PhoneNumber == phoneNumber && v.CountryCode == countryCode {
+			return v, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *mockAuthStore) IncrementVerificationCodeAttempts(ctx context.Context, vc *VerificationCode) error {
+	vc.Attempts++
+
+	return nil
+}
+
+func (s *mockAuthStore) StoreVerificationFailure(ctx context.Context, failure *VerificationFailure) error {
+	s.verificationFailures = append(s.verificationFailures, failure)
+
+	return nil
+}
+
+func (s *mockAuthStore) CountVerificationFailuresByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string, since time.Time) (int, error) {
+	count := 0
+
+	for _, f := range s.verificationFailures {
+		if f.PhoneNumber == phoneNumber && f.CountryCode == countryCode && !f.CreatedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *mockAuthStore) CountVerificationFailuresByIPAddress(ctx context.Context, ipAddress string, since time.Time) (int, error) {
+	count := 0
+
+	for _, f := range s.verificationFailures {
+		if f.IPAddress == ipAddress && !f.CreatedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
 func (s *mockAuthStore) StoreVerificationCode(ctx context.Context, vc *VerificationCode) error {
 	s.verificationCodes = append(s.verificationCodes, vc)
 
@@ -91,20 +145,138 @@ func (s *mockAuthStore) UpdateUser(ctx context.Context, user *User) error {
 	return nil
 }
 
+func (s *mockAuthStore) GetRefreshTokenByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	for _, rt := range s.refreshTokens {
+		if rt.TokenHash == tokenHash {
+			return rt, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *mockAuthStore) StoreRefreshToken(ctx context.Context, refreshToken *RefreshToken) error {
+	s.refreshTokens = append(s.refreshTokens, refreshToken)
+
+	return nil
+}
+
+func (s *mockAuthStore) RotateRefreshToken(ctx context.Context, refreshToken *RefreshToken, rotatedAt time.Time) (bool, error) {
+	for _, rt := range s.refreshTokens {
+		if rt.ID == refreshToken.ID && rt.RotatedAt.IsZero() && rt.RevokedAt.IsZero() {
+			rt.RotatedAt = rotatedAt
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *mockAuthStore) RevokeRefreshTokensByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error {
+	for _, rt := range s.refreshTokens {
+		if rt.FamilyID == familyID && rt.RevokedAt.IsZero() {
+			rt.RevokedAt = revokedAt
+		}
+	}
+
+	return nil
+}
+
+func (s *mockAuthStore) GetSessionsByUserID(ctx context.Context, userID string) ([]*Session, error) {
+	sessions := make([]*Session, 0)
+
+	for _, session := range s.sessions {
+		if session.UserID == userID && !session.IsRevoked() {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (s *mockAuthStore) GetSessionByID(ctx context.Context, id string) (*Session, error) {
+	for _, session := range s.sessions {
+		if session.ID == id {
+			return session, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *mockAuthStore) StoreSession(ctx context.Context, session *Session) error {
+	s.sessions = append(s.sessions, session)
+
+	return nil
+}
+
+func (s *mockAuthStore) UpdateSession(ctx context.Context, session *Session) error {
+	for _, existing := range s.sessions {
+		if existing.ID == session.ID && !existing.IsRevoked() {
+			existing.UserAgent = session.UserAgent
+			existing.IPAddress = session.IPAddress
+			existing.LastSeenAt = session.LastSeenAt
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *mockAuthStore) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	for _, existing := range s.sessions {
+		if existing.ID == id && !existing.IsRevoked() {
+			existing.RevokedAt = revokedAt
+			break
+		}
+	}
+
+	return nil
+}
+
+// mockTransactor runs functions without a transaction, as the mock store cannot roll back
+type mockTransactor struct{}
+
+func (t *mockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type mockSMSMessageStore struct {
+	smsMessages []*phone.SMSMessage
+}
+
+func (s *mockSMSMessageStore) GetSMSMessageByProviderMessageID(ctx context.Context, provider string, providerMessageID string) (*phone.SMSMessage, error) {
+	return nil, nil
+}
+
+func (s *mockSMSMessageStore) StoreSMSMessage(ctx context.Context, smsMessage *phone.SMSMessage) error {
+	s.smsMessages = append(s.smsMessages, smsMessage)
+
+	return nil
+}
+
+func (s *mockSMSMessageStore) UpdateSMSMessage(ctx context.Context, smsMessage *phone.SMSMessage) error {
+	return nil
+}
+
+var codeRegexp = regexp.MustCompile(`\d{6}`)
+
 type smsSenderMock struct {
 	Text string
+	Code string
 }
 
-func (s *smsSenderMock) SendSMS(phoneNumber string, countryCode string, text string) error {
+func (s *smsSenderMock) SendSMS(ctx context.Context, phoneNumber string, countryCode string, text string) error {
 	s.Text = text
+	s.Code = codeRegexp.FindString(text)
 	return nil
 }
 
 func TestLoginHappyPath(t *testing.T) {
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenKeys, _ := GenerateTokenKeys()
 	ms := &mockAuthStore{}
 	smsSender := &smsSenderMock{}
-	as := NewService(ms, tokenAuth, smsSender)
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
 
 	var code string
 	var verificationID string
@@ -112,7 +284,7 @@ func TestLoginHappyPath(t *testing.T) {
 
 	t.Run("should send code and return verificationID when login start", func(t *testing.T) {
 		verificationID, err = as.LoginVerify(context.Background(), "999111333", "VN")
-		code = smsSender.Text
+		code = smsSender.Code
 
 		if err != nil {
 			t.Error(err)
@@ -126,24 +298,80 @@ func TestLoginHappyPath(t *testing.T) {
 	})
 
 	t.Run("should return access token when login verified", func(t *testing.T) {
-		accessToken, err := as.LoginCheck(context.Background(), verificationID, code)
+		tokenPair, _, err := as.LoginCheck(context.Background(), verificationID, code, testDevice)
 
 		if err != nil {
 			t.Error(err)
 			return
 		}
 
-		if accessToken == "" {
+		if tokenPair.AccessToken == "" {
 			t.Error("missing access token")
+			return
+		}
+
+		if tokenPair.RefreshToken == "" {
+			t.Error("missing refresh token")
+			return
+		}
+
+		token, err := tokenKeys.Decode(tokenPair.AccessToken)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		claims := token.Claims.(jwt.MapClaims)
+
+		if claims.VerifyExpiresAt(time.Now().Add(accessTokenExpiry+time.Minute).Unix(), true) {
+			t.Error("access token should expire")
+		}
+
+		if claims["jti"] == nil || claims["iat"] == nil {
+			t.Error("access token should have jti and iat claims")
 		}
 	})
 }
 
+func TestLoginSamePhoneNumberFromOtherCountry(t *testing.T) {
+	tokenKeys, _ := GenerateTokenKeys()
+	ms := &mockAuthStore{}
+	smsSender := &smsSenderMock{}
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
+
+	_, err := as.LoginVerify(context.Background(), "0901234567", "VN")
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Age the code so that another can be sent
+	ms.verificationCodes[0].CreatedAt = time.Now().Add(-verificationCodeResendCooldown)
+
+	_, err = as.LoginVerify(context.Background(), "+84 90 123 4567", "US")
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(ms.users) != 1 {
+		t.Errorf("expected 1 user, got %d", len(ms.users))
+		return
+	}
+
+	if ms.users[0].PhoneNumber != "+84901234567" || ms.users[0].CountryCode != "VN" {
+		t.Errorf("phone number should be stored in E.164 format, got %s %s", ms.users[0].PhoneNumber, ms.users[0].CountryCode)
+	}
+}
+
 func TestLoginWithExpiredVerificationCode(t *testing.T) {
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenKeys, _ := GenerateTokenKeys()
 	ms := &mockAuthStore{}
 	smsSender := &smsSenderMock{}
-	as := NewService(ms, tokenAuth, smsSender)
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
 
 	now := time.Now()
 
@@ -177,7 +405,7 @@ func TestLoginWithExpiredVerificationCode(t *testing.T) {
 	}
 
 	t.Run("should return error when log in verify with expired verification code", func(t *testing.T) {
-		_, err := as.LoginCheck(context.Background(), expiredVerificationCode.VerificationID, expiredVerificationCode.Code)
+		_, _, err := as.LoginCheck(context.Background(), expiredVerificationCode.VerificationID, expiredVerificationCode.Code, testDevice)
 
 		if errors.Is(errors.KindInvalid, err) == false {
 			t.Error("error should be invalid kind")
@@ -186,10 +414,10 @@ func TestLoginWithExpiredVerificationCode(t *testing.T) {
 }
 
 func TestLoginVerifyTwice(t *testing.T) {
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenKeys, _ := GenerateTokenKeys()
 	ms := &mockAuthStore{}
 	smsSender := &smsSenderMock{}
-	as := NewService(ms, tokenAuth, smsSender)
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
 
 	var codeOne string
 	var verificationIDOne string
@@ -199,7 +427,7 @@ func TestLoginVerifyTwice(t *testing.T) {
 
 	t.Run("should send code and return verificationID when login start first time", func(t *testing.T) {
 		verificationIDOne, err = as.LoginVerify(context.Background(), "999111334", "VN")
-		codeOne = smsSender.Text
+		codeOne = smsSender.Code
 
 		if err != nil {
 			t.Error(err)
@@ -211,10 +439,20 @@ func TestLoginVerifyTwice(t *testing.T) {
 		}
 	})
 
+	t.Run("should not resend code before cooldown", func(t *testing.T) {
+		_, err := as.LoginVerify(context.Background(), "999111334", "VN")
+
+		if errors.Is(errors.KindRateLimited, err) == false {
+			t.Error("error should be rate limited kind")
+		}
+	})
+
 	// This behaves like "resending"
 	t.Run("should send different code and verificationID when login start second time", func(t *testing.T) {
+		ms.verificationCodes[0].CreatedAt = time.Now().Add(-verificationCodeResendCooldown)
+
 		verificationIDTwo, err = as.LoginVerify(context.Background(), "999111334", "VN")
-		codeTwo = smsSender.Text
+		codeTwo = smsSender.Code
 
 		if err != nil {
 			t.Error(err)
@@ -233,10 +471,10 @@ func TestLoginVerifyTwice(t *testing.T) {
 }
 
 func TestUpdatePhoneNumberHappyPath(t *testing.T) {
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenKeys, _ := GenerateTokenKeys()
 	ms := &mockAuthStore{}
 	smsSender := &smsSenderMock{}
-	as := NewService(ms, tokenAuth, smsSender)
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
 
 	var code string
 	var verificationID string
@@ -255,7 +493,7 @@ func TestUpdatePhoneNumberHappyPath(t *testing.T) {
 
 	t.Run("should send code and return verificationID when login start", func(t *testing.T) {
 		verificationID, err = as.UpdatePhoneNumberVerify(context.Background(), newPhoneNumber, "VN", currentUser)
-		code = smsSender.Text
+		code = smsSender.Code
 
 		if err != nil {
 			t.Error(err)
@@ -269,7 +507,7 @@ func TestUpdatePhoneNumberHappyPath(t *testing.T) {
 	})
 
 	t.Run("should return access token when login verified", func(t *testing.T) {
-		user, err := as.UpdatePhoneNumberCheck(context.Background(), verificationID, code, currentUser)
+		user, err := as.UpdatePhoneNumberCheck(context.Background(), verificationID, code, testDevice, currentUser)
 
 		if err != nil {
 			t.Error(err)
@@ -283,10 +521,10 @@ func TestUpdatePhoneNumberHappyPath(t *testing.T) {
 }
 
 func TestUpdateUserProfileHappyPath(t *testing.T) {
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenKeys, _ := GenerateTokenKeys()
 	ms := &mockAuthStore{}
 	smsSender := &smsSenderMock{}
-	as := NewService(ms, tokenAuth, smsSender)
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
 
 	phoneNumber, err := phone.FormatPhoneNumber("999111337", "VN")
 
@@ -320,3 +558,346 @@ func TestUpdateUserProfileHappyPath(t *testing.T) {
 		}
 	})
 }
+
+func TestPatchUserProfile(t *testing.T) {
+	tokenKeys, _ := GenerateTokenKeys()
+	ms := &mockAuthStore{}
+	as := NewService(ms, tokenKeys, &smsSenderMock{}, phone.NewSMSTemplates(nil), &mockTransactor{})
+
+	phoneNumber, _ := phone.FormatPhoneNumber("999111338", "VN")
+	currentUser := NewUser(phoneNumber, "VN")
+	currentUser.FullName = "name"
+	currentUser.ProfileImageID = "image"
+
+	err := ms.StoreUser(context.Background(), currentUser)
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("should remove null fields and leave absent fields unchanged", func(t *testing.T) {
+		input := &PatchUserProfileInput{}
+
+		err := json.Unmarshal([]byte(`{"image_id": null}`), input)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		user, err := as.PatchUserProfile(context.Background(), input, currentUser)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if user.FullName != "name" || user.ProfileImageID != "" {
+			t.Errorf("expected full name without profile image, got %+v", user)
+		}
+	})
+
+	t.Run("should remove full name", func(t *testing.T) {
+		input := &PatchUserProfileInput{}
+
+		err := json.Unmarshal([]byte(`{"full_name": null}`), input)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		user, err := as.PatchUserProfile(context.Background(), input, currentUser)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if user.FullName != "" {
+			t.Errorf("expected no full name, got %q", user.FullName)
+		}
+	})
+}
+
+var testDevice = &Device{UserAgent: "test", IPAddress: "127.0.0.1"}
+
+func login(t *testing.T, as Service, smsSender *smsSenderMock) *TokenPair {
+	verificationID, err := as.LoginVerify(context.Background(), "999111333", "VN")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenPair, _, err := as.LoginCheck(context.Background(), verificationID, smsSender.Code, testDevice)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tokenPair
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	tokenKeys, _ := GenerateTokenKeys()
+	ms := &mockAuthStore{}
+	smsSender := &smsSenderMock{}
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
+
+	tokenPair := login(t, as, smsSender)
+	var rotatedTokenPair *TokenPair
+
+	t.Run("should rotate refresh token", func(t *testing.T) {
+		var err error
+		rotatedTokenPair, err = as.RefreshToken(context.Background(), tokenPair.RefreshToken, testDevice)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if rotatedTokenPair.RefreshToken == tokenPair.RefreshToken {
+			t.Error("refresh token should be rotated")
+		}
+	})
+
+	t.Run("should revoke token family when refresh token reused", func(t *testing.T) {
+		_, err := as.RefreshToken(context.Background(), tokenPair.RefreshToken, testDevice)
+
+		if errors.Is(errors.KindUnauthorized, err) == false {
+			t.Error("error should be unauthorized kind")
+			return
+		}
+
+		_, err = as.RefreshToken(context.Background(), rotatedTokenPair.RefreshToken, testDevice)
+
+		if errors.Is(errors.KindUnauthorized, err) == false {
+			t.Error("rotated refresh token should be revoked")
+		}
+	})
+
+	t.Run("should reject expired refresh token", func(t *testing.T) {
+		expiredTokenPair := login(t, as, smsSender)
+
+		refreshToken, _ := ms.GetRefreshTokenByTokenHash(context.Background(), hashRefreshToken(expiredTokenPair.RefreshToken))
+		refreshToken.ExpiredAt = time.Now().Add(-time.Minute)
+
+		_, err := as.RefreshToken(context.Background(), expiredTokenPair.RefreshToken, testDevice)
+
+		if errors.Is(errors.KindUnauthorized, err) == false {
+			t.Error("error should be unauthorized kind")
+		}
+	})
+}
+
+func TestLogout(t *testing.T) {
+	tokenKeys, _ := GenerateTokenKeys()
+	ms := &mockAuthStore{}
+	smsSender := &smsSenderMock{}
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
+
+	tokenPair := login(t, as, smsSender)
+
+	t.Run("should revoke refresh token on logout", func(t *testing.T) {
+		err := as.Logout(context.Background(), tokenPair.RefreshToken)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		_, err = as.RefreshToken(context.Background(), tokenPair.RefreshToken, testDevice)
+
+		if errors.Is(errors.KindUnauthorized, err) == false {
+			t.Error("error should be unauthorized kind")
+		}
+	})
+}
+
+func contextWithAccessToken(t *testing.T, tokenKeys *TokenKeys, accessToken string) context.Context {
+	token, err := tokenKeys.Decode(accessToken)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return jwtauth.NewContext(context.Background(), token, nil)
+}
+
+func TestSessions(t *testing.T) {
+	tokenKeys, _ := GenerateTokenKeys()
+	ms := &mockAuthStore{}
+	smsSender := &smsSenderMock{}
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
+
+	tokenPair := login(t, as, smsSender)
+	otherTokenPair := login(t, as, smsSender)
+	ctx := contextWithAccessToken(t, tokenKeys, tokenPair.AccessToken)
+	otherCtx := contextWithAccessToken(t, tokenKeys, otherTokenPair.AccessToken)
+	user := ms.users[0]
+
+	t.Run("should list sessions of user", func(t *testing.T) {
+		sessions, err := as.GetSessions(ctx, user)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(sessions) != 2 {
+			t.Errorf("there should be 2 sessions. received=%d", len(sessions))
+		}
+	})
+
+	t.Run("should not revoke session of other user", func(t *testing.T) {
+		otherUser := NewUser("999111444", "VN")
+
+		_, err := as.RevokeSession(ctx, SessionIDFromContext(otherCtx), otherUser)
+
+		if errors.Is(errors.KindNotFound, err) == false {
+			t.Error("error should be not found kind")
+		}
+	})
+
+	t.Run("should reject access token of revoked session", func(t *testing.T) {
+		_, err := as.RevokeSession(ctx, SessionIDFromContext(otherCtx), user)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = as.VerifySession(otherCtx)
+
+		if errors.Is(errors.KindUnauthorized, err) == false {
+			t.Error("error should be unauthorized kind")
+			return
+		}
+
+		_, err = as.RefreshToken(ctx, otherTokenPair.RefreshToken, testDevice)
+
+		if errors.Is(errors.KindUnauthorized, err) == false {
+			t.Error("refresh token of revoked session should be rejected")
+			return
+		}
+
+		err = as.VerifySession(ctx)
+
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestVerificationCodeBruteForce(t *testing.T) {
+	tokenKeys, _ := GenerateTokenKeys()
+	ms := &mockAuthStore{}
+	smsSender := &smsSenderMock{}
+	as := NewService(ms, tokenKeys, smsSender, phone.NewSMSTemplates(nil), &mockTransactor{})
+
+	verificationID, err := as.LoginVerify(context.Background(), "999111337", "VN")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := smsSender.Code
+	wrongCode := "000000"
+
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+
+	t.Run("should lock verification after too many wrong codes", func(t *testing.T) {
+		for i := 0; i < verificationCodeMaxAttempts; i++ {
+			_, _, err := as.LoginCheck(context.Background(), verificationID, wrongCode, testDevice)
+
+			if errors.Is(errors.KindInvalid, err) == false {
+				t.Error("error should be invalid kind")
+				return
+			}
+		}
+
+		_, _, err := as.LoginCheck(context.Background(), verificationID, code, testDevice)
+
+		if errors.Is(errors.KindRateLimited, err) == false {
+			t.Error("error should be rate limited kind")
+		}
+	})
+
+	t.Run("should throttle phone number across codes", func(t *testing.T) {
+		ms.verificationCodes[0].CreatedAt = time.Now().Add(-verificationCodeResendCooldown)
+
+		verificationID, err := as.LoginVerify(context.Background(), "999111337", "VN")
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		otherDevice := &Device{IPAddress: "127.0.0.2"}
+
+		for i := verificationCodeMaxAttempts; i < verificationMaxFailuresPerPhoneNumber; i++ {
+			_, _, err = as.LoginCheck(context.Background(), verificationID, wrongCode, otherDevice)
+		}
+
+		_, _, err = as.LoginCheck(context.Background(), verificationID, smsSender.Code, otherDevice)
+
+		if errors.Is(errors.KindRateLimited, err) == false {
+			t.Error("error should be rate limited kind")
+		}
+	})
+
+	t.Run("should throttle ip address", func(t *testing.T) {
+		for i := 0; i < verificationMaxFailuresPerIPAddress; i++ {
+			_, _, err = as.LoginCheck(context.Background(), "unknown", wrongCode, testDevice)
+		}
+
+		_, _, err = as.LoginCheck(context.Background(), "unknown", wrongCode, testDevice)
+
+		if errors.Is(errors.KindRateLimited, err) == false {
+			t.Error("error should be rate limited kind")
+		}
+	})
+}
+
+func TestLoginThroughSMSGateway(t *testing.T) {
+	gateway := fakegateway.NewServer()
+	defer gateway.Close()
+
+	config := &phone.SMSConfig{Provider: "http", GatewayURL: gateway.URL, GatewayAPIKey: fakegateway.APIKey, MaxAttempts: 2}
+	provider, err := phone.NewSMSProvider(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenKeys, _ := GenerateTokenKeys()
+	ms := &mockAuthStore{}
+	as := NewService(ms, tokenKeys, phone.NewProviderSMSSender(provider, &mockSMSMessageStore{}, config), phone.NewSMSTemplates(nil), &mockTransactor{})
+
+	t.Run("should log in with code delivered by gateway", func(t *testing.T) {
+		gateway.FailNext(http.StatusBadGateway)
+
+		verificationID, err := as.LoginVerify(context.Background(), "999111338", "VN")
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		message := gateway.LastMessage("+84999111338")
+
+		if message == nil {
+			t.Error("gateway should receive code")
+			return
+		}
+
+		_, _, err = as.LoginCheck(context.Background(), verificationID, codeRegexp.FindString(message.Text), testDevice)
+
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
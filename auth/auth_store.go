@@ -3,20 +3,37 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 // Store ...
 type Store interface {
-	GetVerificationCodeByIDAndCode(ctx context.Context, verificationID string, code string) (*VerificationCode, error)
+	GetVerificationCodeByVerificationID(ctx context.Context, verificationID string) (*VerificationCode, error)
+	GetVerificationCodeByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) (*VerificationCode, error)
 	StoreVerificationCode(ctx context.Context, vc *VerificationCode) error
+	IncrementVerificationCodeAttempts(ctx context.Context, vc *VerificationCode) error
 	DeleteVerificationCodeByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) error
 	DeleteVerificationCodeByID(ctx context.Context, id string) error
+	StoreVerificationFailure(ctx context.Context, failure *VerificationFailure) error
+	CountVerificationFailuresByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string, since time.Time) (int, error)
+	CountVerificationFailuresByIPAddress(ctx context.Context, ipAddress string, since time.Time) (int, error)
 	GetUserByID(ctx context.Context, id string) (*User, error)
 	GetUserByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) (*User, error)
 	StoreUser(ctx context.Context, user *User) error
 	UpdateUser(ctx context.Context, user *User) error
+	GetRefreshTokenByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	StoreRefreshToken(ctx context.Context, refreshToken *RefreshToken) error
+	RotateRefreshToken(ctx context.Context, refreshToken *RefreshToken, rotatedAt time.Time) (bool, error)
+	RevokeRefreshTokensByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error
+	GetSessionsByUserID(ctx context.Context, userID string) ([]*Session, error)
+	GetSessionByID(ctx context.Context, id string) (*Session, error)
+	StoreSession(ctx context.Context, session *Session) error
+	UpdateSession(ctx context.Context, session *Session) error
+	RevokeSession(ctx context.Context, id string, revokedAt time.Time) error
 }
 
 // store ...
@@ -29,22 +46,57 @@ func NewStore(db *sql.DB) Store {
 	return &store{db: db}
 }
 
-// GetVerificationCodeByIDAndCode gets VerificationCode by code
-func (s *store) GetVerificationCodeByIDAndCode(ctx context.Context, verificationID string, code string) (*VerificationCode, error) {
-	const op = "auth/store.GetVerificationCodeByIDAndCode"
+func scanVerificationCode(row rowScanner) (*VerificationCode, error) {
+	vc := &VerificationCode{}
+
+	err := row.Scan(&vc.ID, &vc.UserID, &vc.Code, &vc.VerificationID, &vc.CodeType, &vc.PhoneNumber, &vc.CountryCode, &vc.Attempts, &vc.ExpiredAt, &vc.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+// GetVerificationCodeByVerificationID gets VerificationCode by verification id
+func (s *store) GetVerificationCodeByVerificationID(ctx context.Context, verificationID string) (*VerificationCode, error) {
+	const op = "auth/store.GetVerificationCodeByVerificationID"
 
 	query := `
-		SELECT id, user_id, code, verification_id, code_type, phone_number, country_code, expired_at, created_at
+		SELECT id, user_id, code, verification_id, code_type, phone_number, country_code, attempts, expired_at, created_at
 		FROM verification_code
-		WHERE verification_id=$1
-			AND code=$2;
+		WHERE verification_id=$1;
 	`
 
-	var vc VerificationCode
+	row := transaction.DB(ctx, s.db).QueryRow(query, verificationID)
+
+	vc, err := scanVerificationCode(row)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return vc, nil
+}
+
+// GetVerificationCodeByPhoneNumber gets the pending VerificationCode of the phone number
+func (s *store) GetVerificationCodeByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) (*VerificationCode, error) {
+	const op = "auth/store.GetVerificationCodeByPhoneNumber"
+
+	query := `
+		SELECT id, user_id, code, verification_id, code_type, phone_number, country_code, attempts, expired_at, created_at
+		FROM verification_code
+		WHERE phone_number=$1
+			AND country_code=$2;
+	`
 
-	row := s.db.QueryRow(query, verificationID, code)
+	row := transaction.DB(ctx, s.db).QueryRow(query, phoneNumber, countryCode)
 
-	err := row.Scan(&vc.ID, &vc.UserID, &vc.Code, &vc.VerificationID, &vc.CodeType, &vc.PhoneNumber, &vc.CountryCode, &vc.ExpiredAt, &vc.CreatedAt)
+	vc, err := scanVerificationCode(row)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -54,7 +106,28 @@ func (s *store) GetVerificationCodeByIDAndCode(ctx context.Context, verification
 		return nil, errors.Wrap(op, err, "database error")
 	}
 
-	return &vc, nil
+	return vc, nil
+}
+
+// IncrementVerificationCodeAttempts counts an attempt of checking the VerificationCode. The count is incremented
+// in the database so that concurrent attempts cannot exceed the limit
+func (s *store) IncrementVerificationCodeAttempts(ctx context.Context, vc *VerificationCode) error {
+	const op = "auth/store.IncrementVerificationCodeAttempts"
+
+	query := `
+		UPDATE verification_code
+		SET attempts=attempts + 1
+		WHERE id=$1
+		RETURNING attempts;
+	`
+
+	err := transaction.DB(ctx, s.db).QueryRow(query, vc.ID).Scan(&vc.Attempts)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
 }
 
 // StoreVerificationCode persists VerificationCode
@@ -62,11 +135,11 @@ func (s *store) StoreVerificationCode(ctx context.Context, vc *VerificationCode)
 	const op = "auth/store.StoreVerificationCode"
 
 	query := `
-		INSERT INTO verification_code (id, user_id, code, verification_id, code_type, phone_number, country_code, expired_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO verification_code (id, user_id, code, verification_id, code_type, phone_number, country_code, attempts, expired_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
-	_, err := s.db.Exec(query, vc.ID, vc.UserID, vc.Code, vc.VerificationID, vc.CodeType, vc.PhoneNumber, vc.CountryCode, vc.ExpiredAt, vc.CreatedAt)
+	_, err := transaction.DB(ctx, s.db).Exec(query, vc.ID, vc.UserID, vc.Code, vc.VerificationID, vc.CodeType, vc.PhoneNumber, vc.CountryCode, vc.Attempts, vc.ExpiredAt, vc.CreatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
@@ -84,7 +157,7 @@ func (s *store) DeleteVerificationCodeByPhoneNumber(ctx context.Context, phoneNu
 		WHERE phone_number=$1 AND country_code=$2;
 	`
 
-	_, err := s.db.Exec(query, phoneNumber, countryCode)
+	_, err := transaction.DB(ctx, s.db).Exec(query, phoneNumber, countryCode)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
@@ -102,7 +175,25 @@ func (s *store) DeleteVerificationCodeByID(ctx context.Context, id string) error
 		WHERE id=$1;
 	`
 
-	_, err := s.db.Exec(query, id)
+	_, err := transaction.DB(ctx, s.db).Exec(query, id)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// StoreVerificationFailure persists VerificationFailure
+func (s *store) StoreVerificationFailure(ctx context.Context, failure *VerificationFailure) error {
+	const op = "auth/store.StoreVerificationFailure"
+
+	query := `
+		INSERT INTO verification_failure (id, phone_number, country_code, ip_address, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, failure.ID, failure.PhoneNumber, failure.CountryCode, failure.IPAddress, failure.CreatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
@@ -111,26 +202,71 @@ func (s *store) DeleteVerificationCodeByID(ctx context.Context, id string) error
 	return nil
 }
 
+// CountVerificationFailuresByPhoneNumber counts VerificationFailures of the phone number since the time
+func (s *store) CountVerificationFailuresByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string, since time.Time) (int, error) {
+	const op = "auth/store.CountVerificationFailuresByPhoneNumber"
+
+	query := `
+		SELECT COUNT(*)
+		FROM verification_failure
+		WHERE phone_number=$1
+			AND country_code=$2
+			AND created_at >= $3;
+	`
+
+	var count int
+
+	err := transaction.DB(ctx, s.db).QueryRow(query, phoneNumber, countryCode, since).Scan(&count)
+
+	if err != nil {
+		return 0, errors.Wrap(op, err, "database error")
+	}
+
+	return count, nil
+}
+
+// CountVerificationFailuresByIPAddress counts VerificationFailures from the IP address since the time
+func (s *store) CountVerificationFailuresByIPAddress(ctx context.Context, ipAddress string, since time.Time) (int, error) {
+	const op = "auth/store.CountVerificationFailuresByIPAddress"
+
+	query := `
+		SELECT COUNT(*)
+		FROM verification_failure
+		WHERE ip_address=$1
+			AND created_at >= $2;
+	`
+
+	var count int
+
+	err := transaction.DB(ctx, s.db).QueryRow(query, ipAddress, since).Scan(&count)
+
+	if err != nil {
+		return 0, errors.Wrap(op, err, "database error")
+	}
+
+	return count, nil
+}
+
 // GetUserByID gets User by ID
 func (s *store) GetUserByID(ctx context.Context, id string) (*User, error) {
 	const op = "auth/store.GetUserByID"
 
 	query := `
-		SELECT id, full_name, phone_number, country_code, is_phone_number_verified, created_at, updated_at
+		SELECT id, full_name, phone_number, country_code, profile_image_id, is_phone_number_verified, created_at, updated_at
 		FROM kedul_user
 		WHERE id=$1;
 	`
 
 	var user User
 
-	row := s.db.QueryRow(query, id)
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
 
-	if row == nil {
+	err := row.Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.CountryCode, &user.ProfileImageID, &user.IsPhoneNumberVerified, &user.CreatedAt, &user.UpdatedAt)
+
+	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 
-	err := row.Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.CountryCode, &user.IsPhoneNumberVerified, &user.CreatedAt, &user.UpdatedAt)
-
 	if err != nil {
 		return nil, errors.Wrap(op, err, "database error")
 	}
@@ -143,7 +279,7 @@ func (s *store) GetUserByPhoneNumber(ctx context.Context, phoneNumber string, co
 	const op = "auth/store.GetUserByPhoneNumber"
 
 	query := `
-		SELECT id, full_name, phone_number, country_code, is_phone_number_verified, created_at, updated_at
+		SELECT id, full_name, phone_number, country_code, profile_image_id, is_phone_number_verified, created_at, updated_at
 		FROM kedul_user
 		WHERE phone_number=$1
 			AND country_code=$2;
@@ -151,9 +287,9 @@ func (s *store) GetUserByPhoneNumber(ctx context.Context, phoneNumber string, co
 
 	var user User
 
-	row := s.db.QueryRow(query, phoneNumber, countryCode)
+	row := transaction.DB(ctx, s.db).QueryRow(query, phoneNumber, countryCode)
 
-	err := row.Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.CountryCode, &user.IsPhoneNumberVerified, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.FullName, &user.PhoneNumber, &user.CountryCode, &user.ProfileImageID, &user.IsPhoneNumberVerified, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -171,11 +307,11 @@ func (s *store) StoreUser(ctx context.Context, user *User) error {
 	const op = "auth/store.StoreUser"
 
 	query := `
-		INSERT INTO kedul_user (id, full_name, phone_number, country_code, is_phone_number_verified, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO kedul_user (id, full_name, phone_number, country_code, profile_image_id, is_phone_number_verified, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := s.db.Exec(query, user.ID, user.FullName, user.PhoneNumber, user.CountryCode, user.IsPhoneNumberVerified, user.CreatedAt, user.UpdatedAt)
+	_, err := transaction.DB(ctx, s.db).Exec(query, user.ID, user.FullName, user.PhoneNumber, user.CountryCode, user.ProfileImageID, user.IsPhoneNumberVerified, user.CreatedAt, user.UpdatedAt)
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
@@ -190,11 +326,212 @@ func (s *store) UpdateUser(ctx context.Context, user *User) error {
 
 	query := `
 		UPDATE kedul_user
-		SET full_name=$2, phone_number=$3, country_code=$4, is_phone_number_verified=$5, created_at=$6, updated_at=$7
+		SET full_name=$2, phone_number=$3, country_code=$4, profile_image_id=$5, is_phone_number_verified=$6, created_at=$7, updated_at=$8
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, user.ID, user.FullName, user.PhoneNumber, user.CountryCode, user.ProfileImageID, user.IsPhoneNumberVerified, user.CreatedAt, user.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// GetRefreshTokenByTokenHash gets RefreshToken by the hash of the token
+func (s *store) GetRefreshTokenByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	const op = "auth/store.GetRefreshTokenByTokenHash"
+
+	query := `
+		SELECT id, user_id, family_id, token_hash, expired_at, rotated_at, revoked_at, created_at
+		FROM refresh_token
+		WHERE token_hash=$1;
+	`
+
+	var refreshToken RefreshToken
+	var rotatedAt, revokedAt pq.NullTime
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, tokenHash)
+
+	err := row.Scan(&refreshToken.ID, &refreshToken.UserID, &refreshToken.FamilyID, &refreshToken.TokenHash, &refreshToken.ExpiredAt, &rotatedAt, &revokedAt, &refreshToken.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	refreshToken.RotatedAt = rotatedAt.Time
+	refreshToken.RevokedAt = revokedAt.Time
+
+	return &refreshToken, nil
+}
+
+// StoreRefreshToken persists RefreshToken
+func (s *store) StoreRefreshToken(ctx context.Context, refreshToken *RefreshToken) error {
+	const op = "auth/store.StoreRefreshToken"
+
+	query := `
+		INSERT INTO refresh_token (id, user_id, family_id, token_hash, expired_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, refreshToken.ID, refreshToken.UserID, refreshToken.FamilyID, refreshToken.TokenHash, refreshToken.ExpiredAt, refreshToken.CreatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// RotateRefreshToken marks RefreshToken as exchanged. It returns false when the token was already exchanged or revoked,
+// so that two concurrent refreshes with the same token cannot both succeed
+func (s *store) RotateRefreshToken(ctx context.Context, refreshToken *RefreshToken, rotatedAt time.Time) (bool, error) {
+	const op = "auth/store.RotateRefreshToken"
+
+	query := `
+		UPDATE refresh_token
+		SET rotated_at=$2
+		WHERE id=$1
+			AND rotated_at IS NULL
+			AND revoked_at IS NULL;
+	`
+
+	result, err := transaction.DB(ctx, s.db).Exec(query, refreshToken.ID, rotatedAt)
+
+	if err != nil {
+		return false, errors.Wrap(op, err, "database error")
+	}
+
+	count, err := result.RowsAffected()
+
+	if err != nil {
+		return false, errors.Wrap(op, err, "database error")
+	}
+
+	if count == 0 {
+		return false, nil
+	}
+
+	refreshToken.RotatedAt = rotatedAt
+
+	return true, nil
+}
+
+// RevokeRefreshTokensByFamilyID revokes all RefreshTokens descending from the same login
+func (s *store) RevokeRefreshTokensByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error {
+	const op = "auth/store.RevokeRefreshTokensByFamilyID"
+
+	query := `
+		UPDATE refresh_token
+		SET revoked_at=$2
+		WHERE family_id=$1
+			AND revoked_at IS NULL;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, familyID, revokedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+func scanSession(row rowScanner) (*Session, error) {
+	session := &Session{}
+	var revokedAt pq.NullTime
+
+	err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.LastSeenAt, &revokedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session.RevokedAt = revokedAt.Time
+
+	return session, nil
+}
+
+// GetSessionsByUserID gets Sessions of the User which were not revoked, most recently used first
+func (s *store) GetSessionsByUserID(ctx context.Context, userID string) ([]*Session, error) {
+	const op = "auth/store.GetSessionsByUserID"
+
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at
+		FROM user_session
+		WHERE user_id=$1
+			AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC;
+	`
+	sessions := make([]*Session, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, userID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return sessions, nil
+}
+
+// GetSessionByID gets Session by ID
+func (s *store) GetSessionByID(ctx context.Context, id string) (*Session, error) {
+	const op = "auth/store.GetSessionByID"
+
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at
+		FROM user_session
 		WHERE id=$1;
 	`
 
-	_, err := s.db.Exec(query, user.ID, user.FullName, user.PhoneNumber, user.CountryCode, user.IsPhoneNumberVerified, user.CreatedAt, user.UpdatedAt)
+	row := transaction.DB(ctx, s.db).QueryRow(query, id)
+
+	session, err := scanSession(row)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return session, nil
+}
+
+// StoreSession persists Session
+func (s *store) StoreSession(ctx context.Context, session *Session) error {
+	const op = "auth/store.StoreSession"
+
+	query := `
+		INSERT INTO user_session (id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, session.ID, session.UserID, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastSeenAt, toNullTime(session.RevokedAt))
 
 	if err != nil {
 		return errors.Wrap(op, err, "database error")
@@ -202,3 +539,53 @@ func (s *store) UpdateUser(ctx context.Context, user *User) error {
 
 	return nil
 }
+
+// UpdateSession updates the device and the last use of Session, unless it was revoked meanwhile. It does not change
+// RevokedAt, so that a session revoked concurrently stays revoked
+func (s *store) UpdateSession(ctx context.Context, session *Session) error {
+	const op = "auth/store.UpdateSession"
+
+	query := `
+		UPDATE user_session
+		SET user_agent=$2, ip_address=$3, last_seen_at=$4
+		WHERE id=$1
+			AND revoked_at IS NULL;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, session.ID, session.UserAgent, session.IPAddress, session.LastSeenAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// RevokeSession revokes the Session, unless it was revoked already
+func (s *store) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	const op = "auth/store.RevokeSession"
+
+	query := `
+		UPDATE user_session
+		SET revoked_at=$2
+		WHERE id=$1
+			AND revoked_at IS NULL;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, id, revokedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// toNullTime maps zero times to NULL
+func toNullTime(t time.Time) pq.NullTime {
+	return pq.NullTime{Time: t, Valid: !t.IsZero()}
+}
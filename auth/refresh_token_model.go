@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// refreshTokenExpiry is how long a refresh token can be exchanged for new tokens
+const refreshTokenExpiry = 30 * 24 * time.Hour
+
+// RefreshToken is persisted by its hash only. Tokens issued by rotation share the FamilyID of the token issued at login
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	ExpiredAt time.Time
+	// RotatedAt is set once the token was exchanged. Presenting it again means it was stolen
+	RotatedAt time.Time
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+// NewRefreshToken constructor for RefreshToken
+func NewRefreshToken(token string, userID string, familyID string) *RefreshToken {
+	now := time.Now()
+	id := uuid.Must(uuid.New(), nil).String()
+
+	refreshToken := RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(token),
+		ExpiredAt: now.Add(refreshTokenExpiry),
+		CreatedAt: now,
+	}
+
+	return &refreshToken
+}
+
+func hashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
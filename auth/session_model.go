@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Device describes where a login happened
+type Device struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session is a login of the User on a device. Its ID is also the family of the refresh tokens issued for it
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  time.Time
+}
+
+// NewSession constructor for Session
+func NewSession(userID string, device *Device) *Session {
+	now := time.Now()
+	id := uuid.Must(uuid.New(), nil).String()
+
+	session := Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	return &session
+}
+
+// IsRevoked reports whether the session was logged out or revoked
+func (s *Session) IsRevoked() bool {
+	return !s.RevokedAt.IsZero()
+}
@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// TokenKeysConfig points to PEM encoded keys. The signing key is a PKCS#8 or PKCS#1 private key.
+// Public keys of retired signing keys are kept in VerificationKeyFiles until the tokens they signed have expired
+type TokenKeysConfig struct {
+	SigningKeyFile       string
+	VerificationKeyFiles []string
+}
+
+// VerificationKey is a public key that access tokens are verified with. ID is its RFC 7638 thumbprint, sent as the kid header
+type VerificationKey struct {
+	ID        string
+	Algorithm string
+	PublicKey crypto.PublicKey
+}
+
+// TokenKeys signs access tokens with a single key and verifies them with any of the verification keys
+type TokenKeys struct {
+	signingKey       crypto.Signer
+	signingKeyID     string
+	signingMethod    jwt.SigningMethod
+	verificationKeys []*VerificationKey
+}
+
+// NewTokenKeys constructor for TokenKeys. The public key of the signing key is always a verification key
+func NewTokenKeys(signingKey crypto.Signer, verificationKeys ...crypto.PublicKey) (*TokenKeys, error) {
+	const op = "auth/NewTokenKeys"
+
+	tokenKeys := &TokenKeys{signingKey: signingKey}
+
+	for _, publicKey := range append([]crypto.PublicKey{signingKey.Public()}, verificationKeys...) {
+		verificationKey, err := newVerificationKey(publicKey)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "invalid verification key")
+		}
+
+		if tokenKeys.getVerificationKey(verificationKey.ID) != nil {
+			continue
+		}
+
+		tokenKeys.verificationKeys = append(tokenKeys.verificationKeys, verificationKey)
+	}
+
+	tokenKeys.signingKeyID = tokenKeys.verificationKeys[0].ID
+	tokenKeys.signingMethod = jwt.GetSigningMethod(tokenKeys.verificationKeys[0].Algorithm)
+
+	return tokenKeys, nil
+}
+
+// LoadTokenKeys reads the keys of the config from disk
+func LoadTokenKeys(config *TokenKeysConfig) (*TokenKeys, error) {
+	const op = "auth/LoadTokenKeys"
+
+	block, err := readPEMFile(config.SigningKeyFile)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to read signing key")
+	}
+
+	signingKey, err := parsePrivateKey(block)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to parse signing key")
+	}
+
+	verificationKeys := []crypto.PublicKey{}
+
+	for _, file := range config.VerificationKeyFiles {
+		block, err := readPEMFile(file)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to read verification key")
+		}
+
+		publicKey, err := parsePublicKey(block)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to parse verification key")
+		}
+
+		verificationKeys = append(verificationKeys, publicKey)
+	}
+
+	return NewTokenKeys(signingKey, verificationKeys...)
+}
+
+// GenerateTokenKeys creates TokenKeys with a new Ed25519 key. Tokens signed with it do not survive a restart
+func GenerateTokenKeys() (*TokenKeys, error) {
+	const op = "auth/GenerateTokenKeys"
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to generate key")
+	}
+
+	return NewTokenKeys(privateKey)
+}
+
+// Encode signs the claims with the signing key
+func (k *TokenKeys) Encode(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signingMethod, claims)
+	token.Header["kid"] = k.signingKeyID
+
+	return token.SignedString(k.signingKey)
+}
+
+// Decode parses the token and verifies it with the verification key named by its kid header
+func (k *TokenKeys) Decode(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		verificationKey := k.getVerificationKey(keyID)
+
+		if verificationKey == nil {
+			return nil, fmt.Errorf("unknown key id %q", keyID)
+		}
+
+		if token.Method.Alg() != verificationKey.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+
+		return verificationKey.PublicKey, nil
+	})
+}
+
+// VerificationKeys returns the keys that tokens are verified with, the signing key first
+func (k *TokenKeys) VerificationKeys() []*VerificationKey {
+	return k.verificationKeys
+}
+
+func (k *TokenKeys) getVerificationKey(id string) *VerificationKey {
+	for _, verificationKey := range k.verificationKeys {
+		if verificationKey.ID == id {
+			return verificationKey
+		}
+	}
+
+	return nil
+}
+
+// JWK is the JSON Web Key representation of a public key, RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWK returns the public key as JSON Web Key
+func (k *VerificationKey) JWK() *JWK {
+	jwk := &JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+
+	switch publicKey := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
+
+func newVerificationKey(publicKey crypto.PublicKey) (*VerificationKey, error) {
+	verificationKey := &VerificationKey{PublicKey: publicKey}
+
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		verificationKey.Algorithm = jwt.SigningMethodRS256.Alg()
+	case ed25519.PublicKey:
+		verificationKey.Algorithm = SigningMethodEdDSA.Alg()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", publicKey)
+	}
+
+	jwk := verificationKey.JWK()
+
+	// Thumbprint members in lexicographic order, RFC 7638
+	var members interface{}
+
+	if jwk.KeyType == "RSA" {
+		members = struct {
+			E       string `json:"e"`
+			KeyType string `json:"kty"`
+			N       string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	} else {
+		members = struct {
+			Curve   string `json:"crv"`
+			KeyType string `json:"kty"`
+			X       string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+
+	b, err := json.Marshal(members)
+
+	if err != nil {
+		return nil, err
+	}
+
+	thumbprint := sha256.Sum256(b)
+	verificationKey.ID = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+
+	return verificationKey, nil
+}
+
+func readPEMFile(file string) (*pem.Block, error) {
+	b, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", privateKey)
+	}
+
+	return signer, nil
+}
+
+func parsePublicKey(block *pem.Block) (crypto.PublicKey, error) {
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func writePEMFile(t *testing.T, dir string, name string, blockType string, b []byte) string {
+	file := filepath.Join(dir, name)
+
+	err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: b}), 0600)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestTokenKeysRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token_keys")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldPublicKey, oldPrivateKey, _ := ed25519.GenerateKey(rand.Reader)
+	newPrivateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	oldPrivateKeyBytes, _ := x509.MarshalPKCS8PrivateKey(oldPrivateKey)
+	oldPublicKeyBytes, _ := x509.MarshalPKIXPublicKey(oldPublicKey)
+
+	oldTokenKeys, err := LoadTokenKeys(&TokenKeysConfig{
+		SigningKeyFile: writePEMFile(t, dir, "old.pem", "PRIVATE KEY", oldPrivateKeyBytes),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newTokenKeys, err := LoadTokenKeys(&TokenKeysConfig{
+		SigningKeyFile:       writePEMFile(t, dir, "new.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(newPrivateKey)),
+		VerificationKeyFiles: []string{writePEMFile(t, dir, "old.pub.pem", "PUBLIC KEY", oldPublicKeyBytes)},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{"user_id": "1"}
+
+	t.Run("should sign with new key", func(t *testing.T) {
+		tokenString, err := newTokenKeys.Encode(claims)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		token, err := newTokenKeys.Decode(tokenString)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if token.Header["alg"] != "RS256" || token.Header["kid"] != newTokenKeys.VerificationKeys()[0].ID {
+			t.Errorf("token should be signed with new key. received alg=%v, kid=%v", token.Header["alg"], token.Header["kid"])
+		}
+
+		_, err = oldTokenKeys.Decode(tokenString)
+
+		if err == nil {
+			t.Error("token should not verify with old keys")
+		}
+	})
+
+	t.Run("should verify tokens signed with retired key", func(t *testing.T) {
+		tokenString, err := oldTokenKeys.Encode(claims)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		_, err = newTokenKeys.Decode(tokenString)
+
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should publish both keys", func(t *testing.T) {
+		verificationKeys := newTokenKeys.VerificationKeys()
+
+		if len(verificationKeys) != 2 {
+			t.Errorf("there should be 2 verification keys. received=%d", len(verificationKeys))
+			return
+		}
+
+		if jwk := verificationKeys[0].JWK(); jwk.KeyType != "RSA" || jwk.N == "" || jwk.E != "AQAB" {
+			t.Errorf("invalid RSA JWK %+v", jwk)
+		}
+
+		if jwk := verificationKeys[1].JWK(); jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.X == "" {
+			t.Errorf("invalid Ed25519 JWK %+v", jwk)
+		}
+	})
+}
+
+func TestTokenKeysRejectsUnknownKey(t *testing.T) {
+	tokenKeys, _ := GenerateTokenKeys()
+	otherTokenKeys, _ := GenerateTokenKeys()
+
+	tokenString, err := otherTokenKeys.Encode(jwt.MapClaims{"user_id": "1"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tokenKeys.Decode(tokenString)
+
+	if err == nil {
+		t.Error("token signed with unknown key should be rejected")
+	}
+}
@@ -15,6 +15,7 @@ type VerificationCode struct {
 	CodeType       string    `db:"code_type"`
 	PhoneNumber    string    `db:"phone_number"`
 	CountryCode    string    `db:"country_code"`
+	Attempts       int       `db:"attempts"`
 	CreatedAt      time.Time `db:"created_at"`
 	ExpiredAt      time.Time `db:"expired_at"`
 }
@@ -38,3 +39,27 @@ func NewVerificationCode(verificationID string, code string, userID string, phon
 
 	return &verificationCode
 }
+
+// VerificationFailure records a wrong code entered for the phone number from the IP address
+type VerificationFailure struct {
+	ID          string    `db:"id"`
+	PhoneNumber string    `db:"phone_number"`
+	CountryCode string    `db:"country_code"`
+	IPAddress   string    `db:"ip_address"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// NewVerificationFailure constructor for VerificationFailure
+func NewVerificationFailure(phoneNumber string, countryCode string, ipAddress string) *VerificationFailure {
+	id := uuid.Must(uuid.New(), nil).String()
+
+	verificationFailure := VerificationFailure{
+		ID:          id,
+		PhoneNumber: phoneNumber,
+		CountryCode: countryCode,
+		IPAddress:   ipAddress,
+		CreatedAt:   time.Now(),
+	}
+
+	return &verificationFailure
+}
@@ -10,10 +10,16 @@ type Kind int
 
 // Application error categories.
 const (
-	KindInvalid      Kind = iota + 1 // action cannot be performed or bad request
-	KindUnauthorized                 // authorization error
-	KindNotFound                     // not found error
-	KindUnexpected                   // unexpected error
+	KindInvalid            Kind = iota + 1 // action cannot be performed or bad request
+	KindUnauthorized                       // authorization error
+	KindNotFound                           // not found error
+	KindUnexpected                         // unexpected error
+	KindRateLimited                        // too many attempts, retry later
+	KindConflict                           // conflicts with the current state, e.g. duplicate
+	KindForbidden                          // authenticated but not allowed
+	KindUnavailable                        // dependency temporarily unavailable, retry later
+	KindTimeout                            // deadline of the request or query exceeded
+	KindPreconditionFailed                 // resource changed since the version the request was based on
 )
 
 func (kind Kind) String() string {
@@ -26,11 +32,51 @@ func (kind Kind) String() string {
 		return "not found"
 	case KindUnexpected:
 		return "unexpected"
+	case KindRateLimited:
+		return "rate limited"
+	case KindConflict:
+		return "conflict"
+	case KindForbidden:
+		return "forbidden"
+	case KindUnavailable:
+		return "unavailable"
+	case KindTimeout:
+		return "timeout"
+	case KindPreconditionFailed:
+		return "precondition failed"
 	}
 
 	return "unknown error kind"
 }
 
+// Code is the machine-readable name of the kind, e.g. not_found
+func (kind Kind) Code() string {
+	switch kind {
+	case KindInvalid:
+		return "invalid"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindNotFound:
+		return "not_found"
+	case KindUnexpected:
+		return "unexpected"
+	case KindRateLimited:
+		return "rate_limited"
+	case KindConflict:
+		return "conflict"
+	case KindForbidden:
+		return "forbidden"
+	case KindUnavailable:
+		return "unavailable"
+	case KindTimeout:
+		return "timeout"
+	case KindPreconditionFailed:
+		return "precondition_failed"
+	}
+
+	return "unexpected"
+}
+
 // Error defines a standard application error.
 type Error struct {
 	// Category of the error
@@ -44,6 +90,12 @@ type Error struct {
 
 	// Wrapped error value
 	Err error
+
+	// Machine-readable code. The code of the kind is used when empty
+	Code string
+
+	// Invalid fields of the input
+	Violations []FieldViolation
 }
 
 // Invalid returns Error with KindInvalid
@@ -51,6 +103,16 @@ func Invalid(op string, message string) *Error {
 	return &Error{Kind: KindInvalid, Op: op, Message: message}
 }
 
+// InvalidFields returns Error with KindInvalid listing the invalid fields of the input
+func InvalidFields(op string, violations []FieldViolation) *Error {
+	return &Error{Kind: KindInvalid, Op: op, Message: "invalid input", Code: CodeValidationFailed, Violations: violations}
+}
+
+// InvalidField returns Error with KindInvalid for a single invalid field of the input
+func InvalidField(op string, field string, code string, message string) *Error {
+	return InvalidFields(op, []FieldViolation{{Field: field, Code: code, Message: message}})
+}
+
 // Unauthorized returns Error with KindUnauthorized
 func Unauthorized(op string, err error) *Error {
 	return &Error{Kind: KindUnauthorized, Err: err, Op: op}
@@ -61,6 +123,36 @@ func NotFound(op string) *Error {
 	return &Error{Kind: KindNotFound, Op: op, Message: "not found"}
 }
 
+// RateLimited returns Error with KindRateLimited
+func RateLimited(op string, message string) *Error {
+	return &Error{Kind: KindRateLimited, Op: op, Message: message}
+}
+
+// Conflict returns Error with KindConflict
+func Conflict(op string, message string) *Error {
+	return &Error{Kind: KindConflict, Op: op, Message: message}
+}
+
+// Forbidden returns Error with KindForbidden
+func Forbidden(op string, err error) *Error {
+	return &Error{Kind: KindForbidden, Err: err, Op: op}
+}
+
+// Unavailable returns Error with KindUnavailable
+func Unavailable(op string, err error, message string) *Error {
+	return &Error{Kind: KindUnavailable, Op: op, Err: err, Message: message}
+}
+
+// Timeout returns Error with KindTimeout
+func Timeout(op string, err error) *Error {
+	return &Error{Kind: KindTimeout, Op: op, Err: err, Message: "deadline exceeded"}
+}
+
+// PreconditionFailed returns Error with KindPreconditionFailed
+func PreconditionFailed(op string, message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Op: op, Message: message}
+}
+
 // Unexpected returns Error with KindUnexpected
 func Unexpected(op string, err error, message string) *Error {
 	return &Error{Kind: KindUnexpected, Op: op, Err: err, Message: message}
@@ -71,6 +163,12 @@ func Wrap(op string, err error, message string) *Error {
 	return &Error{Op: op, Err: err, Message: message}
 }
 
+// WithCode sets the machine-readable code of a specific failure. Codes are listed in the registry
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
 // Ops prints the stacktrace
 func Ops(e *Error) []string {
 	res := []string{e.Op}
@@ -133,7 +231,14 @@ func ErrorKind(err error) Kind {
 		return 0
 	}
 
-	if e, ok := err.(*Error); ok && e.Kind != 0 {
+	e, ok := err.(*Error)
+
+	// Callers report failed queries as unexpected, including those that timed out
+	if ok && e.Kind == KindUnexpected && e.Err != nil && ErrorKind(e.Err) == KindTimeout {
+		return KindTimeout
+	}
+
+	if ok && e.Kind != 0 {
 		return e.Kind
 	} else if ok && e.Err != nil {
 		return ErrorKind(e.Err)
@@ -142,6 +247,36 @@ func ErrorKind(err error) Kind {
 	return KindUnexpected
 }
 
+// ErrorCode extract machine-readable code from error values
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if e, ok := err.(*Error); ok && e.Code != "" {
+		return e.Code
+	} else if ok && e.Kind == 0 && e.Err != nil {
+		return ErrorCode(e.Err)
+	}
+
+	return ErrorKind(err).Code()
+}
+
+// ErrorViolations extract field violations from error values
+func ErrorViolations(err error) []FieldViolation {
+	if err == nil {
+		return nil
+	}
+
+	if e, ok := err.(*Error); ok && len(e.Violations) > 0 {
+		return e.Violations
+	} else if ok && e.Kind == 0 && e.Err != nil {
+		return ErrorViolations(e.Err)
+	}
+
+	return nil
+}
+
 // Is compares whether error matches the kind
 func Is(kind Kind, err error) bool {
 	return kind == ErrorKind(err)
@@ -0,0 +1,97 @@
+package errors
+
+import "sort"
+
+// Codes of specific failures. Clients can rely on them, so they must not change once released
+const (
+	CodeBusinessNameTaken             = "business_name_taken"
+	CodeBusinessDeleted               = "business_deleted"
+	CodeOwnerRoleImmutable            = "owner_role_immutable"
+	CodeEmployeeRoleInUse             = "employee_role_in_use"
+	CodeEmployeeAlreadyJoined         = "employee_already_joined"
+	CodeEmployeeUnavailable           = "employee_unavailable"
+	CodeAlreadyEmployee               = "already_employee"
+	CodeInvitationNotPending          = "invitation_not_pending"
+	CodeAppointmentOverlap            = "appointment_overlap"
+	CodeClientPhoneNumberTaken        = "client_phone_number_taken"
+	CodePhoneNumberTaken              = "phone_number_taken"
+	CodePhoneNumberNotVerified        = "phone_number_not_verified"
+	CodeVerificationCodeResendTooSoon = "verification_code_resend_too_soon"
+	CodeTooManyVerificationAttempts   = "too_many_verification_attempts"
+	CodeRefreshTokenInvalid           = "refresh_token_invalid"
+	CodeSessionRevoked                = "session_revoked"
+)
+
+// docPath is the path error codes are documented at. It is the type of problem responses
+const docPath = "/errors/"
+
+// Problem documents an error code
+type Problem struct {
+	Code        string
+	Title       string
+	Description string
+}
+
+// DocURL returns the url the code is documented at
+func (p *Problem) DocURL() string {
+	return docPath + p.Code
+}
+
+var registry = map[string]*Problem{}
+
+func register(code string, title string, description string) {
+	registry[code] = &Problem{Code: code, Title: title, Description: description}
+}
+
+func init() {
+	register(KindInvalid.Code(), "Invalid request", "The request cannot be performed as it is.")
+	register(KindUnauthorized.Code(), "Unauthorized", "The access token is missing or invalid.")
+	register(KindNotFound.Code(), "Not found", "The resource does not exist.")
+	register(KindUnexpected.Code(), "Unexpected error", "The server failed to perform the request. Please contact technical support if it persists.")
+	register(KindRateLimited.Code(), "Too many requests", "Too many attempts were made. Retry later.")
+	register(KindConflict.Code(), "Conflict", "The request conflicts with the current state of the resource.")
+	register(KindForbidden.Code(), "Forbidden", "The current user is not allowed to perform the request.")
+	register(KindUnavailable.Code(), "Service unavailable", "A service the request depends on is temporarily unavailable. Retry later.")
+	register(KindTimeout.Code(), "Timeout", "The request took too long and was cancelled. Retry later.")
+	register(KindPreconditionFailed.Code(), "Precondition failed", "The resource was changed since it was read. Get it again and retry with its new ETag.")
+
+	register(CodeValidationFailed, "Validation failed", "Some fields of the request are invalid. They are listed in violations, with the reason of each.")
+	register(CodeBusinessNameTaken, "Business name taken", "Another business already has the name.")
+	register(CodeBusinessDeleted, "Business deleted", "The business of the location was deleted. Restore the business instead.")
+	register(CodeOwnerRoleImmutable, "Owner role cannot be changed", "The owner role cannot be updated, deleted, given to employees or taken away from the owner.")
+	register(CodeEmployeeRoleInUse, "Employee role in use", "Employees still have the role. Change their role before deleting it.")
+	register(CodeEmployeeAlreadyJoined, "Employee already joined", "The employee is already linked to a user.")
+	register(CodeEmployeeUnavailable, "Employee unavailable", "The employee of the invitation no longer exists or has joined meanwhile.")
+	register(CodeAlreadyEmployee, "Already an employee", "The current user is already an employee of the location.")
+	register(CodeInvitationNotPending, "Invitation not pending", "The invitation expired, was revoked or was already accepted.")
+	register(CodeAppointmentOverlap, "Appointment overlap", "The employee already has an appointment at this time.")
+	register(CodeClientPhoneNumberTaken, "Client phone number taken", "Another client of the business has the phone number.")
+	register(CodePhoneNumberTaken, "Phone number taken", "Another user has the phone number.")
+	register(CodePhoneNumberNotVerified, "Phone number not verified", "The phone number of the current user must be verified first.")
+	register(CodeVerificationCodeResendTooSoon, "Verification code sent recently", "A verification code was sent recently. Wait before requesting a new one.")
+	register(CodeTooManyVerificationAttempts, "Too many verification attempts", "Too many wrong verification codes were entered. Request a new code or retry later.")
+	register(CodeRefreshTokenInvalid, "Refresh token invalid", "The refresh token is unknown, expired, revoked or was already used. Log in again.")
+	register(CodeSessionRevoked, "Session revoked", "The session was revoked. Log in again.")
+}
+
+// LookupProblem returns the documentation of the code
+func LookupProblem(code string) (*Problem, bool) {
+	problem, ok := registry[code]
+
+	return problem, ok
+}
+
+// Problems returns the documentation of every code, sorted by code
+func Problems() []*Problem {
+	problems := make([]*Problem, 0, len(registry))
+
+	for _, problem := range registry {
+		problems = append(problems, problem)
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Code < problems[j].Code
+	})
+
+	return problems
+}
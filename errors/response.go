@@ -6,18 +6,23 @@ import (
 	"github.com/go-chi/render"
 )
 
-// ErrResponse represents standardized error response
-type ErrResponse struct {
-	HTTPStatusCode int `json:"-"` // http response status code
+// ProblemContentType is the media type of error responses, see RFC 7807
+const ProblemContentType = "application/problem+json"
 
-	Message string `json:"message,omitempty"` // human readable message
-	Code    string `json:"code,omitempty"`    // human readable message
-	DocURL  string `json:"doc_url,omitempty"` // human readable message
+// ErrResponse represents standardized error response, as a RFC 7807 problem details object
+type ErrResponse struct {
+	Type       string           `json:"type"`                 // url documenting the code
+	Title      string           `json:"title"`                // short summary of the code
+	Status     int              `json:"status"`               // http response status code
+	Detail     string           `json:"detail,omitempty"`     // human readable message specific to the occurrence
+	Instance   string           `json:"instance,omitempty"`   // request id of the occurrence
+	Code       string           `json:"code"`                 // machine-readable code, e.g. not_found
+	Violations []FieldViolation `json:"violations,omitempty"` // invalid fields of the input
 }
 
 // Render error with HTTP status code
 func (e *ErrResponse) Render(w http.ResponseWriter, r *http.Request) error {
-	render.Status(r, e.HTTPStatusCode)
+	render.Status(r, e.Status)
 	return nil
 }
 
@@ -27,30 +32,46 @@ func HTTPStatusCode(err error) int {
 		return http.StatusOK
 	}
 
-	if e, ok := err.(*Error); ok && e.Kind != 0 {
-		switch e.Kind {
-		case KindInvalid:
-			return http.StatusBadRequest
-		case KindUnauthorized:
-			return http.StatusUnauthorized
-		case KindNotFound:
-			return http.StatusNotFound
-		case KindUnexpected:
-			return http.StatusInternalServerError
-		default:
-			return http.StatusInternalServerError
-		}
-	} else if ok && e.Err != nil {
-		return HTTPStatusCode(e.Err)
+	switch ErrorKind(err) {
+	case KindInvalid:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindConflict:
+		return http.StatusConflict
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
 	}
 
 	return http.StatusInternalServerError
 }
 
-// NewErrResponse are converted from Error from internal errors package
-func NewErrResponse(err error) render.Renderer {
+// NewErrResponse are converted from Error from internal errors package. requestID identifies the occurrence
+func NewErrResponse(err error, requestID string) *ErrResponse {
+	code := ErrorCode(err)
+	problem, ok := LookupProblem(code)
+
+	if !ok {
+		problem, _ = LookupProblem(ErrorKind(err).Code())
+	}
+
 	return &ErrResponse{
-		HTTPStatusCode: HTTPStatusCode(err),
-		Message:        ErrorMessage(err),
+		Type:       problem.DocURL(),
+		Title:      problem.Title,
+		Status:     HTTPStatusCode(err),
+		Detail:     ErrorMessage(err),
+		Instance:   requestID,
+		Code:       code,
+		Violations: ErrorViolations(err),
 	}
 }
@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewErrResponse(t *testing.T) {
+	t.Run("should describe specific failure", func(t *testing.T) {
+		err := Wrap("app/businessService.CreateBusiness", Conflict("app/businessStore.StoreBusiness", "business with name b1 already exists").WithCode(CodeBusinessNameTaken), "failed to store business")
+		errResponse := NewErrResponse(err, "host/abc-000001")
+
+		if errResponse.Status != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, errResponse.Status)
+		}
+
+		if errResponse.Code != CodeBusinessNameTaken || errResponse.Type != "/errors/business_name_taken" || errResponse.Title != "Business name taken" {
+			t.Errorf("unexpected problem %s %s %s", errResponse.Code, errResponse.Type, errResponse.Title)
+		}
+
+		if errResponse.Detail != "failed to store business" || errResponse.Instance != "host/abc-000001" {
+			t.Errorf("unexpected detail %s or instance %s", errResponse.Detail, errResponse.Instance)
+		}
+	})
+
+	t.Run("should fall back to code of the kind", func(t *testing.T) {
+		errResponse := NewErrResponse(Unexpected("op", fmt.Errorf("connection refused"), "database error"), "")
+
+		if errResponse.Status != http.StatusInternalServerError || errResponse.Code != "unexpected" || errResponse.Type != "/errors/unexpected" {
+			t.Errorf("unexpected problem %d %s %s", errResponse.Status, errResponse.Code, errResponse.Type)
+		}
+	})
+
+	t.Run("should map kind to status", func(t *testing.T) {
+		statuses := map[error]int{
+			Forbidden("op", fmt.Errorf("current user not owner")):                                               http.StatusForbidden,
+			Conflict("op", "invitation not pending"):                                                            http.StatusConflict,
+			Unavailable("op", fmt.Errorf("503 Service Unavailable"), "down"):                                    http.StatusServiceUnavailable,
+			Unexpected("op", Timeout("op", fmt.Errorf("canceling statement")), "failed to get user"):            http.StatusGatewayTimeout,
+			Wrap("op", PreconditionFailed("op", "location was changed meanwhile"), "failed to update location"): http.StatusPreconditionFailed,
+		}
+
+		for err, status := range statuses {
+			errResponse := NewErrResponse(err, "")
+
+			if errResponse.Status != status {
+				t.Errorf("expected status %d, got %d for %s", status, errResponse.Status, errResponse.Code)
+			}
+		}
+	})
+
+	t.Run("should list field violations", func(t *testing.T) {
+		v := &Validation{}
+		v.Required("name", " ")
+		v.Range("duration", 0, 1, 60)
+
+		errResponse := NewErrResponse(Wrap("op", v.Err("op"), "invalid input"), "")
+
+		if errResponse.Code != CodeValidationFailed || len(errResponse.Violations) != 2 {
+			t.Errorf("unexpected problem %s %v", errResponse.Code, errResponse.Violations)
+		}
+	})
+
+	t.Run("should document every code", func(t *testing.T) {
+		for _, problem := range Problems() {
+			if problem.Title == "" || problem.Description == "" {
+				t.Errorf("code %s is not documented", problem.Code)
+			}
+		}
+	})
+}
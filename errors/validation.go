@@ -0,0 +1,80 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CodeValidationFailed is the code of errors listing field violations
+const CodeValidationFailed = "validation_failed"
+
+// Codes of field violations
+const (
+	CodeRequired      = "required"
+	CodeBlank         = "blank"
+	CodeTooLong       = "too_long"
+	CodeOutOfRange    = "out_of_range"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidValue  = "invalid_value"
+	CodeExpired       = "expired"
+)
+
+// FieldViolation describes why the value of an input field is invalid. Field is the path of the field
+// in the request body, e.g. working_hours[0].start_minute
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Validation collects the field violations of an input
+type Validation struct {
+	violations []FieldViolation
+}
+
+// Add adds a violation of the field
+func (v *Validation) Add(field string, code string, message string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Code: code, Message: message})
+}
+
+// Check adds a violation of the field unless ok
+func (v *Validation) Check(ok bool, field string, code string, message string) {
+	if !ok {
+		v.Add(field, code, message)
+	}
+}
+
+// Required checks that the field is not empty
+func (v *Validation) Required(field string, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, CodeRequired, fmt.Sprintf("%s is required", field))
+}
+
+// NotBlank checks that the field, when given, is not only whitespace
+func (v *Validation) NotBlank(field string, value string) {
+	v.Check(value == "" || strings.TrimSpace(value) != "", field, CodeBlank, fmt.Sprintf("%s must not be blank", field))
+}
+
+// MaxLength checks that the field is at most max characters long
+func (v *Validation) MaxLength(field string, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, CodeTooLong, fmt.Sprintf("%s must not be longer than %d characters", field, max))
+}
+
+// Range checks that the field is between min and max inclusive
+func (v *Validation) Range(field string, value int, min int, max int) {
+	v.Check(value >= min && value <= max, field, CodeOutOfRange, fmt.Sprintf("%s must be between %d and %d", field, min, max))
+}
+
+// Valid returns whether no violation was added
+func (v *Validation) Valid() bool {
+	return len(v.violations) == 0
+}
+
+// Err returns Error listing the violations, or nil when there are none
+func (v *Validation) Err(op string) error {
+	if v.Valid() {
+		return nil
+	}
+
+	return InvalidFields(op, v.violations)
+}
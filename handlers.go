@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -9,6 +12,9 @@ import (
 	"github.com/minheq/kedul_server_main/app"
 	"github.com/minheq/kedul_server_main/auth"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/logger"
+	"github.com/minheq/kedul_server_main/phone"
+	"github.com/minheq/kedul_server_main/query"
 )
 
 type phoneNumberVerifyRequest struct {
@@ -57,15 +63,36 @@ func (p *phoneNumberCheckRequest) Bind(r *http.Request) error {
 	return nil
 }
 
-type loginCheckResponse struct {
-	AccessToken string `json:"access_token"`
+// newDevice describes the client of the request. RemoteAddr was already resolved by middleware.RealIP
+func newDevice(r *http.Request) *auth.Device {
+	ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		ipAddress = r.RemoteAddr
+	}
+
+	return &auth.Device{UserAgent: r.UserAgent(), IPAddress: ipAddress}
+}
+
+type tokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiredAt time.Time `json:"access_token_expired_at"`
+	RefreshToken         string    `json:"refresh_token"`
 }
 
-func (rd *loginCheckResponse) Render(w http.ResponseWriter, r *http.Request) error {
+func (rd *tokenResponse) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-func (s *server) handleLoginCheck(authService auth.Service) http.HandlerFunc {
+func newTokenResponse(tokenPair *auth.TokenPair) *tokenResponse {
+	return &tokenResponse{
+		AccessToken:          tokenPair.AccessToken,
+		AccessTokenExpiredAt: tokenPair.AccessTokenExpiredAt,
+		RefreshToken:         tokenPair.RefreshToken,
+	}
+}
+
+func (s *server) handleLoginCheck(authService auth.Service, clientService app.ClientService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		data := &phoneNumberCheckRequest{}
 
@@ -74,14 +101,174 @@ func (s *server) handleLoginCheck(authService auth.Service) http.HandlerFunc {
 			return
 		}
 
-		accessToken, err := authService.LoginCheck(r.Context(), data.VerificationID, data.Code)
+		tokenPair, user, err := authService.LoginCheck(r.Context(), data.VerificationID, data.Code, newDevice(r))
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		s.linkClientsToUser(r, clientService, user)
+
+		render.Render(w, r, newTokenResponse(tokenPair))
+	}
+}
+
+// linkClientsToUser links the clients having the phone number the user just verified. The verification code is
+// already used by then, so failing to link is logged rather than failing the request. Clients are linked again
+// on the next login
+func (s *server) linkClientsToUser(r *http.Request, clientService app.ClientService, user *auth.User) {
+	_, err := clientService.LinkClientsToUser(r.Context(), user)
+
+	if err != nil {
+		s.logger.WithFields(logger.Fields{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		}).Error("error linking clients to user")
+	}
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (p *refreshTokenRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleRefreshToken(authService auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := &refreshTokenRequest{}
+
+		if err := render.Bind(r, data); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		tokenPair, err := authService.RefreshToken(r.Context(), data.RefreshToken, newDevice(r))
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newTokenResponse(tokenPair))
+	}
+}
+
+func (s *server) handleLogout(authService auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := &refreshTokenRequest{}
+
+		if err := render.Bind(r, data); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		err := authService.Logout(r.Context(), data.RefreshToken)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.NoContent(w, r)
+	}
+}
+
+type jwksResponse struct {
+	Keys []*auth.JWK `json:"keys"`
+}
+
+func (rd *jwksResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetJWKS(tokenKeys *auth.TokenKeys) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := []*auth.JWK{}
+
+		for _, verificationKey := range tokenKeys.VerificationKeys() {
+			keys = append(keys, verificationKey.JWK())
+		}
+
+		render.Render(w, r, &jwksResponse{Keys: keys})
+	}
+}
+
+type problemResponse struct {
+	Type        string `json:"type"`
+	Code        string `json:"code"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func newProblemResponse(problem *errors.Problem) *problemResponse {
+	return &problemResponse{
+		Type:        problem.DocURL(),
+		Code:        problem.Code,
+		Title:       problem.Title,
+		Description: problem.Description,
+	}
+}
+
+func (rd *problemResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type problemListResponse struct {
+	TotalCount int                `json:"total_count,omitempty"`
+	PageInfo   *pageInfo          `json:"page_info,omitempty"`
+	Data       []*problemResponse `json:"data"`
+}
+
+func newProblemListResponse(problems []*errors.Problem) *problemListResponse {
+	data := []*problemResponse{}
+
+	for _, problem := range problems {
+		data = append(data, newProblemResponse(problem))
+	}
+
+	return &problemListResponse{
+		Data: data,
+	}
+}
+
+func (rd *problemListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetProblems() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.Render(w, r, newProblemListResponse(errors.Problems()))
+	}
+}
+
+func (s *server) handleGetProblem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetProblem"
+
+		problem, ok := errors.LookupProblem(chi.URLParam(r, "code"))
+
+		if !ok {
+			s.respondError(w, r, errors.NotFound(op))
+			return
+		}
+
+		render.Render(w, r, newProblemResponse(problem))
+	}
+}
+
+func (s *server) handleTrackSMSDeliveryStatus(deliveryStatusTracker phone.DeliveryStatusTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, err := deliveryStatusTracker.TrackDeliveryStatus(r.Context(), r)
 
 		if err != nil {
 			s.respondError(w, r, err)
 			return
 		}
 
-		render.Render(w, r, &loginCheckResponse{AccessToken: accessToken})
+		render.NoContent(w, r)
 	}
 }
 
@@ -90,6 +277,7 @@ type userResponse struct {
 	FullName              string    `json:"full_name"`
 	PhoneNumber           string    `json:"phone_number"`
 	CountryCode           string    `json:"country_code"`
+	ProfileImageID        string    `json:"profile_image_id"`
 	IsPhoneNumberVerified bool      `json:"is_phone_number_verified"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
@@ -99,8 +287,9 @@ func newUserResponse(user *auth.User) *userResponse {
 	return &userResponse{
 		ID:                    user.ID,
 		FullName:              user.FullName,
-		PhoneNumber:           user.PhoneNumber,
+		PhoneNumber:           phone.FormatNational(user.PhoneNumber, user.CountryCode),
 		CountryCode:           user.CountryCode,
+		ProfileImageID:        user.ProfileImageID,
 		IsPhoneNumberVerified: user.IsPhoneNumberVerified,
 		CreatedAt:             user.CreatedAt,
 		UpdatedAt:             user.UpdatedAt,
@@ -141,6 +330,28 @@ func (s *server) handleUpdateUserProfile(authService auth.Service) http.HandlerF
 	}
 }
 
+func (s *server) handlePatchUserProfile(authService auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		input := &auth.PatchUserProfileInput{}
+
+		if err := s.decodePatch(r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		user, err := authService.PatchUserProfile(r.Context(), input, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newUserResponse(user))
+	}
+}
+
 func (s *server) handleUpdatePhoneNumberVerify(authService auth.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
@@ -162,7 +373,7 @@ func (s *server) handleUpdatePhoneNumberVerify(authService auth.Service) http.Ha
 	}
 }
 
-func (s *server) handleUpdatePhoneNumberCheck(authService auth.Service) http.HandlerFunc {
+func (s *server) handleUpdatePhoneNumberCheck(authService auth.Service, clientService app.ClientService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
 		data := &phoneNumberCheckRequest{}
@@ -172,20 +383,166 @@ func (s *server) handleUpdatePhoneNumberCheck(authService auth.Service) http.Han
 			return
 		}
 
-		user, err := authService.UpdatePhoneNumberCheck(r.Context(), data.VerificationID, data.Code, currentUser)
+		user, err := authService.UpdatePhoneNumberCheck(r.Context(), data.VerificationID, data.Code, newDevice(r), currentUser)
 
 		if err != nil {
 			s.respondError(w, r, err)
 			return
 		}
 
+		s.linkClientsToUser(r, clientService, user)
+
 		render.Render(w, r, newUserResponse(user))
 	}
 }
 
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	IsCurrent  bool      `json:"is_current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func newSessionResponse(session *auth.Session, currentSessionID string) *sessionResponse {
+	return &sessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		IsCurrent:  session.ID == currentSessionID,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+	}
+}
+
+func (rd *sessionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type sessionListResponse struct {
+	TotalCount int                `json:"total_count,omitempty"`
+	PageInfo   *pageInfo          `json:"page_info,omitempty"`
+	Data       []*sessionResponse `json:"data"`
+}
+
+func newSessionListResponse(sessions []*auth.Session, currentSessionID string) *sessionListResponse {
+	data := []*sessionResponse{}
+
+	for _, session := range sessions {
+		data = append(data, newSessionResponse(session, currentSessionID))
+	}
+
+	return &sessionListResponse{
+		Data: data,
+	}
+}
+
+func (rd *sessionListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetSessions(authService auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		sessions, err := authService.GetSessions(r.Context(), currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newSessionListResponse(sessions, auth.SessionIDFromContext(r.Context())))
+	}
+}
+
+func (s *server) handleRevokeSession(authService auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleRevokeSession"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		sessionID := chi.URLParam(r, "sessionID")
+
+		if sessionID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		session, err := authService.RevokeSession(r.Context(), sessionID, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newSessionResponse(session, auth.SessionIDFromContext(r.Context())))
+	}
+}
+
 type pageInfo struct {
-	HasNextPage     bool `json:"has_next_page"`
-	HasPreviousPage bool `json:"has_previous_page"`
+	HasNextPage     bool   `json:"has_next_page"`
+	HasPreviousPage bool   `json:"has_previous_page"`
+	StartCursor     string `json:"start_cursor,omitempty"`
+	EndCursor       string `json:"end_cursor,omitempty"`
+}
+
+func newPageInfo(page *app.Page) *pageInfo {
+	return &pageInfo{
+		HasNextPage:     page.PageInfo.HasNextPage,
+		HasPreviousPage: page.PageInfo.HasPreviousPage,
+		StartCursor:     page.PageInfo.StartCursor,
+		EndCursor:       page.PageInfo.EndCursor,
+	}
+}
+
+// parsePageArgs reads the first, after, last and before query params of list endpoints, and the filter and sort
+// query params on the fields of the listed resource
+func parsePageArgs(r *http.Request, fields query.Fields) (app.PageArgs, error) {
+	const op = "server.parsePageArgs"
+
+	params := r.URL.Query()
+	v := &errors.Validation{}
+
+	args := app.PageArgs{
+		First:  parsePageSize(v, "first", params.Get("first")),
+		After:  params.Get("after"),
+		Last:   parsePageSize(v, "last", params.Get("last")),
+		Before: params.Get("before"),
+	}
+
+	err := v.Err(op)
+
+	if err != nil {
+		return args, err
+	}
+
+	args.Filter, err = query.ParseFilter(fields, params["filter"])
+
+	if err != nil {
+		return args, errors.Wrap(op, err, "invalid filter")
+	}
+
+	args.Sort, err = query.ParseSort(fields, params.Get("sort"))
+
+	if err != nil {
+		return args, errors.Wrap(op, err, "invalid sort")
+	}
+
+	return args, nil
+}
+
+// parsePageSize reads the first or last query param, adding a violation when it is not a number
+func parsePageSize(v *errors.Validation, key string, value string) int {
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+
+	v.Check(err == nil, key, errors.CodeInvalidFormat, fmt.Sprintf("%s must be a number", key))
+
+	return n
 }
 
 type businessListResponse struct {
@@ -194,7 +551,7 @@ type businessListResponse struct {
 	Data       []*businessResponse `json:"data"`
 }
 
-func newBusinessListResponse(businesses []*app.Business) *businessListResponse {
+func newBusinessListResponse(businesses []*app.Business, page *app.Page) *businessListResponse {
 	data := []*businessResponse{}
 
 	for _, business := range businesses {
@@ -202,7 +559,9 @@ func newBusinessListResponse(businesses []*app.Business) *businessListResponse {
 	}
 
 	return &businessListResponse{
-		Data: data,
+		TotalCount: page.TotalCount,
+		PageInfo:   newPageInfo(page),
+		Data:       data,
 	}
 }
 
@@ -221,24 +580,42 @@ func (s *server) handleGetBusinessesByUserID(businessService app.BusinessService
 			s.respondError(w, r, errors.Invalid(op, "missing param"))
 		}
 
-		businesses, err := businessService.GetBusinessesByUserID(r.Context(), userID, currentUser)
+		args, err := parsePageArgs(r, app.BusinessFields)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		businesses, page, err := businessService.GetBusinessesByUserID(r.Context(), userID, args, currentUser)
 
 		if err != nil {
 			s.respondError(w, r, err)
 			return
 		}
 
-		render.Render(w, r, newBusinessListResponse(businesses))
+		render.Render(w, r, newBusinessListResponse(businesses, page))
+	}
+}
+
+// deletedAt is nil for records that are not deleted, so that deleted_at is omitted from their responses
+func deletedAt(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
 	}
+
+	return &t
 }
 
 type businessResponse struct {
-	ID             string    `json:"id"`
-	UserID         string    `json:"user_id"`
-	Name           string    `json:"name"`
-	ProfileImageID string    `json:"profile_image_id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	Name           string     `json:"name"`
+	ProfileImageID string     `json:"profile_image_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Version        int        `json:"version"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
 }
 
 func newBusinessResponse(business *app.Business) *businessResponse {
@@ -249,6 +626,8 @@ func newBusinessResponse(business *app.Business) *businessResponse {
 		ProfileImageID: business.ProfileImageID,
 		CreatedAt:      business.CreatedAt,
 		UpdatedAt:      business.UpdatedAt,
+		Version:        business.Version,
+		DeletedAt:      deletedAt(business.DeletedAt),
 	}
 }
 
@@ -273,6 +652,7 @@ func (s *server) handleGetBusiness(businessService app.BusinessService) http.Han
 			return
 		}
 
+		setETag(w, business.Version)
 		render.Render(w, r, newBusinessResponse(business))
 	}
 }
@@ -315,13 +695,58 @@ func (s *server) handleUpdateBusiness(businessService app.BusinessService) http.
 			return
 		}
 
-		business, err := businessService.UpdateBusiness(r.Context(), businessID, input, currentUser)
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		business, err := businessService.UpdateBusiness(r.Context(), businessID, version, input, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		setETag(w, business.Version)
+		render.Render(w, r, newBusinessResponse(business))
+	}
+}
+
+func (s *server) handlePatchBusiness(businessService app.BusinessService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handlePatchBusiness"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.PatchBusinessInput{}
+
+		businessID := chi.URLParam(r, "businessID")
+
+		if businessID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decodePatch(r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		business, err := businessService.PatchBusiness(r.Context(), businessID, version, input, currentUser)
 
 		if err != nil {
 			s.respondError(w, r, err)
 			return
 		}
 
+		setETag(w, business.Version)
 		render.Render(w, r, newBusinessResponse(business))
 	}
 }
@@ -336,7 +761,14 @@ func (s *server) handleDeleteBusiness(businessService app.BusinessService) http.
 			s.respondError(w, r, errors.Invalid(op, "missing param"))
 		}
 
-		business, err := businessService.DeleteBusiness(r.Context(), businessID, currentUser)
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		business, err := businessService.DeleteBusiness(r.Context(), businessID, version, currentUser)
 
 		if err != nil {
 			s.respondError(w, r, err)
@@ -347,13 +779,148 @@ func (s *server) handleDeleteBusiness(businessService app.BusinessService) http.
 	}
 }
 
-type locationResponse struct {
-	ID             string    `json:"id"`
-	BusinessID     string    `json:"business_id"`
-	Name           string    `json:"name"`
-	ProfileImageID string    `json:"profile_image_id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+func (s *server) handleRestoreBusiness(businessService app.BusinessService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleRestoreBusiness"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		businessID := chi.URLParam(r, "businessID")
+
+		if businessID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+		}
+
+		business, err := businessService.RestoreBusiness(r.Context(), businessID, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newBusinessResponse(business))
+	}
+}
+
+type smsTemplateResponse struct {
+	*app.SMSTemplate
+}
+
+func newSMSTemplateResponse(smsTemplate *app.SMSTemplate) *smsTemplateResponse {
+	return &smsTemplateResponse{SMSTemplate: smsTemplate}
+}
+
+func (rd *smsTemplateResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type smsTemplateListResponse struct {
+	TotalCount int                    `json:"total_count,omitempty"`
+	PageInfo   *pageInfo              `json:"page_info,omitempty"`
+	Data       []*smsTemplateResponse `json:"data"`
+}
+
+func newSMSTemplateListResponse(smsTemplates []*app.SMSTemplate) *smsTemplateListResponse {
+	data := []*smsTemplateResponse{}
+
+	for _, smsTemplate := range smsTemplates {
+		data = append(data, newSMSTemplateResponse(smsTemplate))
+	}
+
+	return &smsTemplateListResponse{
+		Data: data,
+	}
+}
+
+func (rd *smsTemplateListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetSMSTemplates(smsTemplateService app.SMSTemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetSMSTemplates"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		businessID := chi.URLParam(r, "businessID")
+
+		if businessID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		smsTemplates, err := smsTemplateService.GetSMSTemplates(r.Context(), businessID, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newSMSTemplateListResponse(smsTemplates))
+	}
+}
+
+func (s *server) handleSetSMSTemplate(smsTemplateService app.SMSTemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleSetSMSTemplate"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.SetSMSTemplateInput{}
+
+		businessID := chi.URLParam(r, "businessID")
+
+		if businessID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		smsTemplate, err := smsTemplateService.SetSMSTemplate(r.Context(), businessID, input, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newSMSTemplateResponse(smsTemplate))
+	}
+}
+
+func (s *server) handleResetSMSTemplate(smsTemplateService app.SMSTemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleResetSMSTemplate"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		businessID := chi.URLParam(r, "businessID")
+		messageType := chi.URLParam(r, "messageType")
+		locale := chi.URLParam(r, "locale")
+
+		if businessID == "" || messageType == "" || locale == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		smsTemplate, err := smsTemplateService.ResetSMSTemplate(r.Context(), businessID, messageType, locale, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newSMSTemplateResponse(smsTemplate))
+	}
+}
+
+type locationResponse struct {
+	ID             string     `json:"id"`
+	BusinessID     string     `json:"business_id"`
+	Name           string     `json:"name"`
+	ProfileImageID string     `json:"profile_image_id"`
+	TimeZone       string     `json:"time_zone"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Version        int        `json:"version"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
 }
 
 func newLocationResponse(location *app.Location) *locationResponse {
@@ -362,8 +929,11 @@ func newLocationResponse(location *app.Location) *locationResponse {
 		BusinessID:     location.BusinessID,
 		Name:           location.Name,
 		ProfileImageID: location.ProfileImageID,
+		TimeZone:       location.TimeZone,
 		CreatedAt:      location.CreatedAt,
 		UpdatedAt:      location.UpdatedAt,
+		Version:        location.Version,
+		DeletedAt:      deletedAt(location.DeletedAt),
 	}
 }
 
@@ -377,7 +947,7 @@ type locationListResponse struct {
 	Data       []*locationResponse `json:"data"`
 }
 
-func newLocationListResponse(locations []*app.Location) *locationListResponse {
+func newLocationListResponse(locations []*app.Location, page *app.Page) *locationListResponse {
 	data := []*locationResponse{}
 
 	for _, location := range locations {
@@ -385,7 +955,9 @@ func newLocationListResponse(locations []*app.Location) *locationListResponse {
 	}
 
 	return &locationListResponse{
-		Data: data,
+		TotalCount: page.TotalCount,
+		PageInfo:   newPageInfo(page),
+		Data:       data,
 	}
 }
 
@@ -409,14 +981,21 @@ func (s *server) handleGetLocationsByUserIDAndBusinessID(locationsService app.Lo
 			s.respondError(w, r, errors.Invalid(op, "missing param"))
 		}
 
-		locations, err := locationsService.GetLocationsByUserIDAndBusinessID(r.Context(), userID, businessID, currentUser)
+		args, err := parsePageArgs(r, app.LocationFields)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		locations, page, err := locationsService.GetLocationsByUserIDAndBusinessID(r.Context(), userID, businessID, args, currentUser)
 
 		if err != nil {
 			s.respondError(w, r, err)
 			return
 		}
 
-		render.Render(w, r, newLocationListResponse(locations))
+		render.Render(w, r, newLocationListResponse(locations, page))
 	}
 }
 
@@ -445,6 +1024,7 @@ func (s *server) handleGetLocation(locationService app.LocationService, permissi
 			return
 		}
 
+		setETag(w, location.Version)
 		render.Render(w, r, newLocationResponse(location))
 	}
 }
@@ -494,13 +1074,65 @@ func (s *server) handleUpdateLocation(locationService app.LocationService, permi
 			return
 		}
 
-		location, err := locationService.UpdateLocation(r.Context(), locationID, input, actor)
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		location, err := locationService.UpdateLocation(r.Context(), locationID, version, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		setETag(w, location.Version)
+		render.Render(w, r, newLocationResponse(location))
+	}
+}
+
+func (s *server) handlePatchLocation(locationService app.LocationService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handlePatchLocation"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.PatchLocationInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decodePatch(r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		location, err := locationService.PatchLocation(r.Context(), locationID, version, input, actor)
 
 		if err != nil {
 			s.respondError(w, r, err)
 			return
 		}
 
+		setETag(w, location.Version)
 		render.Render(w, r, newLocationResponse(location))
 	}
 }
@@ -515,7 +1147,35 @@ func (s *server) handleDeleteLocation(locationService app.LocationService) http.
 			s.respondError(w, r, errors.Invalid(op, "missing param"))
 		}
 
-		location, err := locationService.DeleteLocation(r.Context(), locationID, currentUser)
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		location, err := locationService.DeleteLocation(r.Context(), locationID, version, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newLocationResponse(location))
+	}
+}
+
+func (s *server) handleRestoreLocation(locationService app.LocationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleRestoreLocation"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+		}
+
+		location, err := locationService.RestoreLocation(r.Context(), locationID, currentUser)
 
 		if err != nil {
 			s.respondError(w, r, err)
@@ -525,3 +1185,1980 @@ func (s *server) handleDeleteLocation(locationService app.LocationService) http.
 		render.Render(w, r, newLocationResponse(location))
 	}
 }
+
+type employeeResponse struct {
+	ID             string    `json:"id"`
+	LocationID     string    `json:"location_id"`
+	Name           string    `json:"name"`
+	UserID         string    `json:"user_id"`
+	ProfileImageID string    `json:"profile_image_id"`
+	EmployeeRoleID string    `json:"employee_role_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Version        int       `json:"version"`
+}
+
+func newEmployeeResponse(employee *app.Employee) *employeeResponse {
+	return &employeeResponse{
+		ID:             employee.ID,
+		LocationID:     employee.LocationID,
+		Name:           employee.Name,
+		UserID:         employee.UserID,
+		ProfileImageID: employee.ProfileImageID,
+		EmployeeRoleID: employee.EmployeeRoleID,
+		CreatedAt:      employee.CreatedAt,
+		UpdatedAt:      employee.UpdatedAt,
+		Version:        employee.Version,
+	}
+}
+
+func (rd *employeeResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type employeeListResponse struct {
+	TotalCount int                 `json:"total_count,omitempty"`
+	PageInfo   *pageInfo           `json:"page_info,omitempty"`
+	Data       []*employeeResponse `json:"data"`
+}
+
+func newEmployeeListResponse(employees []*app.Employee, page *app.Page) *employeeListResponse {
+	data := []*employeeResponse{}
+
+	for _, employee := range employees {
+		data = append(data, newEmployeeResponse(employee))
+	}
+
+	return &employeeListResponse{
+		TotalCount: page.TotalCount,
+		PageInfo:   newPageInfo(page),
+		Data:       data,
+	}
+}
+
+func (rd *employeeListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetEmployeesByLocationID(employeeService app.EmployeeService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetEmployeesByLocationID"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		args, err := parsePageArgs(r, app.EmployeeFields)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employees, page, err := employeeService.GetEmployeesByLocationID(r.Context(), locationID, args, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newEmployeeListResponse(employees, page))
+	}
+}
+
+func (s *server) handleGetEmployee(employeeService app.EmployeeService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetEmployee"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employee, err := employeeService.GetEmployeeByID(r.Context(), locationID, employeeID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		setETag(w, employee.Version)
+		render.Render(w, r, newEmployeeResponse(employee))
+	}
+}
+
+func (s *server) handleCreateEmployee(employeeService app.EmployeeService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateEmployee"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateEmployeeInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employee, err := employeeService.CreateEmployee(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newEmployeeResponse(employee))
+	}
+}
+
+func (s *server) handleUpdateEmployee(employeeService app.EmployeeService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleUpdateEmployee"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.UpdateEmployeeInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employee, err := employeeService.UpdateEmployee(r.Context(), locationID, employeeID, version, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		setETag(w, employee.Version)
+		render.Render(w, r, newEmployeeResponse(employee))
+	}
+}
+
+func (s *server) handlePatchEmployee(employeeService app.EmployeeService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handlePatchEmployee"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.PatchEmployeeInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decodePatch(r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employee, err := employeeService.PatchEmployee(r.Context(), locationID, employeeID, version, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		setETag(w, employee.Version)
+		render.Render(w, r, newEmployeeResponse(employee))
+	}
+}
+
+func (s *server) handleDeleteEmployee(employeeService app.EmployeeService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleDeleteEmployee"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employee, err := employeeService.DeleteEmployee(r.Context(), locationID, employeeID, version, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newEmployeeResponse(employee))
+	}
+}
+
+type employeeRoleResponse struct {
+	ID            string    `json:"id"`
+	LocationID    string    `json:"location_id"`
+	Name          string    `json:"name"`
+	PermissionIDs []string  `json:"permission_ids"`
+	IsOwner       bool      `json:"is_owner"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Version       int       `json:"version"`
+}
+
+func newEmployeeRoleResponse(employeeRole *app.EmployeeRole) *employeeRoleResponse {
+	return &employeeRoleResponse{
+		ID:            employeeRole.ID,
+		LocationID:    employeeRole.LocationID,
+		Name:          employeeRole.Name,
+		PermissionIDs: employeeRole.PermissionIDs,
+		IsOwner:       employeeRole.IsOwner,
+		CreatedAt:     employeeRole.CreatedAt,
+		UpdatedAt:     employeeRole.UpdatedAt,
+		Version:       employeeRole.Version,
+	}
+}
+
+func (rd *employeeRoleResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type employeeRoleListResponse struct {
+	TotalCount int                     `json:"total_count,omitempty"`
+	PageInfo   *pageInfo               `json:"page_info,omitempty"`
+	Data       []*employeeRoleResponse `json:"data"`
+}
+
+func newEmployeeRoleListResponse(employeeRoles []*app.EmployeeRole, page *app.Page) *employeeRoleListResponse {
+	data := []*employeeRoleResponse{}
+
+	for _, employeeRole := range employeeRoles {
+		data = append(data, newEmployeeRoleResponse(employeeRole))
+	}
+
+	return &employeeRoleListResponse{
+		TotalCount: page.TotalCount,
+		PageInfo:   newPageInfo(page),
+		Data:       data,
+	}
+}
+
+func (rd *employeeRoleListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetEmployeeRolesByLocationID(employeeRoleService app.EmployeeRoleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetEmployeeRolesByLocationID"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		args, err := parsePageArgs(r, app.EmployeeRoleFields)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employeeRoles, page, err := employeeRoleService.GetEmployeeRolesByLocationID(r.Context(), locationID, args, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newEmployeeRoleListResponse(employeeRoles, page))
+	}
+}
+
+func (s *server) handleGetEmployeeRole(employeeRoleService app.EmployeeRoleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetEmployeeRole"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeRoleID := chi.URLParam(r, "employeeRoleID")
+
+		if locationID == "" || employeeRoleID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employeeRole, err := employeeRoleService.GetEmployeeRoleByID(r.Context(), locationID, employeeRoleID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		setETag(w, employeeRole.Version)
+		render.Render(w, r, newEmployeeRoleResponse(employeeRole))
+	}
+}
+
+func (s *server) handleCreateEmployeeRole(employeeRoleService app.EmployeeRoleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateEmployeeRole"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateEmployeeRoleInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employeeRole, err := employeeRoleService.CreateEmployeeRole(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newEmployeeRoleResponse(employeeRole))
+	}
+}
+
+func (s *server) handleUpdateEmployeeRole(employeeRoleService app.EmployeeRoleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleUpdateEmployeeRole"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.UpdateEmployeeRoleInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeRoleID := chi.URLParam(r, "employeeRoleID")
+
+		if locationID == "" || employeeRoleID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employeeRole, err := employeeRoleService.UpdateEmployeeRole(r.Context(), locationID, employeeRoleID, version, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		setETag(w, employeeRole.Version)
+		render.Render(w, r, newEmployeeRoleResponse(employeeRole))
+	}
+}
+
+func (s *server) handleDeleteEmployeeRole(employeeRoleService app.EmployeeRoleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleDeleteEmployeeRole"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeRoleID := chi.URLParam(r, "employeeRoleID")
+
+		if locationID == "" || employeeRoleID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		version, err := ifMatch(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		employeeRole, err := employeeRoleService.DeleteEmployeeRole(r.Context(), locationID, employeeRoleID, version, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newEmployeeRoleResponse(employeeRole))
+	}
+}
+
+type invitationResponse struct {
+	ID             string    `json:"id"`
+	LocationID     string    `json:"location_id"`
+	EmployeeID     string    `json:"employee_id"`
+	EmployeeRoleID string    `json:"employee_role_id"`
+	PhoneNumber    string    `json:"phone_number"`
+	CountryCode    string    `json:"country_code"`
+	Status         string    `json:"status"`
+	ExpiredAt      time.Time `json:"expired_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func newInvitationResponse(invitation *app.Invitation) *invitationResponse {
+	return &invitationResponse{
+		ID:             invitation.ID,
+		LocationID:     invitation.LocationID,
+		EmployeeID:     invitation.EmployeeID,
+		EmployeeRoleID: invitation.EmployeeRoleID,
+		PhoneNumber:    phone.FormatNational(invitation.PhoneNumber, invitation.CountryCode),
+		CountryCode:    invitation.CountryCode,
+		Status:         invitation.Status,
+		ExpiredAt:      invitation.ExpiredAt,
+		CreatedAt:      invitation.CreatedAt,
+		UpdatedAt:      invitation.UpdatedAt,
+	}
+}
+
+func (rd *invitationResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type invitationListResponse struct {
+	TotalCount int                   `json:"total_count,omitempty"`
+	PageInfo   *pageInfo             `json:"page_info,omitempty"`
+	Data       []*invitationResponse `json:"data"`
+}
+
+func newInvitationListResponse(invitations []*app.Invitation) *invitationListResponse {
+	data := []*invitationResponse{}
+
+	for _, invitation := range invitations {
+		data = append(data, newInvitationResponse(invitation))
+	}
+
+	return &invitationListResponse{
+		Data: data,
+	}
+}
+
+func (rd *invitationListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetInvitationsByLocationID(invitationService app.InvitationService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetInvitationsByLocationID"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		invitations, err := invitationService.GetInvitationsByLocationID(r.Context(), locationID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newInvitationListResponse(invitations))
+	}
+}
+
+func (s *server) handleCreateInvitation(invitationService app.InvitationService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateInvitation"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateInvitationInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		invitation, err := invitationService.CreateInvitation(r.Context(), input, actor, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newInvitationResponse(invitation))
+	}
+}
+
+func (s *server) handleRevokeInvitation(invitationService app.InvitationService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleRevokeInvitation"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		invitationID := chi.URLParam(r, "invitationID")
+
+		if locationID == "" || invitationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		invitation, err := invitationService.RevokeInvitation(r.Context(), locationID, invitationID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newInvitationResponse(invitation))
+	}
+}
+
+func (s *server) handleGetPendingInvitations(invitationService app.InvitationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		invitations, err := invitationService.GetPendingInvitations(r.Context(), currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newInvitationListResponse(invitations))
+	}
+}
+
+func (s *server) handleAcceptInvitation(invitationService app.InvitationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleAcceptInvitation"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		invitationID := chi.URLParam(r, "invitationID")
+
+		if invitationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		invitation, err := invitationService.AcceptInvitation(r.Context(), invitationID, currentUser)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newInvitationResponse(invitation))
+	}
+}
+
+type serviceResponse struct {
+	ID          string    `json:"id"`
+	LocationID  string    `json:"location_id"`
+	Name        string    `json:"name"`
+	Duration    int       `json:"duration"`
+	Price       int64     `json:"price"`
+	Category    string    `json:"category"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newServiceResponse(service *app.Service) *serviceResponse {
+	return &serviceResponse{
+		ID:          service.ID,
+		LocationID:  service.LocationID,
+		Name:        service.Name,
+		Duration:    service.Duration,
+		Price:       service.Price,
+		Category:    service.Category,
+		Description: service.Description,
+		CreatedAt:   service.CreatedAt,
+		UpdatedAt:   service.UpdatedAt,
+	}
+}
+
+func (rd *serviceResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type serviceListResponse struct {
+	TotalCount int                `json:"total_count,omitempty"`
+	PageInfo   *pageInfo          `json:"page_info,omitempty"`
+	Data       []*serviceResponse `json:"data"`
+}
+
+func newServiceListResponse(services []*app.Service) *serviceListResponse {
+	data := []*serviceResponse{}
+
+	for _, service := range services {
+		data = append(data, newServiceResponse(service))
+	}
+
+	return &serviceListResponse{
+		Data: data,
+	}
+}
+
+func (rd *serviceListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetServicesByLocationID(serviceService app.ServiceService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetServicesByLocationID"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		services, err := serviceService.GetServicesByLocationID(r.Context(), locationID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newServiceListResponse(services))
+	}
+}
+
+func (s *server) handleGetService(serviceService app.ServiceService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetService"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		serviceID := chi.URLParam(r, "serviceID")
+
+		if locationID == "" || serviceID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		service, err := serviceService.GetServiceByID(r.Context(), locationID, serviceID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newServiceResponse(service))
+	}
+}
+
+func (s *server) handleCreateService(serviceService app.ServiceService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateService"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateServiceInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		service, err := serviceService.CreateService(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newServiceResponse(service))
+	}
+}
+
+func (s *server) handleUpdateService(serviceService app.ServiceService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleUpdateService"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.UpdateServiceInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		serviceID := chi.URLParam(r, "serviceID")
+
+		if locationID == "" || serviceID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		service, err := serviceService.UpdateService(r.Context(), locationID, serviceID, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newServiceResponse(service))
+	}
+}
+
+func (s *server) handleDeleteService(serviceService app.ServiceService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleDeleteService"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		serviceID := chi.URLParam(r, "serviceID")
+
+		if locationID == "" || serviceID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		service, err := serviceService.DeleteService(r.Context(), locationID, serviceID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newServiceResponse(service))
+	}
+}
+
+type appointmentResponse struct {
+	ID         string    `json:"id"`
+	LocationID string    `json:"location_id"`
+	EmployeeID string    `json:"employee_id"`
+	ClientID   string    `json:"client_id"`
+	ServiceIDs []string  `json:"service_ids"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func newAppointmentResponse(appointment *app.Appointment) *appointmentResponse {
+	return &appointmentResponse{
+		ID:         appointment.ID,
+		LocationID: appointment.LocationID,
+		EmployeeID: appointment.EmployeeID,
+		ClientID:   appointment.ClientID,
+		ServiceIDs: appointment.ServiceIDs,
+		StartTime:  appointment.StartTime,
+		EndTime:    appointment.EndTime,
+		Note:       appointment.Note,
+		CreatedAt:  appointment.CreatedAt,
+		UpdatedAt:  appointment.UpdatedAt,
+	}
+}
+
+func (rd *appointmentResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type appointmentListResponse struct {
+	TotalCount int                    `json:"total_count,omitempty"`
+	PageInfo   *pageInfo              `json:"page_info,omitempty"`
+	Data       []*appointmentResponse `json:"data"`
+}
+
+func newAppointmentListResponse(appointments []*app.Appointment) *appointmentListResponse {
+	data := []*appointmentResponse{}
+
+	for _, appointment := range appointments {
+		data = append(data, newAppointmentResponse(appointment))
+	}
+
+	return &appointmentListResponse{
+		Data: data,
+	}
+}
+
+func (rd *appointmentListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// parseTimeRange reads start_time and end_time RFC3339 query params, defaulting to the upcoming week
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	const op = "server.parseTimeRange"
+
+	startTime := time.Now().UTC().Truncate(24 * time.Hour)
+	endTime := startTime.AddDate(0, 0, 7)
+
+	if value := r.URL.Query().Get("start_time"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return startTime, endTime, errors.Invalid(op, "invalid start_time")
+		}
+
+		startTime = t
+		endTime = startTime.AddDate(0, 0, 7)
+	}
+
+	if value := r.URL.Query().Get("end_time"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return startTime, endTime, errors.Invalid(op, "invalid end_time")
+		}
+
+		endTime = t
+	}
+
+	return startTime, endTime, nil
+}
+
+func (s *server) handleGetAppointmentsByLocationID(appointmentService app.AppointmentService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetAppointmentsByLocationID"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		startTime, endTime, err := parseTimeRange(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		appointments, err := appointmentService.GetAppointmentsByLocationID(r.Context(), locationID, startTime, endTime, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newAppointmentListResponse(appointments))
+	}
+}
+
+func (s *server) handleGetAppointment(appointmentService app.AppointmentService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetAppointment"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		appointmentID := chi.URLParam(r, "appointmentID")
+
+		if locationID == "" || appointmentID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		appointment, err := appointmentService.GetAppointmentByID(r.Context(), locationID, appointmentID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newAppointmentResponse(appointment))
+	}
+}
+
+func (s *server) handleCreateAppointment(appointmentService app.AppointmentService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateAppointment"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateAppointmentInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		appointment, err := appointmentService.CreateAppointment(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newAppointmentResponse(appointment))
+	}
+}
+
+func (s *server) handleUpdateAppointment(appointmentService app.AppointmentService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleUpdateAppointment"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.UpdateAppointmentInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		appointmentID := chi.URLParam(r, "appointmentID")
+
+		if locationID == "" || appointmentID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		appointment, err := appointmentService.UpdateAppointment(r.Context(), locationID, appointmentID, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newAppointmentResponse(appointment))
+	}
+}
+
+func (s *server) handleDeleteAppointment(appointmentService app.AppointmentService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleDeleteAppointment"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		appointmentID := chi.URLParam(r, "appointmentID")
+
+		if locationID == "" || appointmentID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		appointment, err := appointmentService.DeleteAppointment(r.Context(), locationID, appointmentID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newAppointmentResponse(appointment))
+	}
+}
+
+type workingHoursResponse struct {
+	ID          string    `json:"id"`
+	EmployeeID  string    `json:"employee_id"`
+	Weekday     int       `json:"weekday"`
+	StartMinute int       `json:"start_minute"`
+	EndMinute   int       `json:"end_minute"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newWorkingHoursResponse(workingHours *app.WorkingHours) *workingHoursResponse {
+	return &workingHoursResponse{
+		ID:          workingHours.ID,
+		EmployeeID:  workingHours.EmployeeID,
+		Weekday:     int(workingHours.Weekday),
+		StartMinute: workingHours.StartMinute,
+		EndMinute:   workingHours.EndMinute,
+		CreatedAt:   workingHours.CreatedAt,
+		UpdatedAt:   workingHours.UpdatedAt,
+	}
+}
+
+type workingHoursListResponse struct {
+	Data []*workingHoursResponse `json:"data"`
+}
+
+func newWorkingHoursListResponse(workingHours []*app.WorkingHours) *workingHoursListResponse {
+	data := []*workingHoursResponse{}
+
+	for _, wh := range workingHours {
+		data = append(data, newWorkingHoursResponse(wh))
+	}
+
+	return &workingHoursListResponse{
+		Data: data,
+	}
+}
+
+func (rd *workingHoursListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type workingHoursOverrideResponse struct {
+	ID          string    `json:"id"`
+	EmployeeID  string    `json:"employee_id"`
+	Date        string    `json:"date"`
+	StartMinute int       `json:"start_minute"`
+	EndMinute   int       `json:"end_minute"`
+	IsDayOff    bool      `json:"is_day_off"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newWorkingHoursOverrideResponse(override *app.WorkingHoursOverride) *workingHoursOverrideResponse {
+	return &workingHoursOverrideResponse{
+		ID:          override.ID,
+		EmployeeID:  override.EmployeeID,
+		Date:        override.Date.Format(dateLayout),
+		StartMinute: override.StartMinute,
+		EndMinute:   override.EndMinute,
+		IsDayOff:    override.IsDayOff,
+		CreatedAt:   override.CreatedAt,
+		UpdatedAt:   override.UpdatedAt,
+	}
+}
+
+func (rd *workingHoursOverrideResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type workingHoursOverrideListResponse struct {
+	Data []*workingHoursOverrideResponse `json:"data"`
+}
+
+func newWorkingHoursOverrideListResponse(overrides []*app.WorkingHoursOverride) *workingHoursOverrideListResponse {
+	data := []*workingHoursOverrideResponse{}
+
+	for _, override := range overrides {
+		data = append(data, newWorkingHoursOverrideResponse(override))
+	}
+
+	return &workingHoursOverrideListResponse{
+		Data: data,
+	}
+}
+
+func (rd *workingHoursOverrideListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type timeOffResponse struct {
+	ID         string    `json:"id"`
+	EmployeeID string    `json:"employee_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func newTimeOffResponse(timeOff *app.TimeOff) *timeOffResponse {
+	return &timeOffResponse{
+		ID:         timeOff.ID,
+		EmployeeID: timeOff.EmployeeID,
+		StartTime:  timeOff.StartTime,
+		EndTime:    timeOff.EndTime,
+		Reason:     timeOff.Reason,
+		CreatedAt:  timeOff.CreatedAt,
+		UpdatedAt:  timeOff.UpdatedAt,
+	}
+}
+
+func (rd *timeOffResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type timeOffListResponse struct {
+	Data []*timeOffResponse `json:"data"`
+}
+
+func newTimeOffListResponse(timeOffs []*app.TimeOff) *timeOffListResponse {
+	data := []*timeOffResponse{}
+
+	for _, timeOff := range timeOffs {
+		data = append(data, newTimeOffResponse(timeOff))
+	}
+
+	return &timeOffListResponse{
+		Data: data,
+	}
+}
+
+func (rd *timeOffListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type timeSlotResponse struct {
+	EmployeeID string    `json:"employee_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+}
+
+type availabilityResponse struct {
+	Data []*timeSlotResponse `json:"data"`
+}
+
+func newAvailabilityResponse(slots []*app.TimeSlot) *availabilityResponse {
+	data := []*timeSlotResponse{}
+
+	for _, slot := range slots {
+		data = append(data, &timeSlotResponse{
+			EmployeeID: slot.EmployeeID,
+			StartTime:  slot.StartTime,
+			EndTime:    slot.EndTime,
+		})
+	}
+
+	return &availabilityResponse{
+		Data: data,
+	}
+}
+
+func (rd *availabilityResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+const dateLayout = "2006-01-02"
+
+// parseDate reads a YYYY-MM-DD query param in the given time zone, falling back to today
+func parseDate(r *http.Request, key string, loc *time.Location) (time.Time, error) {
+	const op = "server.parseDate"
+
+	value := r.URL.Query().Get(key)
+
+	if value == "" {
+		year, month, day := time.Now().In(loc).Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, loc), nil
+	}
+
+	date, err := time.ParseInLocation(dateLayout, value, loc)
+
+	if err != nil {
+		return date, errors.Invalid(op, fmt.Sprintf("invalid %s", key))
+	}
+
+	return date, nil
+}
+
+func (s *server) handleGetWorkingHours(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetWorkingHours"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		workingHours, err := employeeScheduleService.GetWorkingHours(r.Context(), locationID, employeeID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newWorkingHoursListResponse(workingHours))
+	}
+}
+
+func (s *server) handleSetWorkingHours(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleSetWorkingHours"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.SetWorkingHoursInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		workingHours, err := employeeScheduleService.SetWorkingHours(r.Context(), locationID, employeeID, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newWorkingHoursListResponse(workingHours))
+	}
+}
+
+func (s *server) handleGetWorkingHoursOverrides(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetWorkingHoursOverrides"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		startDate, err := parseDate(r, "start_date", time.UTC)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		endDate := startDate.AddDate(0, 1, 0)
+
+		if r.URL.Query().Get("end_date") != "" {
+			endDate, err = parseDate(r, "end_date", time.UTC)
+
+			if err != nil {
+				s.respondError(w, r, err)
+				return
+			}
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		overrides, err := employeeScheduleService.GetWorkingHoursOverrides(r.Context(), locationID, employeeID, startDate, endDate, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newWorkingHoursOverrideListResponse(overrides))
+	}
+}
+
+func (s *server) handleCreateWorkingHoursOverride(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateWorkingHoursOverride"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateWorkingHoursOverrideInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+		input.EmployeeID = employeeID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		override, err := employeeScheduleService.CreateWorkingHoursOverride(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newWorkingHoursOverrideResponse(override))
+	}
+}
+
+func (s *server) handleDeleteWorkingHoursOverride(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleDeleteWorkingHoursOverride"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		overrideID := chi.URLParam(r, "overrideID")
+
+		if locationID == "" || overrideID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		override, err := employeeScheduleService.DeleteWorkingHoursOverride(r.Context(), locationID, overrideID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newWorkingHoursOverrideResponse(override))
+	}
+}
+
+func (s *server) handleGetTimeOffs(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetTimeOffs"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		startTime, endTime, err := parseTimeRange(r)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		timeOffs, err := employeeScheduleService.GetTimeOffs(r.Context(), locationID, employeeID, startTime, endTime, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newTimeOffListResponse(timeOffs))
+	}
+}
+
+func (s *server) handleCreateTimeOff(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateTimeOff"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateTimeOffInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		employeeID := chi.URLParam(r, "employeeID")
+
+		if locationID == "" || employeeID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+		input.EmployeeID = employeeID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		timeOff, err := employeeScheduleService.CreateTimeOff(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newTimeOffResponse(timeOff))
+	}
+}
+
+func (s *server) handleDeleteTimeOff(employeeScheduleService app.EmployeeScheduleService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleDeleteTimeOff"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		timeOffID := chi.URLParam(r, "timeOffID")
+
+		if locationID == "" || timeOffID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		timeOff, err := employeeScheduleService.DeleteTimeOff(r.Context(), locationID, timeOffID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newTimeOffResponse(timeOff))
+	}
+}
+
+func (s *server) handleGetAvailability(availabilityService app.AvailabilityService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetAvailability"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		serviceID := r.URL.Query().Get("service_id")
+
+		if locationID == "" || serviceID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		// Dates are days in the time zone of the location. The service defaults them to today at the location
+		var date, endDate time.Time
+		var err error
+
+		if r.URL.Query().Get("date") != "" {
+			date, err = parseDate(r, "date", time.UTC)
+
+			if err != nil {
+				s.respondError(w, r, err)
+				return
+			}
+		}
+
+		if r.URL.Query().Get("end_date") != "" {
+			endDate, err = parseDate(r, "end_date", time.UTC)
+
+			if err != nil {
+				s.respondError(w, r, err)
+				return
+			}
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input := &app.GetAvailableSlotsInput{
+			LocationID: locationID,
+			ServiceID:  serviceID,
+			EmployeeID: r.URL.Query().Get("employee_id"),
+			StartDate:  date,
+			EndDate:    endDate,
+		}
+
+		slots, err := availabilityService.GetAvailableSlots(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newAvailabilityResponse(slots))
+	}
+}
+
+type clientResponse struct {
+	ID          string    `json:"id"`
+	BusinessID  string    `json:"business_id"`
+	UserID      string    `json:"user_id"`
+	Name        string    `json:"name"`
+	PhoneNumber string    `json:"phone_number"`
+	CountryCode string    `json:"country_code"`
+	Email       string    `json:"email"`
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
+	Birthday    string    `json:"birthday"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newClientResponse(client *app.Client) *clientResponse {
+	birthday := ""
+
+	if !client.Birthday.IsZero() {
+		birthday = client.Birthday.Format(dateLayout)
+	}
+
+	return &clientResponse{
+		ID:          client.ID,
+		BusinessID:  client.BusinessID,
+		UserID:      client.UserID,
+		Name:        client.Name,
+		PhoneNumber: phone.FormatNational(client.PhoneNumber, client.CountryCode),
+		CountryCode: client.CountryCode,
+		Email:       client.Email,
+		Notes:       client.Notes,
+		Tags:        client.Tags,
+		Birthday:    birthday,
+		CreatedAt:   client.CreatedAt,
+		UpdatedAt:   client.UpdatedAt,
+	}
+}
+
+func (rd *clientResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type clientListResponse struct {
+	TotalCount int               `json:"total_count,omitempty"`
+	PageInfo   *pageInfo         `json:"page_info,omitempty"`
+	Data       []*clientResponse `json:"data"`
+}
+
+func newClientListResponse(clients []*app.Client) *clientListResponse {
+	data := []*clientResponse{}
+
+	for _, client := range clients {
+		data = append(data, newClientResponse(client))
+	}
+
+	return &clientListResponse{
+		Data: data,
+	}
+}
+
+func (rd *clientListResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *server) handleGetClients(clientService app.ClientService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetClients"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		clients, err := clientService.GetClients(r.Context(), locationID, r.URL.Query().Get("search"), actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newClientListResponse(clients))
+	}
+}
+
+func (s *server) handleGetClient(clientService app.ClientService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleGetClient"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		clientID := chi.URLParam(r, "clientID")
+
+		if locationID == "" || clientID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		client, err := clientService.GetClientByID(r.Context(), locationID, clientID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newClientResponse(client))
+	}
+}
+
+func (s *server) handleCreateClient(clientService app.ClientService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleCreateClient"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.CreateClientInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+
+		if locationID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		input.LocationID = locationID
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		client, err := clientService.CreateClient(r.Context(), input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newClientResponse(client))
+	}
+}
+
+func (s *server) handleUpdateClient(clientService app.ClientService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleUpdateClient"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+		input := &app.UpdateClientInput{}
+
+		locationID := chi.URLParam(r, "locationID")
+		clientID := chi.URLParam(r, "clientID")
+
+		if locationID == "" || clientID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		if err := s.decode(w, r, input); err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		client, err := clientService.UpdateClient(r.Context(), locationID, clientID, input, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newClientResponse(client))
+	}
+}
+
+func (s *server) handleDeleteClient(clientService app.ClientService, permissionsService app.PermissionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.handleDeleteClient"
+		currentUser, _ := r.Context().Value(userCtxKey).(*auth.User)
+
+		locationID := chi.URLParam(r, "locationID")
+		clientID := chi.URLParam(r, "clientID")
+
+		if locationID == "" || clientID == "" {
+			s.respondError(w, r, errors.Invalid(op, "missing param"))
+			return
+		}
+
+		actor, err := permissionsService.GetEmployeeActor(r.Context(), currentUser.ID, locationID)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		client, err := clientService.DeleteClient(r.Context(), locationID, clientID, actor)
+
+		if err != nil {
+			s.respondError(w, r, err)
+			return
+		}
+
+		render.Render(w, r, newClientResponse(client))
+	}
+}
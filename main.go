@@ -1,35 +1,201 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	_ "github.com/lib/pq"
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/auth"
 	"github.com/minheq/kedul_server_main/logger"
 	"github.com/minheq/kedul_server_main/phone"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	store := flag.String("store", "postgres", "where records are stored, postgres or memory")
+	flag.Parse()
+
 	router := chi.NewRouter()
 	log := logger.NewLogger()
-	dbURL := os.Getenv("DATABASE_URL")
-	db, err := sql.Open("postgres", dbURL)
-	smsSender := phone.NewSMSSender()
+	stores, err := openStores(*store, log)
+
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"store":        *store,
+			"DATABASE_URL": os.Getenv("DATABASE_URL"),
+			"error":        err.Error(),
+		}).Fatal("error opening stores")
+	}
+
+	smsSender, err := newSMSSender(stores.smsMessage)
 
 	if err != nil {
 		log.WithFields(logrus.Fields{
-			"DATABASE_URL": dbURL,
+			"SMS_PROVIDER": os.Getenv("SMS_PROVIDER"),
 			"error":        err.Error(),
-		}).Fatal("error opening database")
+		}).Fatal("error configuring sms provider")
+	}
+
+	tokenKeys, err := loadTokenKeys(*store == "memory", log)
+
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Fatal("error loading token keys")
 	}
 
-	server := newServer(db, router, log, smsSender)
+	timeouts, err := loadTimeouts()
+
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"REQUEST_TIMEOUT": os.Getenv("REQUEST_TIMEOUT"),
+			"QUERY_TIMEOUT":   os.Getenv("QUERY_TIMEOUT"),
+			"error":           err.Error(),
+		}).Fatal("error parsing timeouts")
+	}
+
+	server := newServer(stores, router, log, smsSender, tokenKeys, timeouts)
+
+	go purgeDeleted(stores, log)
 
 	fmt.Println("Server listening at localhost:4000")
 
 	http.ListenAndServe(":4000", server.router)
 }
+
+// openStores opens the stores selected by the -store flag. Memory stores do not need a database, but lose
+// their records when the server stops. Phone numbers stored by previous versions in Postgres are formatted first
+func openStores(store string, log *logger.Logger) (*stores, error) {
+	switch store {
+	case "memory":
+		return newMemoryStores(), nil
+	case "postgres":
+		db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		unformatted, err := formatPhoneNumbers(context.Background(), db)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range unformatted {
+			log.WithFields(logrus.Fields{
+				"table":        row.Table,
+				"id":           row.ID,
+				"phone_number": row.PhoneNumber,
+				"country_code": row.CountryCode,
+				"reason":       row.Reason,
+			}).Warn("phone number left unformatted, fix or merge the row by hand")
+		}
+
+		return newPostgresStores(db), nil
+	default:
+		return nil, fmt.Errorf("unknown store %q", store)
+	}
+}
+
+// purgeDeleted permanently deletes, every hour, the records deleted longer ago than they can be restored
+func purgeDeleted(stores *stores, log *logger.Logger) {
+	purgeService := app.NewPurgeService(stores.business, stores.location, stores.employee, stores.employeeRole, stores.service, stores.appointment, stores.employeeSchedule, stores.invitation, stores.client, stores.transactor)
+
+	for now := range time.Tick(time.Hour) {
+		err := purgeService.PurgeDeleted(context.Background(), now)
+
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err.Error(),
+			}).Error("error purging deleted records")
+		}
+	}
+}
+
+// loadTimeouts reads REQUEST_TIMEOUT and QUERY_TIMEOUT, e.g. 30s. Requests default to 30 seconds and queries to 10 seconds
+func loadTimeouts() (*timeouts, error) {
+	t := &timeouts{Request: 30 * time.Second, Query: 10 * time.Second}
+
+	for env, timeout := range map[string]*time.Duration{"REQUEST_TIMEOUT": &t.Request, "QUERY_TIMEOUT": &t.Query} {
+		value := os.Getenv(env)
+
+		if value == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", env, err)
+		}
+
+		*timeout = d
+	}
+
+	return t, nil
+}
+
+// loadTokenKeys reads the access token keys configured by JWT_SIGNING_KEY_FILE and the comma separated
+// JWT_VERIFICATION_KEY_FILES. The signing key is required, except in development with the memory stores, where
+// a key is generated which does not survive a restart
+func loadTokenKeys(dev bool, log *logger.Logger) (*auth.TokenKeys, error) {
+	signingKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE")
+
+	if signingKeyFile == "" && !dev {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE is required, unless -store=memory")
+	}
+
+	if signingKeyFile == "" {
+		log.Warn("JWT_SIGNING_KEY_FILE is not set, signing access tokens with a generated key. Tokens do not survive a restart")
+
+		return auth.GenerateTokenKeys()
+	}
+
+	config := &auth.TokenKeysConfig{SigningKeyFile: signingKeyFile}
+
+	for _, file := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			config.VerificationKeyFiles = append(config.VerificationKeyFiles, file)
+		}
+	}
+
+	return auth.LoadTokenKeys(config)
+}
+
+// newSMSSender sends through the provider selected by SMS_PROVIDER. Without one, messages are printed
+func newSMSSender(smsMessageStore phone.SMSMessageStore) (phone.SMSSender, error) {
+	config := &phone.SMSConfig{
+		Provider:          os.Getenv("SMS_PROVIDER"),
+		GatewayURL:        os.Getenv("SMS_GATEWAY_URL"),
+		GatewayAPIKey:     os.Getenv("SMS_GATEWAY_API_KEY"),
+		TwilioBaseURL:     os.Getenv("TWILIO_BASE_URL"),
+		TwilioAccountSID:  os.Getenv("TWILIO_ACCOUNT_SID"),
+		TwilioAuthToken:   os.Getenv("TWILIO_AUTH_TOKEN"),
+		TwilioFromNumber:  os.Getenv("TWILIO_FROM_NUMBER"),
+		StatusCallbackURL: os.Getenv("SMS_STATUS_CALLBACK_URL"),
+		Timeout:           10 * time.Second,
+		MaxAttempts:       3,
+		RetryBackoff:      500 * time.Millisecond,
+	}
+
+	provider, err := phone.NewSMSProvider(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if provider == nil {
+		return phone.NewSMSSender(), nil
+	}
+
+	return phone.NewProviderSMSSender(provider, smsMessageStore, config), nil
+}
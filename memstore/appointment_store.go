@@ -0,0 +1,160 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type appointmentStore struct {
+	mu           sync.RWMutex
+	appointments map[string]*app.Appointment
+}
+
+// NewAppointmentStore ...
+func NewAppointmentStore() app.AppointmentStore {
+	return &appointmentStore{appointments: map[string]*app.Appointment{}}
+}
+
+func copyAppointment(a *app.Appointment) *app.Appointment {
+	appointment := *a
+	appointment.ServiceIDs = append([]string{}, a.ServiceIDs...)
+
+	return &appointment
+}
+
+// filter returns copies of the Appointments matching, earliest start first
+func (s *appointmentStore) filter(match func(a *app.Appointment) bool) []*app.Appointment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	appointments := make([]*app.Appointment, 0)
+
+	for _, a := range s.appointments {
+		if match(a) {
+			appointments = append(appointments, copyAppointment(a))
+		}
+	}
+
+	sort.Slice(appointments, func(i, j int) bool {
+		return appointments[i].StartTime.Before(appointments[j].StartTime)
+	})
+
+	return appointments
+}
+
+// overlaps mirrors EX_appointment_1, which excludes overlapping Appointments of the same Employee
+func (s *appointmentStore) overlaps(appointment *app.Appointment) bool {
+	for _, a := range s.appointments {
+		if a.ID != appointment.ID && a.EmployeeID == appointment.EmployeeID &&
+			a.StartTime.Before(appointment.EndTime) && a.EndTime.After(appointment.StartTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetAppointmentsByLocationIDAndTimeRange ...
+func (s *appointmentStore) GetAppointmentsByLocationIDAndTimeRange(ctx context.Context, locationID string, startTime time.Time, endTime time.Time) ([]*app.Appointment, error) {
+	return s.filter(func(a *app.Appointment) bool {
+		return a.LocationID == locationID && a.StartTime.Before(endTime) && a.EndTime.After(startTime)
+	}), nil
+}
+
+// GetAppointmentsByEmployeeIDAndTimeRange ...
+func (s *appointmentStore) GetAppointmentsByEmployeeIDAndTimeRange(ctx context.Context, employeeID string, startTime time.Time, endTime time.Time) ([]*app.Appointment, error) {
+	return s.filter(func(a *app.Appointment) bool {
+		return a.EmployeeID == employeeID && a.StartTime.Before(endTime) && a.EndTime.After(startTime)
+	}), nil
+}
+
+// GetAppointmentByID ...
+func (s *appointmentStore) GetAppointmentByID(ctx context.Context, id string) (*app.Appointment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	appointment, ok := s.appointments[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyAppointment(appointment), nil
+}
+
+// StoreAppointment ...
+func (s *appointmentStore) StoreAppointment(ctx context.Context, appointment *app.Appointment) error {
+	const op = "memstore/appointmentStore.StoreAppointment"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.appointments[appointment.ID]; ok {
+		return uniqueViolation(op, "PK_appointment_1")
+	}
+
+	if s.overlaps(appointment) {
+		return errors.Conflict(op, "employee already has an appointment at this time").WithCode(errors.CodeAppointmentOverlap)
+	}
+
+	s.appointments[appointment.ID] = copyAppointment(appointment)
+
+	return nil
+}
+
+// UpdateAppointment ...
+func (s *appointmentStore) UpdateAppointment(ctx context.Context, appointment *app.Appointment) error {
+	const op = "memstore/appointmentStore.UpdateAppointment"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appointments[appointment.ID]
+
+	if !ok {
+		return nil
+	}
+
+	if s.overlaps(appointment) {
+		return errors.Conflict(op, "employee already has an appointment at this time").WithCode(errors.CodeAppointmentOverlap)
+	}
+
+	a.EmployeeID = appointment.EmployeeID
+	a.ClientID = appointment.ClientID
+	a.ServiceIDs = append([]string{}, appointment.ServiceIDs...)
+	a.StartTime = appointment.StartTime
+	a.EndTime = appointment.EndTime
+	a.Note = appointment.Note
+	a.UpdatedAt = appointment.UpdatedAt
+
+	return nil
+}
+
+// DeleteAppointment ...
+func (s *appointmentStore) DeleteAppointment(ctx context.Context, appointment *app.Appointment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.appointments, appointment.ID)
+
+	return nil
+}
+
+// PurgeAppointmentsByEmployeeIDs ...
+func (s *appointmentStore) PurgeAppointmentsByEmployeeIDs(ctx context.Context, employeeIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, appointment := range s.appointments {
+		if containsString(employeeIDs, appointment.EmployeeID) {
+			delete(s.appointments, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,430 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/auth"
+)
+
+type authStore struct {
+	mu                   sync.RWMutex
+	verificationCodes    map[string]*auth.VerificationCode
+	verificationFailures []*auth.VerificationFailure
+	users                map[string]*auth.User
+	refreshTokens        map[string]*auth.RefreshToken
+	sessions             map[string]*auth.Session
+}
+
+// NewAuthStore ...
+func NewAuthStore() auth.Store {
+	return &authStore{
+		verificationCodes: map[string]*auth.VerificationCode{},
+		users:             map[string]*auth.User{},
+		refreshTokens:     map[string]*auth.RefreshToken{},
+		sessions:          map[string]*auth.Session{},
+	}
+}
+
+func copyVerificationCode(vc *auth.VerificationCode) *auth.VerificationCode {
+	verificationCode := *vc
+
+	return &verificationCode
+}
+
+func copyUser(u *auth.User) *auth.User {
+	user := *u
+
+	return &user
+}
+
+func copyRefreshToken(rt *auth.RefreshToken) *auth.RefreshToken {
+	refreshToken := *rt
+
+	return &refreshToken
+}
+
+func copySession(s *auth.Session) *auth.Session {
+	session := *s
+
+	return &session
+}
+
+// GetVerificationCodeByVerificationID ...
+func (s *authStore) GetVerificationCodeByVerificationID(ctx context.Context, verificationID string) (*auth.VerificationCode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, vc := range s.verificationCodes {
+		if vc.VerificationID == verificationID {
+			return copyVerificationCode(vc), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetVerificationCodeByPhoneNumber ...
+func (s *authStore) GetVerificationCodeByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) (*auth.VerificationCode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, vc := range s.verificationCodes {
+		if vc.PhoneNumber == phoneNumber && vc.CountryCode == countryCode {
+			return copyVerificationCode(vc), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// StoreVerificationCode ...
+func (s *authStore) StoreVerificationCode(ctx context.Context, vc *auth.VerificationCode) error {
+	const op = "memstore/authStore.StoreVerificationCode"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.verificationCodes[vc.ID]; ok {
+		return uniqueViolation(op, "PK_verification_code_1")
+	}
+
+	for _, existing := range s.verificationCodes {
+		if existing.PhoneNumber == vc.PhoneNumber {
+			return uniqueViolation(op, "UN_verification_code_1")
+		}
+	}
+
+	s.verificationCodes[vc.ID] = copyVerificationCode(vc)
+
+	return nil
+}
+
+// IncrementVerificationCodeAttempts ...
+func (s *authStore) IncrementVerificationCodeAttempts(ctx context.Context, vc *auth.VerificationCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.verificationCodes[vc.ID]
+
+	if !ok {
+		return nil
+	}
+
+	existing.Attempts++
+	vc.Attempts = existing.Attempts
+
+	return nil
+}
+
+// DeleteVerificationCodeByPhoneNumber ...
+func (s *authStore) DeleteVerificationCodeByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, vc := range s.verificationCodes {
+		if vc.PhoneNumber == phoneNumber && vc.CountryCode == countryCode {
+			delete(s.verificationCodes, id)
+		}
+	}
+
+	return nil
+}
+
+// DeleteVerificationCodeByID ...
+func (s *authStore) DeleteVerificationCodeByID(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.verificationCodes, id)
+
+	return nil
+}
+
+// StoreVerificationFailure ...
+func (s *authStore) StoreVerificationFailure(ctx context.Context, failure *auth.VerificationFailure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := *failure
+	s.verificationFailures = append(s.verificationFailures, &f)
+
+	return nil
+}
+
+// CountVerificationFailuresByPhoneNumber ...
+func (s *authStore) CountVerificationFailuresByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string, since time.Time) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+
+	for _, f := range s.verificationFailures {
+		if f.PhoneNumber == phoneNumber && f.CountryCode == countryCode && !f.CreatedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CountVerificationFailuresByIPAddress ...
+func (s *authStore) CountVerificationFailuresByIPAddress(ctx context.Context, ipAddress string, since time.Time) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+
+	for _, f := range s.verificationFailures {
+		if f.IPAddress == ipAddress && !f.CreatedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// GetUserByID ...
+func (s *authStore) GetUserByID(ctx context.Context, id string) (*auth.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyUser(user), nil
+}
+
+// GetUserByPhoneNumber ...
+func (s *authStore) GetUserByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) (*auth.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.PhoneNumber == phoneNumber && user.CountryCode == countryCode {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// duplicateUser mirrors UN_kedul_user_1, which keeps phone numbers unique across Users
+func (s *authStore) duplicateUser(user *auth.User) bool {
+	for _, u := range s.users {
+		if u.ID != user.ID && u.PhoneNumber == user.PhoneNumber {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StoreUser ...
+func (s *authStore) StoreUser(ctx context.Context, user *auth.User) error {
+	const op = "memstore/authStore.StoreUser"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.ID]; ok {
+		return uniqueViolation(op, "PK_kedul_user_1")
+	}
+
+	if s.duplicateUser(user) {
+		return uniqueViolation(op, "UN_kedul_user_1")
+	}
+
+	u := copyUser(user)
+	u.ProfileImageID = ""
+	s.users[u.ID] = u
+
+	return nil
+}
+
+// UpdateUser ...
+func (s *authStore) UpdateUser(ctx context.Context, user *auth.User) error {
+	const op = "memstore/authStore.UpdateUser"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[user.ID]
+
+	if !ok {
+		return nil
+	}
+
+	if s.duplicateUser(user) {
+		return uniqueViolation(op, "UN_kedul_user_1")
+	}
+
+	u.FullName = user.FullName
+	u.PhoneNumber = user.PhoneNumber
+	u.CountryCode = user.CountryCode
+	u.ProfileImageID = user.ProfileImageID
+	u.IsPhoneNumberVerified = user.IsPhoneNumberVerified
+	u.CreatedAt = user.CreatedAt
+	u.UpdatedAt = user.UpdatedAt
+
+	return nil
+}
+
+// GetRefreshTokenByTokenHash ...
+func (s *authStore) GetRefreshTokenByTokenHash(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rt := range s.refreshTokens {
+		if rt.TokenHash == tokenHash {
+			return copyRefreshToken(rt), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// StoreRefreshToken ...
+func (s *authStore) StoreRefreshToken(ctx context.Context, refreshToken *auth.RefreshToken) error {
+	const op = "memstore/authStore.StoreRefreshToken"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.refreshTokens[refreshToken.ID]; ok {
+		return uniqueViolation(op, "PK_refresh_token_1")
+	}
+
+	for _, rt := range s.refreshTokens {
+		if rt.TokenHash == refreshToken.TokenHash {
+			return uniqueViolation(op, "UN_refresh_token_1")
+		}
+	}
+
+	rt := copyRefreshToken(refreshToken)
+	rt.RotatedAt = time.Time{}
+	rt.RevokedAt = time.Time{}
+	s.refreshTokens[rt.ID] = rt
+
+	return nil
+}
+
+// RotateRefreshToken ...
+func (s *authStore) RotateRefreshToken(ctx context.Context, refreshToken *auth.RefreshToken, rotatedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rt, ok := s.refreshTokens[refreshToken.ID]
+
+	if !ok || !rt.RotatedAt.IsZero() || !rt.RevokedAt.IsZero() {
+		return false, nil
+	}
+
+	rt.RotatedAt = rotatedAt
+	refreshToken.RotatedAt = rotatedAt
+
+	return true, nil
+}
+
+// RevokeRefreshTokensByFamilyID ...
+func (s *authStore) RevokeRefreshTokensByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rt := range s.refreshTokens {
+		if rt.FamilyID == familyID && rt.RevokedAt.IsZero() {
+			rt.RevokedAt = revokedAt
+		}
+	}
+
+	return nil
+}
+
+// GetSessionsByUserID ...
+func (s *authStore) GetSessionsByUserID(ctx context.Context, userID string) ([]*auth.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]*auth.Session, 0)
+
+	for _, session := range s.sessions {
+		if session.UserID == userID && !session.IsRevoked() {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// GetSessionByID ...
+func (s *authStore) GetSessionByID(ctx context.Context, id string) (*auth.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copySession(session), nil
+}
+
+// StoreSession ...
+func (s *authStore) StoreSession(ctx context.Context, session *auth.Session) error {
+	const op = "memstore/authStore.StoreSession"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[session.ID]; ok {
+		return uniqueViolation(op, "PK_user_session_1")
+	}
+
+	s.sessions[session.ID] = copySession(session)
+
+	return nil
+}
+
+// UpdateSession ...
+func (s *authStore) UpdateSession(ctx context.Context, session *auth.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.sessions[session.ID]
+
+	if !ok || existing.IsRevoked() {
+		return nil
+	}
+
+	existing.UserAgent = session.UserAgent
+	existing.IPAddress = session.IPAddress
+	existing.LastSeenAt = session.LastSeenAt
+
+	return nil
+}
+
+// RevokeSession ...
+func (s *authStore) RevokeSession(ctx context.Context, id string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.sessions[id]
+
+	if !ok || existing.IsRevoked() {
+		return nil
+	}
+
+	existing.RevokedAt = revokedAt
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type businessStore struct {
+	mu         sync.RWMutex
+	businesses map[string]*app.Business
+}
+
+// NewBusinessStore ...
+func NewBusinessStore() app.BusinessStore {
+	return &businessStore{businesses: map[string]*app.Business{}}
+}
+
+func copyBusiness(b *app.Business) *app.Business {
+	business := *b
+
+	return &business
+}
+
+// filter returns copies of the Businesses matching, oldest first
+func (s *businessStore) filter(match func(b *app.Business) bool) []*app.Business {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	businesses := make([]*app.Business, 0)
+
+	for _, b := range s.businesses {
+		if match(b) {
+			businesses = append(businesses, copyBusiness(b))
+		}
+	}
+
+	sort.Slice(businesses, func(i, j int) bool {
+		return cursorOf(businesses[i].CreatedAt, businesses[i].ID).Before(cursorOf(businesses[j].CreatedAt, businesses[j].ID))
+	})
+
+	return businesses
+}
+
+func (s *businessStore) first(match func(b *app.Business) bool) *app.Business {
+	businesses := s.filter(match)
+
+	if len(businesses) == 0 {
+		return nil
+	}
+
+	return businesses[0]
+}
+
+// GetBusinessesByIDs ...
+func (s *businessStore) GetBusinessesByIDs(ctx context.Context, ids []string) ([]*app.Business, error) {
+	return s.filter(func(b *app.Business) bool {
+		return containsString(ids, b.ID) && b.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetBusinessesByUserID ...
+func (s *businessStore) GetBusinessesByUserID(ctx context.Context, userID string) ([]*app.Business, error) {
+	return s.filter(func(b *app.Business) bool {
+		return b.UserID == userID && b.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetBusinessPageByUserIDOrIDs ...
+func (s *businessStore) GetBusinessPageByUserIDOrIDs(ctx context.Context, userID string, ids []string, args app.PageArgs) ([]*app.Business, *app.Page, error) {
+	const op = "memstore/businessStore.GetBusinessPageByUserIDOrIDs"
+
+	businesses := s.filter(func(b *app.Business) bool {
+		return (b.UserID == userID || containsString(ids, b.ID)) && b.DeletedAt.IsZero() && args.Filter.Match(b)
+	})
+
+	cursors := make([]app.Cursor, len(businesses))
+
+	for i, b := range businesses {
+		cursors[i] = args.CursorOf(b, b.CreatedAt, b.ID)
+	}
+
+	sort.Sort(byCursor{cursors: cursors, swap: func(i, j int) { businesses[i], businesses[j] = businesses[j], businesses[i] }})
+
+	start, end, page, err := app.Paginate(cursors, args)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
+	}
+
+	return businesses[start:end], page, nil
+}
+
+// GetBusinessByID ...
+func (s *businessStore) GetBusinessByID(ctx context.Context, id string) (*app.Business, error) {
+	return s.first(func(b *app.Business) bool {
+		return b.ID == id && b.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetBusinessByName ...
+func (s *businessStore) GetBusinessByName(ctx context.Context, name string) (*app.Business, error) {
+	return s.first(func(b *app.Business) bool {
+		return b.Name == name && b.DeletedAt.IsZero()
+	}), nil
+}
+
+// nameTaken reports whether another live Business has the name, as deleted Businesses release their names
+func (s *businessStore) nameTaken(id string, name string) bool {
+	for _, business := range s.businesses {
+		if business.ID != id && business.Name == name && business.DeletedAt.IsZero() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StoreBusiness ...
+func (s *businessStore) StoreBusiness(ctx context.Context, b *app.Business) error {
+	const op = "memstore/businessStore.StoreBusiness"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.businesses[b.ID]; ok {
+		return uniqueViolation(op, "PK_business_1")
+	}
+
+	if s.nameTaken(b.ID, b.Name) {
+		return uniqueViolation(op, "UN_business_1")
+	}
+
+	b.Version = 1
+	s.businesses[b.ID] = copyBusiness(b)
+
+	return nil
+}
+
+// UpdateBusiness ...
+func (s *businessStore) UpdateBusiness(ctx context.Context, b *app.Business) error {
+	const op = "memstore/businessStore.UpdateBusiness"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	business, ok := s.businesses[b.ID]
+
+	if !ok || business.Version != b.Version {
+		return errors.PreconditionFailed(op, "business was changed meanwhile")
+	}
+
+	if s.nameTaken(b.ID, b.Name) {
+		return uniqueViolation(op, "UN_business_1")
+	}
+
+	business.Name = b.Name
+	business.ProfileImageID = b.ProfileImageID
+	business.UpdatedAt = b.UpdatedAt
+	business.Version++
+	b.Version = business.Version
+
+	return nil
+}
+
+// DeleteBusiness ...
+func (s *businessStore) DeleteBusiness(ctx context.Context, b *app.Business) error {
+	const op = "memstore/businessStore.DeleteBusiness"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	business, ok := s.businesses[b.ID]
+
+	if !ok || business.Version != b.Version {
+		return errors.PreconditionFailed(op, "business was changed meanwhile")
+	}
+
+	business.DeletedAt = b.DeletedAt
+	business.Version++
+	b.Version = business.Version
+
+	return nil
+}
+
+// GetDeletedBusinessByID ...
+func (s *businessStore) GetDeletedBusinessByID(ctx context.Context, id string) (*app.Business, error) {
+	return s.first(func(b *app.Business) bool {
+		return b.ID == id && !b.DeletedAt.IsZero()
+	}), nil
+}
+
+// RestoreBusiness ...
+func (s *businessStore) RestoreBusiness(ctx context.Context, b *app.Business) error {
+	const op = "memstore/businessStore.RestoreBusiness"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	business, ok := s.businesses[b.ID]
+
+	if !ok {
+		return nil
+	}
+
+	if s.nameTaken(business.ID, business.Name) {
+		return uniqueViolation(op, "UN_business_1")
+	}
+
+	business.DeletedAt = time.Time{}
+	business.UpdatedAt = b.UpdatedAt
+
+	return nil
+}
+
+// PurgeBusinesses ...
+func (s *businessStore) PurgeBusinesses(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0)
+
+	for id, business := range s.businesses {
+		if !business.DeletedAt.IsZero() && business.DeletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+			delete(s.businesses, id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,195 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/minheq/kedul_server_main/app"
+)
+
+type clientStore struct {
+	mu      sync.RWMutex
+	clients map[string]*app.Client
+}
+
+// NewClientStore ...
+func NewClientStore() app.ClientStore {
+	return &clientStore{clients: map[string]*app.Client{}}
+}
+
+func copyClient(c *app.Client) *app.Client {
+	client := *c
+	client.Tags = append([]string{}, c.Tags...)
+
+	return &client
+}
+
+// filter returns copies of the Clients matching, sorted by name
+func (s *clientStore) filter(match func(c *app.Client) bool) []*app.Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clients := make([]*app.Client, 0)
+
+	for _, c := range s.clients {
+		if match(c) {
+			clients = append(clients, copyClient(c))
+		}
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Name < clients[j].Name
+	})
+
+	return clients
+}
+
+// duplicate mirrors UN_client_1, which keeps phone numbers unique within a Business
+func (s *clientStore) duplicate(client *app.Client) bool {
+	if client.PhoneNumber == "" {
+		return false
+	}
+
+	for _, c := range s.clients {
+		if c.ID != client.ID && c.BusinessID == client.BusinessID && c.PhoneNumber == client.PhoneNumber && c.CountryCode == client.CountryCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetClientsByBusinessID ...
+func (s *clientStore) GetClientsByBusinessID(ctx context.Context, businessID string, search string) ([]*app.Client, error) {
+	name := strings.ToLower(search)
+	phoneDigits := strings.TrimLeft(digitsOnly(search), "0")
+
+	return s.filter(func(c *app.Client) bool {
+		if c.BusinessID != businessID {
+			return false
+		}
+
+		return search == "" ||
+			strings.Contains(strings.ToLower(c.Name), name) ||
+			(phoneDigits != "" && strings.Contains(digitsOnly(c.PhoneNumber), phoneDigits))
+	}), nil
+}
+
+// GetClientsByPhoneNumber ...
+func (s *clientStore) GetClientsByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string) ([]*app.Client, error) {
+	return s.filter(func(c *app.Client) bool {
+		return c.PhoneNumber == phoneNumber && c.CountryCode == countryCode
+	}), nil
+}
+
+// GetClientByID ...
+func (s *clientStore) GetClientByID(ctx context.Context, id string) (*app.Client, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	client, ok := s.clients[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyClient(client), nil
+}
+
+// GetClientByPhoneNumber ...
+func (s *clientStore) GetClientByPhoneNumber(ctx context.Context, businessID string, phoneNumber string, countryCode string) (*app.Client, error) {
+	clients := s.filter(func(c *app.Client) bool {
+		return c.BusinessID == businessID && c.PhoneNumber == phoneNumber && c.CountryCode == countryCode
+	})
+
+	if len(clients) == 0 {
+		return nil, nil
+	}
+
+	return clients[0], nil
+}
+
+// StoreClient ...
+func (s *clientStore) StoreClient(ctx context.Context, client *app.Client) error {
+	const op = "memstore/clientStore.StoreClient"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[client.ID]; ok {
+		return uniqueViolation(op, "PK_client_1")
+	}
+
+	if s.duplicate(client) {
+		return uniqueViolation(op, "UN_client_1")
+	}
+
+	s.clients[client.ID] = copyClient(client)
+
+	return nil
+}
+
+// UpdateClient ...
+func (s *clientStore) UpdateClient(ctx context.Context, client *app.Client) error {
+	const op = "memstore/clientStore.UpdateClient"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.clients[client.ID]
+
+	if !ok {
+		return nil
+	}
+
+	if s.duplicate(client) {
+		return uniqueViolation(op, "UN_client_1")
+	}
+
+	c.UserID = client.UserID
+	c.Name = client.Name
+	c.PhoneNumber = client.PhoneNumber
+	c.CountryCode = client.CountryCode
+	c.Email = client.Email
+	c.Notes = client.Notes
+	c.Tags = append([]string{}, client.Tags...)
+	c.Birthday = client.Birthday
+	c.UpdatedAt = client.UpdatedAt
+
+	return nil
+}
+
+// DeleteClient ...
+func (s *clientStore) DeleteClient(ctx context.Context, client *app.Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.clients, client.ID)
+
+	return nil
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// PurgeClientsByBusinessIDs ...
+func (s *clientStore) PurgeClientsByBusinessIDs(ctx context.Context, businessIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, client := range s.clients {
+		if containsString(businessIDs, client.BusinessID) {
+			delete(s.clients, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type employeeRoleStore struct {
+	mu            sync.RWMutex
+	employeeRoles map[string]*app.EmployeeRole
+}
+
+// NewEmployeeRoleStore ...
+func NewEmployeeRoleStore() app.EmployeeRoleStore {
+	return &employeeRoleStore{employeeRoles: map[string]*app.EmployeeRole{}}
+}
+
+// copyEmployeeRole copies the EmployeeRole with its permissions, which are looked up by PermissionIDs like in Postgres
+func copyEmployeeRole(r *app.EmployeeRole) (*app.EmployeeRole, error) {
+	employeeRole := *r
+	employeeRole.PermissionIDs = append([]string{}, r.PermissionIDs...)
+
+	permissions, err := app.GetPermissionsByPermissionIDs(employeeRole.PermissionIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	employeeRole.Permissions = permissions
+
+	return &employeeRole, nil
+}
+
+// GetEmployeeRolesByLocationID ...
+func (s *employeeRoleStore) GetEmployeeRolesByLocationID(ctx context.Context, locationID string) ([]*app.EmployeeRole, error) {
+	const op = "memstore/employeeRoleStore.GetEmployeeRolesByLocationID"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	employeeRoles := make([]*app.EmployeeRole, 0)
+
+	for _, r := range s.employeeRoles {
+		if r.LocationID != locationID || !r.DeletedAt.IsZero() {
+			continue
+		}
+
+		employeeRole, err := copyEmployeeRole(r)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "failed to get permissions")
+		}
+
+		employeeRoles = append(employeeRoles, employeeRole)
+	}
+
+	sort.Slice(employeeRoles, func(i, j int) bool {
+		return cursorOf(employeeRoles[i].CreatedAt, employeeRoles[i].ID).Before(cursorOf(employeeRoles[j].CreatedAt, employeeRoles[j].ID))
+	})
+
+	return employeeRoles, nil
+}
+
+// GetEmployeeRolePageByLocationID ...
+func (s *employeeRoleStore) GetEmployeeRolePageByLocationID(ctx context.Context, locationID string, args app.PageArgs) ([]*app.EmployeeRole, *app.Page, error) {
+	const op = "memstore/employeeRoleStore.GetEmployeeRolePageByLocationID"
+
+	employeeRoles, err := s.GetEmployeeRolesByLocationID(ctx, locationID)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "failed to get employee roles by location id")
+	}
+
+	matching := make([]*app.EmployeeRole, 0)
+	cursors := make([]app.Cursor, 0)
+
+	for _, r := range employeeRoles {
+		if args.Filter.Match(r) {
+			matching = append(matching, r)
+			cursors = append(cursors, args.CursorOf(r, r.CreatedAt, r.ID))
+		}
+	}
+
+	employeeRoles = matching
+	sort.Sort(byCursor{cursors: cursors, swap: func(i, j int) { employeeRoles[i], employeeRoles[j] = employeeRoles[j], employeeRoles[i] }})
+
+	start, end, page, err := app.Paginate(cursors, args)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
+	}
+
+	return employeeRoles[start:end], page, nil
+}
+
+// GetEmployeeRoleByID ...
+func (s *employeeRoleStore) GetEmployeeRoleByID(ctx context.Context, id string) (*app.EmployeeRole, error) {
+	const op = "memstore/employeeRoleStore.GetEmployeeRoleByID"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.employeeRoles[id]
+
+	if !ok || !r.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	employeeRole, err := copyEmployeeRole(r)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get permissions")
+	}
+
+	return employeeRole, nil
+}
+
+// StoreEmployeeRole ...
+func (s *employeeRoleStore) StoreEmployeeRole(ctx context.Context, employeeRole *app.EmployeeRole) error {
+	const op = "memstore/employeeRoleStore.StoreEmployeeRole"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.employeeRoles[employeeRole.ID]; ok {
+		return uniqueViolation(op, "PK_employee_role_1")
+	}
+
+	employeeRole.Version = 1
+	r := *employeeRole
+	r.PermissionIDs = append([]string{}, employeeRole.PermissionIDs...)
+	r.Permissions = nil
+	s.employeeRoles[r.ID] = &r
+
+	return nil
+}
+
+// UpdateEmployeeRole ...
+func (s *employeeRoleStore) UpdateEmployeeRole(ctx context.Context, employeeRole *app.EmployeeRole) error {
+	const op = "memstore/employeeRoleStore.UpdateEmployeeRole"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.employeeRoles[employeeRole.ID]
+
+	if !ok || r.Version != employeeRole.Version {
+		return errors.PreconditionFailed(op, "employee role was changed meanwhile")
+	}
+
+	r.Name = employeeRole.Name
+	r.PermissionIDs = append([]string{}, employeeRole.PermissionIDs...)
+	r.UpdatedAt = employeeRole.UpdatedAt
+	r.Version++
+	employeeRole.Version = r.Version
+
+	return nil
+}
+
+// DeleteEmployeeRole ...
+func (s *employeeRoleStore) DeleteEmployeeRole(ctx context.Context, employeeRole *app.EmployeeRole) error {
+	const op = "memstore/employeeRoleStore.DeleteEmployeeRole"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.employeeRoles[employeeRole.ID]
+
+	if !ok || r.Version != employeeRole.Version {
+		return errors.PreconditionFailed(op, "employee role was changed meanwhile")
+	}
+
+	r.DeletedAt = employeeRole.DeletedAt
+	r.Version++
+	employeeRole.Version = r.Version
+
+	return nil
+}
+
+// DeleteEmployeeRolesByLocationID ...
+func (s *employeeRoleStore) DeleteEmployeeRolesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.employeeRoles {
+		if r.LocationID == locationID && r.DeletedAt.IsZero() {
+			r.DeletedAt = deletedAt
+		}
+	}
+
+	return nil
+}
+
+// RestoreEmployeeRolesByLocationID ...
+func (s *employeeRoleStore) RestoreEmployeeRolesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.employeeRoles {
+		if r.LocationID == locationID && r.DeletedAt.Equal(deletedAt) {
+			r.DeletedAt = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// PurgeEmployeeRoles ...
+func (s *employeeRoleStore) PurgeEmployeeRoles(ctx context.Context, deletedBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, r := range s.employeeRoles {
+		if !r.DeletedAt.IsZero() && r.DeletedAt.Before(deletedBefore) {
+			delete(s.employeeRoles, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+)
+
+const dateLayout = "2006-01-02"
+
+type employeeScheduleStore struct {
+	mu           sync.RWMutex
+	workingHours map[string][]*app.WorkingHours
+	overrides    map[string]*app.WorkingHoursOverride
+	timeOffs     map[string]*app.TimeOff
+}
+
+// NewEmployeeScheduleStore ...
+func NewEmployeeScheduleStore() app.EmployeeScheduleStore {
+	return &employeeScheduleStore{
+		workingHours: map[string][]*app.WorkingHours{},
+		overrides:    map[string]*app.WorkingHoursOverride{},
+		timeOffs:     map[string]*app.TimeOff{},
+	}
+}
+
+func copyWorkingHoursOverride(o *app.WorkingHoursOverride) *app.WorkingHoursOverride {
+	override := *o
+
+	return &override
+}
+
+func copyTimeOff(t *app.TimeOff) *app.TimeOff {
+	timeOff := *t
+
+	return &timeOff
+}
+
+// GetWorkingHoursByEmployeeID ...
+func (s *employeeScheduleStore) GetWorkingHoursByEmployeeID(ctx context.Context, employeeID string) ([]*app.WorkingHours, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workingHours := make([]*app.WorkingHours, 0)
+
+	for _, wh := range s.workingHours[employeeID] {
+		copied := *wh
+		workingHours = append(workingHours, &copied)
+	}
+
+	sort.Slice(workingHours, func(i, j int) bool {
+		if workingHours[i].Weekday != workingHours[j].Weekday {
+			return workingHours[i].Weekday < workingHours[j].Weekday
+		}
+
+		return workingHours[i].StartMinute < workingHours[j].StartMinute
+	})
+
+	return workingHours, nil
+}
+
+// ReplaceWorkingHours ...
+func (s *employeeScheduleStore) ReplaceWorkingHours(ctx context.Context, employeeID string, workingHours []*app.WorkingHours) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replaced := make([]*app.WorkingHours, 0, len(workingHours))
+
+	for _, wh := range workingHours {
+		copied := *wh
+		replaced = append(replaced, &copied)
+	}
+
+	s.workingHours[employeeID] = replaced
+
+	return nil
+}
+
+// GetWorkingHoursOverridesByEmployeeIDAndDateRange ...
+func (s *employeeScheduleStore) GetWorkingHoursOverridesByEmployeeIDAndDateRange(ctx context.Context, employeeID string, startDate time.Time, endDate time.Time) ([]*app.WorkingHoursOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := startDate.Format(dateLayout)
+	end := endDate.Format(dateLayout)
+	overrides := make([]*app.WorkingHoursOverride, 0)
+
+	for _, o := range s.overrides {
+		date := o.Date.Format(dateLayout)
+
+		if o.EmployeeID == employeeID && date >= start && date <= end {
+			overrides = append(overrides, copyWorkingHoursOverride(o))
+		}
+	}
+
+	sort.Slice(overrides, func(i, j int) bool {
+		if !overrides[i].Date.Equal(overrides[j].Date) {
+			return overrides[i].Date.Before(overrides[j].Date)
+		}
+
+		return overrides[i].StartMinute < overrides[j].StartMinute
+	})
+
+	return overrides, nil
+}
+
+// GetWorkingHoursOverrideByID ...
+func (s *employeeScheduleStore) GetWorkingHoursOverrideByID(ctx context.Context, id string) (*app.WorkingHoursOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	override, ok := s.overrides[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyWorkingHoursOverride(override), nil
+}
+
+// StoreWorkingHoursOverride ...
+func (s *employeeScheduleStore) StoreWorkingHoursOverride(ctx context.Context, override *app.WorkingHoursOverride) error {
+	const op = "memstore/employeeScheduleStore.StoreWorkingHoursOverride"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.overrides[override.ID]; ok {
+		return uniqueViolation(op, "PK_working_hours_override_1")
+	}
+
+	o := copyWorkingHoursOverride(override)
+
+	// Like the date column, keep only the date
+	o.Date, _ = time.Parse(dateLayout, override.Date.Format(dateLayout))
+	s.overrides[o.ID] = o
+
+	return nil
+}
+
+// DeleteWorkingHoursOverride ...
+func (s *employeeScheduleStore) DeleteWorkingHoursOverride(ctx context.Context, override *app.WorkingHoursOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.overrides, override.ID)
+
+	return nil
+}
+
+// GetTimeOffsByEmployeeIDAndTimeRange ...
+func (s *employeeScheduleStore) GetTimeOffsByEmployeeIDAndTimeRange(ctx context.Context, employeeID string, startTime time.Time, endTime time.Time) ([]*app.TimeOff, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	timeOffs := make([]*app.TimeOff, 0)
+
+	for _, t := range s.timeOffs {
+		if t.EmployeeID == employeeID && t.StartTime.Before(endTime) && t.EndTime.After(startTime) {
+			timeOffs = append(timeOffs, copyTimeOff(t))
+		}
+	}
+
+	sort.Slice(timeOffs, func(i, j int) bool {
+		return timeOffs[i].StartTime.Before(timeOffs[j].StartTime)
+	})
+
+	return timeOffs, nil
+}
+
+// GetTimeOffByID ...
+func (s *employeeScheduleStore) GetTimeOffByID(ctx context.Context, id string) (*app.TimeOff, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	timeOff, ok := s.timeOffs[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyTimeOff(timeOff), nil
+}
+
+// StoreTimeOff ...
+func (s *employeeScheduleStore) StoreTimeOff(ctx context.Context, timeOff *app.TimeOff) error {
+	const op = "memstore/employeeScheduleStore.StoreTimeOff"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.timeOffs[timeOff.ID]; ok {
+		return uniqueViolation(op, "PK_time_off_1")
+	}
+
+	s.timeOffs[timeOff.ID] = copyTimeOff(timeOff)
+
+	return nil
+}
+
+// DeleteTimeOff ...
+func (s *employeeScheduleStore) DeleteTimeOff(ctx context.Context, timeOff *app.TimeOff) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.timeOffs, timeOff.ID)
+
+	return nil
+}
+
+// PurgeEmployeeSchedulesByEmployeeIDs ...
+func (s *employeeScheduleStore) PurgeEmployeeSchedulesByEmployeeIDs(ctx context.Context, employeeIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, employeeID := range employeeIDs {
+		delete(s.workingHours, employeeID)
+	}
+
+	for id, o := range s.overrides {
+		if containsString(employeeIDs, o.EmployeeID) {
+			delete(s.overrides, id)
+		}
+	}
+
+	for id, t := range s.timeOffs {
+		if containsString(employeeIDs, t.EmployeeID) {
+			delete(s.timeOffs, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type employeeStore struct {
+	mu        sync.RWMutex
+	employees map[string]*app.Employee
+}
+
+// NewEmployeeStore ...
+func NewEmployeeStore() app.EmployeeStore {
+	return &employeeStore{employees: map[string]*app.Employee{}}
+}
+
+func copyEmployee(e *app.Employee) *app.Employee {
+	employee := *e
+
+	return &employee
+}
+
+// filter returns copies of the Employees matching, oldest first
+func (s *employeeStore) filter(match func(e *app.Employee) bool) []*app.Employee {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	employees := make([]*app.Employee, 0)
+
+	for _, e := range s.employees {
+		if match(e) {
+			employees = append(employees, copyEmployee(e))
+		}
+	}
+
+	sort.Slice(employees, func(i, j int) bool {
+		return cursorOf(employees[i].CreatedAt, employees[i].ID).Before(cursorOf(employees[j].CreatedAt, employees[j].ID))
+	})
+
+	return employees
+}
+
+func (s *employeeStore) first(match func(e *app.Employee) bool) *app.Employee {
+	employees := s.filter(match)
+
+	if len(employees) == 0 {
+		return nil
+	}
+
+	return employees[0]
+}
+
+// GetEmployeesByUserID ...
+func (s *employeeStore) GetEmployeesByUserID(ctx context.Context, userID string) ([]*app.Employee, error) {
+	return s.filter(func(e *app.Employee) bool {
+		return e.UserID != "" && e.UserID == userID && e.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetEmployeesByLocationID ...
+func (s *employeeStore) GetEmployeesByLocationID(ctx context.Context, locationID string) ([]*app.Employee, error) {
+	return s.filter(func(e *app.Employee) bool {
+		return e.LocationID == locationID && e.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetEmployeePageByLocationID ...
+func (s *employeeStore) GetEmployeePageByLocationID(ctx context.Context, locationID string, args app.PageArgs) ([]*app.Employee, *app.Page, error) {
+	const op = "memstore/employeeStore.GetEmployeePageByLocationID"
+
+	employees := s.filter(func(e *app.Employee) bool {
+		return e.LocationID == locationID && e.DeletedAt.IsZero() && args.Filter.Match(e)
+	})
+
+	cursors := make([]app.Cursor, len(employees))
+
+	for i, e := range employees {
+		cursors[i] = args.CursorOf(e, e.CreatedAt, e.ID)
+	}
+
+	sort.Sort(byCursor{cursors: cursors, swap: func(i, j int) { employees[i], employees[j] = employees[j], employees[i] }})
+
+	start, end, page, err := app.Paginate(cursors, args)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
+	}
+
+	return employees[start:end], page, nil
+}
+
+// GetEmployeesByEmployeeRoleID ...
+func (s *employeeStore) GetEmployeesByEmployeeRoleID(ctx context.Context, employeeRoleID string) ([]*app.Employee, error) {
+	return s.filter(func(e *app.Employee) bool {
+		return e.EmployeeRoleID == employeeRoleID && e.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetEmployeeByUserIDAndLocationID ...
+func (s *employeeStore) GetEmployeeByUserIDAndLocationID(ctx context.Context, userID string, locationID string) (*app.Employee, error) {
+	return s.first(func(e *app.Employee) bool {
+		return e.UserID != "" && e.UserID == userID && e.LocationID == locationID && e.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetEmployeeByID ...
+func (s *employeeStore) GetEmployeeByID(ctx context.Context, id string) (*app.Employee, error) {
+	return s.first(func(e *app.Employee) bool {
+		return e.ID == id && e.DeletedAt.IsZero()
+	}), nil
+}
+
+// StoreEmployee ...
+func (s *employeeStore) StoreEmployee(ctx context.Context, employee *app.Employee) error {
+	const op = "memstore/employeeStore.StoreEmployee"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.employees[employee.ID]; ok {
+		return uniqueViolation(op, "PK_employee_1")
+	}
+
+	employee.Version = 1
+	s.employees[employee.ID] = copyEmployee(employee)
+
+	return nil
+}
+
+// UpdateEmployee ...
+func (s *employeeStore) UpdateEmployee(ctx context.Context, employee *app.Employee) error {
+	const op = "memstore/employeeStore.UpdateEmployee"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.employees[employee.ID]
+
+	if !ok || e.Version != employee.Version {
+		return errors.PreconditionFailed(op, "employee was changed meanwhile")
+	}
+
+	e.UserID = employee.UserID
+	e.Name = employee.Name
+	e.EmployeeRoleID = employee.EmployeeRoleID
+	e.ProfileImageID = employee.ProfileImageID
+	e.UpdatedAt = employee.UpdatedAt
+	e.Version++
+	employee.Version = e.Version
+
+	return nil
+}
+
+// DeleteEmployee ...
+func (s *employeeStore) DeleteEmployee(ctx context.Context, employee *app.Employee) error {
+	const op = "memstore/employeeStore.DeleteEmployee"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.employees[employee.ID]
+
+	if !ok || e.Version != employee.Version {
+		return errors.PreconditionFailed(op, "employee was changed meanwhile")
+	}
+
+	e.DeletedAt = employee.DeletedAt
+	e.Version++
+	employee.Version = e.Version
+
+	return nil
+}
+
+// DeleteEmployeesByLocationID ...
+func (s *employeeStore) DeleteEmployeesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.employees {
+		if e.LocationID == locationID && e.DeletedAt.IsZero() {
+			e.DeletedAt = deletedAt
+		}
+	}
+
+	return nil
+}
+
+// RestoreEmployeesByLocationID ...
+func (s *employeeStore) RestoreEmployeesByLocationID(ctx context.Context, locationID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.employees {
+		if e.LocationID == locationID && e.DeletedAt.Equal(deletedAt) {
+			e.DeletedAt = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// PurgeEmployees ...
+func (s *employeeStore) PurgeEmployees(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0)
+
+	for id, e := range s.employees {
+		if !e.DeletedAt.IsZero() && e.DeletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+			delete(s.employees, id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,129 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+)
+
+type invitationStore struct {
+	mu          sync.RWMutex
+	invitations map[string]*app.Invitation
+}
+
+// NewInvitationStore ...
+func NewInvitationStore() app.InvitationStore {
+	return &invitationStore{invitations: map[string]*app.Invitation{}}
+}
+
+func copyInvitation(i *app.Invitation) *app.Invitation {
+	invitation := *i
+
+	return &invitation
+}
+
+// filter returns copies of the Invitations matching, newest first
+func (s *invitationStore) filter(match func(i *app.Invitation) bool) []*app.Invitation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invitations := make([]*app.Invitation, 0)
+
+	for _, i := range s.invitations {
+		if match(i) {
+			invitations = append(invitations, copyInvitation(i))
+		}
+	}
+
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+	})
+
+	return invitations
+}
+
+// GetInvitationsByLocationID ...
+func (s *invitationStore) GetInvitationsByLocationID(ctx context.Context, locationID string) ([]*app.Invitation, error) {
+	return s.filter(func(i *app.Invitation) bool {
+		return i.LocationID == locationID
+	}), nil
+}
+
+// GetPendingInvitationsByEmployeeID ...
+func (s *invitationStore) GetPendingInvitationsByEmployeeID(ctx context.Context, employeeID string) ([]*app.Invitation, error) {
+	return s.filter(func(i *app.Invitation) bool {
+		return i.EmployeeID == employeeID && i.Status == app.InvitationStatusPending
+	}), nil
+}
+
+// GetPendingInvitationsByPhoneNumber ...
+func (s *invitationStore) GetPendingInvitationsByPhoneNumber(ctx context.Context, phoneNumber string, countryCode string, now time.Time) ([]*app.Invitation, error) {
+	return s.filter(func(i *app.Invitation) bool {
+		return i.PhoneNumber == phoneNumber && i.CountryCode == countryCode && i.Status == app.InvitationStatusPending && i.ExpiredAt.After(now)
+	}), nil
+}
+
+// GetInvitationByID ...
+func (s *invitationStore) GetInvitationByID(ctx context.Context, id string) (*app.Invitation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invitation, ok := s.invitations[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyInvitation(invitation), nil
+}
+
+// StoreInvitation ...
+func (s *invitationStore) StoreInvitation(ctx context.Context, invitation *app.Invitation) error {
+	const op = "memstore/invitationStore.StoreInvitation"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.invitations[invitation.ID]; ok {
+		return uniqueViolation(op, "PK_invitation_1")
+	}
+
+	s.invitations[invitation.ID] = copyInvitation(invitation)
+
+	return nil
+}
+
+// UpdateInvitation ...
+func (s *invitationStore) UpdateInvitation(ctx context.Context, invitation *app.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.invitations[invitation.ID]
+
+	if !ok {
+		return nil
+	}
+
+	i.Status = invitation.Status
+	i.AcceptedByUserID = invitation.AcceptedByUserID
+	i.UpdatedAt = invitation.UpdatedAt
+
+	return nil
+}
+
+// PurgeInvitationsByEmployeeIDs ...
+func (s *invitationStore) PurgeInvitationsByEmployeeIDs(ctx context.Context, employeeIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, invitation := range s.invitations {
+		if containsString(employeeIDs, invitation.EmployeeID) {
+			delete(s.invitations, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type locationStore struct {
+	mu        sync.RWMutex
+	locations map[string]*app.Location
+}
+
+// NewLocationStore ...
+func NewLocationStore() app.LocationStore {
+	return &locationStore{locations: map[string]*app.Location{}}
+}
+
+func copyLocation(l *app.Location) *app.Location {
+	location := *l
+
+	return &location
+}
+
+// filter returns copies of the Locations matching, oldest first
+func (s *locationStore) filter(match func(l *app.Location) bool) []*app.Location {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	locations := make([]*app.Location, 0)
+
+	for _, l := range s.locations {
+		if match(l) {
+			locations = append(locations, copyLocation(l))
+		}
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return cursorOf(locations[i].CreatedAt, locations[i].ID).Before(cursorOf(locations[j].CreatedAt, locations[j].ID))
+	})
+
+	return locations
+}
+
+// GetLocationsByIDs ...
+func (s *locationStore) GetLocationsByIDs(ctx context.Context, ids []string) ([]*app.Location, error) {
+	return s.filter(func(l *app.Location) bool {
+		return containsString(ids, l.ID) && l.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetLocationsByBusinessID ...
+func (s *locationStore) GetLocationsByBusinessID(ctx context.Context, businessID string) ([]*app.Location, error) {
+	return s.filter(func(l *app.Location) bool {
+		return l.BusinessID == businessID && l.DeletedAt.IsZero()
+	}), nil
+}
+
+// GetLocationPageByBusinessIDAndIDs ...
+func (s *locationStore) GetLocationPageByBusinessIDAndIDs(ctx context.Context, businessID string, ids []string, args app.PageArgs) ([]*app.Location, *app.Page, error) {
+	const op = "memstore/locationStore.GetLocationPageByBusinessIDAndIDs"
+
+	locations := s.filter(func(l *app.Location) bool {
+		return l.BusinessID == businessID && containsString(ids, l.ID) && l.DeletedAt.IsZero() && args.Filter.Match(l)
+	})
+
+	cursors := make([]app.Cursor, len(locations))
+
+	for i, l := range locations {
+		cursors[i] = args.CursorOf(l, l.CreatedAt, l.ID)
+	}
+
+	sort.Sort(byCursor{cursors: cursors, swap: func(i, j int) { locations[i], locations[j] = locations[j], locations[i] }})
+
+	start, end, page, err := app.Paginate(cursors, args)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(op, err, "invalid page args")
+	}
+
+	return locations[start:end], page, nil
+}
+
+// GetLocationByID ...
+func (s *locationStore) GetLocationByID(ctx context.Context, id string) (*app.Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	location, ok := s.locations[id]
+
+	if !ok || !location.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	return copyLocation(location), nil
+}
+
+// StoreLocation ...
+func (s *locationStore) StoreLocation(ctx context.Context, location *app.Location) error {
+	const op = "memstore/locationStore.StoreLocation"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.locations[location.ID]; ok {
+		return uniqueViolation(op, "PK_location_1")
+	}
+
+	location.Version = 1
+	s.locations[location.ID] = copyLocation(location)
+
+	return nil
+}
+
+// UpdateLocation ...
+func (s *locationStore) UpdateLocation(ctx context.Context, location *app.Location) error {
+	const op = "memstore/locationStore.UpdateLocation"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.locations[location.ID]
+
+	if !ok || l.Version != location.Version {
+		return errors.PreconditionFailed(op, "location was changed meanwhile")
+	}
+
+	l.Name = location.Name
+	l.ProfileImageID = location.ProfileImageID
+	l.TimeZone = location.TimeZone
+	l.UpdatedAt = location.UpdatedAt
+	l.Version++
+	location.Version = l.Version
+
+	return nil
+}
+
+// DeleteLocation ...
+func (s *locationStore) DeleteLocation(ctx context.Context, location *app.Location) error {
+	const op = "memstore/locationStore.DeleteLocation"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.locations[location.ID]
+
+	if !ok || l.Version != location.Version {
+		return errors.PreconditionFailed(op, "location was changed meanwhile")
+	}
+
+	l.DeletedAt = location.DeletedAt
+	l.Version++
+	location.Version = l.Version
+
+	return nil
+}
+
+// DeleteLocationsByBusinessID ...
+func (s *locationStore) DeleteLocationsByBusinessID(ctx context.Context, businessID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, l := range s.locations {
+		if l.BusinessID == businessID && l.DeletedAt.IsZero() {
+			l.DeletedAt = deletedAt
+		}
+	}
+
+	return nil
+}
+
+// GetDeletedLocationByID ...
+func (s *locationStore) GetDeletedLocationByID(ctx context.Context, id string) (*app.Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	location, ok := s.locations[id]
+
+	if !ok || location.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	return copyLocation(location), nil
+}
+
+// RestoreLocation ...
+func (s *locationStore) RestoreLocation(ctx context.Context, location *app.Location) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.locations[location.ID]; ok {
+		l.DeletedAt = time.Time{}
+		l.UpdatedAt = location.UpdatedAt
+	}
+
+	return nil
+}
+
+// RestoreLocationsByBusinessID ...
+func (s *locationStore) RestoreLocationsByBusinessID(ctx context.Context, businessID string, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, l := range s.locations {
+		if l.BusinessID == businessID && l.DeletedAt.Equal(deletedAt) {
+			l.DeletedAt = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// PurgeLocations ...
+func (s *locationStore) PurgeLocations(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0)
+
+	for id, l := range s.locations {
+		if !l.DeletedAt.IsZero() && l.DeletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+			delete(s.locations, id)
+		}
+	}
+
+	return ids, nil
+}
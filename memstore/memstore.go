@@ -0,0 +1,73 @@
+// Package memstore implements the stores in memory, so that the server runs without Postgres, e.g. during
+// development and in the tests of the services. Records are copied in and out of the stores, so that changes are
+// only visible once stored
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+type transactionKey struct{}
+
+type transactor struct {
+	mu sync.Mutex
+}
+
+// NewTransactor constructor for a Transactor of the memory stores. Transactions run one at a time, so that
+// concurrent multi-write operations do not interleave. They are not rolled back when they fail
+func NewTransactor() transaction.Transactor {
+	return &transactor{}
+}
+
+// WithinTransaction ...
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(transactionKey{}) != nil {
+		return fn(ctx)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return fn(context.WithValue(ctx, transactionKey{}, true))
+}
+
+// uniqueViolation reports a duplicate the same way the Postgres stores report a violated unique constraint
+func uniqueViolation(op string, constraint string) error {
+	return errors.Wrap(op, fmt.Errorf("duplicate key value violates unique constraint %q", constraint), "database error")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cursorOf returns the cursor of a record. Lists are ordered by cursor, like in Postgres, so that pages do not overlap
+func cursorOf(createdAt time.Time, id string) app.Cursor {
+	return app.Cursor{CreatedAt: createdAt, ID: id}
+}
+
+// byCursor sorts records by their cursors. swap swaps the records along with their cursors
+type byCursor struct {
+	cursors []app.Cursor
+	swap    func(i, j int)
+}
+
+func (s byCursor) Len() int           { return len(s.cursors) }
+func (s byCursor) Less(i, j int) bool { return s.cursors[i].Before(s.cursors[j]) }
+
+func (s byCursor) Swap(i, j int) {
+	s.cursors[i], s.cursors[j] = s.cursors[j], s.cursors[i]
+	s.swap(i, j)
+}
@@ -0,0 +1,39 @@
+package memstore
+
+import (
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/storetest"
+)
+
+func TestBusinessStore(t *testing.T) {
+	storetest.TestBusinessStore(t, func(t *testing.T) app.BusinessStore {
+		return NewBusinessStore()
+	})
+}
+
+func TestLocationStore(t *testing.T) {
+	storetest.TestLocationStore(t, func(t *testing.T) app.LocationStore {
+		return NewLocationStore()
+	})
+}
+
+func TestEmployeeStore(t *testing.T) {
+	storetest.TestEmployeeStore(t, func(t *testing.T) app.EmployeeStore {
+		return NewEmployeeStore()
+	})
+}
+
+func TestEmployeeRoleStore(t *testing.T) {
+	storetest.TestEmployeeRoleStore(t, func(t *testing.T) app.EmployeeRoleStore {
+		return NewEmployeeRoleStore()
+	})
+}
+
+func TestAuthStore(t *testing.T) {
+	storetest.TestAuthStore(t, func(t *testing.T) auth.Store {
+		return NewAuthStore()
+	})
+}
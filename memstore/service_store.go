@@ -0,0 +1,120 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/minheq/kedul_server_main/app"
+)
+
+type serviceStore struct {
+	mu       sync.RWMutex
+	services map[string]*app.Service
+}
+
+// NewServiceStore ...
+func NewServiceStore() app.ServiceStore {
+	return &serviceStore{services: map[string]*app.Service{}}
+}
+
+func copyService(s *app.Service) *app.Service {
+	service := *s
+
+	return &service
+}
+
+// GetServicesByLocationID ...
+func (s *serviceStore) GetServicesByLocationID(ctx context.Context, locationID string) ([]*app.Service, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	services := make([]*app.Service, 0)
+
+	for _, service := range s.services {
+		if service.LocationID == locationID {
+			services = append(services, copyService(service))
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	return services, nil
+}
+
+// GetServiceByID ...
+func (s *serviceStore) GetServiceByID(ctx context.Context, id string) (*app.Service, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	service, ok := s.services[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyService(service), nil
+}
+
+// StoreService ...
+func (s *serviceStore) StoreService(ctx context.Context, service *app.Service) error {
+	const op = "memstore/serviceStore.StoreService"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.services[service.ID]; ok {
+		return uniqueViolation(op, "PK_service_1")
+	}
+
+	s.services[service.ID] = copyService(service)
+
+	return nil
+}
+
+// UpdateService ...
+func (s *serviceStore) UpdateService(ctx context.Context, service *app.Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.services[service.ID]
+
+	if !ok {
+		return nil
+	}
+
+	existing.Name = service.Name
+	existing.Duration = service.Duration
+	existing.Price = service.Price
+	existing.Category = service.Category
+	existing.Description = service.Description
+	existing.UpdatedAt = service.UpdatedAt
+
+	return nil
+}
+
+// DeleteService ...
+func (s *serviceStore) DeleteService(ctx context.Context, service *app.Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.services, service.ID)
+
+	return nil
+}
+
+// PurgeServicesByLocationIDs ...
+func (s *serviceStore) PurgeServicesByLocationIDs(ctx context.Context, locationIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, service := range s.services {
+		if containsString(locationIDs, service.LocationID) {
+			delete(s.services, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package memstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/minheq/kedul_server_main/phone"
+)
+
+type smsMessageStore struct {
+	mu          sync.RWMutex
+	smsMessages map[string]*phone.SMSMessage
+}
+
+// NewSMSMessageStore ...
+func NewSMSMessageStore() phone.SMSMessageStore {
+	return &smsMessageStore{smsMessages: map[string]*phone.SMSMessage{}}
+}
+
+func copySMSMessage(m *phone.SMSMessage) *phone.SMSMessage {
+	smsMessage := *m
+
+	return &smsMessage
+}
+
+// GetSMSMessageByProviderMessageID ...
+func (s *smsMessageStore) GetSMSMessageByProviderMessageID(ctx context.Context, provider string, providerMessageID string) (*phone.SMSMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, m := range s.smsMessages {
+		if m.Provider == provider && m.ProviderMessageID == providerMessageID {
+			return copySMSMessage(m), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// StoreSMSMessage ...
+func (s *smsMessageStore) StoreSMSMessage(ctx context.Context, smsMessage *phone.SMSMessage) error {
+	const op = "memstore/smsMessageStore.StoreSMSMessage"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.smsMessages[smsMessage.ID]; ok {
+		return uniqueViolation(op, "PK_sms_message_1")
+	}
+
+	s.smsMessages[smsMessage.ID] = copySMSMessage(smsMessage)
+
+	return nil
+}
+
+// UpdateSMSMessage ...
+func (s *smsMessageStore) UpdateSMSMessage(ctx context.Context, smsMessage *phone.SMSMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.smsMessages[smsMessage.ID]
+
+	if !ok {
+		return nil
+	}
+
+	m.ProviderMessageID = smsMessage.ProviderMessageID
+	m.Status = smsMessage.Status
+	m.Attempts = smsMessage.Attempts
+	m.Error = smsMessage.Error
+	m.UpdatedAt = smsMessage.UpdatedAt
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/minheq/kedul_server_main/phone"
+)
+
+type smsTemplateStore struct {
+	mu           sync.RWMutex
+	smsTemplates map[string]*phone.SMSTemplate
+}
+
+// NewSMSTemplateStore ...
+func NewSMSTemplateStore() phone.SMSTemplateStore {
+	return &smsTemplateStore{smsTemplates: map[string]*phone.SMSTemplate{}}
+}
+
+func copySMSTemplate(t *phone.SMSTemplate) *phone.SMSTemplate {
+	smsTemplate := *t
+
+	return &smsTemplate
+}
+
+// GetSMSTemplatesByBusinessID ...
+func (s *smsTemplateStore) GetSMSTemplatesByBusinessID(ctx context.Context, businessID string) ([]*phone.SMSTemplate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	smsTemplates := make([]*phone.SMSTemplate, 0)
+
+	for _, t := range s.smsTemplates {
+		if t.BusinessID == businessID {
+			smsTemplates = append(smsTemplates, copySMSTemplate(t))
+		}
+	}
+
+	sort.Slice(smsTemplates, func(i, j int) bool {
+		if smsTemplates[i].MessageType != smsTemplates[j].MessageType {
+			return smsTemplates[i].MessageType < smsTemplates[j].MessageType
+		}
+
+		return smsTemplates[i].Locale < smsTemplates[j].Locale
+	})
+
+	return smsTemplates, nil
+}
+
+// GetSMSTemplate ...
+func (s *smsTemplateStore) GetSMSTemplate(ctx context.Context, businessID string, messageType string, locale string) (*phone.SMSTemplate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.smsTemplates {
+		if t.BusinessID == businessID && t.MessageType == messageType && t.Locale == locale {
+			return copySMSTemplate(t), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// StoreSMSTemplate ...
+func (s *smsTemplateStore) StoreSMSTemplate(ctx context.Context, smsTemplate *phone.SMSTemplate) error {
+	const op = "memstore/smsTemplateStore.StoreSMSTemplate"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.smsTemplates[smsTemplate.ID]; ok {
+		return uniqueViolation(op, "PK_sms_template_1")
+	}
+
+	for _, t := range s.smsTemplates {
+		if t.BusinessID == smsTemplate.BusinessID && t.MessageType == smsTemplate.MessageType && t.Locale == smsTemplate.Locale {
+			return uniqueViolation(op, "UN_sms_template_1")
+		}
+	}
+
+	s.smsTemplates[smsTemplate.ID] = copySMSTemplate(smsTemplate)
+
+	return nil
+}
+
+// UpdateSMSTemplate ...
+func (s *smsTemplateStore) UpdateSMSTemplate(ctx context.Context, smsTemplate *phone.SMSTemplate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.smsTemplates[smsTemplate.ID]
+
+	if !ok {
+		return nil
+	}
+
+	t.Body = smsTemplate.Body
+	t.UpdatedAt = smsTemplate.UpdatedAt
+
+	return nil
+}
+
+// DeleteSMSTemplate ...
+func (s *smsTemplateStore) DeleteSMSTemplate(ctx context.Context, smsTemplate *phone.SMSTemplate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.smsTemplates, smsTemplate.ID)
+
+	return nil
+}
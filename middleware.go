@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/go-chi/jwtauth"
 	"github.com/minheq/kedul_server_main/auth"
 	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
 )
 
 type contextKey struct {
@@ -19,12 +21,31 @@ var (
 	userCtxKey = &contextKey{"user"}
 )
 
+// timeout cancels the request once requestTimeout elapses, and each of its queries once queryTimeout elapses
+func (s *server) timeout(requestTimeout time.Duration, queryTimeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+			defer cancel()
+
+			ctx = transaction.WithQueryTimeout(ctx, queryTimeout)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func (s *server) addCurrentUserContext(authService auth.Service) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			const op = "server.addCurrentUserContext"
 			user, err := authService.GetCurrentUser(r.Context())
 
+			if errors.Is(errors.KindTimeout, err) {
+				s.respondError(w, r, errors.Wrap(op, err, "failed to get current user"))
+				return
+			}
+
 			if err != nil {
 				s.respondError(w, r, errors.Unauthorized(op, err))
 				return
@@ -37,40 +58,73 @@ func (s *server) addCurrentUserContext(authService auth.Service) func(next http.
 	}
 }
 
-func (s *server) authenticate(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		const op = "server.authenticate"
-		token, _ := r.Context().Value(jwtauth.TokenCtxKey).(*jwt.Token)
+// verifyToken puts the access token of the request into the context the same way jwtauth.Verifier does,
+// verifying it with any of the configured keys
+func (s *server) verifyToken(tokenKeys *auth.TokenKeys) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var token *jwt.Token
+			err := jwtauth.ErrNoTokenFound
+
+			for _, findToken := range []func(r *http.Request) string{jwtauth.TokenFromQuery, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie} {
+				tokenString := findToken(r)
+
+				if tokenString != "" {
+					token, err = tokenKeys.Decode(tokenString)
+					break
+				}
+			}
 
-		var claims jwt.MapClaims
+			ctx := jwtauth.NewContext(r.Context(), token, err)
 
-		if token != nil {
-			if tokenClaims, ok := token.Claims.(jwt.MapClaims); ok {
-				claims = tokenClaims
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (s *server) authenticate(authService auth.Service) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "server.authenticate"
+			token, _ := r.Context().Value(jwtauth.TokenCtxKey).(*jwt.Token)
+
+			var claims jwt.MapClaims
+
+			if token != nil {
+				if tokenClaims, ok := token.Claims.(jwt.MapClaims); ok {
+					claims = tokenClaims
+				} else {
+					s.respondError(w, r, errors.Unauthorized(op, fmt.Errorf("jwtauth: unknown type of Claims: %T", token.Claims)))
+				}
 			} else {
-				s.respondError(w, r, errors.Unauthorized(op, fmt.Errorf("jwtauth: unknown type of Claims: %T", token.Claims)))
+				claims = jwt.MapClaims{}
+			}
+
+			err, _ := r.Context().Value(jwtauth.ErrorCtxKey).(error)
+
+			if err != nil {
+				s.respondError(w, r, errors.Unauthorized(op, err))
+				return
 			}
-		} else {
-			claims = jwt.MapClaims{}
-		}
 
-		err, _ := r.Context().Value(jwtauth.ErrorCtxKey).(error)
+			if token == nil || !token.Valid {
+				s.respondError(w, r, errors.Unauthorized(op, fmt.Errorf("invalid token")))
+				return
+			}
 
-		if err != nil {
-			s.respondError(w, r, errors.Unauthorized(op, err))
-			return
-		}
+			if claims == nil {
+				s.respondError(w, r, errors.Unauthorized(op, fmt.Errorf("invalid claims")))
+				return
+			}
 
-		if token == nil || !token.Valid {
-			s.respondError(w, r, errors.Unauthorized(op, fmt.Errorf("invalid token")))
-			return
-		}
+			err = authService.VerifySession(r.Context())
 
-		if claims == nil {
-			s.respondError(w, r, errors.Unauthorized(op, fmt.Errorf("invalid claims")))
-			return
-		}
+			if err != nil {
+				s.respondError(w, r, errors.Wrap(op, err, "failed to verify session"))
+				return
+			}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,12 @@
+CREATE TABLE service (
+  id UUID NOT NULL,
+  location_id UUID NOT NULL,
+  name TEXT NOT NULL,
+  duration INTEGER NOT NULL,
+  price BIGINT NOT NULL,
+  category TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_service_1" PRIMARY KEY (id)
+);
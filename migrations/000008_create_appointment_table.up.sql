@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE appointment (
+  id UUID NOT NULL,
+  location_id UUID NOT NULL,
+  employee_id UUID NOT NULL,
+  client_id UUID,
+  service_ids UUID [] NOT NULL,
+  start_time TIMESTAMPTZ NOT NULL,
+  end_time TIMESTAMPTZ NOT NULL,
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_appointment_1" PRIMARY KEY (id),
+  CONSTRAINT "CK_appointment_1" CHECK (start_time < end_time),
+  CONSTRAINT "EX_appointment_1" EXCLUDE USING gist (employee_id WITH =, tstzrange(start_time, end_time) WITH &&)
+);
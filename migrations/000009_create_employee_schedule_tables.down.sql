@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS time_off;
+DROP TABLE IF EXISTS working_hours_override;
+DROP TABLE IF EXISTS working_hours;
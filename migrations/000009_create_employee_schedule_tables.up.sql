@@ -0,0 +1,37 @@
+CREATE TABLE working_hours (
+  id UUID NOT NULL,
+  employee_id UUID NOT NULL,
+  weekday INTEGER NOT NULL,
+  start_minute INTEGER NOT NULL,
+  end_minute INTEGER NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_working_hours_1" PRIMARY KEY (id),
+  CONSTRAINT "CK_working_hours_1" CHECK (weekday BETWEEN 0 AND 6),
+  CONSTRAINT "CK_working_hours_2" CHECK (0 <= start_minute AND start_minute < end_minute AND end_minute <= 1440)
+);
+
+CREATE TABLE working_hours_override (
+  id UUID NOT NULL,
+  employee_id UUID NOT NULL,
+  date DATE NOT NULL,
+  start_minute INTEGER NOT NULL,
+  end_minute INTEGER NOT NULL,
+  is_day_off BOOLEAN NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_working_hours_override_1" PRIMARY KEY (id),
+  CONSTRAINT "CK_working_hours_override_1" CHECK (0 <= start_minute AND start_minute <= end_minute AND end_minute <= 1440)
+);
+
+CREATE TABLE time_off (
+  id UUID NOT NULL,
+  employee_id UUID NOT NULL,
+  start_time TIMESTAMPTZ NOT NULL,
+  end_time TIMESTAMPTZ NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_time_off_1" PRIMARY KEY (id),
+  CONSTRAINT "CK_time_off_1" CHECK (start_time < end_time)
+);
@@ -0,0 +1,18 @@
+CREATE TABLE client (
+  id UUID NOT NULL,
+  business_id UUID NOT NULL,
+  user_id UUID,
+  name TEXT NOT NULL,
+  phone_number TEXT NOT NULL DEFAULT '',
+  country_code TEXT NOT NULL DEFAULT '',
+  email TEXT NOT NULL DEFAULT '',
+  notes TEXT NOT NULL DEFAULT '',
+  tags TEXT [] NOT NULL DEFAULT '{}',
+  birthday DATE,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_client_1" PRIMARY KEY (id)
+);
+
+CREATE INDEX "IX_client_1" ON client (business_id, name);
+CREATE UNIQUE INDEX "UN_client_1" ON client (business_id, phone_number, country_code) WHERE phone_number <> '';
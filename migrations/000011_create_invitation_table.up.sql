@@ -0,0 +1,18 @@
+CREATE TABLE invitation (
+  id UUID NOT NULL,
+  location_id UUID NOT NULL,
+  employee_id UUID NOT NULL,
+  employee_role_id UUID NOT NULL,
+  phone_number TEXT NOT NULL,
+  country_code TEXT NOT NULL,
+  status TEXT NOT NULL,
+  invited_by_user_id UUID NOT NULL,
+  accepted_by_user_id UUID,
+  expired_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_invitation_1" PRIMARY KEY (id)
+);
+
+CREATE INDEX "IX_invitation_1" ON invitation (phone_number, country_code, status);
+CREATE INDEX "IX_invitation_2" ON invitation (location_id);
@@ -0,0 +1,14 @@
+CREATE TABLE refresh_token (
+  id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  family_id UUID NOT NULL,
+  token_hash TEXT NOT NULL,
+  expired_at TIMESTAMPTZ NOT NULL,
+  rotated_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_refresh_token_1" PRIMARY KEY (id),
+  CONSTRAINT "UN_refresh_token_1" UNIQUE (token_hash)
+);
+
+CREATE INDEX "IX_refresh_token_1" ON refresh_token (family_id);
@@ -0,0 +1,12 @@
+CREATE TABLE user_session (
+  id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  user_agent TEXT NOT NULL,
+  ip_address TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  last_seen_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ,
+  CONSTRAINT "PK_user_session_1" PRIMARY KEY (id)
+);
+
+CREATE INDEX "IX_user_session_1" ON user_session (user_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS verification_failure;
+
+ALTER TABLE verification_code DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,13 @@
+ALTER TABLE verification_code ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE verification_failure (
+  id UUID NOT NULL,
+  phone_number TEXT NOT NULL,
+  country_code TEXT NOT NULL,
+  ip_address TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_verification_failure_1" PRIMARY KEY (id)
+);
+
+CREATE INDEX "IX_verification_failure_1" ON verification_failure (phone_number, country_code, created_at);
+CREATE INDEX "IX_verification_failure_2" ON verification_failure (ip_address, created_at);
@@ -0,0 +1,15 @@
+CREATE TABLE sms_message (
+  id UUID NOT NULL,
+  provider TEXT NOT NULL,
+  provider_message_id TEXT NOT NULL,
+  phone_number TEXT NOT NULL,
+  country_code TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL,
+  error TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_sms_message_1" PRIMARY KEY (id)
+);
+
+CREATE INDEX "IX_sms_message_1" ON sms_message (provider, provider_message_id);
@@ -0,0 +1,11 @@
+CREATE TABLE sms_template (
+  id UUID NOT NULL,
+  business_id UUID NOT NULL,
+  message_type TEXT NOT NULL,
+  locale TEXT NOT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  CONSTRAINT "PK_sms_template_1" PRIMARY KEY (id),
+  CONSTRAINT "UN_sms_template_1" UNIQUE (business_id, message_type, locale)
+);
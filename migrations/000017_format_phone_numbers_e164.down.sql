@@ -0,0 +1,3 @@
+-- Irreversible on purpose. The national format of each phone number depends on the numbering plan of its country
+-- and the original formatting, e.g. spaces, was not kept, so it cannot be restored. Phone numbers stay in
+-- E.164 format, which the previous version also accepts as input
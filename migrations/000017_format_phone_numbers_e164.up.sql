@@ -0,0 +1,4 @@
+-- Phone numbers were stored in national format, e.g. 090 123 45 67, and are now stored in E.164 format, e.g. +84901234567.
+-- The server rewrites them when it starts, see formatPhoneNumbers, with the same phone number library the services
+-- use, since the numbering plans of all countries cannot be kept in SQL. Rows that cannot be formatted, or that
+-- would duplicate another row, are logged and left unchanged to be merged by hand
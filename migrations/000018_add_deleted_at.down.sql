@@ -0,0 +1,13 @@
+-- Deleted rows cannot be represented without deleted_at and are purged
+DELETE FROM employee_role WHERE deleted_at IS NOT NULL;
+DELETE FROM employee WHERE deleted_at IS NOT NULL;
+DELETE FROM location WHERE deleted_at IS NOT NULL;
+DELETE FROM business WHERE deleted_at IS NOT NULL;
+
+DROP INDEX "UN_business_1";
+ALTER TABLE business ADD CONSTRAINT "UN_business_1" UNIQUE (name);
+
+ALTER TABLE employee_role DROP COLUMN deleted_at;
+ALTER TABLE employee DROP COLUMN deleted_at;
+ALTER TABLE location DROP COLUMN deleted_at;
+ALTER TABLE business DROP COLUMN deleted_at;
@@ -0,0 +1,8 @@
+ALTER TABLE business ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE location ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE employee ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE employee_role ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Deleted businesses keep their name until they are restored or purged, so only names in use are unique
+ALTER TABLE business DROP CONSTRAINT "UN_business_1";
+CREATE UNIQUE INDEX "UN_business_1" ON business (name) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE employee_role DROP COLUMN version;
+ALTER TABLE employee DROP COLUMN version;
+ALTER TABLE location DROP COLUMN version;
+ALTER TABLE business DROP COLUMN version;
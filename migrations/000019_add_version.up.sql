@@ -0,0 +1,5 @@
+-- Version is incremented by every update, so that an update based on an older version can be refused
+ALTER TABLE business ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE location ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE employee ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE employee_role ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
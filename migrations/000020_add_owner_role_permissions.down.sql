@@ -0,0 +1,3 @@
+-- Irreversible on purpose. Owner roles of locations created since services, appointments and clients have
+-- permissions were given these permissions when they were created, so they cannot be told apart from the backfilled
+-- owner roles
@@ -0,0 +1,11 @@
+-- Owner roles of locations created before services, appointments and clients had permissions only manage the
+-- location, its employee roles and its employees. The owner role is the role of the employee of the business owner
+UPDATE employee_role
+SET permission_ids = ARRAY(SELECT DISTINCT unnest(employee_role.permission_ids || ARRAY['4', '5', '6']) ORDER BY 1),
+  version = employee_role.version + 1
+FROM employee, location, business
+WHERE employee.employee_role_id = employee_role.id
+  AND employee.location_id = location.id
+  AND location.business_id = business.id
+  AND employee.user_id = business.user_id
+  AND NOT employee_role.permission_ids @> ARRAY['4', '5', '6'];
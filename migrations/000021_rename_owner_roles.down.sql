@@ -0,0 +1,2 @@
+-- Irreversible on purpose. The previous version also names the owner roles owner, and owner roles of locations
+-- created since the owner role was renamed cannot be told apart from the renamed owner roles
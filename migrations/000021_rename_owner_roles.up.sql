@@ -0,0 +1,11 @@
+-- Owner roles of locations created before the owner role was renamed are named admin, like the admin role. The owner
+-- role is the role of the employee of the business owner
+UPDATE employee_role
+SET name = 'owner',
+  version = employee_role.version + 1
+FROM employee, location, business
+WHERE employee.employee_role_id = employee_role.id
+  AND employee.location_id = location.id
+  AND location.business_id = business.id
+  AND employee.user_id = business.user_id
+  AND employee_role.name <> 'owner';
@@ -0,0 +1 @@
+ALTER TABLE employee_role DROP COLUMN is_owner;
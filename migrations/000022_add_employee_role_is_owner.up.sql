@@ -0,0 +1,11 @@
+-- The owner role is flagged, so that it is told apart from other roles without relying on its name. The owner role
+-- is the role of the employee of the business owner
+ALTER TABLE employee_role ADD COLUMN is_owner BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE employee_role
+SET is_owner = TRUE
+FROM employee, location, business
+WHERE employee.employee_role_id = employee_role.id
+  AND employee.location_id = location.id
+  AND location.business_id = business.id
+  AND employee.user_id = business.user_id;
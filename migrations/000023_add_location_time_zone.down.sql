@@ -0,0 +1 @@
+ALTER TABLE location DROP COLUMN time_zone;
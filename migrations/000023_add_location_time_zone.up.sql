@@ -0,0 +1,2 @@
+-- Working hours and dates of a location are in its time zone, an IANA name, e.g. Asia/Ho_Chi_Minh
+ALTER TABLE location ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
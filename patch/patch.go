@@ -0,0 +1,68 @@
+// Package patch reads the members of JSON merge patch documents (RFC 7396). A member that is absent leaves its field
+// unchanged, null removes the field and a value replaces it
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// String is a string member of a merge patch document
+type String struct {
+	// Set tells whether the member is in the document, either null or with a value
+	Set bool
+	// Null tells whether the member is null. Value is then empty
+	Null  bool
+	Value string
+}
+
+// UnmarshalJSON is only called for members in the document, which is how absent members are told apart
+func (s *String) UnmarshalJSON(b []byte) error {
+	s.Set = true
+	s.Null = string(b) == "null"
+
+	if s.Null {
+		s.Value = ""
+		return nil
+	}
+
+	return json.Unmarshal(b, &s.Value)
+}
+
+// Value returns a member with the value
+func Value(value string) String {
+	return String{Set: true, Value: value}
+}
+
+// Null returns a null member
+func Null() String {
+	return String{Set: true, Null: true}
+}
+
+// Changed returns a member with the value, or an absent member when the value is empty, for inputs where empty means
+// no change
+func Changed(value string) String {
+	if value == "" {
+		return String{}
+	}
+
+	return Value(value)
+}
+
+// Required checks that the member of a field that cannot be removed is not null, nor blank
+func (s String) Required(v *errors.Validation, field string) {
+	if s.Null {
+		v.Add(field, errors.CodeRequired, fmt.Sprintf("%s cannot be removed", field))
+		return
+	}
+
+	v.Check(!s.Set || strings.TrimSpace(s.Value) != "", field, errors.CodeBlank, fmt.Sprintf("%s must not be blank", field))
+}
+
+// Optional checks that the member of a field that can be removed is not blank, as it is removed with null
+func (s String) Optional(v *errors.Validation, field string) {
+	v.Check(!s.Set || s.Null || strings.TrimSpace(s.Value) != "", field, errors.CodeBlank, fmt.Sprintf("%s must not be blank, remove it with null", field))
+}
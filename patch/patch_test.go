@@ -0,0 +1,87 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type document struct {
+	Name           String `json:"name"`
+	ProfileImageID String `json:"profile_image_id"`
+}
+
+func TestUnmarshalString(t *testing.T) {
+	tests := []struct {
+		document string
+		want     String
+	}{
+		{`{}`, String{}},
+		{`{"name": null}`, String{Set: true, Null: true}},
+		{`{"name": ""}`, String{Set: true}},
+		{`{"name": "Salon"}`, String{Set: true, Value: "Salon"}},
+	}
+
+	for _, test := range tests {
+		d := &document{}
+		err := json.Unmarshal([]byte(test.document), d)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if d.Name != test.want {
+			t.Errorf("expected %+v for %s, got %+v", test.want, test.document, d.Name)
+		}
+	}
+
+	err := json.Unmarshal([]byte(`{"name": 1}`), &document{})
+
+	if _, ok := err.(*json.UnmarshalTypeError); !ok {
+		t.Errorf("expected type error for a number, got %v", err)
+	}
+}
+
+// violation returns the code of the violation of the field found by check, if any
+func violation(check func(v *errors.Validation, field string)) string {
+	v := &errors.Validation{}
+	check(v, "name")
+
+	if violations := errors.ErrorViolations(v.Err("patch.violation")); len(violations) > 0 {
+		return violations[0].Code
+	}
+
+	return ""
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     String
+		required string
+		optional string
+	}{
+		{String{}, "", ""},
+		{Value("Salon"), "", ""},
+		{Null(), errors.CodeRequired, ""},
+		{Value(" "), errors.CodeBlank, errors.CodeBlank},
+		{Value(""), errors.CodeBlank, errors.CodeBlank},
+	}
+
+	for _, test := range tests {
+		if code := violation(test.name.Required); code != test.required {
+			t.Errorf("expected required %+v to be %q, got %q", test.name, test.required, code)
+		}
+
+		if code := violation(test.name.Optional); code != test.optional {
+			t.Errorf("expected optional %+v to be %q, got %q", test.name, test.optional, code)
+		}
+	}
+}
+
+func TestChanged(t *testing.T) {
+	if Changed("") != (String{}) || Changed("Salon") != Value("Salon") {
+		t.Error("expected empty value to be absent and others to be set")
+	}
+}
@@ -0,0 +1,150 @@
+// Package fakegateway is a local stand-in for SMS gateways. It speaks the API of the generic HTTP gateway
+// and the Twilio Messages API, so that SMS flows can be exercised without a network
+package fakegateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Credentials the gateway accepts
+const (
+	APIKey     = "test_api_key"
+	AccountSID = "test_account_sid"
+	AuthToken  = "test_auth_token"
+)
+
+// Message is a message received by the gateway
+type Message struct {
+	ID                string
+	To                string
+	From              string
+	Text              string
+	StatusCallbackURL string
+}
+
+// Server is a running fake gateway. Use URL as the gateway url or Twilio base url
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages []*Message
+	failures []int
+}
+
+// NewServer starts a fake gateway. Close it when done
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Messages returns the messages received so far
+func (s *Server) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Message{}, s.messages...)
+}
+
+// LastMessage returns the last message sent to the phone number in E.164 format
+func (s *Server) LastMessage(to string) *Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].To == to {
+			return s.messages[i]
+		}
+	}
+
+	return nil
+}
+
+// FailNext makes the next requests respond with the status codes, in order
+func (s *Server) FailNext(statusCodes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, statusCodes...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.failures) > 0 {
+		statusCode := s.failures[0]
+		s.failures = s.failures[1:]
+		http.Error(w, http.StatusText(statusCode), statusCode)
+		return
+	}
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/messages":
+		s.handleGatewayMessage(w, r)
+	case r.Method == "POST" && r.URL.Path == fmt.Sprintf("/2010-04-01/Accounts/%s/Messages.json", AccountSID):
+		s.handleTwilioMessage(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleGatewayMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+APIKey {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+		return
+	}
+
+	body := struct {
+		To                string `json:"to"`
+		Text              string `json:"text"`
+		StatusCallbackURL string `json:"status_callback_url"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+
+	if err != nil || body.To == "" {
+		http.Error(w, "invalid message", http.StatusBadRequest)
+		return
+	}
+
+	message := s.store(&Message{To: body.To, Text: body.Text, StatusCallbackURL: body.StatusCallbackURL})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": message.ID, "status": "sent"})
+}
+
+func (s *Server) handleTwilioMessage(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+
+	if !ok || username != AccountSID || password != AuthToken {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	err := r.ParseForm()
+
+	if err != nil || r.PostForm.Get("To") == "" || !strings.HasPrefix(r.PostForm.Get("To"), "+") {
+		http.Error(w, "invalid message", http.StatusBadRequest)
+		return
+	}
+
+	message := s.store(&Message{To: r.PostForm.Get("To"), From: r.PostForm.Get("From"), Text: r.PostForm.Get("Body"), StatusCallbackURL: r.PostForm.Get("StatusCallback")})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"sid": message.ID, "status": "queued"})
+}
+
+func (s *Server) store(message *Message) *Message {
+	message.ID = fmt.Sprintf("SM%d", len(s.messages)+1)
+	s.messages = append(s.messages, message)
+
+	return message
+}
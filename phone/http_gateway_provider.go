@@ -0,0 +1,104 @@
+package phone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// httpGatewayProvider talks to a generic gateway which accepts messages as JSON on POST {url}/messages,
+// authenticated by a bearer API key, and posts delivery statuses back as JSON with the same key
+type httpGatewayProvider struct {
+	client            *http.Client
+	url               string
+	apiKey            string
+	statusCallbackURL string
+}
+
+type httpGatewayMessage struct {
+	ID                string `json:"id,omitempty"`
+	To                string `json:"to,omitempty"`
+	Text              string `json:"text,omitempty"`
+	Status            string `json:"status,omitempty"`
+	StatusCallbackURL string `json:"status_callback_url,omitempty"`
+}
+
+func (p *httpGatewayProvider) Name() string {
+	return "http"
+}
+
+func (p *httpGatewayProvider) Send(ctx context.Context, to string, text string) (string, error) {
+	body, err := json.Marshal(&httpGatewayMessage{To: to, Text: text, StatusCallbackURL: p.statusCallbackURL})
+
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(p.url, "/")+"/messages", bytes.NewReader(body))
+
+	if err != nil {
+		return "", err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return "", &ProviderError{StatusCode: resp.StatusCode, Body: string(b)}
+	}
+
+	message := &httpGatewayMessage{}
+
+	err = json.NewDecoder(resp.Body).Decode(message)
+
+	if err != nil {
+		return "", err
+	}
+
+	return message.ID, nil
+}
+
+func (p *httpGatewayProvider) ParseDeliveryStatus(r *http.Request) (*DeliveryStatus, error) {
+	if r.Header.Get("Authorization") != "Bearer "+p.apiKey {
+		return nil, errInvalidCallbackCredentials
+	}
+
+	message := &httpGatewayMessage{}
+
+	err := json.NewDecoder(r.Body).Decode(message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := ""
+
+	switch message.Status {
+	case "sent":
+		status = SMSStatusSent
+	case "delivered":
+		status = SMSStatusDelivered
+	case "undelivered":
+		status = SMSStatusUndelivered
+	case "failed":
+		status = SMSStatusFailed
+	default:
+		return nil, fmt.Errorf("unknown status %s", message.Status)
+	}
+
+	return &DeliveryStatus{ProviderMessageID: message.ID, Status: status}, nil
+}
@@ -1,20 +1,85 @@
 package phone
 
 import (
+	"strings"
+
 	"github.com/minheq/kedul_server_main/errors"
 	"github.com/nyaruka/phonenumbers"
 )
 
-// FormatPhoneNumber formats phone number
+// FormatPhoneNumber formats phone number in the international E.164 format, e.g. +84901234567. Phone numbers
+// are stored in this format, so that the same number is stored the same way whatever country it was entered from
 func FormatPhoneNumber(phoneNumber string, countryCode string) (string, error) {
-	const op = "phone/phone_number.formatPhoneNumber"
+	const op = "phone/phone_number.FormatPhoneNumber"
 	parsedPhoneNumber, err := phonenumbers.Parse(phoneNumber, countryCode)
 
 	if err != nil {
 		return "", errors.Wrap(op, err, "failed to parse phone number")
 	}
 
-	formattedPhoneNumber := phonenumbers.Format(parsedPhoneNumber, phonenumbers.NATIONAL)
+	formattedPhoneNumber := phonenumbers.Format(parsedPhoneNumber, phonenumbers.E164)
 
 	return formattedPhoneNumber, nil
 }
+
+// FormatNational formats phone number in the national format of its country for display, e.g. 090 123 45 67.
+// Phone numbers that cannot be parsed are returned as they are
+func FormatNational(phoneNumber string, countryCode string) string {
+	if phoneNumber == "" {
+		return ""
+	}
+
+	parsedPhoneNumber, err := phonenumbers.Parse(phoneNumber, countryCode)
+
+	if err != nil {
+		return phoneNumber
+	}
+
+	return phonenumbers.Format(parsedPhoneNumber, phonenumbers.NATIONAL)
+}
+
+// CountryCodeOf returns the country of the phone number in E.164 format. Numbers entered from another country,
+// e.g. +84901234567 entered as US, belong to the country of their calling code
+func CountryCodeOf(phoneNumber string, countryCode string) string {
+	parsedPhoneNumber, err := phonenumbers.Parse(phoneNumber, countryCode)
+
+	if err != nil {
+		return countryCode
+	}
+
+	if regionCode := phonenumbers.GetRegionCodeForNumber(parsedPhoneNumber); regionCode != "" {
+		return regionCode
+	}
+
+	return countryCode
+}
+
+// NormalizePhoneNumber formats a stored phone number in E.164 format together with its country, e.g. 090 123 45 67
+// in vn becomes +84901234567 in VN. Numbers that are not valid in the country they were stored with are refused
+// rather than guessed, since the country is then likely wrong too
+func NormalizePhoneNumber(phoneNumber string, countryCode string) (string, string, error) {
+	const op = "phone/phone_number.NormalizePhoneNumber"
+	parsedPhoneNumber, err := phonenumbers.Parse(phoneNumber, strings.ToUpper(countryCode))
+
+	if err != nil {
+		return "", "", errors.Wrap(op, err, "failed to parse phone number")
+	}
+
+	if !phonenumbers.IsValidNumber(parsedPhoneNumber) {
+		return "", "", errors.Invalid(op, "phone number is not valid in its country")
+	}
+
+	return phonenumbers.Format(parsedPhoneNumber, phonenumbers.E164), phonenumbers.GetRegionCodeForNumber(parsedPhoneNumber), nil
+}
+
+// ValidatePhoneNumber checks that the field is a phone number of the country
+func ValidatePhoneNumber(v *errors.Validation, field string, phoneNumber string, countryCode string) {
+	if phoneNumber == "" {
+		v.Required(field, phoneNumber)
+		return
+	}
+
+	_, err := FormatPhoneNumber(phoneNumber, countryCode)
+
+	v.Check(err == nil, field, errors.CodeInvalidFormat, "invalid phone number")
+}
@@ -0,0 +1,84 @@
+package phone
+
+import (
+	"testing"
+)
+
+func TestFormatPhoneNumber(t *testing.T) {
+	t.Run("should format the same number the same way whatever country it was entered from", func(t *testing.T) {
+		inputs := []struct {
+			phoneNumber string
+			countryCode string
+		}{
+			{"0901234567", "VN"},
+			{"090 123 45 67", "VN"},
+			{"+84 90 123 4567", "VN"},
+			{"+84901234567", "US"},
+		}
+
+		for _, input := range inputs {
+			formattedPhoneNumber, err := FormatPhoneNumber(input.phoneNumber, input.countryCode)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if formattedPhoneNumber != "+84901234567" {
+				t.Errorf("expected +84901234567 for %s in %s, got %s", input.phoneNumber, input.countryCode, formattedPhoneNumber)
+			}
+
+			if countryCode := CountryCodeOf(formattedPhoneNumber, input.countryCode); countryCode != "VN" {
+				t.Errorf("expected country VN for %s in %s, got %s", input.phoneNumber, input.countryCode, countryCode)
+			}
+		}
+	})
+
+	t.Run("should not format invalid phone number", func(t *testing.T) {
+		_, err := FormatPhoneNumber("not a number", "VN")
+
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("should display national format", func(t *testing.T) {
+		if phoneNumber := FormatNational("+84901234567", "VN"); phoneNumber != "090 123 45 67" {
+			t.Errorf("expected 090 123 45 67, got %s", phoneNumber)
+		}
+
+		if phoneNumber := FormatNational("", "VN"); phoneNumber != "" {
+			t.Errorf("expected empty phone number, got %s", phoneNumber)
+		}
+	})
+	t.Run("should normalize stored phone number and its country", func(t *testing.T) {
+		inputs := []struct {
+			phoneNumber string
+			countryCode string
+		}{
+			{"090 123 45 67", "vn"},
+			{"+84901234567", "US"},
+		}
+
+		for _, input := range inputs {
+			phoneNumber, countryCode, err := NormalizePhoneNumber(input.phoneNumber, input.countryCode)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if phoneNumber != "+84901234567" || countryCode != "VN" {
+				t.Errorf("expected +84901234567 in VN for %s in %s, got %s in %s", input.phoneNumber, input.countryCode, phoneNumber, countryCode)
+			}
+		}
+	})
+
+	t.Run("should not normalize phone number stored with the wrong country", func(t *testing.T) {
+		_, _, err := NormalizePhoneNumber("0901234567", "US")
+
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
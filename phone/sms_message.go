@@ -0,0 +1,53 @@
+package phone
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses of SMSMessage
+const (
+	SMSStatusPending     = "PENDING"
+	SMSStatusSent        = "SENT"
+	SMSStatusDelivered   = "DELIVERED"
+	SMSStatusUndelivered = "UNDELIVERED"
+	SMSStatusFailed      = "FAILED"
+)
+
+// SMSMessage tracks the delivery of a single SMS. The text is not kept since it may contain verification codes
+type SMSMessage struct {
+	ID                string
+	Provider          string
+	ProviderMessageID string
+	PhoneNumber       string
+	CountryCode       string
+	Status            string
+	Attempts          int
+	Error             string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// NewSMSMessage constructor for SMSMessage
+func NewSMSMessage(provider string, phoneNumber string, countryCode string) *SMSMessage {
+	now := time.Now()
+	id := uuid.Must(uuid.New(), nil).String()
+
+	smsMessage := SMSMessage{
+		ID:          id,
+		Provider:    provider,
+		PhoneNumber: phoneNumber,
+		CountryCode: countryCode,
+		Status:      SMSStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	return &smsMessage
+}
+
+// IsFinal reports whether the gateway will not report further statuses of the message
+func (m *SMSMessage) IsFinal() bool {
+	return m.Status == SMSStatusDelivered || m.Status == SMSStatusUndelivered || m.Status == SMSStatusFailed
+}
@@ -0,0 +1,90 @@
+package phone
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// SMSMessageStore ...
+type SMSMessageStore interface {
+	GetSMSMessageByProviderMessageID(ctx context.Context, provider string, providerMessageID string) (*SMSMessage, error)
+	StoreSMSMessage(ctx context.Context, smsMessage *SMSMessage) error
+	UpdateSMSMessage(ctx context.Context, smsMessage *SMSMessage) error
+}
+
+type smsMessageStore struct {
+	db *sql.DB
+}
+
+// NewSMSMessageStore ...
+func NewSMSMessageStore(db *sql.DB) SMSMessageStore {
+	return &smsMessageStore{db: db}
+}
+
+// GetSMSMessageByProviderMessageID gets SMSMessage by the id the provider assigned to it
+func (s *smsMessageStore) GetSMSMessageByProviderMessageID(ctx context.Context, provider string, providerMessageID string) (*SMSMessage, error) {
+	const op = "phone/smsMessageStore.GetSMSMessageByProviderMessageID"
+
+	query := `
+		SELECT id, provider, provider_message_id, phone_number, country_code, status, attempts, error, created_at, updated_at
+		FROM sms_message
+		WHERE provider=$1
+			AND provider_message_id=$2;
+	`
+
+	smsMessage := &SMSMessage{}
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, provider, providerMessageID)
+
+	err := row.Scan(&smsMessage.ID, &smsMessage.Provider, &smsMessage.ProviderMessageID, &smsMessage.PhoneNumber, &smsMessage.CountryCode, &smsMessage.Status, &smsMessage.Attempts, &smsMessage.Error, &smsMessage.CreatedAt, &smsMessage.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return smsMessage, nil
+}
+
+// StoreSMSMessage persists SMSMessage
+func (s *smsMessageStore) StoreSMSMessage(ctx context.Context, smsMessage *SMSMessage) error {
+	const op = "phone/smsMessageStore.StoreSMSMessage"
+
+	query := `
+		INSERT INTO sms_message (id, provider, provider_message_id, phone_number, country_code, status, attempts, error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, smsMessage.ID, smsMessage.Provider, smsMessage.ProviderMessageID, smsMessage.PhoneNumber, smsMessage.CountryCode, smsMessage.Status, smsMessage.Attempts, smsMessage.Error, smsMessage.CreatedAt, smsMessage.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// UpdateSMSMessage updates SMSMessage including all fields
+func (s *smsMessageStore) UpdateSMSMessage(ctx context.Context, smsMessage *SMSMessage) error {
+	const op = "phone/smsMessageStore.UpdateSMSMessage"
+
+	query := `
+		UPDATE sms_message
+		SET provider_message_id=$2, status=$3, attempts=$4, error=$5, updated_at=$6
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, smsMessage.ID, smsMessage.ProviderMessageID, smsMessage.Status, smsMessage.Attempts, smsMessage.Error, smsMessage.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package phone
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// SMSProvider sends messages through an SMS gateway
+type SMSProvider interface {
+	// Name identifies the provider of persisted messages
+	Name() string
+	// Send hands the message to the gateway and returns the id the gateway assigned to it. to is in E.164 format
+	Send(ctx context.Context, to string, text string) (string, error)
+	// ParseDeliveryStatus authenticates and reads a delivery status callback of the gateway
+	ParseDeliveryStatus(r *http.Request) (*DeliveryStatus, error)
+}
+
+// DeliveryStatus is reported by the gateway after a message was sent
+type DeliveryStatus struct {
+	ProviderMessageID string
+	Status            string
+}
+
+// SMSConfig selects and configures the SMS provider
+type SMSConfig struct {
+	// Provider is "http" for the generic gateway, "twilio", or empty to print messages instead
+	Provider          string
+	GatewayURL        string
+	GatewayAPIKey     string
+	TwilioBaseURL     string
+	TwilioAccountSID  string
+	TwilioAuthToken   string
+	TwilioFromNumber  string
+	StatusCallbackURL string
+	// Timeout of a single request to the gateway
+	Timeout time.Duration
+	// MaxAttempts to send a message when the gateway fails temporarily
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+// NewSMSProvider constructs the provider selected by the config. It returns nil if no provider is selected
+func NewSMSProvider(config *SMSConfig) (SMSProvider, error) {
+	const op = "phone/NewSMSProvider"
+
+	client := &http.Client{Timeout: config.Timeout}
+
+	switch config.Provider {
+	case "":
+		return nil, nil
+	case "http":
+		if config.GatewayURL == "" {
+			return nil, errors.Invalid(op, "missing gateway url")
+		}
+
+		return &httpGatewayProvider{client: client, url: config.GatewayURL, apiKey: config.GatewayAPIKey, statusCallbackURL: config.StatusCallbackURL}, nil
+	case "twilio":
+		if config.TwilioAccountSID == "" || config.TwilioAuthToken == "" || config.TwilioFromNumber == "" {
+			return nil, errors.Invalid(op, "missing twilio credentials")
+		}
+
+		baseURL := config.TwilioBaseURL
+
+		if baseURL == "" {
+			baseURL = "https://api.twilio.com"
+		}
+
+		return &twilioProvider{client: client, baseURL: baseURL, accountSID: config.TwilioAccountSID, authToken: config.TwilioAuthToken, fromNumber: config.TwilioFromNumber, statusCallbackURL: config.StatusCallbackURL}, nil
+	}
+
+	return nil, errors.Invalid(op, fmt.Sprintf("unknown sms provider %s", config.Provider))
+}
+
+// errInvalidCallbackCredentials is returned for status callbacks not coming from the gateway
+var errInvalidCallbackCredentials = fmt.Errorf("invalid status callback credentials")
+
+// ProviderError is returned when the gateway responds with an error status
+type ProviderError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("sms gateway responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// isTemporary reports whether sending again may succeed
+func isTemporary(err error) bool {
+	switch e := err.(type) {
+	case *ProviderError:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+	case net.Error:
+		return true
+	}
+
+	return err == context.DeadlineExceeded
+}
@@ -1,12 +1,22 @@
 package phone
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
 )
 
-// SMSSender sends sms.
+// SMSSender sends sms. Sending stops when the context is done, e.g. when the request is canceled
 type SMSSender interface {
-	SendSMS(phoneNumber string, countryCode string, text string) error
+	SendSMS(ctx context.Context, phoneNumber string, countryCode string, text string) error
+}
+
+// DeliveryStatusTracker records delivery statuses reported by the gateway
+type DeliveryStatusTracker interface {
+	TrackDeliveryStatus(ctx context.Context, r *http.Request) (*SMSMessage, error)
 }
 
 type sender struct{}
@@ -16,7 +26,153 @@ func NewSMSSender() SMSSender {
 	return &sender{}
 }
 
-func (s *sender) SendSMS(phoneNumber string, countryCode string, text string) error {
+func (s *sender) SendSMS(ctx context.Context, phoneNumber string, countryCode string, text string) error {
 	fmt.Println(phoneNumber, countryCode, text)
 	return nil
 }
+
+// providerSender sends through an SMSProvider and persists the delivery of every message
+type providerSender struct {
+	provider     SMSProvider
+	messageStore SMSMessageStore
+	timeout      time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+// NewProviderSMSSender constructor for SMSSender sending through the provider
+func NewProviderSMSSender(provider SMSProvider, messageStore SMSMessageStore, config *SMSConfig) SMSSender {
+	maxAttempts := config.MaxAttempts
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &providerSender{provider: provider, messageStore: messageStore, timeout: config.Timeout, maxAttempts: maxAttempts, retryBackoff: config.RetryBackoff}
+}
+
+// SendSMS retries temporary failures of the gateway with linear backoff, until the context is done
+func (s *providerSender) SendSMS(ctx context.Context, phoneNumber string, countryCode string, text string) error {
+	const op = "phone/providerSender.SendSMS"
+
+	to, err := FormatPhoneNumber(phoneNumber, countryCode)
+
+	if err != nil {
+		return errors.Invalid(op, "invalid phone number")
+	}
+
+	smsMessage := NewSMSMessage(s.provider.Name(), to, countryCode)
+
+	err = s.messageStore.StoreSMSMessage(ctx, smsMessage)
+
+	if err != nil {
+		return errors.Wrap(op, err, "failed to store sms message")
+	}
+
+	var providerMessageID string
+
+	for {
+		smsMessage.Attempts++
+
+		providerMessageID, err = s.send(ctx, to, text)
+
+		if err == nil || smsMessage.Attempts >= s.maxAttempts || !isTemporary(err) {
+			break
+		}
+
+		if !wait(ctx, time.Duration(smsMessage.Attempts)*s.retryBackoff) {
+			break
+		}
+	}
+
+	smsMessage.UpdatedAt = time.Now()
+
+	if err != nil {
+		smsMessage.Status = SMSStatusFailed
+		smsMessage.Error = err.Error()
+	} else {
+		smsMessage.Status = SMSStatusSent
+		smsMessage.ProviderMessageID = providerMessageID
+	}
+
+	updateErr := s.messageStore.UpdateSMSMessage(ctx, smsMessage)
+
+	if err != nil && isTemporary(err) {
+		return errors.Unavailable(op, err, "sms gateway unavailable")
+	}
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to send sms")
+	}
+
+	if updateErr != nil {
+		return errors.Wrap(op, updateErr, "failed to update sms message")
+	}
+
+	return nil
+}
+
+// send makes one attempt, which times out after the configured timeout or when the context is done
+func (s *providerSender) send(ctx context.Context, to string, text string) (string, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	return s.provider.Send(ctx, to, text)
+}
+
+// wait waits for the duration, unless the context is done first
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// TrackDeliveryStatus updates the message from a status callback of the gateway
+func (s *providerSender) TrackDeliveryStatus(ctx context.Context, r *http.Request) (*SMSMessage, error) {
+	const op = "phone/providerSender.TrackDeliveryStatus"
+
+	deliveryStatus, err := s.provider.ParseDeliveryStatus(r)
+
+	if err == errInvalidCallbackCredentials {
+		return nil, errors.Unauthorized(op, err)
+	}
+
+	if err != nil {
+		return nil, errors.Invalid(op, err.Error())
+	}
+
+	smsMessage, err := s.messageStore.GetSMSMessageByProviderMessageID(ctx, s.provider.Name(), deliveryStatus.ProviderMessageID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to get sms message")
+	}
+
+	if smsMessage == nil {
+		return nil, errors.NotFound(op)
+	}
+
+	// Callbacks may arrive out of order
+	if smsMessage.IsFinal() {
+		return smsMessage, nil
+	}
+
+	smsMessage.Status = deliveryStatus.Status
+	smsMessage.UpdatedAt = time.Now()
+
+	err = s.messageStore.UpdateSMSMessage(ctx, smsMessage)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "failed to update sms message")
+	}
+
+	return smsMessage, nil
+}
@@ -0,0 +1,222 @@
+package phone
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/phone/fakegateway"
+)
+
+type mockSMSMessageStore struct {
+	smsMessages []*SMSMessage
+}
+
+func (s *mockSMSMessageStore) GetSMSMessageByProviderMessageID(ctx context.Context, provider string, providerMessageID string) (*SMSMessage, error) {
+	for _, m := range s.smsMessages {
+		if m.Provider == provider && m.ProviderMessageID == providerMessageID {
+			return m, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *mockSMSMessageStore) StoreSMSMessage(ctx context.Context, smsMessage *SMSMessage) error {
+	s.smsMessages = append(s.smsMessages, smsMessage)
+
+	return nil
+}
+
+func (s *mockSMSMessageStore) UpdateSMSMessage(ctx context.Context, smsMessage *SMSMessage) error {
+	for i, m := range s.smsMessages {
+		if m.ID == smsMessage.ID {
+			s.smsMessages[i] = smsMessage
+			break
+		}
+	}
+
+	return nil
+}
+
+func newTestSMSSender(t *testing.T, config *SMSConfig) (SMSSender, *mockSMSMessageStore) {
+	config.Timeout = time.Second
+	config.MaxAttempts = 3
+
+	provider, err := NewSMSProvider(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messageStore := &mockSMSMessageStore{}
+
+	return NewProviderSMSSender(provider, messageStore, config), messageStore
+}
+
+func TestHTTPGatewaySendSMS(t *testing.T) {
+	gateway := fakegateway.NewServer()
+	defer gateway.Close()
+
+	smsSender, messageStore := newTestSMSSender(t, &SMSConfig{Provider: "http", GatewayURL: gateway.URL, GatewayAPIKey: fakegateway.APIKey})
+
+	t.Run("should send sms in E.164 format", func(t *testing.T) {
+		err := smsSender.SendSMS(context.Background(), "0901234567", "VN", "hello")
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		message := gateway.LastMessage("+84901234567")
+
+		if message == nil || message.Text != "hello" {
+			t.Error("gateway should receive message")
+			return
+		}
+
+		smsMessage := messageStore.smsMessages[0]
+
+		if smsMessage.Status != SMSStatusSent || smsMessage.ProviderMessageID != message.ID {
+			t.Errorf("sms message should be sent. received status=%s", smsMessage.Status)
+		}
+	})
+
+	t.Run("should retry when gateway fails temporarily", func(t *testing.T) {
+		gateway.FailNext(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+		err := smsSender.SendSMS(context.Background(), "0901234567", "VN", "retried")
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		smsMessage := messageStore.smsMessages[1]
+
+		if smsMessage.Status != SMSStatusSent || smsMessage.Attempts != 3 {
+			t.Errorf("sms message should be sent on third attempt. received status=%s, attempts=%d", smsMessage.Status, smsMessage.Attempts)
+		}
+	})
+
+	t.Run("should not retry when gateway rejects message", func(t *testing.T) {
+		gateway.FailNext(http.StatusBadRequest)
+
+		err := smsSender.SendSMS(context.Background(), "0901234567", "VN", "rejected")
+
+		if err == nil {
+			t.Error("sending should fail")
+			return
+		}
+
+		smsMessage := messageStore.smsMessages[2]
+
+		if smsMessage.Status != SMSStatusFailed || smsMessage.Attempts != 1 || smsMessage.Error == "" {
+			t.Errorf("sms message should fail after first attempt. received status=%s, attempts=%d", smsMessage.Status, smsMessage.Attempts)
+		}
+	})
+
+	t.Run("should report gateway unavailable after last attempt", func(t *testing.T) {
+		gateway.FailNext(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+		err := smsSender.SendSMS(context.Background(), "0901234567", "VN", "unavailable")
+
+		if errors.Is(errors.KindUnavailable, err) == false {
+			t.Errorf("error should be unavailable kind, got %v", err)
+		}
+	})
+
+	t.Run("should stop retrying when the request is done", func(t *testing.T) {
+		smsSender, messageStore := newTestSMSSender(t, &SMSConfig{Provider: "http", GatewayURL: gateway.URL, GatewayAPIKey: fakegateway.APIKey, RetryBackoff: time.Hour})
+		gateway.FailNext(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := smsSender.SendSMS(ctx, "0901234567", "VN", "canceled")
+
+		if err == nil {
+			t.Error("sending should fail")
+			return
+		}
+
+		smsMessage := messageStore.smsMessages[0]
+
+		if smsMessage.Status != SMSStatusFailed || smsMessage.Attempts != 1 {
+			t.Errorf("sms message should fail without retrying. received status=%s, attempts=%d", smsMessage.Status, smsMessage.Attempts)
+		}
+	})
+}
+
+func TestTwilioDeliveryStatus(t *testing.T) {
+	gateway := fakegateway.NewServer()
+	defer gateway.Close()
+
+	callbackURL := "https://kedul.test/sms/delivery_status"
+	smsSender, messageStore := newTestSMSSender(t, &SMSConfig{
+		Provider:          "twilio",
+		TwilioBaseURL:     gateway.URL,
+		TwilioAccountSID:  fakegateway.AccountSID,
+		TwilioAuthToken:   fakegateway.AuthToken,
+		TwilioFromNumber:  "+15005550006",
+		StatusCallbackURL: callbackURL,
+	})
+
+	err := smsSender.SendSMS(context.Background(), "0901234567", "VN", "hello")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := gateway.LastMessage("+84901234567")
+
+	newCallback := func(status string, authToken string) *http.Request {
+		form := url.Values{"MessageSid": {message.ID}, "MessageStatus": {status}}
+		r := httptest.NewRequest("POST", callbackURL, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("X-Twilio-Signature", twilioSignature(authToken, callbackURL, form))
+
+		return r
+	}
+
+	tracker := smsSender.(DeliveryStatusTracker)
+
+	t.Run("should reject callback with invalid signature", func(t *testing.T) {
+		_, err := tracker.TrackDeliveryStatus(context.Background(), newCallback("delivered", "wrong"))
+
+		if errors.Is(errors.KindUnauthorized, err) == false {
+			t.Error("error should be unauthorized kind")
+		}
+	})
+
+	t.Run("should track delivery status", func(t *testing.T) {
+		smsMessage, err := tracker.TrackDeliveryStatus(context.Background(), newCallback("delivered", fakegateway.AuthToken))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if smsMessage.Status != SMSStatusDelivered || messageStore.smsMessages[0].Status != SMSStatusDelivered {
+			t.Errorf("sms message should be delivered. received=%s", smsMessage.Status)
+		}
+	})
+
+	t.Run("should ignore statuses arriving after final status", func(t *testing.T) {
+		smsMessage, err := tracker.TrackDeliveryStatus(context.Background(), newCallback("sent", fakegateway.AuthToken))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if smsMessage.Status != SMSStatusDelivered {
+			t.Errorf("sms message should stay delivered. received=%s", smsMessage.Status)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package phone
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// Types of templated messages
+const (
+	SMSTypeLoginCode           = "LOGIN_CODE"
+	SMSTypePhoneNumberChange   = "PHONE_NUMBER_CHANGE"
+	SMSTypeInvitation          = "INVITATION"
+	SMSTypeAppointmentReminder = "APPOINTMENT_REMINDER"
+)
+
+// Locales templates are written in
+const (
+	LocaleVietnamese = "vi"
+	LocaleEnglish    = "en"
+)
+
+// smsTemplateMaxLength keeps rendered messages within a few SMS segments
+const smsTemplateMaxLength = 320
+
+// smsTemplateVariables lists the variables each message type is rendered with. Templates may use them as {name}
+var smsTemplateVariables = map[string][]string{
+	SMSTypeLoginCode:           {"code"},
+	SMSTypePhoneNumberChange:   {"code"},
+	SMSTypeInvitation:          {"business", "location"},
+	SMSTypeAppointmentReminder: {"business", "location", "date", "time"},
+}
+
+var defaultSMSTemplates = map[string]map[string]string{
+	SMSTypeLoginCode: {
+		LocaleVietnamese: "Mã đăng nhập Kedul của bạn là {code}. Mã có hiệu lực trong 10 phút.",
+		LocaleEnglish:    "Your Kedul login code is {code}. It expires in 10 minutes.",
+	},
+	SMSTypePhoneNumberChange: {
+		LocaleVietnamese: "Mã xác nhận đổi số điện thoại Kedul của bạn là {code}. Không chia sẻ mã này với bất kỳ ai.",
+		LocaleEnglish:    "Your Kedul code to change your phone number is {code}. Do not share it with anyone.",
+	},
+	SMSTypeInvitation: {
+		LocaleVietnamese: "Bạn được mời tham gia {location} của {business}. Đăng nhập Kedul bằng số điện thoại này để chấp nhận.",
+		LocaleEnglish:    "You have been invited to join {location} at {business}. Log in to Kedul with this phone number to accept.",
+	},
+	SMSTypeAppointmentReminder: {
+		LocaleVietnamese: "Nhắc lịch: bạn có lịch hẹn tại {location} ({business}) lúc {time} ngày {date}.",
+		LocaleEnglish:    "Reminder: you have an appointment at {location} ({business}) on {date} at {time}.",
+	},
+}
+
+var smsTemplateVariableRegexp = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// SMSTemplate overrides the default template of a message type and locale for a business
+type SMSTemplate struct {
+	ID          string
+	BusinessID  string
+	MessageType string
+	Locale      string
+	Body        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewSMSTemplate constructor for SMSTemplate
+func NewSMSTemplate(businessID string, messageType string, locale string, body string) *SMSTemplate {
+	now := time.Now()
+	id := uuid.Must(uuid.New(), nil).String()
+
+	smsTemplate := SMSTemplate{
+		ID:          id,
+		BusinessID:  businessID,
+		MessageType: messageType,
+		Locale:      locale,
+		Body:        body,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	return &smsTemplate
+}
+
+// LocaleFromCountryCode chooses the locale of messages sent to phone numbers of the country
+func LocaleFromCountryCode(countryCode string) string {
+	if strings.ToUpper(countryCode) == "VN" {
+		return LocaleVietnamese
+	}
+
+	return LocaleEnglish
+}
+
+// DefaultSMSTemplate returns the bundled template of the message type and locale
+func DefaultSMSTemplate(messageType string, locale string) (string, bool) {
+	body, ok := defaultSMSTemplates[messageType][locale]
+
+	return body, ok
+}
+
+// SMSTemplateLocales returns the locales there are default templates in
+func SMSTemplateLocales() []string {
+	return []string{LocaleVietnamese, LocaleEnglish}
+}
+
+// ValidateSMSTemplate checks that the body only uses variables of the message type
+func ValidateSMSTemplate(messageType string, body string) error {
+	const op = "phone/ValidateSMSTemplate"
+
+	variables, ok := smsTemplateVariables[messageType]
+
+	if !ok {
+		return errors.InvalidField(op, "message_type", errors.CodeInvalidValue, fmt.Sprintf("unknown message type %s", messageType))
+	}
+
+	if strings.TrimSpace(body) == "" {
+		return errors.InvalidField(op, "body", errors.CodeRequired, "template must not be empty")
+	}
+
+	if len([]rune(body)) > smsTemplateMaxLength {
+		return errors.InvalidField(op, "body", errors.CodeTooLong, fmt.Sprintf("template must not be longer than %d characters", smsTemplateMaxLength))
+	}
+
+	for _, match := range smsTemplateVariableRegexp.FindAllStringSubmatch(body, -1) {
+		known := false
+
+		for _, variable := range variables {
+			if match[1] == variable {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return errors.InvalidField(op, "body", errors.CodeInvalidValue, fmt.Sprintf("unknown variable {%s}", match[1]))
+		}
+	}
+
+	return nil
+}
+
+// renderSMSTemplate substitutes {name} with the value of the variable. Unknown variables are left as they are
+func renderSMSTemplate(body string, variables map[string]string) string {
+	return smsTemplateVariableRegexp.ReplaceAllStringFunc(body, func(match string) string {
+		value, ok := variables[match[1:len(match)-1]]
+
+		if !ok {
+			return match
+		}
+
+		return value
+	})
+}
+
+// SMSTemplates renders messages from the templates of a business, falling back to the default templates
+type SMSTemplates struct {
+	templateStore SMSTemplateStore
+}
+
+// NewSMSTemplates constructor for SMSTemplates
+func NewSMSTemplates(templateStore SMSTemplateStore) *SMSTemplates {
+	return &SMSTemplates{templateStore: templateStore}
+}
+
+// Render renders the message type in the locale of the country code. businessID is empty for messages not sent on behalf of a business
+func (t *SMSTemplates) Render(ctx context.Context, businessID string, messageType string, countryCode string, variables map[string]string) (string, error) {
+	const op = "phone/SMSTemplates.Render"
+
+	locale := LocaleFromCountryCode(countryCode)
+
+	if businessID != "" {
+		smsTemplate, err := t.templateStore.GetSMSTemplate(ctx, businessID, messageType, locale)
+
+		if err != nil {
+			return "", errors.Wrap(op, err, "failed to get sms template")
+		}
+
+		if smsTemplate != nil {
+			return renderSMSTemplate(smsTemplate.Body, variables), nil
+		}
+	}
+
+	body, ok := DefaultSMSTemplate(messageType, locale)
+
+	if !ok {
+		return "", errors.Unexpected(op, fmt.Errorf("missing default template"), fmt.Sprintf("no template for %s in %s", messageType, locale))
+	}
+
+	return renderSMSTemplate(body, variables), nil
+}
@@ -0,0 +1,152 @@
+package phone
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// SMSTemplateStore ...
+type SMSTemplateStore interface {
+	GetSMSTemplatesByBusinessID(ctx context.Context, businessID string) ([]*SMSTemplate, error)
+	GetSMSTemplate(ctx context.Context, businessID string, messageType string, locale string) (*SMSTemplate, error)
+	StoreSMSTemplate(ctx context.Context, smsTemplate *SMSTemplate) error
+	UpdateSMSTemplate(ctx context.Context, smsTemplate *SMSTemplate) error
+	DeleteSMSTemplate(ctx context.Context, smsTemplate *SMSTemplate) error
+}
+
+type smsTemplateStore struct {
+	db *sql.DB
+}
+
+// NewSMSTemplateStore ...
+func NewSMSTemplateStore(db *sql.DB) SMSTemplateStore {
+	return &smsTemplateStore{db: db}
+}
+
+// GetSMSTemplatesByBusinessID gets SMSTemplates overridden by the business
+func (s *smsTemplateStore) GetSMSTemplatesByBusinessID(ctx context.Context, businessID string) ([]*SMSTemplate, error) {
+	const op = "phone/smsTemplateStore.GetSMSTemplatesByBusinessID"
+
+	query := `
+		SELECT id, business_id, message_type, locale, body, created_at, updated_at
+		FROM sms_template
+		WHERE business_id=$1
+		ORDER BY message_type, locale;
+	`
+	smsTemplates := make([]*SMSTemplate, 0)
+
+	rows, err := transaction.DB(ctx, s.db).Query(query, businessID)
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		smsTemplate := &SMSTemplate{}
+
+		err := rows.Scan(&smsTemplate.ID, &smsTemplate.BusinessID, &smsTemplate.MessageType, &smsTemplate.Locale, &smsTemplate.Body, &smsTemplate.CreatedAt, &smsTemplate.UpdatedAt)
+
+		if err != nil {
+			return nil, errors.Wrap(op, err, "row scan error")
+		}
+
+		smsTemplates = append(smsTemplates, smsTemplate)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return smsTemplates, nil
+}
+
+// GetSMSTemplate gets SMSTemplate of the business for the message type and locale
+func (s *smsTemplateStore) GetSMSTemplate(ctx context.Context, businessID string, messageType string, locale string) (*SMSTemplate, error) {
+	const op = "phone/smsTemplateStore.GetSMSTemplate"
+
+	query := `
+		SELECT id, business_id, message_type, locale, body, created_at, updated_at
+		FROM sms_template
+		WHERE business_id=$1
+			AND message_type=$2
+			AND locale=$3;
+	`
+
+	smsTemplate := &SMSTemplate{}
+
+	row := transaction.DB(ctx, s.db).QueryRow(query, businessID, messageType, locale)
+
+	err := row.Scan(&smsTemplate.ID, &smsTemplate.BusinessID, &smsTemplate.MessageType, &smsTemplate.Locale, &smsTemplate.Body, &smsTemplate.CreatedAt, &smsTemplate.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(op, err, "database error")
+	}
+
+	return smsTemplate, nil
+}
+
+// StoreSMSTemplate persists SMSTemplate
+func (s *smsTemplateStore) StoreSMSTemplate(ctx context.Context, smsTemplate *SMSTemplate) error {
+	const op = "phone/smsTemplateStore.StoreSMSTemplate"
+
+	query := `
+		INSERT INTO sms_template (id, business_id, message_type, locale, body, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, smsTemplate.ID, smsTemplate.BusinessID, smsTemplate.MessageType, smsTemplate.Locale, smsTemplate.Body, smsTemplate.CreatedAt, smsTemplate.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// UpdateSMSTemplate updates SMSTemplate including all fields
+func (s *smsTemplateStore) UpdateSMSTemplate(ctx context.Context, smsTemplate *SMSTemplate) error {
+	const op = "phone/smsTemplateStore.UpdateSMSTemplate"
+
+	query := `
+		UPDATE sms_template
+		SET body=$2, updated_at=$3
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, smsTemplate.ID, smsTemplate.Body, smsTemplate.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
+
+// DeleteSMSTemplate deletes SMSTemplate, so that the default template is used again
+func (s *smsTemplateStore) DeleteSMSTemplate(ctx context.Context, smsTemplate *SMSTemplate) error {
+	const op = "phone/smsTemplateStore.DeleteSMSTemplate"
+
+	query := `
+		DELETE FROM sms_template
+		WHERE id=$1;
+	`
+
+	_, err := transaction.DB(ctx, s.db).Exec(query, smsTemplate.ID)
+
+	if err != nil {
+		return errors.Wrap(op, err, "database error")
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package phone
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// twilioProvider talks to the Twilio Messages REST API, or any gateway mimicking it
+type twilioProvider struct {
+	client            *http.Client
+	baseURL           string
+	accountSID        string
+	authToken         string
+	fromNumber        string
+	statusCallbackURL string
+}
+
+type twilioMessage struct {
+	SID    string `json:"sid"`
+	Status string `json:"status"`
+}
+
+func (p *twilioProvider) Name() string {
+	return "twilio"
+}
+
+func (p *twilioProvider) Send(ctx context.Context, to string, text string) (string, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", p.fromNumber)
+	form.Set("Body", text)
+
+	if p.statusCallbackURL != "" {
+		form.Set("StatusCallback", p.statusCallbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimSuffix(p.baseURL, "/"), p.accountSID)
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.accountSID, p.authToken)
+
+	resp, err := p.client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return "", &ProviderError{StatusCode: resp.StatusCode, Body: string(b)}
+	}
+
+	message := &twilioMessage{}
+
+	err = json.NewDecoder(resp.Body).Decode(message)
+
+	if err != nil {
+		return "", err
+	}
+
+	return message.SID, nil
+}
+
+// ParseDeliveryStatus validates the X-Twilio-Signature of the status callback, which is signed over the configured callback url
+func (p *twilioProvider) ParseDeliveryStatus(r *http.Request) (*DeliveryStatus, error) {
+	err := r.ParseForm()
+
+	if err != nil {
+		return nil, err
+	}
+
+	expectedSignature := twilioSignature(p.authToken, p.statusCallbackURL, r.PostForm)
+
+	if !hmac.Equal([]byte(r.Header.Get("X-Twilio-Signature")), []byte(expectedSignature)) {
+		return nil, errInvalidCallbackCredentials
+	}
+
+	status := ""
+
+	switch r.PostForm.Get("MessageStatus") {
+	case "accepted", "queued", "sending", "sent":
+		status = SMSStatusSent
+	case "delivered":
+		status = SMSStatusDelivered
+	case "undelivered":
+		status = SMSStatusUndelivered
+	case "failed":
+		status = SMSStatusFailed
+	default:
+		return nil, fmt.Errorf("unknown status %s", r.PostForm.Get("MessageStatus"))
+	}
+
+	return &DeliveryStatus{ProviderMessageID: r.PostForm.Get("MessageSid"), Status: status}, nil
+}
+
+// twilioSignature signs the url followed by the sorted form parameters and their values
+func twilioSignature(authToken string, callbackURL string, form url.Values) string {
+	keys := make([]string, 0, len(form))
+
+	for key := range form {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(callbackURL)
+
+	for _, key := range keys {
+		for _, value := range form[key] {
+			b.WriteString(key)
+			b.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/minheq/kedul_server_main/phone"
+)
+
+// pqUniqueViolation is raised when a formatted phone number is already stored in another row
+const pqUniqueViolation = "23505"
+
+// phoneNumberTables store phone numbers together with the country they were entered from
+var phoneNumberTables = []string{"kedul_user", "verification_code", "verification_failure", "invitation", "client"}
+
+// unformattedPhoneNumber is a stored phone number that was left unchanged, because it is not valid in its country
+// or because its formatted number is already stored in another row, e.g. a user who signed up both as
+// 0901234567 and as +84901234567. These rows have to be fixed or merged by hand
+type unformattedPhoneNumber struct {
+	Table       string
+	ID          string
+	PhoneNumber string
+	CountryCode string
+	Reason      string
+}
+
+// formatPhoneNumbers rewrites the phone numbers stored in national format, e.g. 090 123 45 67, in E.164 format,
+// e.g. +84901234567, together with the country of the number. Numbers are formatted the same way as the services
+// format them, so that lookups by phone number find the rows stored before. It only changes rows that are not
+// formatted yet, so it can run on every start
+func formatPhoneNumbers(ctx context.Context, db *sql.DB) ([]*unformattedPhoneNumber, error) {
+	unformatted := []*unformattedPhoneNumber{}
+
+	for _, table := range phoneNumberTables {
+		rows, err := getUnformattedPhoneNumbers(ctx, db, table)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			phoneNumber, countryCode, err := phone.NormalizePhoneNumber(row.PhoneNumber, row.CountryCode)
+
+			if err != nil {
+				row.Reason = "phone number is not valid in its country"
+				unformatted = append(unformatted, row)
+				continue
+			}
+
+			query := fmt.Sprintf("UPDATE %s SET phone_number=$2, country_code=$3 WHERE id=$1", table)
+
+			_, err = db.ExecContext(ctx, query, row.ID, phoneNumber, countryCode)
+
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pqUniqueViolation {
+				row.Reason = fmt.Sprintf("%s is already stored, constraint %s", phoneNumber, pqErr.Constraint)
+				unformatted = append(unformatted, row)
+				continue
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("failed to format phone number of %s id=%s: %v", table, row.ID, err)
+			}
+		}
+	}
+
+	return unformatted, nil
+}
+
+// getUnformattedPhoneNumbers gets the rows of the table whose phone number is not in E.164 format or whose country
+// is not in upper case
+func getUnformattedPhoneNumbers(ctx context.Context, db *sql.DB, table string) ([]*unformattedPhoneNumber, error) {
+	query := fmt.Sprintf(`SELECT id, phone_number, country_code FROM %s
+	WHERE phone_number <> '' AND (phone_number NOT LIKE '+%%' OR country_code <> upper(country_code))`, table)
+
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get phone numbers of %s: %v", table, err)
+	}
+
+	defer rows.Close()
+
+	unformatted := []*unformattedPhoneNumber{}
+
+	for rows.Next() {
+		row := &unformattedPhoneNumber{Table: table}
+
+		err := rows.Scan(&row.ID, &row.PhoneNumber, &row.CountryCode)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan phone number of %s: %v", table, err)
+		}
+
+		unformatted = append(unformatted, row)
+	}
+
+	return unformatted, rows.Err()
+}
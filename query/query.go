@@ -0,0 +1,280 @@
+// Package query parses the filter and sort query params of list endpoints into conditions on the fields a resource
+// allows. Postgres stores translate them to SQL with parameters, and in-memory stores evaluate them on records.
+//
+// A filter param is field:op:value, e.g. name:prefix:Sal or created_at:gt:2020-01-01T00:00:00Z. Filter params can be
+// repeated and must all match. The sort param is a field, descending when prefixed with -, e.g. -created_at
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// MaxConditions is how many filter params a list can have
+const MaxConditions = 10
+
+// Type of a field, which decides how its values are parsed and compared
+type Type int
+
+// Types of fields
+const (
+	String Type = iota
+	Time
+)
+
+// Field a list can be filtered and sorted by
+type Field struct {
+	// Name of the field in query params
+	Name string
+	// Column of the field in Postgres, or an expression of the columns of a record
+	Column string
+	Type   Type
+	// Value returns the value of the field of a record, a string or a time.Time by Type
+	Value func(record interface{}) interface{}
+}
+
+// Fields lists the fields of a resource lists can be filtered and sorted by. Other fields are rejected
+type Fields []*Field
+
+func (fields Fields) lookup(name string) *Field {
+	for _, field := range fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+func (fields Fields) names() string {
+	names := []string{}
+
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Op compares the value of a field with the value of a condition
+type Op string
+
+// Ops of conditions. OpPrefix only applies to String fields
+const (
+	OpEq     Op = "eq"
+	OpNe     Op = "ne"
+	OpLt     Op = "lt"
+	OpLe     Op = "le"
+	OpGt     Op = "gt"
+	OpGe     Op = "ge"
+	OpPrefix Op = "prefix"
+)
+
+var ops = []Op{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe, OpPrefix}
+
+// Condition on the value of a field. Value is a string or a time.Time by the Type of the field
+type Condition struct {
+	Field *Field
+	Op    Op
+	Value interface{}
+}
+
+// Filter matches records meeting all its conditions
+type Filter []Condition
+
+// Sort orders a list by a field. Records with the same value keep the order of their creation
+type Sort struct {
+	Field *Field
+	Desc  bool
+}
+
+// Key identifies the sort in cursors, e.g. -name
+func (s *Sort) Key() string {
+	if s == nil {
+		return ""
+	}
+
+	if s.Desc {
+		return "-" + s.Field.Name
+	}
+
+	return s.Field.Name
+}
+
+// ParseFilter parses filter params into a Filter on the fields
+func ParseFilter(fields Fields, params []string) (Filter, error) {
+	const op = "query.ParseFilter"
+
+	v := &errors.Validation{}
+	filter := Filter{}
+
+	v.Check(len(params) <= MaxConditions, "filter", errors.CodeOutOfRange, fmt.Sprintf("filter must not have more than %d conditions", MaxConditions))
+
+	for _, param := range params {
+		condition, err := parseCondition(fields, param)
+
+		if err != nil {
+			v.Add("filter", errors.CodeInvalidValue, err.Error())
+			continue
+		}
+
+		filter = append(filter, *condition)
+	}
+
+	err := v.Err(op)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+func parseCondition(fields Fields, param string) (*Condition, error) {
+	parts := strings.SplitN(param, ":", 3)
+
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%q must be field:op:value", param)
+	}
+
+	field := fields.lookup(parts[0])
+
+	if field == nil {
+		return nil, fmt.Errorf("%q cannot be filtered, use one of %s", parts[0], fields.names())
+	}
+
+	op := Op(parts[1])
+
+	if !validOp(op) || (op == OpPrefix && field.Type != String) {
+		return nil, fmt.Errorf("%q is not an op of %s", parts[1], field.Name)
+	}
+
+	value, err := parseValue(field, parts[2])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Condition{Field: field, Op: op, Value: value}, nil
+}
+
+func validOp(op Op) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseValue(field *Field, s string) (interface{}, error) {
+	if field.Type == Time {
+		t, err := time.Parse(time.RFC3339Nano, s)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 time", field.Name)
+		}
+
+		return t, nil
+	}
+
+	return s, nil
+}
+
+// ParseSort parses the sort param into a Sort on the fields. An empty param keeps the order of creation and returns nil
+func ParseSort(fields Fields, param string) (*Sort, error) {
+	const op = "query.ParseSort"
+
+	if param == "" {
+		return nil, nil
+	}
+
+	sort := &Sort{Desc: strings.HasPrefix(param, "-")}
+	sort.Field = fields.lookup(strings.TrimPrefix(param, "-"))
+
+	if sort.Field == nil {
+		return nil, errors.InvalidField(op, "sort", errors.CodeInvalidValue, fmt.Sprintf("%q cannot be sorted by, use one of %s", param, fields.names()))
+	}
+
+	return sort, nil
+}
+
+// Match reports whether the record meets all conditions of the filter
+func (f Filter) Match(record interface{}) bool {
+	for _, condition := range f {
+		if !condition.Match(record) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Match reports whether the record meets the condition
+func (c *Condition) Match(record interface{}) bool {
+	value := c.Field.Value(record)
+
+	if c.Op == OpPrefix {
+		return strings.HasPrefix(value.(string), c.Value.(string))
+	}
+
+	cmp := Compare(value, c.Value)
+
+	switch c.Op {
+	case OpEq:
+		return cmp == 0
+	case OpNe:
+		return cmp != 0
+	case OpLt:
+		return cmp < 0
+	case OpLe:
+		return cmp <= 0
+	case OpGt:
+		return cmp > 0
+	case OpGe:
+		return cmp >= 0
+	}
+
+	return false
+}
+
+// Compare returns -1, 0 or 1 when a is less than, equal to or greater than b, which are both strings or both times.
+// Strings are compared byte by byte, like the "C" collation of Postgres
+func Compare(a interface{}, b interface{}) int {
+	if at, ok := a.(time.Time); ok {
+		bt := b.(time.Time)
+
+		if at.Before(bt) {
+			return -1
+		}
+
+		if at.After(bt) {
+			return 1
+		}
+
+		return 0
+	}
+
+	return strings.Compare(a.(string), b.(string))
+}
+
+// timeFormat has fixed width, so that formatted times in UTC compare like the times
+const timeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Format returns the value of a field as text, e.g. for cursors. Formatted values of a field compare like the values
+func Format(field *Field, value interface{}) string {
+	if field.Type == Time {
+		return value.(time.Time).UTC().Format(timeFormat)
+	}
+
+	return value.(string)
+}
+
+// Parse returns the value of a field formatted by Format
+func Parse(field *Field, s string) (interface{}, error) {
+	return parseValue(field, s)
+}
@@ -0,0 +1,139 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type record struct {
+	name      string
+	createdAt time.Time
+}
+
+var fields = Fields{
+	{Name: "name", Column: "name", Type: String, Value: func(r interface{}) interface{} { return r.(*record).name }},
+	{Name: "created_at", Column: "created_at", Type: Time, Value: func(r interface{}) interface{} { return r.(*record).createdAt }},
+}
+
+func TestParseFilter(t *testing.T) {
+	t.Run("should parse conditions", func(t *testing.T) {
+		filter, err := ParseFilter(fields, []string{"name:prefix:Sa:lon", "created_at:gt:2020-01-01T00:00:00Z"})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(filter) != 2 || filter[0].Field.Name != "name" || filter[0].Op != OpPrefix || filter[0].Value != "Sa:lon" {
+			t.Errorf("expected name prefix Sa:lon, got %+v", filter)
+			return
+		}
+
+		if !filter[1].Value.(time.Time).Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected created_at after 2020-01-01, got %v", filter[1].Value)
+		}
+	})
+
+	t.Run("should reject invalid conditions", func(t *testing.T) {
+		for _, param := range []string{
+			"name",
+			"name:eq",
+			"user_id:eq:1",
+			"name:like:Sal",
+			"created_at:prefix:2020",
+			"created_at:gt:yesterday",
+		} {
+			_, err := ParseFilter(fields, []string{param})
+
+			if errors.Is(errors.KindInvalid, err) == false {
+				t.Errorf("expected filter %q to be invalid, got %v", param, err)
+			}
+		}
+	})
+
+	t.Run("should reject too many conditions", func(t *testing.T) {
+		params := strings.Split(strings.Repeat("name:ne:x,", MaxConditions+1), ",")
+
+		_, err := ParseFilter(fields, params[:MaxConditions+1])
+
+		if errors.Is(errors.KindInvalid, err) == false {
+			t.Errorf("expected too many conditions to be invalid, got %v", err)
+		}
+	})
+}
+
+func TestParseSort(t *testing.T) {
+	sort, err := ParseSort(fields, "-created_at")
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if sort.Field.Name != "created_at" || !sort.Desc || sort.Key() != "-created_at" {
+		t.Errorf("expected created_at descending, got %+v", sort)
+	}
+
+	sort, err = ParseSort(fields, "")
+
+	if err != nil || sort != nil {
+		t.Errorf("expected no sort, got %+v, %v", sort, err)
+	}
+
+	_, err = ParseSort(fields, "user_id")
+
+	if errors.Is(errors.KindInvalid, err) == false {
+		t.Errorf("expected sort by user_id to be invalid, got %v", err)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	r := &record{name: "Salon", createdAt: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		params []string
+		match  bool
+	}{
+		{[]string{}, true},
+		{[]string{"name:eq:Salon"}, true},
+		{[]string{"name:ne:Salon"}, false},
+		{[]string{"name:prefix:Sal"}, true},
+		{[]string{"name:prefix:sal"}, false},
+		{[]string{"name:lt:Salons"}, true},
+		{[]string{"name:ge:Salons"}, false},
+		{[]string{"created_at:gt:2020-01-01T00:00:00Z", "name:le:Salon"}, true},
+		{[]string{"created_at:gt:2020-01-01T00:00:00Z", "name:prefix:Spa"}, false},
+		{[]string{"created_at:lt:2020-02-01T07:00:00+07:00"}, false},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseFilter(fields, test.params)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if filter.Match(r) != test.match {
+			t.Errorf("expected filter %v to match %v, got %v", test.params, test.match, !test.match)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	earlier := Format(fields[1], time.Date(2020, time.January, 1, 9, 0, 0, 0, time.UTC))
+	later := Format(fields[1], time.Date(2020, time.January, 1, 9, 0, 0, 500, time.UTC))
+
+	if earlier >= later {
+		t.Errorf("expected %s to sort before %s", earlier, later)
+	}
+
+	value, err := Parse(fields[1], later)
+
+	if err != nil || !value.(time.Time).Equal(time.Date(2020, time.January, 1, 9, 0, 0, 500, time.UTC)) {
+		t.Errorf("expected %s to parse back, got %v, %v", later, value, err)
+	}
+}
@@ -0,0 +1,19 @@
+package random
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// Token generates a url safe random string from n cryptographically secure random bytes
+func Token(n int) string {
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
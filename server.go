@@ -1,14 +1,16 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-chi/jwtauth"
 	"github.com/go-chi/render"
 	"github.com/minheq/kedul_server_main/app"
 	"github.com/minheq/kedul_server_main/auth"
@@ -18,23 +20,35 @@ import (
 )
 
 type server struct {
-	db        *sql.DB
+	stores    *stores
 	router    *chi.Mux
 	logger    *logger.Logger
 	smsSender phone.SMSSender
+	tokenKeys *auth.TokenKeys
+	timeouts  *timeouts
+}
+
+// timeouts limit how long requests and each of their queries may take
+type timeouts struct {
+	Request time.Duration
+	Query   time.Duration
 }
 
 func newServer(
-	db *sql.DB,
+	stores *stores,
 	router *chi.Mux,
 	logger *logger.Logger,
 	smsSender phone.SMSSender,
+	tokenKeys *auth.TokenKeys,
+	timeouts *timeouts,
 ) *server {
 	s := &server{
-		db:        db,
+		stores:    stores,
 		router:    router,
 		logger:    logger,
 		smsSender: smsSender,
+		tokenKeys: tokenKeys,
+		timeouts:  timeouts,
 	}
 
 	s.routes()
@@ -43,54 +57,85 @@ func newServer(
 }
 
 func (s *server) routes() {
-	// dependencies
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	transactor := s.stores.transactor
+
+	// phone
+	smsTemplateStore := s.stores.smsTemplate
+	smsTemplates := phone.NewSMSTemplates(smsTemplateStore)
 
 	// auth
-	authStore := auth.NewStore(s.db)
-	authService := auth.NewService(authStore, tokenAuth, s.smsSender)
+	authStore := s.stores.auth
+	authService := auth.NewService(authStore, s.tokenKeys, s.smsSender, smsTemplates, transactor)
 
 	// app
-	businessStore := app.NewBusinessStore(s.db)
-	locationStore := app.NewLocationStore(s.db)
-	employeeStore := app.NewEmployeeStore(s.db)
-	employeeRoleStore := app.NewEmployeeRoleStore(s.db)
-	businessService := app.NewBusinessService(businessStore, locationStore, employeeStore)
-	locationService := app.NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore)
+	businessStore := s.stores.business
+	locationStore := s.stores.location
+	employeeStore := s.stores.employee
+	employeeRoleStore := s.stores.employeeRole
+	serviceStore := s.stores.service
+	appointmentStore := s.stores.appointment
+	employeeScheduleStore := s.stores.employeeSchedule
+	clientStore := s.stores.client
+	invitationStore := s.stores.invitation
+	businessService := app.NewBusinessService(businessStore, locationStore, employeeStore, employeeRoleStore, transactor)
+	locationService := app.NewLocationService(businessStore, locationStore, employeeStore, employeeRoleStore, transactor)
 	permissionService := app.NewPermissionService(employeeRoleStore, employeeStore)
-	// employeeService := app.NewEmployeeService(employeeStore)
-	// employeeRoleService := app.NewEmployeeRoleService(employeeStore, employeeRoleStore)
+	serviceService := app.NewServiceService(serviceStore)
+	appointmentService := app.NewAppointmentService(appointmentStore, employeeStore, serviceStore, clientStore, locationStore)
+	employeeScheduleService := app.NewEmployeeScheduleService(employeeScheduleStore, employeeStore)
+	availabilityService := app.NewAvailabilityService(locationStore, employeeStore, serviceStore, employeeScheduleStore, appointmentStore)
+	clientService := app.NewClientService(clientStore, locationStore)
+	employeeService := app.NewEmployeeService(employeeStore, employeeRoleStore)
+	employeeRoleService := app.NewEmployeeRoleService(employeeStore, employeeRoleStore)
+	invitationService := app.NewInvitationService(invitationStore, employeeStore, employeeRoleStore, locationStore, businessStore, s.smsSender, smsTemplates, transactor)
+	smsTemplateService := app.NewSMSTemplateService(smsTemplateStore, businessStore)
 
 	// middlewares
 	s.router.Use(middleware.RequestID)
 	s.router.Use(middleware.RealIP)
 	s.router.Use(logger.NewRequestLogger(s.logger))
 	s.router.Use(middleware.Recoverer)
+	s.router.Use(s.timeout(s.timeouts.Request, s.timeouts.Query))
 	s.router.Use(cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Workspace", "X-CSRF-Token"},
-		ExposedHeaders:   []string{""},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", "Workspace", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}).Handler)
 
 	// public handlers
 	s.router.Group(func(r chi.Router) {
+		s.router.Get("/.well-known/jwks.json", s.handleGetJWKS(s.tokenKeys))
+		s.router.Get("/errors", s.handleGetProblems())
+		s.router.Get("/errors/{code}", s.handleGetProblem())
+		if deliveryStatusTracker, ok := s.smsSender.(phone.DeliveryStatusTracker); ok {
+			s.router.Post("/sms/delivery_status", s.handleTrackSMSDeliveryStatus(deliveryStatusTracker))
+		}
+
 		s.router.Post("/auth/login_verify", s.handleLoginVerify(authService))
-		s.router.Post("/auth/login_check", s.handleLoginCheck(authService))
+		s.router.Post("/auth/login_check", s.handleLoginCheck(authService, clientService))
+		s.router.Post("/auth/refresh", s.handleRefreshToken(authService))
+		s.router.Post("/auth/logout", s.handleLogout(authService))
 	})
 
 	// protected handlers
 	s.router.Group(func(r chi.Router) {
-		r.Use(jwtauth.Verifier(tokenAuth))
-		r.Use(s.authenticate)
+		r.Use(s.verifyToken(s.tokenKeys))
+		r.Use(s.authenticate(authService))
 		r.Use(s.addCurrentUserContext(authService))
 
 		r.Get("/auth/current_user", s.handleGetCurrentUser(authService))
 		r.Post("/auth/update_phone_number_verify", s.handleUpdatePhoneNumberVerify(authService))
-		r.Post("/auth/update_phone_number_check", s.handleUpdatePhoneNumberCheck(authService))
+		r.Post("/auth/update_phone_number_check", s.handleUpdatePhoneNumberCheck(authService, clientService))
 		r.Post("/auth/update_user_profile", s.handleUpdateUserProfile(authService))
+		r.Patch("/auth/current_user", s.handlePatchUserProfile(authService))
+		r.Get("/auth/sessions", s.handleGetSessions(authService))
+		r.Post("/auth/sessions/{sessionID}/revoke", s.handleRevokeSession(authService))
+
+		r.Get("/invitations", s.handleGetPendingInvitations(invitationService))
+		r.Post("/invitations/{invitationID}/accept", s.handleAcceptInvitation(invitationService))
 
 		r.Get("/users/{userID}/businesses", s.handleGetBusinessesByUserID(businessService))
 		r.Get("/users/{userID}/businesses/{businessID}/locations", s.handleGetLocationsByUserIDAndBusinessID(locationService))
@@ -98,18 +143,76 @@ func (s *server) routes() {
 		r.Post("/businesses", s.handleCreateBusiness(businessService))
 		r.Get("/businesses/{businessID}", s.handleGetBusiness(businessService))
 		r.Post("/businesses/{businessID}", s.handleUpdateBusiness(businessService))
+		r.Patch("/businesses/{businessID}", s.handlePatchBusiness(businessService))
 		r.Delete("/businesses/{businessID}", s.handleDeleteBusiness(businessService))
+		r.Post("/businesses/{businessID}/restore", s.handleRestoreBusiness(businessService))
+		r.Get("/businesses/{businessID}/sms_templates", s.handleGetSMSTemplates(smsTemplateService))
+		r.Post("/businesses/{businessID}/sms_templates", s.handleSetSMSTemplate(smsTemplateService))
+		r.Delete("/businesses/{businessID}/sms_templates/{messageType}/{locale}", s.handleResetSMSTemplate(smsTemplateService))
 
 		r.Post("/locations", s.handleCreateLocation(locationService))
 		r.Post("/locations/{locationID}", s.handleUpdateLocation(locationService, permissionService))
+		r.Patch("/locations/{locationID}", s.handlePatchLocation(locationService, permissionService))
 		r.Get("/locations/{locationID}", s.handleGetLocation(locationService, permissionService))
 		r.Delete("/locations/{locationID}", s.handleDeleteLocation(locationService))
+		r.Post("/locations/{locationID}/restore", s.handleRestoreLocation(locationService))
+
+		r.Get("/locations/{locationID}/employees", s.handleGetEmployeesByLocationID(employeeService, permissionService))
+		r.Post("/locations/{locationID}/employees", s.handleCreateEmployee(employeeService, permissionService))
+		r.Get("/locations/{locationID}/employees/{employeeID}", s.handleGetEmployee(employeeService, permissionService))
+		r.Post("/locations/{locationID}/employees/{employeeID}", s.handleUpdateEmployee(employeeService, permissionService))
+		r.Patch("/locations/{locationID}/employees/{employeeID}", s.handlePatchEmployee(employeeService, permissionService))
+		r.Delete("/locations/{locationID}/employees/{employeeID}", s.handleDeleteEmployee(employeeService, permissionService))
+
+		r.Get("/locations/{locationID}/employee_roles", s.handleGetEmployeeRolesByLocationID(employeeRoleService, permissionService))
+		r.Post("/locations/{locationID}/employee_roles", s.handleCreateEmployeeRole(employeeRoleService, permissionService))
+		r.Get("/locations/{locationID}/employee_roles/{employeeRoleID}", s.handleGetEmployeeRole(employeeRoleService, permissionService))
+		r.Post("/locations/{locationID}/employee_roles/{employeeRoleID}", s.handleUpdateEmployeeRole(employeeRoleService, permissionService))
+		r.Delete("/locations/{locationID}/employee_roles/{employeeRoleID}", s.handleDeleteEmployeeRole(employeeRoleService, permissionService))
+
+		r.Get("/locations/{locationID}/invitations", s.handleGetInvitationsByLocationID(invitationService, permissionService))
+		r.Post("/locations/{locationID}/invitations", s.handleCreateInvitation(invitationService, permissionService))
+		r.Post("/locations/{locationID}/invitations/{invitationID}/revoke", s.handleRevokeInvitation(invitationService, permissionService))
+
+		r.Get("/locations/{locationID}/services", s.handleGetServicesByLocationID(serviceService, permissionService))
+		r.Post("/locations/{locationID}/services", s.handleCreateService(serviceService, permissionService))
+		r.Get("/locations/{locationID}/services/{serviceID}", s.handleGetService(serviceService, permissionService))
+		r.Post("/locations/{locationID}/services/{serviceID}", s.handleUpdateService(serviceService, permissionService))
+		r.Delete("/locations/{locationID}/services/{serviceID}", s.handleDeleteService(serviceService, permissionService))
+
+		r.Get("/locations/{locationID}/appointments", s.handleGetAppointmentsByLocationID(appointmentService, permissionService))
+		r.Post("/locations/{locationID}/appointments", s.handleCreateAppointment(appointmentService, permissionService))
+		r.Get("/locations/{locationID}/appointments/{appointmentID}", s.handleGetAppointment(appointmentService, permissionService))
+		r.Post("/locations/{locationID}/appointments/{appointmentID}", s.handleUpdateAppointment(appointmentService, permissionService))
+		r.Delete("/locations/{locationID}/appointments/{appointmentID}", s.handleDeleteAppointment(appointmentService, permissionService))
+
+		r.Get("/locations/{locationID}/employees/{employeeID}/working_hours", s.handleGetWorkingHours(employeeScheduleService, permissionService))
+		r.Post("/locations/{locationID}/employees/{employeeID}/working_hours", s.handleSetWorkingHours(employeeScheduleService, permissionService))
+		r.Get("/locations/{locationID}/employees/{employeeID}/working_hours_overrides", s.handleGetWorkingHoursOverrides(employeeScheduleService, permissionService))
+		r.Post("/locations/{locationID}/employees/{employeeID}/working_hours_overrides", s.handleCreateWorkingHoursOverride(employeeScheduleService, permissionService))
+		r.Delete("/locations/{locationID}/employees/{employeeID}/working_hours_overrides/{overrideID}", s.handleDeleteWorkingHoursOverride(employeeScheduleService, permissionService))
+		r.Get("/locations/{locationID}/employees/{employeeID}/time_offs", s.handleGetTimeOffs(employeeScheduleService, permissionService))
+		r.Post("/locations/{locationID}/employees/{employeeID}/time_offs", s.handleCreateTimeOff(employeeScheduleService, permissionService))
+		r.Delete("/locations/{locationID}/employees/{employeeID}/time_offs/{timeOffID}", s.handleDeleteTimeOff(employeeScheduleService, permissionService))
+
+		r.Get("/locations/{locationID}/availability", s.handleGetAvailability(availabilityService, permissionService))
+
+		r.Get("/locations/{locationID}/clients", s.handleGetClients(clientService, permissionService))
+		r.Post("/locations/{locationID}/clients", s.handleCreateClient(clientService, permissionService))
+		r.Get("/locations/{locationID}/clients/{clientID}", s.handleGetClient(clientService, permissionService))
+		r.Post("/locations/{locationID}/clients/{clientID}", s.handleUpdateClient(clientService, permissionService))
+		r.Delete("/locations/{locationID}/clients/{clientID}", s.handleDeleteClient(clientService, permissionService))
 	})
 }
 
+// respondError writes the error as application/problem+json, identified by the request id
 func (s *server) respondError(w http.ResponseWriter, r *http.Request, err error) {
 	s.logger.Error((err))
-	render.Render(w, r, errors.NewErrResponse(err))
+	errResponse := errors.NewErrResponse(err, middleware.GetReqID(r.Context()))
+
+	w.Header().Set("Content-Type", errors.ProblemContentType)
+	w.WriteHeader(errResponse.Status)
+	json.NewEncoder(w).Encode(errResponse)
 }
 
 func (s *server) respondSuccess(w http.ResponseWriter, r *http.Request, v render.Renderer) {
@@ -121,3 +224,46 @@ func (s *server) decode(w http.ResponseWriter, r *http.Request, v interface{}) e
 
 	return err
 }
+
+// decodePatch decodes a JSON merge patch document (RFC 7396), which must be an object, into v. Members of the wrong
+// type are reported as invalid fields
+func (s *server) decodePatch(r *http.Request, v interface{}) error {
+	const op = "server.decodePatch"
+
+	err := json.NewDecoder(r.Body).Decode(v)
+
+	if e, ok := err.(*json.UnmarshalTypeError); ok && e.Field != "" {
+		return errors.InvalidField(op, e.Field, errors.CodeInvalidFormat, fmt.Sprintf("%s must be a %s", e.Field, e.Type))
+	}
+
+	if err != nil {
+		return errors.Invalid(op, "merge patch must be a JSON object")
+	}
+
+	return nil
+}
+
+// setETag tells the version of the record in the response, for the client to send it back in If-Match
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// ifMatch returns the version in the If-Match header of an update or delete. Without the header, or with *, any
+// version matches and 0 is returned
+func ifMatch(r *http.Request) (int, error) {
+	const op = "server.ifMatch"
+
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`))
+
+	if err != nil || version < 1 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, errors.PreconditionFailed(op, "If-Match does not match the ETag of the resource")
+	}
+
+	return version, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,7 +10,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/golang-migrate/migrate/v4"
@@ -21,12 +25,16 @@ import (
 	"github.com/minheq/kedul_server_main/logger"
 )
 
+var codeRegexp = regexp.MustCompile(`\d{6}`)
+
 type smsSenderMock struct {
 	Text string
+	Code string
 }
 
-func (s *smsSenderMock) SendSMS(phoneNumber string, countryCode string, text string) error {
+func (s *smsSenderMock) SendSMS(ctx context.Context, phoneNumber string, countryCode string, text string) error {
 	s.Text = text
+	s.Code = codeRegexp.FindString(text)
 	return nil
 }
 
@@ -46,6 +54,23 @@ func (t *testHTTPClient) post(target string, body interface{}, response interfac
 	w := httptest.NewRecorder()
 	t.server.router.ServeHTTP(w, req)
 
+	if w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		return fmt.Errorf("request error. received %v status code. response body: %v", w.Code, w.Body)
+	}
+
+	json.NewDecoder(w.Body).Decode(response)
+
+	return nil
+}
+
+func (t *testHTTPClient) patch(target string, document string, response interface{}) error {
+	req := httptest.NewRequest("PATCH", target, strings.NewReader(document))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.accessToken))
+
+	w := httptest.NewRecorder()
+	t.server.router.ServeHTTP(w, req)
+
 	if w.Code != http.StatusOK {
 		return fmt.Errorf("request error. received %v status code. response body: %v", w.Code, w.Body)
 	}
@@ -72,6 +97,23 @@ func (t *testHTTPClient) get(target string, response interface{}) error {
 	return nil
 }
 
+func (t *testHTTPClient) delete(target string, response interface{}) error {
+	req := httptest.NewRequest("DELETE", target, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.accessToken))
+
+	w := httptest.NewRecorder()
+	t.server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("request error. received %v status code. response body: %v", w.Code, w.Body)
+	}
+
+	json.NewDecoder(w.Body).Decode(response)
+
+	return nil
+}
+
 func setupDB(db *sql.DB) {
 	databaseName := os.Getenv("DATABASE_NAME")
 	driver, _ := postgres.WithInstance(db, &postgres.Config{})
@@ -97,18 +139,29 @@ func setupDB(db *sql.DB) {
 func TestEndToEnd(t *testing.T) {
 	router := chi.NewRouter()
 	log := logger.NewLogger()
-	dbURL := os.Getenv("DATABASE_URL")
-	db, err := sql.Open("postgres", dbURL)
+	stores := newMemoryStores()
 
-	if err != nil {
-		t.Error(err)
-	}
+	// Without a database, the test runs against the memory stores
+	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
+		db, err := sql.Open("postgres", dbURL)
+
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	setupDB(db)
+		setupDB(db)
+		stores = newPostgresStores(db)
+	}
 
 	smsSender := &smsSenderMock{}
 
-	server := newServer(db, router, log, smsSender)
+	tokenKeys, err := auth.GenerateTokenKeys()
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	server := newServer(stores, router, log, smsSender, tokenKeys, &timeouts{Request: 30 * time.Second, Query: 10 * time.Second})
 
 	loginVerifyResp := &phoneNumberVerifyResponse{}
 
@@ -130,22 +183,71 @@ func TestEndToEnd(t *testing.T) {
 		}
 	})
 
+	t.Run("get jwks", func(t *testing.T) {
+		resp := &jwksResponse{}
+		err := client.get("/.well-known/jwks.json", resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Keys) != 1 || resp.Keys[0].KeyID == "" {
+			t.Error(fmt.Errorf("there should be 1 key with kid"))
+		}
+	})
+
+	tokens := &tokenResponse{}
+
 	t.Run("login check", func(t *testing.T) {
 		body := phoneNumberCheckRequest{
 			VerificationID: loginVerifyResp.VerificationID,
-			Code:           smsSender.Text,
+			Code:           smsSender.Code,
 		}
 
-		loginCheckResp := &loginCheckResponse{}
+		err := client.post("/auth/login_check", body, tokens)
 
-		err := client.post("/auth/login_check", body, loginCheckResp)
+		client.accessToken = tokens.AccessToken
 
-		client.accessToken = loginCheckResp.AccessToken
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("refresh token", func(t *testing.T) {
+		body := refreshTokenRequest{
+			RefreshToken: tokens.RefreshToken,
+		}
+		refreshedTokens := &tokenResponse{}
+
+		err := client.post("/auth/refresh", body, refreshedTokens)
 
 		if err != nil {
 			t.Error(err)
 			return
 		}
+
+		if refreshedTokens.RefreshToken == tokens.RefreshToken {
+			t.Error(fmt.Errorf("refresh token should be rotated"))
+		}
+
+		tokens = refreshedTokens
+		client.accessToken = tokens.AccessToken
+	})
+
+	t.Run("get sessions", func(t *testing.T) {
+		resp := &sessionListResponse{}
+		err := client.get("/auth/sessions", resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 1 || resp.Data[0].IsCurrent == false {
+			t.Error(fmt.Errorf("there should be 1 current session"))
+		}
 	})
 
 	t.Run("get current user", func(t *testing.T) {
@@ -157,6 +259,27 @@ func TestEndToEnd(t *testing.T) {
 		}
 	})
 
+	t.Run("patch current user", func(t *testing.T) {
+		profile := &userResponse{}
+		err := client.patch("/auth/current_user", `{"full_name": "Linh", "image_id": "image"}`, profile)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = client.patch("/auth/current_user", `{"image_id": null}`, profile)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if profile.FullName != "Linh" || profile.ProfileImageID != "" {
+			t.Error(fmt.Errorf("profile image should be removed and full name unchanged, got %+v", profile))
+		}
+	})
+
 	// App
 	business := &app.Business{}
 	location := &app.Location{}
@@ -216,6 +339,40 @@ func TestEndToEnd(t *testing.T) {
 		}
 	})
 
+	t.Run("set sms template", func(t *testing.T) {
+		body := &app.SetSMSTemplateInput{
+			MessageType: "INVITATION",
+			Locale:      "vi",
+			Body:        "{business} mời bạn tham gia {location}",
+		}
+		resp := &app.SMSTemplate{}
+
+		err := client.post(fmt.Sprintf("/businesses/%s/sms_templates", business.ID), body, resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if resp.IsDefault {
+			t.Error(fmt.Errorf("sms template should be overridden"))
+		}
+	})
+
+	t.Run("get sms templates", func(t *testing.T) {
+		resp := &smsTemplateListResponse{}
+		err := client.get(fmt.Sprintf("/businesses/%s/sms_templates", business.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 4 {
+			t.Error(fmt.Errorf("there should be 4 sms templates, received=%d", len(resp.Data)))
+		}
+	})
+
 	t.Run("create location", func(t *testing.T) {
 		body := &app.CreateLocationInput{
 			BusinessID: business.ID,
@@ -257,6 +414,74 @@ func TestEndToEnd(t *testing.T) {
 		}
 	})
 
+	t.Run("patch location", func(t *testing.T) {
+		resp := &app.Location{}
+		err := client.patch(fmt.Sprintf("/locations/%s", location.ID), `{"profile_image_id": null}`, resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if resp.Name != location.Name || resp.ProfileImageID != "" {
+			t.Error(fmt.Errorf("profile image should be removed and name unchanged, got %+v", resp))
+		}
+
+		for _, document := range []string{`{"name": null}`, `{"name": 1}`, `[]`} {
+			err = client.patch(fmt.Sprintf("/locations/%s", location.ID), document, resp)
+
+			if err == nil || !strings.Contains(err.Error(), "400") {
+				t.Error(fmt.Errorf("patch %s should be invalid, got %v", document, err))
+			}
+		}
+	})
+
+	t.Run("update location only with current etag", func(t *testing.T) {
+		request := func(method string, document string, etag string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, fmt.Sprintf("/locations/%s", location.ID), strings.NewReader(document))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.accessToken))
+
+			if etag != "" {
+				req.Header.Set("If-Match", etag)
+			}
+
+			w := httptest.NewRecorder()
+			client.server.router.ServeHTTP(w, req)
+
+			return w
+		}
+
+		w := request("GET", "", "")
+		etag := w.Header().Get("ETag")
+
+		if w.Code != http.StatusOK || etag == "" {
+			t.Error(fmt.Errorf("location should have an etag, got %v %q", w.Code, etag))
+			return
+		}
+
+		w = request("PATCH", `{"name": "location renamed"}`, etag)
+
+		if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+			t.Error(fmt.Errorf("patch with current etag should change the etag, got %v %v", w.Code, w.Body))
+			return
+		}
+
+		w = request("PATCH", `{"name": "location overwritten"}`, etag)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Error(fmt.Errorf("patch with stale etag should fail with 412, got %v %v", w.Code, w.Body))
+		}
+
+		w = request("DELETE", "", etag)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Error(fmt.Errorf("delete with stale etag should fail with 412, got %v %v", w.Code, w.Body))
+		}
+
+		location.Name = "location renamed"
+	})
+
 	t.Run("get user locations", func(t *testing.T) {
 		resp := &locationListResponse{}
 		err := client.get(fmt.Sprintf("/users/%s/businesses/%s/locations", user.ID, business.ID), resp)
@@ -271,4 +496,530 @@ func TestEndToEnd(t *testing.T) {
 			return
 		}
 	})
+
+	ownerEmployee := &employeeResponse{}
+
+	t.Run("get location employees", func(t *testing.T) {
+		resp := &employeeListResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/employees", location.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 1 {
+			t.Error(fmt.Errorf("there should be 1 employee"))
+			return
+		}
+
+		ownerEmployee = resp.Data[0]
+
+		if ownerEmployee.UserID != user.ID {
+			t.Error(fmt.Errorf("employee user does not match. expected=%s, received=%s", user.ID, ownerEmployee.UserID))
+		}
+	})
+
+	t.Run("get location employee roles", func(t *testing.T) {
+		resp := &employeeRoleListResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/employee_roles", location.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 5 {
+			t.Error(fmt.Errorf("there should be 5 default employee roles"))
+			return
+		}
+	})
+
+	t.Run("page location employee roles", func(t *testing.T) {
+		resp := &employeeRoleListResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/employee_roles?first=3", location.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 3 || resp.TotalCount != 5 || !resp.PageInfo.HasNextPage {
+			t.Error(fmt.Errorf("first page should have 3 of 5 employee roles and a next page"))
+			return
+		}
+
+		next := &employeeRoleListResponse{}
+		err = client.get(fmt.Sprintf("/locations/%s/employee_roles?first=3&after=%s", location.ID, resp.PageInfo.EndCursor), next)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(next.Data) != 2 || next.PageInfo.HasNextPage || next.Data[0].ID == resp.Data[2].ID {
+			t.Error(fmt.Errorf("second page should have the last 2 employee roles"))
+		}
+	})
+
+	t.Run("filter and sort location employee roles", func(t *testing.T) {
+		resp := &employeeRoleListResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/employee_roles?filter=name:ne:owner&sort=name", location.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		names := []string{}
+
+		for _, r := range resp.Data {
+			names = append(names, r.Name)
+		}
+
+		if fmt.Sprint(names) != "[admin manager receptionist specialist]" || resp.TotalCount != 4 {
+			t.Error(fmt.Errorf("expected employee roles other than owner by name, got %v", names))
+		}
+
+		err = client.get(fmt.Sprintf("/locations/%s/employee_roles?filter=permission_ids:eq:1", location.ID), resp)
+
+		if err == nil {
+			t.Error(fmt.Errorf("filter on a field that is not allowed should be rejected"))
+		}
+	})
+
+	employeeRole := &employeeRoleResponse{}
+
+	t.Run("create employee role", func(t *testing.T) {
+		body := &app.CreateEmployeeRoleInput{
+			Name:          "stylist",
+			PermissionIDs: []string{},
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/employee_roles", location.ID), body, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("update employee role", func(t *testing.T) {
+		body := &app.UpdateEmployeeRoleInput{
+			Name: "senior stylist",
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/employee_roles/%s", location.ID, employeeRole.ID), body, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if employeeRole.Name != body.Name {
+			t.Error(fmt.Errorf("employee role name does not match. expected=%s, received=%s", body.Name, employeeRole.Name))
+		}
+	})
+
+	employee := &employeeResponse{}
+
+	t.Run("create employee", func(t *testing.T) {
+		body := &app.CreateEmployeeInput{
+			Name:           "employee1",
+			EmployeeRoleID: employeeRole.ID,
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/employees", location.ID), body, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("update employee", func(t *testing.T) {
+		body := &app.UpdateEmployeeInput{
+			Name: "employee2",
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/employees/%s", location.ID, employee.ID), body, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if employee.Name != body.Name {
+			t.Error(fmt.Errorf("employee name does not match. expected=%s, received=%s", body.Name, employee.Name))
+		}
+	})
+
+	invitation := &invitationResponse{}
+
+	t.Run("create invitation", func(t *testing.T) {
+		body := &app.CreateInvitationInput{
+			EmployeeID:  employee.ID,
+			PhoneNumber: "888888888",
+			CountryCode: "VN",
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/invitations", location.ID), body, invitation)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if invitation.Status != app.InvitationStatusPending {
+			t.Error(fmt.Errorf("invitation should be pending. received=%s", invitation.Status))
+		}
+	})
+
+	invitedClient := &testHTTPClient{server: server, accessToken: ""}
+
+	t.Run("invited user login", func(t *testing.T) {
+		verifyBody := phoneNumberVerifyRequest{
+			PhoneNumber: "888888888",
+			CountryCode: "VN",
+		}
+		verifyResp := &phoneNumberVerifyResponse{}
+
+		err := invitedClient.post("/auth/login_verify", verifyBody, verifyResp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBody := phoneNumberCheckRequest{
+			VerificationID: verifyResp.VerificationID,
+			Code:           smsSender.Code,
+		}
+		checkResp := &tokenResponse{}
+
+		err = invitedClient.post("/auth/login_check", checkBody, checkResp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		invitedClient.accessToken = checkResp.AccessToken
+	})
+
+	t.Run("get pending invitations", func(t *testing.T) {
+		resp := &invitationListResponse{}
+		err := invitedClient.get("/invitations", resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 1 {
+			t.Error(fmt.Errorf("there should be 1 pending invitation"))
+			return
+		}
+	})
+
+	t.Run("accept invitation", func(t *testing.T) {
+		err := invitedClient.post(fmt.Sprintf("/invitations/%s/accept", invitation.ID), nil, invitation)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		resp := &employeeResponse{}
+		err = client.get(fmt.Sprintf("/locations/%s/employees/%s", location.ID, employee.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if resp.UserID == "" {
+			t.Error(fmt.Errorf("employee should be linked to invited user"))
+		}
+	})
+
+	t.Run("delete employee", func(t *testing.T) {
+		resp := &employeeResponse{}
+		err := client.delete(fmt.Sprintf("/locations/%s/employees/%s", location.ID, employee.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("delete employee role", func(t *testing.T) {
+		resp := &employeeRoleResponse{}
+		err := client.delete(fmt.Sprintf("/locations/%s/employee_roles/%s", location.ID, employeeRole.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	service := &app.Service{}
+
+	t.Run("create service", func(t *testing.T) {
+		body := &app.CreateServiceInput{
+			Name:     "haircut",
+			Duration: 30,
+			Price:    100000,
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/services", location.ID), body, service)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("update service", func(t *testing.T) {
+		body := &app.UpdateServiceInput{
+			Duration: 45,
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/services/%s", location.ID, service.ID), body, service)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if service.Duration != body.Duration {
+			t.Error(fmt.Errorf("service duration does not match. expected=%d, received=%d", body.Duration, service.Duration))
+		}
+	})
+
+	t.Run("get location services", func(t *testing.T) {
+		resp := &serviceListResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/services", location.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 1 {
+			t.Error(fmt.Errorf("there should be 1 service"))
+			return
+		}
+	})
+
+	tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+
+	t.Run("set working hours", func(t *testing.T) {
+		body := &app.SetWorkingHoursInput{
+			WorkingHours: []*app.WorkingHoursInput{
+				{Weekday: tomorrow.Weekday(), StartMinute: 9 * 60, EndMinute: 17 * 60},
+			},
+		}
+		resp := &workingHoursListResponse{}
+		err := client.post(fmt.Sprintf("/locations/%s/employees/%s/working_hours", location.ID, ownerEmployee.ID), body, resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	appointment := &appointmentResponse{}
+
+	t.Run("create appointment", func(t *testing.T) {
+		body := &app.CreateAppointmentInput{
+			EmployeeID: ownerEmployee.ID,
+			ServiceIDs: []string{service.ID},
+			StartTime:  tomorrow.Add(9 * time.Hour),
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/appointments", location.ID), body, appointment)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("get location appointments", func(t *testing.T) {
+		resp := &appointmentListResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/appointments", location.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 1 {
+			t.Error(fmt.Errorf("there should be 1 appointment"))
+			return
+		}
+	})
+
+	t.Run("get availability", func(t *testing.T) {
+		resp := &availabilityResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/availability?service_id=%s&date=%s", location.ID, service.ID, tomorrow.Format(dateLayout)), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) == 0 {
+			t.Error(fmt.Errorf("there should be available slots"))
+			return
+		}
+
+		if resp.Data[0].StartTime.Equal(appointment.EndTime) == false {
+			t.Error(fmt.Errorf("first slot should start after the appointment. expected=%v, received=%v", appointment.EndTime, resp.Data[0].StartTime))
+		}
+	})
+
+	businessClient := &clientResponse{}
+
+	t.Run("create client", func(t *testing.T) {
+		body := &app.CreateClientInput{
+			Name:        "client1",
+			PhoneNumber: "0901234567",
+			CountryCode: "VN",
+		}
+
+		err := client.post(fmt.Sprintf("/locations/%s/clients", location.ID), body, businessClient)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if businessClient.BusinessID != business.ID {
+			t.Error(fmt.Errorf("client business does not match. expected=%s, received=%s", business.ID, businessClient.BusinessID))
+		}
+
+		if businessClient.PhoneNumber != "090 123 45 67" {
+			t.Error(fmt.Errorf("client phone number should be displayed in national format, received=%s", businessClient.PhoneNumber))
+		}
+	})
+
+	t.Run("search clients", func(t *testing.T) {
+		resp := &clientListResponse{}
+		err := client.get(fmt.Sprintf("/locations/%s/clients?search=%s", location.ID, "090123"), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(resp.Data) != 1 {
+			t.Error(fmt.Errorf("there should be 1 client"))
+			return
+		}
+	})
+
+	t.Run("delete and restore location", func(t *testing.T) {
+		resp := &locationResponse{}
+		err := client.delete(fmt.Sprintf("/locations/%s", location.ID), resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if resp.DeletedAt == nil {
+			t.Error(fmt.Errorf("deleted location should have deleted_at"))
+		}
+
+		err = client.get(fmt.Sprintf("/locations/%s", location.ID), &locationResponse{})
+
+		if err == nil {
+			t.Error(fmt.Errorf("deleted location should not be found"))
+		}
+
+		err = client.post(fmt.Sprintf("/locations/%s/restore", location.ID), nil, &locationResponse{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = client.get(fmt.Sprintf("/locations/%s/services", location.ID), &serviceListResponse{})
+
+		if err != nil {
+			t.Error(fmt.Errorf("owner should keep access to restored location: %v", err))
+		}
+	})
+
+	t.Run("delete and restore business", func(t *testing.T) {
+		err := client.delete(fmt.Sprintf("/businesses/%s", business.ID), &businessResponse{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = client.post(fmt.Sprintf("/locations/%s/restore", location.ID), nil, &locationResponse{})
+
+		if err == nil {
+			t.Error(fmt.Errorf("location of deleted business should not be restored on its own"))
+		}
+
+		err = client.post(fmt.Sprintf("/businesses/%s/restore", business.ID), nil, &businessResponse{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = client.get(fmt.Sprintf("/locations/%s", location.ID), &locationResponse{})
+
+		if err != nil {
+			t.Error(fmt.Errorf("location should be restored with business: %v", err))
+		}
+	})
+
+	t.Run("revoke session", func(t *testing.T) {
+		resp := &sessionListResponse{}
+		err := invitedClient.get("/auth/sessions", resp)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = invitedClient.post(fmt.Sprintf("/auth/sessions/%s/revoke", resp.Data[0].ID), nil, &sessionResponse{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = invitedClient.get("/auth/current_user", &userResponse{})
+
+		if err == nil {
+			t.Error(fmt.Errorf("access token of revoked session should be rejected"))
+		}
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		body := refreshTokenRequest{
+			RefreshToken: tokens.RefreshToken,
+		}
+
+		err := client.post("/auth/logout", body, nil)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = client.post("/auth/refresh", body, &tokenResponse{})
+
+		if err == nil {
+			t.Error(fmt.Errorf("refresh token should be revoked after logout"))
+		}
+	})
 }
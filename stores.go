@@ -0,0 +1,65 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/memstore"
+	"github.com/minheq/kedul_server_main/phone"
+	"github.com/minheq/kedul_server_main/transaction"
+)
+
+// stores persist the records of the services, either in Postgres or in memory
+type stores struct {
+	transactor       transaction.Transactor
+	auth             auth.Store
+	smsTemplate      phone.SMSTemplateStore
+	smsMessage       phone.SMSMessageStore
+	business         app.BusinessStore
+	location         app.LocationStore
+	employee         app.EmployeeStore
+	employeeRole     app.EmployeeRoleStore
+	service          app.ServiceStore
+	appointment      app.AppointmentStore
+	employeeSchedule app.EmployeeScheduleStore
+	client           app.ClientStore
+	invitation       app.InvitationStore
+}
+
+func newPostgresStores(db *sql.DB) *stores {
+	return &stores{
+		transactor:       transaction.NewTransactor(db),
+		auth:             auth.NewStore(db),
+		smsTemplate:      phone.NewSMSTemplateStore(db),
+		smsMessage:       phone.NewSMSMessageStore(db),
+		business:         app.NewBusinessStore(db),
+		location:         app.NewLocationStore(db),
+		employee:         app.NewEmployeeStore(db),
+		employeeRole:     app.NewEmployeeRoleStore(db),
+		service:          app.NewServiceStore(db),
+		appointment:      app.NewAppointmentStore(db),
+		employeeSchedule: app.NewEmployeeScheduleStore(db),
+		client:           app.NewClientStore(db),
+		invitation:       app.NewInvitationStore(db),
+	}
+}
+
+// newMemoryStores keeps records until the server stops
+func newMemoryStores() *stores {
+	return &stores{
+		transactor:       memstore.NewTransactor(),
+		auth:             memstore.NewAuthStore(),
+		smsTemplate:      memstore.NewSMSTemplateStore(),
+		smsMessage:       memstore.NewSMSMessageStore(),
+		business:         memstore.NewBusinessStore(),
+		location:         memstore.NewLocationStore(),
+		employee:         memstore.NewEmployeeStore(),
+		employeeRole:     memstore.NewEmployeeRoleStore(),
+		service:          memstore.NewServiceStore(),
+		appointment:      memstore.NewAppointmentStore(),
+		employeeSchedule: memstore.NewEmployeeScheduleStore(),
+		client:           memstore.NewClientStore(),
+		invitation:       memstore.NewInvitationStore(),
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/auth"
+	"github.com/minheq/kedul_server_main/storetest"
+)
+
+// openTestDB opens the database at DATABASE_URL. The stores of each test are made after setupDB migrates it
+// from scratch, so that they start empty. Without a database, the test is skipped
+func openTestDB(t *testing.T) *sql.DB {
+	dbURL := os.Getenv("DATABASE_URL")
+
+	if dbURL == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestPostgresBusinessStore(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	storetest.TestBusinessStore(t, func(t *testing.T) app.BusinessStore {
+		setupDB(db)
+
+		return app.NewBusinessStore(db)
+	})
+}
+
+func TestPostgresLocationStore(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	storetest.TestLocationStore(t, func(t *testing.T) app.LocationStore {
+		setupDB(db)
+
+		return app.NewLocationStore(db)
+	})
+}
+
+func TestPostgresEmployeeStore(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	storetest.TestEmployeeStore(t, func(t *testing.T) app.EmployeeStore {
+		setupDB(db)
+
+		return app.NewEmployeeStore(db)
+	})
+}
+
+func TestPostgresEmployeeRoleStore(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	storetest.TestEmployeeRoleStore(t, func(t *testing.T) app.EmployeeRoleStore {
+		setupDB(db)
+
+		return app.NewEmployeeRoleStore(db)
+	})
+}
+
+func TestPostgresAuthStore(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+
+	storetest.TestAuthStore(t, func(t *testing.T) auth.Store {
+		setupDB(db)
+
+		return auth.NewStore(db)
+	})
+}
@@ -0,0 +1,617 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/auth"
+)
+
+func newUser(phoneNumber string) *auth.User {
+	return &auth.User{
+		ID:                    newID(),
+		FullName:              "user",
+		PhoneNumber:           phoneNumber,
+		CountryCode:           "VN",
+		IsPhoneNumberVerified: true,
+		CreatedAt:             timestamp(0),
+		UpdatedAt:             timestamp(0),
+	}
+}
+
+func newVerificationCode(phoneNumber string) *auth.VerificationCode {
+	return &auth.VerificationCode{
+		ID:             newID(),
+		UserID:         newID(),
+		Code:           "123456",
+		VerificationID: newID(),
+		CodeType:       "LOGIN",
+		PhoneNumber:    phoneNumber,
+		CountryCode:    "VN",
+		CreatedAt:      timestamp(0),
+		ExpiredAt:      timestamp(10),
+	}
+}
+
+func newRefreshToken(familyID string) *auth.RefreshToken {
+	return &auth.RefreshToken{
+		ID:        newID(),
+		UserID:    newID(),
+		FamilyID:  familyID,
+		TokenHash: newID(),
+		ExpiredAt: timestamp(60),
+		CreatedAt: timestamp(0),
+	}
+}
+
+func newSession(userID string, lastSeenAt int) *auth.Session {
+	return &auth.Session{
+		ID:         newID(),
+		UserID:     userID,
+		UserAgent:  "agent",
+		IPAddress:  "127.0.0.1",
+		CreatedAt:  timestamp(0),
+		LastSeenAt: timestamp(lastSeenAt),
+	}
+}
+
+func checkUser(t *testing.T, got *auth.User, want *auth.User) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected user %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.FullName != want.FullName || got.PhoneNumber != want.PhoneNumber || got.CountryCode != want.CountryCode ||
+		got.ProfileImageID != want.ProfileImageID || got.IsPhoneNumberVerified != want.IsPhoneNumberVerified || !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("expected user %+v, got %+v", want, got)
+	}
+}
+
+func checkVerificationCode(t *testing.T, got *auth.VerificationCode, want *auth.VerificationCode) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected verification code %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.UserID != want.UserID || got.Code != want.Code || got.VerificationID != want.VerificationID ||
+		got.CodeType != want.CodeType || got.PhoneNumber != want.PhoneNumber || got.CountryCode != want.CountryCode ||
+		got.Attempts != want.Attempts || !got.CreatedAt.Equal(want.CreatedAt) || !got.ExpiredAt.Equal(want.ExpiredAt) {
+		t.Errorf("expected verification code %+v, got %+v", want, got)
+	}
+}
+
+func checkRefreshToken(t *testing.T, got *auth.RefreshToken, want *auth.RefreshToken) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected refresh token %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.UserID != want.UserID || got.FamilyID != want.FamilyID || got.TokenHash != want.TokenHash ||
+		!got.ExpiredAt.Equal(want.ExpiredAt) || !got.RotatedAt.Equal(want.RotatedAt) || !got.RevokedAt.Equal(want.RevokedAt) ||
+		!got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("expected refresh token %+v, got %+v", want, got)
+	}
+}
+
+func checkSession(t *testing.T, got *auth.Session, want *auth.Session) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected session %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.UserID != want.UserID || got.UserAgent != want.UserAgent || got.IPAddress != want.IPAddress ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.LastSeenAt.Equal(want.LastSeenAt) || !got.RevokedAt.Equal(want.RevokedAt) {
+		t.Errorf("expected session %+v, got %+v", want, got)
+	}
+}
+
+// TestAuthStore runs the tests against empty auth Stores made by newStore
+func TestAuthStore(t *testing.T, newStore func(t *testing.T) auth.Store) {
+	ctx := context.Background()
+
+	t.Run("should return nil when records do not exist", func(t *testing.T) {
+		store := newStore(t)
+
+		vc, err := store.GetVerificationCodeByVerificationID(ctx, newID())
+
+		if err != nil || vc != nil {
+			t.Errorf("expected nil, nil verification code by verification id, got %v, %v", vc, err)
+		}
+
+		vc, err = store.GetVerificationCodeByPhoneNumber(ctx, "+84999999999", "VN")
+
+		if err != nil || vc != nil {
+			t.Errorf("expected nil, nil verification code by phone number, got %v, %v", vc, err)
+		}
+
+		user, err := store.GetUserByID(ctx, newID())
+
+		if err != nil || user != nil {
+			t.Errorf("expected nil, nil user by id, got %v, %v", user, err)
+		}
+
+		user, err = store.GetUserByPhoneNumber(ctx, "+84999999999", "VN")
+
+		if err != nil || user != nil {
+			t.Errorf("expected nil, nil user by phone number, got %v, %v", user, err)
+		}
+
+		refreshToken, err := store.GetRefreshTokenByTokenHash(ctx, "missing")
+
+		if err != nil || refreshToken != nil {
+			t.Errorf("expected nil, nil refresh token, got %v, %v", refreshToken, err)
+		}
+
+		session, err := store.GetSessionByID(ctx, newID())
+
+		if err != nil || session != nil {
+			t.Errorf("expected nil, nil session, got %v, %v", session, err)
+		}
+	})
+
+	t.Run("should store verification code once per phone number", func(t *testing.T) {
+		store := newStore(t)
+		vc := newVerificationCode("+84999999999")
+
+		err := store.StoreVerificationCode(ctx, vc)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetVerificationCodeByVerificationID(ctx, vc.VerificationID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkVerificationCode(t, got, vc)
+
+		got, err = store.GetVerificationCodeByPhoneNumber(ctx, vc.PhoneNumber, vc.CountryCode)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkVerificationCode(t, got, vc)
+
+		err = store.StoreVerificationCode(ctx, newVerificationCode(vc.PhoneNumber))
+
+		if err == nil {
+			t.Error("second verification code for the phone number should be rejected")
+		}
+	})
+
+	t.Run("should increment verification code attempts", func(t *testing.T) {
+		store := newStore(t)
+		vc := newVerificationCode("+84999999999")
+
+		err := store.StoreVerificationCode(ctx, vc)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		for i := 0; i < 2; i++ {
+			err = store.IncrementVerificationCodeAttempts(ctx, &auth.VerificationCode{ID: vc.ID})
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		err = store.IncrementVerificationCodeAttempts(ctx, vc)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if vc.Attempts != 3 {
+			t.Errorf("expected attempts to be set to 3, got %d", vc.Attempts)
+		}
+
+		got, err := store.GetVerificationCodeByVerificationID(ctx, vc.VerificationID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkVerificationCode(t, got, vc)
+	})
+
+	t.Run("should delete verification codes", func(t *testing.T) {
+		store := newStore(t)
+		byPhoneNumber := newVerificationCode("+84999999999")
+		byID := newVerificationCode("+84888888888")
+
+		for _, vc := range []*auth.VerificationCode{byPhoneNumber, byID} {
+			err := store.StoreVerificationCode(ctx, vc)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		err := store.DeleteVerificationCodeByPhoneNumber(ctx, byPhoneNumber.PhoneNumber, byPhoneNumber.CountryCode)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.DeleteVerificationCodeByID(ctx, byID.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		for _, vc := range []*auth.VerificationCode{byPhoneNumber, byID} {
+			got, err := store.GetVerificationCodeByVerificationID(ctx, vc.VerificationID)
+
+			if err != nil || got != nil {
+				t.Errorf("expected nil, nil after delete, got %v, %v", got, err)
+			}
+		}
+	})
+
+	t.Run("should count verification failures since time", func(t *testing.T) {
+		store := newStore(t)
+		failures := []*auth.VerificationFailure{
+			{ID: newID(), PhoneNumber: "+84999999999", CountryCode: "VN", IPAddress: "10.0.0.1", CreatedAt: timestamp(0)},
+			{ID: newID(), PhoneNumber: "+84999999999", CountryCode: "VN", IPAddress: "10.0.0.2", CreatedAt: timestamp(5)},
+			{ID: newID(), PhoneNumber: "+84888888888", CountryCode: "VN", IPAddress: "10.0.0.1", CreatedAt: timestamp(10)},
+		}
+
+		for _, failure := range failures {
+			err := store.StoreVerificationFailure(ctx, failure)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		count, err := store.CountVerificationFailuresByPhoneNumber(ctx, "+84999999999", "VN", timestamp(0))
+
+		if err != nil || count != 2 {
+			t.Errorf("expected 2 failures of phone number, got %d, %v", count, err)
+		}
+
+		count, err = store.CountVerificationFailuresByPhoneNumber(ctx, "+84999999999", "VN", timestamp(1))
+
+		if err != nil || count != 1 {
+			t.Errorf("expected 1 failure of phone number since later, got %d, %v", count, err)
+		}
+
+		count, err = store.CountVerificationFailuresByIPAddress(ctx, "10.0.0.1", timestamp(0))
+
+		if err != nil || count != 2 {
+			t.Errorf("expected 2 failures of ip address, got %d, %v", count, err)
+		}
+	})
+
+	t.Run("should store user once per phone number", func(t *testing.T) {
+		store := newStore(t)
+		user := newUser("+84999999999")
+
+		err := store.StoreUser(ctx, user)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetUserByID(ctx, user.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkUser(t, got, user)
+
+		got, err = store.GetUserByPhoneNumber(ctx, user.PhoneNumber, user.CountryCode)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkUser(t, got, user)
+
+		err = store.StoreUser(ctx, newUser(user.PhoneNumber))
+
+		if err == nil {
+			t.Error("user with same phone number should be rejected")
+		}
+	})
+
+	t.Run("should update user", func(t *testing.T) {
+		store := newStore(t)
+		user := newUser("+84999999999")
+		other := newUser("+84888888888")
+
+		for _, u := range []*auth.User{user, other} {
+			err := store.StoreUser(ctx, u)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		user.FullName = "renamed"
+		user.PhoneNumber = "+84777777777"
+		user.ProfileImageID = "image"
+		user.IsPhoneNumberVerified = false
+		user.UpdatedAt = timestamp(1)
+
+		err := store.UpdateUser(ctx, user)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetUserByID(ctx, user.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkUser(t, got, user)
+
+		user.PhoneNumber = other.PhoneNumber
+
+		err = store.UpdateUser(ctx, user)
+
+		if err == nil {
+			t.Error("changing to the phone number of another user should be rejected")
+		}
+	})
+
+	t.Run("should rotate refresh token once", func(t *testing.T) {
+		store := newStore(t)
+		refreshToken := newRefreshToken(newID())
+
+		err := store.StoreRefreshToken(ctx, refreshToken)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetRefreshTokenByTokenHash(ctx, refreshToken.TokenHash)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkRefreshToken(t, got, refreshToken)
+
+		rotated, err := store.RotateRefreshToken(ctx, refreshToken, timestamp(1))
+
+		if err != nil || !rotated {
+			t.Errorf("expected first rotation to succeed, got %v, %v", rotated, err)
+		}
+
+		if !refreshToken.RotatedAt.Equal(timestamp(1)) {
+			t.Errorf("expected rotated at to be set, got %v", refreshToken.RotatedAt)
+		}
+
+		rotated, err = store.RotateRefreshToken(ctx, got, timestamp(2))
+
+		if err != nil || rotated {
+			t.Errorf("expected second rotation to fail, got %v, %v", rotated, err)
+		}
+
+		got, err = store.GetRefreshTokenByTokenHash(ctx, refreshToken.TokenHash)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkRefreshToken(t, got, refreshToken)
+
+		duplicate := newRefreshToken(newID())
+		duplicate.TokenHash = refreshToken.TokenHash
+
+		err = store.StoreRefreshToken(ctx, duplicate)
+
+		if err == nil {
+			t.Error("refresh token with same hash should be rejected")
+		}
+	})
+
+	t.Run("should revoke refresh token family", func(t *testing.T) {
+		store := newStore(t)
+		familyID := newID()
+		revoked := newRefreshToken(familyID)
+		latest := newRefreshToken(familyID)
+		other := newRefreshToken(newID())
+
+		for _, rt := range []*auth.RefreshToken{revoked, latest, other} {
+			err := store.StoreRefreshToken(ctx, rt)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		err := store.RevokeRefreshTokensByFamilyID(ctx, familyID, timestamp(1))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.RevokeRefreshTokensByFamilyID(ctx, familyID, timestamp(2))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		revoked.RevokedAt = timestamp(1)
+		latest.RevokedAt = timestamp(1)
+
+		for _, rt := range []*auth.RefreshToken{revoked, latest, other} {
+			got, err := store.GetRefreshTokenByTokenHash(ctx, rt.TokenHash)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			checkRefreshToken(t, got, rt)
+		}
+
+		rotated, err := store.RotateRefreshToken(ctx, latest, timestamp(3))
+
+		if err != nil || rotated {
+			t.Errorf("expected rotation of revoked token to fail, got %v, %v", rotated, err)
+		}
+	})
+
+	t.Run("should list sessions not revoked, most recently seen first", func(t *testing.T) {
+		store := newStore(t)
+		userID := newID()
+		seenEarlier := newSession(userID, 1)
+		seenLater := newSession(userID, 2)
+		revoked := newSession(userID, 3)
+		revoked.RevokedAt = timestamp(4)
+		other := newSession(newID(), 5)
+
+		for _, session := range []*auth.Session{seenEarlier, revoked, other, seenLater} {
+			err := store.StoreSession(ctx, session)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		sessions, err := store.GetSessionsByUserID(ctx, userID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(sessions) != 2 {
+			t.Errorf("expected 2 sessions, got %d", len(sessions))
+			return
+		}
+
+		checkSession(t, sessions[0], seenLater)
+		checkSession(t, sessions[1], seenEarlier)
+
+		got, err := store.GetSessionByID(ctx, revoked.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkSession(t, got, revoked)
+	})
+
+	t.Run("should update session", func(t *testing.T) {
+		store := newStore(t)
+		session := newSession(newID(), 0)
+
+		err := store.StoreSession(ctx, session)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		session.UserAgent = "new agent"
+		session.IPAddress = "10.0.0.1"
+		session.LastSeenAt = timestamp(1)
+
+		err = store.UpdateSession(ctx, session)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetSessionByID(ctx, session.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkSession(t, got, session)
+	})
+
+	t.Run("should keep session revoked when updating a copy read before", func(t *testing.T) {
+		store := newStore(t)
+		session := newSession(newID(), 0)
+
+		err := store.StoreSession(ctx, session)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.RevokeSession(ctx, session.ID, timestamp(2))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale := *session
+		stale.LastSeenAt = timestamp(3)
+
+		err = store.UpdateSession(ctx, &stale)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.RevokeSession(ctx, session.ID, timestamp(4))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetSessionByID(ctx, session.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		session.RevokedAt = timestamp(2)
+		checkSession(t, got, session)
+	})
+}
@@ -0,0 +1,560 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+	"github.com/minheq/kedul_server_main/query"
+)
+
+func newBusiness(userID string, name string, createdAt int) *app.Business {
+	return &app.Business{
+		ID:             newID(),
+		UserID:         userID,
+		Name:           name,
+		ProfileImageID: "image",
+		CreatedAt:      timestamp(createdAt),
+		UpdatedAt:      timestamp(createdAt),
+	}
+}
+
+func checkBusiness(t *testing.T, got *app.Business, want *app.Business) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected business %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.UserID != want.UserID || got.Name != want.Name || got.ProfileImageID != want.ProfileImageID ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || got.Version != want.Version {
+		t.Errorf("expected business %+v, got %+v", want, got)
+	}
+}
+
+func checkBusinessIDs(t *testing.T, got []*app.Business, want ...*app.Business) {
+	t.Helper()
+
+	gotIDs := []string{}
+	wantIDs := []string{}
+
+	for _, b := range got {
+		gotIDs = append(gotIDs, b.ID)
+	}
+
+	for _, b := range want {
+		wantIDs = append(wantIDs, b.ID)
+	}
+
+	if !sameStrings(gotIDs, wantIDs) {
+		t.Errorf("expected businesses %v, got %v", wantIDs, gotIDs)
+	}
+}
+
+// TestBusinessStore runs the tests against empty BusinessStores made by newStore
+func TestBusinessStore(t *testing.T, newStore func(t *testing.T) app.BusinessStore) {
+	ctx := context.Background()
+
+	t.Run("should return nil when business does not exist", func(t *testing.T) {
+		store := newStore(t)
+
+		business, err := store.GetBusinessByID(ctx, newID())
+
+		if err != nil || business != nil {
+			t.Errorf("expected nil, nil by id, got %v, %v", business, err)
+		}
+
+		business, err = store.GetBusinessByName(ctx, "missing")
+
+		if err != nil || business != nil {
+			t.Errorf("expected nil, nil by name, got %v, %v", business, err)
+		}
+	})
+
+	t.Run("should get stored business", func(t *testing.T) {
+		store := newStore(t)
+		business := newBusiness(newID(), "business", 0)
+
+		err := store.StoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetBusinessByID(ctx, business.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, business)
+
+		got, err = store.GetBusinessByName(ctx, business.Name)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, business)
+	})
+
+	t.Run("should not share stored business with caller", func(t *testing.T) {
+		store := newStore(t)
+		business := newBusiness(newID(), "business", 0)
+
+		err := store.StoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		business.Name = "changed"
+
+		got, err := store.GetBusinessByID(ctx, business.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if got.Name != "business" {
+			t.Errorf("change should not be stored until update, got %s", got.Name)
+		}
+	})
+
+	t.Run("should list businesses oldest first", func(t *testing.T) {
+		store := newStore(t)
+		userID := newID()
+		newest := newBusiness(userID, "newest", 2)
+		oldest := newBusiness(userID, "oldest", 0)
+		other := newBusiness(newID(), "other", 1)
+
+		for _, b := range []*app.Business{newest, other, oldest} {
+			err := store.StoreBusiness(ctx, b)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		businesses, err := store.GetBusinessesByUserID(ctx, userID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusinessIDs(t, businesses, oldest, newest)
+
+		businesses, err = store.GetBusinessesByIDs(ctx, []string{newest.ID, other.ID, oldest.ID, newID()})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusinessIDs(t, businesses, oldest, other, newest)
+
+		businesses, err = store.GetBusinessesByIDs(ctx, []string{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusinessIDs(t, businesses)
+	})
+
+	t.Run("should reject duplicate business", func(t *testing.T) {
+		store := newStore(t)
+		business := newBusiness(newID(), "business", 0)
+
+		err := store.StoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.StoreBusiness(ctx, business)
+
+		if err == nil {
+			t.Error("business with same id should be rejected")
+		}
+
+		err = store.StoreBusiness(ctx, newBusiness(newID(), business.Name, 1))
+
+		if err == nil {
+			t.Error("business with same name should be rejected")
+		}
+	})
+
+	t.Run("should update business", func(t *testing.T) {
+		store := newStore(t)
+		business := newBusiness(newID(), "business", 0)
+		other := newBusiness(newID(), "other", 0)
+
+		for _, b := range []*app.Business{business, other} {
+			err := store.StoreBusiness(ctx, b)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		business.Name = "renamed"
+		business.ProfileImageID = "new image"
+		business.UpdatedAt = timestamp(1)
+
+		err := store.UpdateBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetBusinessByID(ctx, business.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, business)
+
+		business.Name = other.Name
+
+		err = store.UpdateBusiness(ctx, business)
+
+		if err == nil {
+			t.Error("renaming to the name of another business should be rejected")
+		}
+	})
+
+	t.Run("should not update or delete business changed meanwhile", func(t *testing.T) {
+		store := newStore(t)
+		business := newBusiness(newID(), "business", 0)
+
+		err := store.StoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale := *business
+		business.Name = "renamed"
+
+		err = store.UpdateBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale.Name = "stale"
+
+		err = store.UpdateBusiness(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale update to fail with precondition failed, got %v", err)
+		}
+
+		stale.DeletedAt = timestamp(10)
+
+		err = store.DeleteBusiness(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale delete to fail with precondition failed, got %v", err)
+		}
+
+		got, err := store.GetBusinessByID(ctx, business.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, business)
+	})
+
+	t.Run("should delete business", func(t *testing.T) {
+		store := newStore(t)
+		business := newBusiness(newID(), "business", 0)
+
+		err := store.StoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		business.DeletedAt = timestamp(10)
+
+		err = store.DeleteBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetBusinessByID(ctx, business.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after delete, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("should restore deleted business", func(t *testing.T) {
+		store := newStore(t)
+		business := newBusiness(newID(), "business", 0)
+
+		err := store.StoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		business.DeletedAt = timestamp(10)
+
+		err = store.DeleteBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetDeletedBusinessByID(ctx, business.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, business)
+
+		if got != nil && !got.DeletedAt.Equal(business.DeletedAt) {
+			t.Errorf("expected deleted at %v, got %v", business.DeletedAt, got.DeletedAt)
+		}
+
+		business.UpdatedAt = timestamp(20)
+
+		err = store.RestoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err = store.GetBusinessByID(ctx, business.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, business)
+
+		got, err = store.GetDeletedBusinessByID(ctx, business.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after restore, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("should release name of deleted business", func(t *testing.T) {
+		store := newStore(t)
+		deleted := newBusiness(newID(), "business", 0)
+		business := newBusiness(newID(), "business", 1)
+
+		err := store.StoreBusiness(ctx, deleted)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		deleted.DeletedAt = timestamp(10)
+
+		err = store.DeleteBusiness(ctx, deleted)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.StoreBusiness(ctx, business)
+
+		if err != nil {
+			t.Errorf("expected name of deleted business to be reusable, got %v", err)
+			return
+		}
+
+		got, err := store.GetBusinessByName(ctx, "business")
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, business)
+
+		err = store.RestoreBusiness(ctx, deleted)
+
+		if err == nil {
+			t.Error("expected error when restoring business whose name is taken")
+		}
+	})
+
+	t.Run("should purge businesses deleted before time", func(t *testing.T) {
+		store := newStore(t)
+		expired := newBusiness(newID(), "business1", 0)
+		recent := newBusiness(newID(), "business2", 0)
+
+		for _, b := range []*app.Business{expired, recent} {
+			err := store.StoreBusiness(ctx, b)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		expired.DeletedAt = timestamp(10)
+		recent.DeletedAt = timestamp(30)
+
+		for _, b := range []*app.Business{expired, recent} {
+			err := store.DeleteBusiness(ctx, b)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		ids, err := store.PurgeBusinesses(ctx, timestamp(20))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(ids) != 1 || ids[0] != expired.ID {
+			t.Errorf("expected purged ids [%s], got %v", expired.ID, ids)
+		}
+
+		got, err := store.GetDeletedBusinessByID(ctx, expired.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after purge, got %v, %v", got, err)
+		}
+
+		got, err = store.GetDeletedBusinessByID(ctx, recent.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkBusiness(t, got, recent)
+	})
+
+	t.Run("should page businesses of user or with ids", func(t *testing.T) {
+		store := newStore(t)
+		userID := newID()
+		owned1 := newBusiness(userID, "business1", 0)
+		owned2 := newBusiness(userID, "business2", 1)
+		owned3 := newBusiness(userID, "business3", 1)
+		other := newBusiness(newID(), "business4", 2)
+		unrelated := newBusiness(newID(), "business5", 2)
+		deleted := newBusiness(userID, "business6", 3)
+
+		for _, b := range []*app.Business{owned1, owned2, owned3, other, unrelated, deleted} {
+			err := store.StoreBusiness(ctx, b)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		deleted.DeletedAt = timestamp(10)
+
+		err := store.DeleteBusiness(ctx, deleted)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		wantIDs := sortByCursor([]app.Cursor{
+			{CreatedAt: owned1.CreatedAt, ID: owned1.ID},
+			{CreatedAt: owned2.CreatedAt, ID: owned2.ID},
+			{CreatedAt: owned3.CreatedAt, ID: owned3.ID},
+			{CreatedAt: other.CreatedAt, ID: other.ID},
+		})
+
+		checkPages(t, wantIDs, func(args app.PageArgs) ([]string, *app.Page, error) {
+			businesses, page, err := store.GetBusinessPageByUserIDOrIDs(ctx, userID, []string{other.ID, deleted.ID}, args)
+			ids := []string{}
+
+			for _, b := range businesses {
+				ids = append(ids, b.ID)
+			}
+
+			return ids, page, err
+		})
+	})
+
+	t.Run("should filter and sort businesses of user", func(t *testing.T) {
+		store := newStore(t)
+		userID := newID()
+		old := newBusiness(userID, "business1", 0)
+		recent1 := newBusiness(userID, "business2", 2)
+		recent2 := newBusiness(userID, "business3", 3)
+		recent3 := newBusiness(userID, "business4", 3)
+
+		for _, b := range []*app.Business{old, recent1, recent2, recent3} {
+			err := store.StoreBusiness(ctx, b)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		list := listArgs(t, app.BusinessFields, []string{"created_at:gt:" + query.Format(app.BusinessFields[1], timestamp(1))}, "-created_at")
+		cursors := []app.Cursor{}
+
+		for _, b := range []*app.Business{recent1, recent2, recent3} {
+			cursors = append(cursors, list.CursorOf(b, b.CreatedAt, b.ID))
+		}
+
+		wantIDs := sortByCursor(cursors)
+
+		if wantIDs[2] != recent1.ID {
+			t.Errorf("expected businesses newest first")
+		}
+
+		checkPages(t, wantIDs, func(args app.PageArgs) ([]string, *app.Page, error) {
+			args.Filter, args.Sort = list.Filter, list.Sort
+			businesses, page, err := store.GetBusinessPageByUserIDOrIDs(ctx, userID, []string{}, args)
+			ids := []string{}
+
+			for _, b := range businesses {
+				ids = append(ids, b.ID)
+			}
+
+			return ids, page, err
+		})
+	})
+}
@@ -0,0 +1,430 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+func newEmployeeRole(locationID string, name string, createdAt int) *app.EmployeeRole {
+	return &app.EmployeeRole{
+		ID:            newID(),
+		LocationID:    locationID,
+		Name:          name,
+		PermissionIDs: []string{"1", "3"},
+		CreatedAt:     timestamp(createdAt),
+		UpdatedAt:     timestamp(createdAt),
+	}
+}
+
+func checkEmployeeRole(t *testing.T, got *app.EmployeeRole, want *app.EmployeeRole) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected employee role %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.LocationID != want.LocationID || got.Name != want.Name || !sameStrings(got.PermissionIDs, want.PermissionIDs) || got.IsOwner != want.IsOwner ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || got.Version != want.Version {
+		t.Errorf("expected employee role %+v, got %+v", want, got)
+		return
+	}
+
+	if len(got.Permissions) != len(want.PermissionIDs) {
+		t.Errorf("expected permissions of %v, got %+v", want.PermissionIDs, got.Permissions)
+		return
+	}
+
+	for i, permission := range got.Permissions {
+		if permission.ID != want.PermissionIDs[i] {
+			t.Errorf("expected permissions of %v, got %+v", want.PermissionIDs, got.Permissions)
+			return
+		}
+	}
+}
+
+// TestEmployeeRoleStore runs the tests against empty EmployeeRoleStores made by newStore
+func TestEmployeeRoleStore(t *testing.T, newStore func(t *testing.T) app.EmployeeRoleStore) {
+	ctx := context.Background()
+
+	t.Run("should return nil when employee role does not exist", func(t *testing.T) {
+		store := newStore(t)
+
+		employeeRole, err := store.GetEmployeeRoleByID(ctx, newID())
+
+		if err != nil || employeeRole != nil {
+			t.Errorf("expected nil, nil, got %v, %v", employeeRole, err)
+		}
+	})
+
+	t.Run("should get stored employee role with permissions", func(t *testing.T) {
+		store := newStore(t)
+		employeeRole := newEmployeeRole(newID(), "role", 0)
+		employeeRole.IsOwner = true
+
+		err := store.StoreEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeRoleByID(ctx, employeeRole.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeRole(t, got, employeeRole)
+	})
+
+	t.Run("should list employee roles oldest first", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		newest := newEmployeeRole(locationID, "newest", 2)
+		oldest := newEmployeeRole(locationID, "oldest", 0)
+		elsewhere := newEmployeeRole(newID(), "elsewhere", 1)
+
+		for _, r := range []*app.EmployeeRole{newest, elsewhere, oldest} {
+			err := store.StoreEmployeeRole(ctx, r)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		employeeRoles, err := store.GetEmployeeRolesByLocationID(ctx, locationID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(employeeRoles) != 2 {
+			t.Errorf("expected 2 employee roles, got %d", len(employeeRoles))
+			return
+		}
+
+		checkEmployeeRole(t, employeeRoles[0], oldest)
+		checkEmployeeRole(t, employeeRoles[1], newest)
+	})
+
+	t.Run("should reject duplicate employee role", func(t *testing.T) {
+		store := newStore(t)
+		employeeRole := newEmployeeRole(newID(), "role", 0)
+
+		err := store.StoreEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.StoreEmployeeRole(ctx, employeeRole)
+
+		if err == nil {
+			t.Error("employee role with same id should be rejected")
+		}
+	})
+
+	t.Run("should update employee role", func(t *testing.T) {
+		store := newStore(t)
+		employeeRole := newEmployeeRole(newID(), "role", 0)
+
+		err := store.StoreEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		employeeRole.Name = "renamed"
+		employeeRole.PermissionIDs = []string{"2"}
+		employeeRole.UpdatedAt = timestamp(1)
+
+		err = store.UpdateEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeRoleByID(ctx, employeeRole.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeRole(t, got, employeeRole)
+	})
+
+	t.Run("should not update or delete employee role changed meanwhile", func(t *testing.T) {
+		store := newStore(t)
+		employeeRole := newEmployeeRole(newID(), "role", 0)
+
+		err := store.StoreEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale := *employeeRole
+		employeeRole.Name = "renamed"
+
+		err = store.UpdateEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale.Name = "stale"
+
+		err = store.UpdateEmployeeRole(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale update to fail with precondition failed, got %v", err)
+		}
+
+		stale.DeletedAt = timestamp(10)
+
+		err = store.DeleteEmployeeRole(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale delete to fail with precondition failed, got %v", err)
+		}
+
+		got, err := store.GetEmployeeRoleByID(ctx, employeeRole.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeRole(t, got, employeeRole)
+	})
+
+	t.Run("should delete employee role", func(t *testing.T) {
+		store := newStore(t)
+		employeeRole := newEmployeeRole(newID(), "role", 0)
+
+		err := store.StoreEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		employeeRole.DeletedAt = timestamp(10)
+
+		err = store.DeleteEmployeeRole(ctx, employeeRole)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeRoleByID(ctx, employeeRole.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after delete, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("should delete and restore employee roles of location", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		employeeRole := newEmployeeRole(locationID, "role1", 0)
+		deletedBefore := newEmployeeRole(locationID, "role2", 1)
+
+		for _, r := range []*app.EmployeeRole{employeeRole, deletedBefore} {
+			err := store.StoreEmployeeRole(ctx, r)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		deletedBefore.DeletedAt = timestamp(5)
+
+		err := store.DeleteEmployeeRole(ctx, deletedBefore)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.DeleteEmployeeRolesByLocationID(ctx, locationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeRolesByLocationID(ctx, locationID)
+
+		if err != nil || len(got) != 0 {
+			t.Errorf("expected no employee roles after delete, got %v, %v", got, err)
+		}
+
+		err = store.RestoreEmployeeRolesByLocationID(ctx, locationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err = store.GetEmployeeRolesByLocationID(ctx, locationID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(got) != 1 {
+			t.Errorf("expected employee roles deleted with location to be restored, got %v", got)
+			return
+		}
+
+		checkEmployeeRole(t, got[0], employeeRole)
+	})
+
+	t.Run("should purge employee roles deleted before time", func(t *testing.T) {
+		store := newStore(t)
+		expired := newEmployeeRole(newID(), "role1", 0)
+		recent := newEmployeeRole(newID(), "role2", 0)
+
+		for _, r := range []*app.EmployeeRole{expired, recent} {
+			err := store.StoreEmployeeRole(ctx, r)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		err := store.DeleteEmployeeRolesByLocationID(ctx, expired.LocationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.DeleteEmployeeRolesByLocationID(ctx, recent.LocationID, timestamp(30))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.PurgeEmployeeRoles(ctx, timestamp(20))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.RestoreEmployeeRolesByLocationID(ctx, expired.LocationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.RestoreEmployeeRolesByLocationID(ctx, recent.LocationID, timestamp(30))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeRoleByID(ctx, expired.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after purge, got %v, %v", got, err)
+		}
+
+		got, err = store.GetEmployeeRoleByID(ctx, recent.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeRole(t, got, recent)
+	})
+
+	t.Run("should page employee roles of location", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		employeeRoles := []*app.EmployeeRole{
+			newEmployeeRole(locationID, "role1", 0),
+			newEmployeeRole(locationID, "role2", 1),
+			newEmployeeRole(locationID, "role3", 1),
+		}
+		cursors := []app.Cursor{}
+
+		for _, r := range append(employeeRoles, newEmployeeRole(newID(), "role4", 0)) {
+			err := store.StoreEmployeeRole(ctx, r)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		for _, r := range employeeRoles {
+			cursors = append(cursors, app.Cursor{CreatedAt: r.CreatedAt, ID: r.ID})
+		}
+
+		checkPages(t, sortByCursor(cursors), func(args app.PageArgs) ([]string, *app.Page, error) {
+			employeeRoles, page, err := store.GetEmployeeRolePageByLocationID(ctx, locationID, args)
+			ids := []string{}
+
+			for _, r := range employeeRoles {
+				ids = append(ids, r.ID)
+			}
+
+			return ids, page, err
+		})
+	})
+
+	t.Run("should filter employee roles by name prefix literally", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		percent := newEmployeeRole(locationID, "50% off", 0)
+		underscore := newEmployeeRole(locationID, "50_off", 1)
+		digits := newEmployeeRole(locationID, "5000", 2)
+
+		for _, r := range []*app.EmployeeRole{percent, underscore, digits} {
+			err := store.StoreEmployeeRole(ctx, r)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		for prefix, want := range map[string]*app.EmployeeRole{"50%": percent, "50_": underscore} {
+			args := listArgs(t, app.EmployeeRoleFields, []string{"name:prefix:" + prefix}, "")
+			employeeRoles, page, err := store.GetEmployeeRolePageByLocationID(ctx, locationID, args)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if len(employeeRoles) != 1 || employeeRoles[0].ID != want.ID || page.TotalCount != 1 {
+				t.Errorf("expected only %q for prefix %q, got %d employee roles", want.Name, prefix, len(employeeRoles))
+			}
+		}
+	})
+}
@@ -0,0 +1,518 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+func newEmployee(locationID string, userID string, employeeRoleID string, createdAt int) *app.Employee {
+	return &app.Employee{
+		ID:             newID(),
+		LocationID:     locationID,
+		Name:           "employee",
+		UserID:         userID,
+		EmployeeRoleID: employeeRoleID,
+		ProfileImageID: "image",
+		CreatedAt:      timestamp(createdAt),
+		UpdatedAt:      timestamp(createdAt),
+	}
+}
+
+func checkEmployee(t *testing.T, got *app.Employee, want *app.Employee) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected employee %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.LocationID != want.LocationID || got.Name != want.Name || got.UserID != want.UserID ||
+		got.EmployeeRoleID != want.EmployeeRoleID || got.ProfileImageID != want.ProfileImageID ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || got.Version != want.Version {
+		t.Errorf("expected employee %+v, got %+v", want, got)
+	}
+}
+
+func checkEmployeeIDs(t *testing.T, got []*app.Employee, want ...*app.Employee) {
+	t.Helper()
+
+	gotIDs := []string{}
+	wantIDs := []string{}
+
+	for _, e := range got {
+		gotIDs = append(gotIDs, e.ID)
+	}
+
+	for _, e := range want {
+		wantIDs = append(wantIDs, e.ID)
+	}
+
+	if !sameStrings(gotIDs, wantIDs) {
+		t.Errorf("expected employees %v, got %v", wantIDs, gotIDs)
+	}
+}
+
+// TestEmployeeStore runs the tests against empty EmployeeStores made by newStore
+func TestEmployeeStore(t *testing.T, newStore func(t *testing.T) app.EmployeeStore) {
+	ctx := context.Background()
+
+	t.Run("should return nil when employee does not exist", func(t *testing.T) {
+		store := newStore(t)
+
+		employee, err := store.GetEmployeeByID(ctx, newID())
+
+		if err != nil || employee != nil {
+			t.Errorf("expected nil, nil by id, got %v, %v", employee, err)
+		}
+
+		employee, err = store.GetEmployeeByUserIDAndLocationID(ctx, newID(), newID())
+
+		if err != nil || employee != nil {
+			t.Errorf("expected nil, nil by user and location, got %v, %v", employee, err)
+		}
+	})
+
+	t.Run("should get stored employee", func(t *testing.T) {
+		store := newStore(t)
+		employee := newEmployee(newID(), newID(), newID(), 0)
+
+		err := store.StoreEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeByID(ctx, employee.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployee(t, got, employee)
+
+		got, err = store.GetEmployeeByUserIDAndLocationID(ctx, employee.UserID, employee.LocationID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployee(t, got, employee)
+	})
+
+	t.Run("should store employee without user", func(t *testing.T) {
+		store := newStore(t)
+		employee := newEmployee(newID(), "", newID(), 0)
+
+		err := store.StoreEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeByID(ctx, employee.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployee(t, got, employee)
+	})
+
+	t.Run("should list employees oldest first", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		userID := newID()
+		employeeRoleID := newID()
+		newest := newEmployee(locationID, userID, employeeRoleID, 2)
+		oldest := newEmployee(locationID, newID(), employeeRoleID, 0)
+		elsewhere := newEmployee(newID(), userID, newID(), 1)
+
+		for _, e := range []*app.Employee{newest, elsewhere, oldest} {
+			err := store.StoreEmployee(ctx, e)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		employees, err := store.GetEmployeesByLocationID(ctx, locationID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeIDs(t, employees, oldest, newest)
+
+		employees, err = store.GetEmployeesByUserID(ctx, userID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeIDs(t, employees, elsewhere, newest)
+
+		employees, err = store.GetEmployeesByEmployeeRoleID(ctx, employeeRoleID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeIDs(t, employees, oldest, newest)
+	})
+
+	t.Run("should reject duplicate employee", func(t *testing.T) {
+		store := newStore(t)
+		employee := newEmployee(newID(), newID(), newID(), 0)
+
+		err := store.StoreEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.StoreEmployee(ctx, employee)
+
+		if err == nil {
+			t.Error("employee with same id should be rejected")
+		}
+	})
+
+	t.Run("should update employee", func(t *testing.T) {
+		store := newStore(t)
+		employee := newEmployee(newID(), "", newID(), 0)
+
+		err := store.StoreEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		employee.UserID = newID()
+		employee.Name = "renamed"
+		employee.EmployeeRoleID = newID()
+		employee.ProfileImageID = "new image"
+		employee.UpdatedAt = timestamp(1)
+
+		err = store.UpdateEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeByID(ctx, employee.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployee(t, got, employee)
+	})
+
+	t.Run("should not update or delete employee changed meanwhile", func(t *testing.T) {
+		store := newStore(t)
+		employee := newEmployee(newID(), "", newID(), 0)
+
+		err := store.StoreEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale := *employee
+		employee.Name = "renamed"
+
+		err = store.UpdateEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale.Name = "stale"
+
+		err = store.UpdateEmployee(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale update to fail with precondition failed, got %v", err)
+		}
+
+		stale.DeletedAt = timestamp(10)
+
+		err = store.DeleteEmployee(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale delete to fail with precondition failed, got %v", err)
+		}
+
+		got, err := store.GetEmployeeByID(ctx, employee.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployee(t, got, employee)
+	})
+
+	t.Run("should delete employee", func(t *testing.T) {
+		store := newStore(t)
+		employee := newEmployee(newID(), newID(), newID(), 0)
+
+		err := store.StoreEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		employee.DeletedAt = timestamp(10)
+
+		err = store.DeleteEmployee(ctx, employee)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeByID(ctx, employee.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after delete, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("should delete and restore employees of location", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		employee := newEmployee(locationID, newID(), newID(), 0)
+		deletedBefore := newEmployee(locationID, newID(), newID(), 1)
+
+		for _, e := range []*app.Employee{employee, deletedBefore} {
+			err := store.StoreEmployee(ctx, e)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		deletedBefore.DeletedAt = timestamp(5)
+
+		err := store.DeleteEmployee(ctx, deletedBefore)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.DeleteEmployeesByLocationID(ctx, locationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeesByLocationID(ctx, locationID)
+
+		if err != nil || len(got) != 0 {
+			t.Errorf("expected no employees after delete, got %v, %v", got, err)
+		}
+
+		err = store.RestoreEmployeesByLocationID(ctx, locationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err = store.GetEmployeesByLocationID(ctx, locationID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployeeIDs(t, got, employee)
+	})
+
+	t.Run("should purge employees deleted before time", func(t *testing.T) {
+		store := newStore(t)
+		expired := newEmployee(newID(), newID(), newID(), 0)
+		recent := newEmployee(newID(), newID(), newID(), 0)
+
+		for _, e := range []*app.Employee{expired, recent} {
+			err := store.StoreEmployee(ctx, e)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		err := store.DeleteEmployeesByLocationID(ctx, expired.LocationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.DeleteEmployeesByLocationID(ctx, recent.LocationID, timestamp(30))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		ids, err := store.PurgeEmployees(ctx, timestamp(20))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(ids) != 1 || ids[0] != expired.ID {
+			t.Errorf("expected purged ids [%s], got %v", expired.ID, ids)
+		}
+
+		err = store.RestoreEmployeesByLocationID(ctx, expired.LocationID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.RestoreEmployeesByLocationID(ctx, recent.LocationID, timestamp(30))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetEmployeeByID(ctx, expired.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after purge, got %v, %v", got, err)
+		}
+
+		got, err = store.GetEmployeeByID(ctx, recent.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkEmployee(t, got, recent)
+	})
+
+	t.Run("should page employees of location", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		employees := []*app.Employee{
+			newEmployee(locationID, newID(), newID(), 0),
+			newEmployee(locationID, newID(), newID(), 0),
+			newEmployee(locationID, newID(), newID(), 1),
+			newEmployee(locationID, "", newID(), 2),
+			newEmployee(locationID, "", newID(), 2),
+		}
+		cursors := []app.Cursor{}
+
+		for _, e := range append(employees, newEmployee(newID(), newID(), newID(), 0)) {
+			err := store.StoreEmployee(ctx, e)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		for _, e := range employees {
+			cursors = append(cursors, app.Cursor{CreatedAt: e.CreatedAt, ID: e.ID})
+		}
+
+		checkPages(t, sortByCursor(cursors), func(args app.PageArgs) ([]string, *app.Page, error) {
+			employees, page, err := store.GetEmployeePageByLocationID(ctx, locationID, args)
+			ids := []string{}
+
+			for _, e := range employees {
+				ids = append(ids, e.ID)
+			}
+
+			return ids, page, err
+		})
+	})
+
+	t.Run("should filter and sort employees of location", func(t *testing.T) {
+		store := newStore(t)
+		locationID := newID()
+		employeeRoleID := newID()
+		employees := []*app.Employee{
+			newEmployee(locationID, newID(), employeeRoleID, 0),
+			newEmployee(locationID, newID(), employeeRoleID, 1),
+			newEmployee(locationID, newID(), employeeRoleID, 2),
+		}
+		otherRole := newEmployee(locationID, newID(), newID(), 0)
+		anotherRole := newEmployee(locationID, newID(), newID(), 1)
+		employees[0].Name = "Linh"
+		employees[1].Name = "An"
+		employees[2].Name = "Minh"
+
+		for _, e := range append(employees, otherRole, anotherRole) {
+			err := store.StoreEmployee(ctx, e)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		list := listArgs(t, app.EmployeeFields, []string{"employee_role_id:eq:" + employeeRoleID}, "-name")
+		cursors := []app.Cursor{}
+
+		for _, e := range employees {
+			cursors = append(cursors, list.CursorOf(e, e.CreatedAt, e.ID))
+		}
+
+		checkPages(t, sortByCursor(cursors), func(args app.PageArgs) ([]string, *app.Page, error) {
+			args.Filter, args.Sort = list.Filter, list.Sort
+			employees, page, err := store.GetEmployeePageByLocationID(ctx, locationID, args)
+			ids := []string{}
+
+			for _, e := range employees {
+				ids = append(ids, e.ID)
+			}
+
+			return ids, page, err
+		})
+
+		list = listArgs(t, app.EmployeeFields, []string{"employee_role_id:ne:" + employeeRoleID}, "")
+
+		checkPages(t, sortByCursor([]app.Cursor{list.CursorOf(otherRole, otherRole.CreatedAt, otherRole.ID), list.CursorOf(anotherRole, anotherRole.CreatedAt, anotherRole.ID)}), func(args app.PageArgs) ([]string, *app.Page, error) {
+			args.Filter = list.Filter
+			employees, page, err := store.GetEmployeePageByLocationID(ctx, locationID, args)
+			ids := []string{}
+
+			for _, e := range employees {
+				ids = append(ids, e.ID)
+			}
+
+			return ids, page, err
+		})
+	})
+}
@@ -0,0 +1,495 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+func newLocation(businessID string, name string, createdAt int) *app.Location {
+	return &app.Location{
+		ID:             newID(),
+		BusinessID:     businessID,
+		Name:           name,
+		ProfileImageID: "image",
+		TimeZone:       "Asia/Ho_Chi_Minh",
+		CreatedAt:      timestamp(createdAt),
+		UpdatedAt:      timestamp(createdAt),
+	}
+}
+
+func checkLocation(t *testing.T, got *app.Location, want *app.Location) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("expected location %s, got nil", want.ID)
+		return
+	}
+
+	if got.ID != want.ID || got.BusinessID != want.BusinessID || got.Name != want.Name || got.ProfileImageID != want.ProfileImageID || got.TimeZone != want.TimeZone ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || got.Version != want.Version {
+		t.Errorf("expected location %+v, got %+v", want, got)
+	}
+}
+
+// TestLocationStore runs the tests against empty LocationStores made by newStore
+func TestLocationStore(t *testing.T, newStore func(t *testing.T) app.LocationStore) {
+	ctx := context.Background()
+
+	t.Run("should return nil when location does not exist", func(t *testing.T) {
+		store := newStore(t)
+
+		location, err := store.GetLocationByID(ctx, newID())
+
+		if err != nil || location != nil {
+			t.Errorf("expected nil, nil, got %v, %v", location, err)
+		}
+	})
+
+	t.Run("should get stored location", func(t *testing.T) {
+		store := newStore(t)
+		location := newLocation(newID(), "location", 0)
+
+		err := store.StoreLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetLocationByID(ctx, location.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkLocation(t, got, location)
+	})
+
+	t.Run("should list locations oldest first", func(t *testing.T) {
+		store := newStore(t)
+		businessID := newID()
+		newest := newLocation(businessID, "newest", 2)
+		oldest := newLocation(businessID, "oldest", 0)
+		middle := newLocation(businessID, "middle", 1)
+
+		for _, l := range []*app.Location{newest, oldest, middle} {
+			err := store.StoreLocation(ctx, l)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		locations, err := store.GetLocationsByIDs(ctx, []string{newest.ID, oldest.ID, newID(), middle.ID})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(locations) != 3 || locations[0].ID != oldest.ID || locations[1].ID != middle.ID || locations[2].ID != newest.ID {
+			t.Errorf("expected oldest, middle and newest location, got %+v", locations)
+		}
+
+		locations, err = store.GetLocationsByIDs(ctx, []string{})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if locations == nil || len(locations) != 0 {
+			t.Errorf("expected empty locations, got %v", locations)
+		}
+	})
+
+	t.Run("should reject duplicate location", func(t *testing.T) {
+		store := newStore(t)
+		location := newLocation(newID(), "location", 0)
+
+		err := store.StoreLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.StoreLocation(ctx, location)
+
+		if err == nil {
+			t.Error("location with same id should be rejected")
+		}
+	})
+
+	t.Run("should update location", func(t *testing.T) {
+		store := newStore(t)
+		location := newLocation(newID(), "location", 0)
+
+		err := store.StoreLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		location.Name = "renamed"
+		location.ProfileImageID = "new image"
+		location.TimeZone = "Europe/Berlin"
+		location.UpdatedAt = timestamp(1)
+
+		err = store.UpdateLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetLocationByID(ctx, location.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkLocation(t, got, location)
+	})
+
+	t.Run("should not update or delete location changed meanwhile", func(t *testing.T) {
+		store := newStore(t)
+		location := newLocation(newID(), "location", 0)
+
+		err := store.StoreLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale := *location
+		location.Name = "renamed"
+
+		err = store.UpdateLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		stale.Name = "stale"
+
+		err = store.UpdateLocation(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale update to fail with precondition failed, got %v", err)
+		}
+
+		stale.DeletedAt = timestamp(10)
+
+		err = store.DeleteLocation(ctx, &stale)
+
+		if errors.Is(errors.KindPreconditionFailed, err) == false {
+			t.Errorf("expected stale delete to fail with precondition failed, got %v", err)
+		}
+
+		got, err := store.GetLocationByID(ctx, location.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkLocation(t, got, location)
+	})
+
+	t.Run("should delete location", func(t *testing.T) {
+		store := newStore(t)
+		location := newLocation(newID(), "location", 0)
+
+		err := store.StoreLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		location.DeletedAt = timestamp(10)
+
+		err = store.DeleteLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetLocationByID(ctx, location.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after delete, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("should delete and restore locations of business", func(t *testing.T) {
+		store := newStore(t)
+		businessID := newID()
+		location1 := newLocation(businessID, "location1", 0)
+		location2 := newLocation(businessID, "location2", 1)
+		deletedBefore := newLocation(businessID, "location3", 2)
+		other := newLocation(newID(), "location4", 3)
+
+		for _, l := range []*app.Location{location1, location2, deletedBefore, other} {
+			err := store.StoreLocation(ctx, l)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		deletedBefore.DeletedAt = timestamp(5)
+
+		err := store.DeleteLocation(ctx, deletedBefore)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = store.DeleteLocationsByBusinessID(ctx, businessID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetLocationsByBusinessID(ctx, businessID)
+
+		if err != nil || len(got) != 0 {
+			t.Errorf("expected no locations after delete, got %v, %v", got, err)
+		}
+
+		err = store.RestoreLocationsByBusinessID(ctx, businessID, timestamp(10))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err = store.GetLocationsByBusinessID(ctx, businessID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(got) != 2 {
+			t.Errorf("expected locations deleted with business to be restored, got %v", got)
+			return
+		}
+
+		checkLocation(t, got[0], location1)
+		checkLocation(t, got[1], location2)
+
+		gotOther, err := store.GetLocationByID(ctx, other.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkLocation(t, gotOther, other)
+	})
+
+	t.Run("should restore deleted location", func(t *testing.T) {
+		store := newStore(t)
+		location := newLocation(newID(), "location", 0)
+
+		err := store.StoreLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		location.DeletedAt = timestamp(10)
+
+		err = store.DeleteLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err := store.GetDeletedLocationByID(ctx, location.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkLocation(t, got, location)
+
+		if got != nil && !got.DeletedAt.Equal(location.DeletedAt) {
+			t.Errorf("expected deleted at %v, got %v", location.DeletedAt, got.DeletedAt)
+		}
+
+		location.UpdatedAt = timestamp(20)
+
+		err = store.RestoreLocation(ctx, location)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		got, err = store.GetLocationByID(ctx, location.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkLocation(t, got, location)
+	})
+
+	t.Run("should purge locations deleted before time", func(t *testing.T) {
+		store := newStore(t)
+		expired := newLocation(newID(), "location1", 0)
+		recent := newLocation(newID(), "location2", 0)
+
+		for _, l := range []*app.Location{expired, recent} {
+			err := store.StoreLocation(ctx, l)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		expired.DeletedAt = timestamp(10)
+		recent.DeletedAt = timestamp(30)
+
+		for _, l := range []*app.Location{expired, recent} {
+			err := store.DeleteLocation(ctx, l)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		ids, err := store.PurgeLocations(ctx, timestamp(20))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(ids) != 1 || ids[0] != expired.ID {
+			t.Errorf("expected purged ids [%s], got %v", expired.ID, ids)
+		}
+
+		got, err := store.GetDeletedLocationByID(ctx, expired.ID)
+
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil after purge, got %v, %v", got, err)
+		}
+
+		got, err = store.GetDeletedLocationByID(ctx, recent.ID)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		checkLocation(t, got, recent)
+	})
+
+	t.Run("should page locations of business with ids", func(t *testing.T) {
+		store := newStore(t)
+		businessID := newID()
+		location1 := newLocation(businessID, "location1", 0)
+		location2 := newLocation(businessID, "location2", 0)
+		location3 := newLocation(businessID, "location3", 1)
+		notListed := newLocation(businessID, "location4", 1)
+		otherBusiness := newLocation(newID(), "location5", 1)
+
+		for _, l := range []*app.Location{location1, location2, location3, notListed, otherBusiness} {
+			err := store.StoreLocation(ctx, l)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		wantIDs := sortByCursor([]app.Cursor{
+			{CreatedAt: location1.CreatedAt, ID: location1.ID},
+			{CreatedAt: location2.CreatedAt, ID: location2.ID},
+			{CreatedAt: location3.CreatedAt, ID: location3.ID},
+		})
+
+		checkPages(t, wantIDs, func(args app.PageArgs) ([]string, *app.Page, error) {
+			locations, page, err := store.GetLocationPageByBusinessIDAndIDs(ctx, businessID, []string{location1.ID, location2.ID, location3.ID, otherBusiness.ID}, args)
+			ids := []string{}
+
+			for _, l := range locations {
+				ids = append(ids, l.ID)
+			}
+
+			return ids, page, err
+		})
+	})
+
+	t.Run("should filter and sort locations of business", func(t *testing.T) {
+		store := newStore(t)
+		businessID := newID()
+		salonB := newLocation(businessID, "Salon B", 0)
+		salonA := newLocation(businessID, "Salon A", 1)
+		salonA2 := newLocation(businessID, "Salon A", 2)
+		spa := newLocation(businessID, "Spa", 0)
+		lowercase := newLocation(businessID, "salon C", 0)
+		locations := []*app.Location{salonB, salonA, salonA2, spa, lowercase}
+		ids := []string{}
+
+		for _, l := range locations {
+			err := store.StoreLocation(ctx, l)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			ids = append(ids, l.ID)
+		}
+
+		list := listArgs(t, app.LocationFields, []string{"name:prefix:Salon"}, "name")
+		cursors := []app.Cursor{}
+
+		for _, l := range []*app.Location{salonB, salonA, salonA2} {
+			cursors = append(cursors, list.CursorOf(l, l.CreatedAt, l.ID))
+		}
+
+		wantIDs := sortByCursor(cursors)
+
+		if !sameStrings(wantIDs, []string{salonA.ID, salonA2.ID, salonB.ID}) {
+			t.Errorf("expected locations by name, then oldest first")
+		}
+
+		checkPages(t, wantIDs, func(args app.PageArgs) ([]string, *app.Page, error) {
+			args.Filter, args.Sort = list.Filter, list.Sort
+			locations, page, err := store.GetLocationPageByBusinessIDAndIDs(ctx, businessID, ids, args)
+			ids := []string{}
+
+			for _, l := range locations {
+				ids = append(ids, l.ID)
+			}
+
+			return ids, page, err
+		})
+	})
+}
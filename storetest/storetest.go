@@ -0,0 +1,128 @@
+// Package storetest verifies that stores behave the same whichever database they are backed by. Each backend
+// runs the tests with a constructor for empty stores, so that an alternative backend cannot drift from Postgres
+package storetest
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minheq/kedul_server_main/app"
+	"github.com/minheq/kedul_server_main/query"
+)
+
+func newID() string {
+	return uuid.Must(uuid.New(), nil).String()
+}
+
+// timestamp is a fixed time offset by minutes. It is rounded to microseconds, like Postgres stores timestamps
+func timestamp(minutes int) time.Time {
+	return time.Date(2020, time.January, 1, 9, 0, 0, 123456000, time.UTC).Add(time.Duration(minutes) * time.Minute)
+}
+
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortByCursor sorts records, given by their cursors, in list order and returns their IDs
+func sortByCursor(cursors []app.Cursor) []string {
+	sort.Slice(cursors, func(i, j int) bool {
+		return cursors[i].Before(cursors[j])
+	})
+
+	ids := []string{}
+
+	for _, c := range cursors {
+		ids = append(ids, c.ID)
+	}
+
+	return ids
+}
+
+// listArgs returns page args with the filter and sort params on the fields
+func listArgs(t *testing.T, fields query.Fields, filter []string, sort string) app.PageArgs {
+	t.Helper()
+
+	f, err := query.ParseFilter(fields, filter)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := query.ParseSort(fields, sort)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return app.PageArgs{Filter: f, Sort: s}
+}
+
+// checkPages walks a list forward, then backward, two records at a time, and checks that each walk returns the
+// records with wantIDs once and in order. getPage returns the IDs of the records of a page
+func checkPages(t *testing.T, wantIDs []string, getPage func(args app.PageArgs) ([]string, *app.Page, error)) {
+	t.Helper()
+
+	forward := []string{}
+	args := app.PageArgs{First: 2}
+
+	for i := 0; i <= len(wantIDs); i++ {
+		ids, page, err := getPage(args)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if page.TotalCount != len(wantIDs) {
+			t.Errorf("expected total count %d, got %d", len(wantIDs), page.TotalCount)
+		}
+
+		forward = append(forward, ids...)
+
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+
+		args = app.PageArgs{First: 2, After: page.PageInfo.EndCursor}
+	}
+
+	if !sameStrings(forward, wantIDs) {
+		t.Errorf("expected pages forward %v, got %v", wantIDs, forward)
+	}
+
+	backward := []string{}
+	args = app.PageArgs{Last: 2}
+
+	for i := 0; i <= len(wantIDs); i++ {
+		ids, page, err := getPage(args)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		backward = append(ids, backward...)
+
+		if !page.PageInfo.HasPreviousPage {
+			break
+		}
+
+		args = app.PageArgs{Last: 2, Before: page.PageInfo.StartCursor}
+	}
+
+	if !sameStrings(backward, wantIDs) {
+		t.Errorf("expected pages backward %v, got %v", wantIDs, backward)
+	}
+}
@@ -0,0 +1,158 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout limits how long each query run with the context may take
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// Querier runs queries in the transaction of the context, or on the database when the context is not in
+// a transaction. Queries are cancelled with the context, and fail with KindTimeout once a deadline is exceeded
+type Querier struct {
+	ctx     context.Context
+	querier querier
+}
+
+// DB returns the Querier of the context
+func DB(ctx context.Context, db *sql.DB) *Querier {
+	tx, ok := ctx.Value(contextKey{}).(*sql.Tx)
+
+	if ok {
+		return &Querier{ctx: ctx, querier: tx}
+	}
+
+	return &Querier{ctx: ctx, querier: db}
+}
+
+// queryContext applies the query timeout of the context. cancel must be called once the result is read
+func (q *Querier) queryContext() (context.Context, context.CancelFunc) {
+	timeout, ok := q.ctx.Value(queryTimeoutKey{}).(time.Duration)
+
+	if !ok || timeout <= 0 {
+		return context.WithCancel(q.ctx)
+	}
+
+	return context.WithTimeout(q.ctx, timeout)
+}
+
+// queryError reports errors caused by an exceeded deadline as KindTimeout. The driver does not, e.g. Postgres
+// reports a cancelled statement
+func queryError(ctx context.Context, err error) error {
+	const op = "transaction/queryError"
+
+	if err == nil || err == sql.ErrNoRows {
+		return err
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Timeout(op, err)
+	}
+
+	return err
+}
+
+// Exec runs a query without returning rows
+func (q *Querier) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := q.queryContext()
+	defer cancel()
+
+	result, err := q.querier.ExecContext(ctx, query, args...)
+
+	return result, queryError(ctx, err)
+}
+
+// Query runs a query returning rows. Callers must close the rows, usually with defer, as returning before every row is
+// read otherwise holds on to the connection and the timeout of the query
+func (q *Querier) Query(query string, args ...interface{}) (*Rows, error) {
+	ctx, cancel := q.queryContext()
+
+	rows, err := q.querier.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		cancel()
+		return nil, queryError(ctx, err)
+	}
+
+	return &Rows{rows: rows, ctx: ctx, cancel: cancel}, nil
+}
+
+// QueryRow runs a query returning at most one row
+func (q *Querier) QueryRow(query string, args ...interface{}) *Row {
+	ctx, cancel := q.queryContext()
+
+	return &Row{row: q.querier.QueryRowContext(ctx, query, args...), ctx: ctx, cancel: cancel}
+}
+
+// Rows is the result of Query. The query ends once every row is read or Rows is closed
+type Rows struct {
+	rows   *sql.Rows
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   bool
+	err    error
+}
+
+// Next prepares the next row for Scan
+func (r *Rows) Next() bool {
+	if r.rows.Next() {
+		return true
+	}
+
+	// The error is kept, as it would be replaced by the cancellation of the query
+	r.done = true
+	r.err = r.rows.Err()
+	r.cancel()
+
+	return false
+}
+
+// Scan copies the columns of the current row into dest
+func (r *Rows) Scan(dest ...interface{}) error {
+	return queryError(r.ctx, r.rows.Scan(dest...))
+}
+
+// Err returns the error encountered while reading the rows
+func (r *Rows) Err() error {
+	if r.done {
+		return queryError(r.ctx, r.err)
+	}
+
+	return queryError(r.ctx, r.rows.Err())
+}
+
+// Close ends the query
+func (r *Rows) Close() error {
+	defer r.cancel()
+
+	return r.rows.Close()
+}
+
+// Row is the result of QueryRow. The query ends once it is scanned
+type Row struct {
+	row    *sql.Row
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Scan copies the columns of the row into dest. It returns sql.ErrNoRows when there is no row
+func (r *Row) Scan(dest ...interface{}) error {
+	defer r.cancel()
+
+	return queryError(r.ctx, r.row.Scan(dest...))
+}
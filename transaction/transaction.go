@@ -0,0 +1,65 @@
+// Package transaction lets stores take part in a database transaction started by a service. The transaction
+// is carried by the context, so that stores and their interfaces do not need to know about it
+package transaction
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+type contextKey struct{}
+
+// Transactor runs functions in a transaction
+type Transactor interface {
+	// WithinTransaction commits when fn succeeds and rolls back when it fails or panics. Stores called with the
+	// context given to fn take part in the transaction. When ctx is already in a transaction, fn joins it
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+// NewTransactor constructor for Transactor
+func NewTransactor(db *sql.DB) Transactor {
+	return &transactor{db: db}
+}
+
+// WithinTransaction ...
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "transaction/transactor.WithinTransaction"
+
+	if _, ok := ctx.Value(contextKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to begin transaction")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, contextKey{}, tx))
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return errors.Unexpected(op, err, "failed to commit transaction")
+	}
+
+	return nil
+}
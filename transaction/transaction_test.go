@@ -0,0 +1,212 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minheq/kedul_server_main/errors"
+)
+
+// recorder records the statements and transaction boundaries received by the fake driver
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return strings.Join(r.events, ",")
+}
+
+type fakeDriver struct{ r *recorder }
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{r: d.r}, nil }
+
+type fakeConn struct{ r *recorder }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{r: c.r, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.r.record("begin")
+	return &fakeTx{r: c.r}, nil
+}
+
+type fakeTx struct{ r *recorder }
+
+func (tx *fakeTx) Commit() error   { tx.r.record("commit"); return nil }
+func (tx *fakeTx) Rollback() error { tx.r.record("rollback"); return nil }
+
+type fakeStmt struct {
+	r     *recorder
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.r.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if s.query == "sleep" {
+		<-ctx.Done()
+		return nil, fmt.Errorf("canceling statement due to user request")
+	}
+
+	s.r.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+var driverCount int
+
+func newTestDB(t *testing.T) (*sql.DB, *recorder) {
+	r := &recorder{}
+	driverCount++
+	name := fmt.Sprintf("transactiontest%d", driverCount)
+	sql.Register(name, &fakeDriver{r: r})
+
+	db, err := sql.Open(name, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	return db, r
+}
+
+func TestWithinTransaction(t *testing.T) {
+	t.Run("should commit when function succeeds", func(t *testing.T) {
+		db, r := newTestDB(t)
+		transactor := NewTransactor(db)
+
+		err := transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+			_, err := DB(ctx, db).Exec("insert 1")
+
+			if err != nil {
+				return err
+			}
+
+			_, err = DB(ctx, db).Exec("insert 2")
+
+			return err
+		})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if r.String() != "begin,insert 1,insert 2,commit" {
+			t.Errorf("unexpected events %s", r)
+		}
+	})
+
+	t.Run("should roll back when function fails", func(t *testing.T) {
+		db, r := newTestDB(t)
+		transactor := NewTransactor(db)
+
+		err := transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+			DB(ctx, db).Exec("insert 1")
+
+			return fmt.Errorf("failed")
+		})
+
+		if err == nil || err.Error() != "failed" {
+			t.Errorf("error of function should be returned, got %v", err)
+		}
+
+		if r.String() != "begin,insert 1,rollback" {
+			t.Errorf("unexpected events %s", r)
+		}
+	})
+
+	t.Run("should roll back when function panics", func(t *testing.T) {
+		db, r := newTestDB(t)
+		transactor := NewTransactor(db)
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("panic should be propagated")
+				}
+			}()
+
+			transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				panic("failed")
+			})
+		}()
+
+		if r.String() != "begin,rollback" {
+			t.Errorf("unexpected events %s", r)
+		}
+	})
+
+	t.Run("should join transaction of context", func(t *testing.T) {
+		db, r := newTestDB(t)
+		transactor := NewTransactor(db)
+
+		err := transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+			return transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				_, err := DB(ctx, db).Exec("insert 1")
+
+				return err
+			})
+		})
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if r.String() != "begin,insert 1,commit" {
+			t.Errorf("unexpected events %s", r)
+		}
+	})
+}
+
+func TestQueryTimeout(t *testing.T) {
+	db, _ := newTestDB(t)
+
+	t.Run("should time out after query timeout", func(t *testing.T) {
+		ctx := WithQueryTimeout(context.Background(), 10*time.Millisecond)
+
+		_, err := DB(ctx, db).Exec("sleep")
+
+		if errors.Is(errors.KindTimeout, err) == false {
+			t.Errorf("error should be timeout kind, got %v", err)
+		}
+	})
+
+	t.Run("should not report cancellation as timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err := DB(ctx, db).Exec("sleep")
+
+		if err == nil || errors.Is(errors.KindTimeout, err) {
+			t.Errorf("error should not be timeout kind, got %v", err)
+		}
+	})
+}